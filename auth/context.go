@@ -0,0 +1,16 @@
+package auth
+
+import "context"
+
+type userTokenKey struct{}
+
+// WithUserToken returns a copy of ctx carrying the inbound user's raw access token.
+func WithUserToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, userTokenKey{}, token)
+}
+
+// UserTokenFromContext returns the inbound user's raw access token stored by UserAuth, if any.
+func UserTokenFromContext(ctx context.Context) (string, bool) {
+	token, ok := ctx.Value(userTokenKey{}).(string)
+	return token, ok && token != ""
+}
@@ -5,12 +5,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"log/slog"
 	"net/http"
 	"strings"
 
 	"github.com/coreos/go-oidc/v3/oidc"
 	"github.com/gin-gonic/gin"
+	common_errors "github.com/hkinc45/dev-kitchen-go-common/errors"
+	"github.com/hkinc45/dev-kitchen-go-common/internal/httptransport"
+	"github.com/hkinc45/dev-kitchen-go-common/logging"
 	"github.com/hkinc45/dev-kitchen-go-common/models"
 )
 
@@ -50,20 +52,20 @@ func (m *Middleware) UserAuth() gin.HandlerFunc {
 
 		idToken, err := m.Verifier.Verify(c.Request.Context(), tokenString)
 		if err != nil {
-			slog.Error("Token verification failed", "err", err)
+			logging.FromContext(c.Request.Context()).Error("Token verification failed", "err", err)
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token: " + err.Error()})
 			return
 		}
 
-		var claims map[string]interface{}
-		if err := idToken.Claims(&claims); err != nil {
-			slog.Error("Failed to extract claims from token", "err", err)
+		claims, err := models.ClaimsFromIDToken(idToken)
+		if err != nil {
+			logging.FromContext(c.Request.Context()).Error("Failed to extract claims from token", "err", err)
 			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to extract claims from token"})
 			return
 		}
 
 		if !m.isAudienceValid(claims) {
-			slog.Error("Token audience validation failed", "expected", m.ClientID, "actual", claims["aud"])
+			logging.FromContext(c.Request.Context()).Error("Token audience validation failed", "expected", m.ClientID, "actual", claims.Audience)
 			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Token not valid for this service"})
 			return
 		}
@@ -72,15 +74,19 @@ func (m *Middleware) UserAuth() gin.HandlerFunc {
 		// This ensures the user exists in the auth-service DB and we get the canonical Application ID.
 		user, err := m.jitProvisionUser(c.Request.Context(), authHeader)
 		if err != nil {
-			slog.Error("JIT provisioning failed", "err", err)
+			logging.FromContext(c.Request.Context()).Error("JIT provisioning failed", "err", err)
 			c.AbortWithStatusJSON(http.StatusFailedDependency, gin.H{"error": "Failed to retrieve user profile from auth service"})
 			return
 		}
 
 		// Set the full user object in the context.
-		c.Set("user", user)
+		c.Set(common_errors.UserContextKey, user)
+		// Keep the raw token on the request context so outbound clients can exchange it for delegated calls,
+		// and add the user to the request logger.
+		ctx := WithUserToken(c.Request.Context(), tokenString)
+		c.Request = c.Request.WithContext(logging.With(ctx, logging.KeyUserID, user.ID))
 
-		slog.Info("User token validated and user object set successfully.")
+		logging.FromContext(c.Request.Context()).Info("User token validated and user object set successfully.")
 		c.Next()
 	}
 }
@@ -94,8 +100,7 @@ func (m *Middleware) jitProvisionUser(ctx context.Context, authHeader string) (*
 	}
 	req.Header.Set("Authorization", authHeader)
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := httptransport.Client().Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute request to auth-service: %w", err)
 	}
@@ -127,71 +132,33 @@ func (m *Middleware) ServiceAuth() gin.HandlerFunc {
 
 		idToken, err := m.Verifier.Verify(c.Request.Context(), tokenString)
 		if err != nil {
-			slog.Error("Token verification failed", "err", err)
+			logging.FromContext(c.Request.Context()).Error("Token verification failed", "err", err)
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token: " + err.Error()})
 			return
 		}
 
-		var claims map[string]interface{}
-		if err := idToken.Claims(&claims); err != nil {
-			slog.Error("Failed to extract claims from token", "err", err)
+		claims, err := models.ClaimsFromIDToken(idToken)
+		if err != nil {
+			logging.FromContext(c.Request.Context()).Error("Failed to extract claims from token", "err", err)
 			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to extract claims from token"})
 			return
 		}
 
 		// For service tokens, we check for the 'internal-comm' role.
-		if !m.hasInternalCommRole(claims) {
-			slog.Error("Service token is missing 'internal-comm' role.")
+		if !claims.HasRealmRole("internal-comm") {
+			logging.FromContext(c.Request.Context()).Error("Service token is missing 'internal-comm' role.")
 			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Access denied: internal-comm role required"})
 			return
 		}
 
-		azp, _ := claims["azp"].(string)
-		slog.Info("Service token validated successfully", "from", azp)
+		logging.FromContext(c.Request.Context()).Info("Service token validated successfully", "from", claims.AuthorizedParty)
 		c.Next()
 	}
 }
 
 // isAudienceValid checks if the service's ClientID or the central auth service's ClientID is present in the 'aud' claim.
 // This allows the service to accept both its own tokens and Requesting Party Tokens (RPTs) from the auth service.
-func (m *Middleware) isAudienceValid(claims map[string]interface{}) bool {
+func (m *Middleware) isAudienceValid(claims *models.TokenClaims) bool {
 	const authServiceClientID = "dev-kitchen-auth-service"
-	aud, ok := claims["aud"]
-	if !ok {
-		return false
-	}
-
-	switch v := aud.(type) {
-	case string:
-		return v == m.ClientID || v == authServiceClientID
-	case []interface{}:
-		for _, a := range v {
-			if s, ok := a.(string); ok {
-				if s == m.ClientID || s == authServiceClientID {
-					return true
-				}
-			}
-		}
-	}
-	return false
-}
-
-// hasInternalCommRole checks if the 'internal-comm' role is present in the token.
-func (m *Middleware) hasInternalCommRole(claims map[string]interface{}) bool {
-	realmAccess, ok := claims["realm_access"].(map[string]interface{})
-	if !ok {
-		return false
-	}
-
-	roles, ok := realmAccess["roles"].([]interface{})
-	if !ok {
-		return false
-	}
-
-	for _, r := range roles {
-		if role, ok := r.(string); ok && role == "internal-comm" {
-			return true
-		}
-	}
-	return false
+	return claims.Audience.Contains(m.ClientID) || claims.Audience.Contains(authServiceClientID)
 }
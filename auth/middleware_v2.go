@@ -2,15 +2,17 @@ package auth
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
-	"log/slog"
 	"net/http"
 	"os"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	common_errors "github.com/hkinc45/dev-kitchen-go-common/errors"
+	"github.com/hkinc45/dev-kitchen-go-common/logging"
+	"github.com/hkinc45/dev-kitchen-go-common/resource_types"
 )
 
 // CheckPermissionRequest defines the structure for requests to the auth service's check endpoint.
@@ -30,87 +32,190 @@ type ResourceIDExtractor func(c *gin.Context) (string, error)
 // - httpClient: An authenticated HTTP client for service-to-service calls.
 // - resourceType: The type of resource being checked (e.g., "project", "recipe").
 // - idExtractor: A function that extracts the resource's ID from the Gin context.
-// - scope: The scope to check for (e.g., "project:read").
+// - scope: The scope to check for (e.g., "project:read"), see resource_types.Scope.
+//
+// It panics if resourceType or scope are unknown to resource_types, so typos fail at startup.
 func RequirePermissionV2(httpClient *http.Client, resourceType string, idExtractor ResourceIDExtractor, scope string) gin.HandlerFunc {
+	if err := resource_types.ValidateType(resourceType); err != nil {
+		panic(fmt.Sprintf("auth: RequirePermissionV2: %v", err))
+	}
+	if err := resource_types.ValidateScope(scope); err != nil {
+		panic(fmt.Sprintf("auth: RequirePermissionV2: %v", err))
+	}
+
 	return func(c *gin.Context) {
 		// 1. Get auth service URL from environment
 		authServiceURL := os.Getenv("AUTH_SERVICE_URL")
 		if authServiceURL == "" {
-			slog.Error("misconfigured authentication service URL", "service", "go-common-auth")
+			logging.FromContext(c.Request.Context()).Error("misconfigured authentication service URL", "service", "go-common-auth")
 			c.Error(common_errors.NewInternalServerError("misconfigured authentication service URL"))
 			c.Abort()
 			return
 		}
 
 		// 2. Get the raw user token from the Authorization header.
-		authHeader := c.GetHeader("Authorization")
-		if !strings.HasPrefix(authHeader, "Bearer ") {
-			slog.Warn("authorization header missing or invalid", "header", authHeader)
-			c.Error(common_errors.NewUnauthorizedError("authorization header missing or improperly formatted"))
-			c.Abort()
+		token, ok := bearerToken(c)
+		if !ok {
 			return
 		}
-		token := strings.TrimPrefix(authHeader, "Bearer ")
 
 		// 3. Extract the resource ID using the provided extractor function
 		resourceID, err := idExtractor(c)
 		if err != nil {
-			slog.Error("failed to extract resource ID", "error", err, "resource_type", resourceType)
+			logging.FromContext(c.Request.Context()).Error("failed to extract resource ID", "error", err, "resource_type", resourceType)
 			c.Error(common_errors.NewBadRequestError(fmt.Sprintf("failed to extract resource ID for permission check: %v", err)))
 			c.Abort()
 			return
 		}
 
-		// 4. Construct the request to the auth service
-		checkReqPayload := CheckPermissionRequest{
-			ResourceType: resourceType,
-			ResourceID:   resourceID,
-			Scope:        scope,
-			SubjectToken: token,
-		}
-
-		payloadBytes, err := json.Marshal(checkReqPayload)
+		// 4. Ask the auth service
+		granted, err := checkPermission(c, httpClient, authServiceURL, token, resourceType, resourceID, scope)
 		if err != nil {
-			slog.Error("failed to construct permission check request", "error", err)
-			c.Error(common_errors.NewInternalServerError("failed to construct permission check request"))
+			c.Error(err)
+			c.Abort()
+			return
+		}
+		if !granted {
+			// The error message from the auth service is now more generic, so we create a specific one here.
+			c.Error(common_errors.NewForbiddenError(fmt.Sprintf("missing required permission: %s on resource %s:%s", scope, resourceType, resourceID)))
 			c.Abort()
 			return
 		}
+		c.Next() // Permission granted
+	}
+}
 
-		// 5. Call the auth service's check endpoint
-		checkURL := fmt.Sprintf("%s/internal/v2/auth/check", authServiceURL)
-		req, err := http.NewRequestWithContext(c.Request.Context(), "POST", checkURL, bytes.NewBuffer(payloadBytes))
-		if err != nil {
-			slog.Error("failed to create request object", "error", err)
-			c.Error(common_errors.NewInternalServerError("failed to create permission check request"))
+// ParentIDResolver returns the ID of the parent of the stored resource resourceType:resourceID, of type
+// resource_types.Parent(resourceType), or "" if it has none. It must read the parent from the stored resource
+// (e.g. the recipe's project_id column), never from the request, or users could claim any parent.
+type ParentIDResolver func(ctx context.Context, resourceType, resourceID string) (string, error)
+
+// RequireInheritedPermissionV2 is RequirePermissionV2 for resources that inherit permissions from their
+// ancestors in the resource_types hierarchy: it grants action if the user has it on the resource itself or on
+// any ancestor, checked from the resource upwards. Only the resource ID comes from the request; the IDs of its
+// ancestors are loaded with parentIDs, so a route like /projects/:project/recipes/:recipe cannot borrow the
+// permissions of a project the recipe does not belong to. For example, a project editor may edit the recipes
+// of the project.
+//
+// It panics if resourceType or action are unknown, or if resourceType has no parent, so mistakes fail at
+// startup.
+func RequireInheritedPermissionV2(httpClient *http.Client, resourceType string, idExtractor ResourceIDExtractor, parentIDs ParentIDResolver, action string) gin.HandlerFunc {
+	if err := resource_types.ValidateScope(resource_types.Scope(resourceType, action)); err != nil {
+		panic(fmt.Sprintf("auth: RequireInheritedPermissionV2: %v", err))
+	}
+	if _, ok := resource_types.Parent(resourceType); !ok {
+		panic(fmt.Sprintf("auth: RequireInheritedPermissionV2: %q has no parent, use RequirePermissionV2", resourceType))
+	}
+
+	return func(c *gin.Context) {
+		logger := logging.FromContext(c.Request.Context())
+		authServiceURL := os.Getenv("AUTH_SERVICE_URL")
+		if authServiceURL == "" {
+			logger.Error("misconfigured authentication service URL", "service", "go-common-auth")
+			c.Error(common_errors.NewInternalServerError("misconfigured authentication service URL"))
 			c.Abort()
 			return
 		}
-		req.Header.Set("Content-Type", "application/json")
+		token, ok := bearerToken(c)
+		if !ok {
+			return
+		}
 
-		resp, err := httpClient.Do(req)
+		resourceID, err := idExtractor(c)
 		if err != nil {
-			slog.Error("failed to communicate with auth service", "error", err, "url", checkURL)
-			c.Error(common_errors.NewInternalServerError("failed to communicate with authentication service"))
+			logger.Error("failed to extract resource ID", "error", err, "resource_type", resourceType)
+			c.Error(common_errors.NewBadRequestError(fmt.Sprintf("failed to extract resource ID for permission check: %v", err)))
 			c.Abort()
 			return
 		}
-		defer resp.Body.Close()
-
-		// 6. Handle the response
-		switch resp.StatusCode {
-		case http.StatusOK:
-			slog.Info("permission granted", "resource", resourceType, "id", resourceID, "scope", scope)
-			c.Next() // Permission granted
-		case http.StatusForbidden:
-			slog.Warn("permission denied", "resource", resourceType, "id", resourceID, "scope", scope)
-			// The error message from the auth service is now more generic, so we create a specific one here.
-			c.Error(common_errors.NewForbiddenError(fmt.Sprintf("missing required permission: %s on resource %s:%s", scope, resourceType, resourceID)))
-			c.Abort()
-		default:
-			slog.Error("unexpected status code from auth service", "status", resp.StatusCode)
-			c.Error(common_errors.NewInternalServerError(fmt.Sprintf("unexpected error from authentication service: status %d", resp.StatusCode)))
-			c.Abort()
+
+		for t, id := resourceType, resourceID; t != "" && id != ""; {
+			granted, err := checkPermission(c, httpClient, authServiceURL, token, t, id, resource_types.Scope(t, action))
+			if err != nil {
+				c.Error(err)
+				c.Abort()
+				return
+			}
+			if granted {
+				c.Next()
+				return
+			}
+			parent, ok := resource_types.Parent(t)
+			if !ok {
+				break
+			}
+			parentID, err := parentIDs(c.Request.Context(), t, id)
+			if err != nil {
+				if apiErr, ok := common_errors.AsAPIError(err); ok {
+					c.Error(apiErr)
+				} else {
+					logger.Error("failed to resolve parent resource", "error", err, "resource_type", t, "id", id)
+					c.Error(common_errors.NewInternalServerError("failed to resolve parent resource for permission check"))
+				}
+				c.Abort()
+				return
+			}
+			t, id = parent, parentID
 		}
+		scope := resource_types.Scope(resourceType, action)
+		c.Error(common_errors.NewForbiddenError(fmt.Sprintf("missing required permission: %s on resource %s:%s", scope, resourceType, resourceID)))
+		c.Abort()
+	}
+}
+
+// bearerToken returns the raw user token of the Authorization header, or aborts with 401 Unauthorized.
+func bearerToken(c *gin.Context) (string, bool) {
+	authHeader := c.GetHeader("Authorization")
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		logging.FromContext(c.Request.Context()).Warn("authorization header missing or invalid", "header", authHeader)
+		c.Error(common_errors.NewUnauthorizedError("authorization header missing or improperly formatted"))
+		c.Abort()
+		return "", false
+	}
+	return strings.TrimPrefix(authHeader, "Bearer "), true
+}
+
+// checkPermission asks the auth service's check endpoint whether the user owning token has scope on the
+// resource. Failures to get an answer are returned as API errors.
+func checkPermission(c *gin.Context, httpClient *http.Client, authServiceURL, token, resourceType, resourceID, scope string) (bool, error) {
+	logger := logging.FromContext(c.Request.Context())
+	checkReqPayload := CheckPermissionRequest{
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+		Scope:        scope,
+		SubjectToken: token,
+	}
+
+	payloadBytes, err := json.Marshal(checkReqPayload)
+	if err != nil {
+		logger.Error("failed to construct permission check request", "error", err)
+		return false, common_errors.NewInternalServerError("failed to construct permission check request")
+	}
+
+	checkURL := fmt.Sprintf("%s/internal/v2/auth/check", authServiceURL)
+	req, err := http.NewRequestWithContext(c.Request.Context(), "POST", checkURL, bytes.NewBuffer(payloadBytes))
+	if err != nil {
+		logger.Error("failed to create request object", "error", err)
+		return false, common_errors.NewInternalServerError("failed to create permission check request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		logger.Error("failed to communicate with auth service", "error", err, "url", checkURL)
+		return false, common_errors.NewInternalServerError("failed to communicate with authentication service")
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		logger.Info("permission granted", "resource", resourceType, "id", resourceID, "scope", scope)
+		return true, nil
+	case http.StatusForbidden:
+		logger.Warn("permission denied", "resource", resourceType, "id", resourceID, "scope", scope)
+		return false, nil
+	default:
+		logger.Error("unexpected status code from auth service", "status", resp.StatusCode)
+		return false, common_errors.NewInternalServerError(fmt.Sprintf("unexpected error from authentication service: status %d", resp.StatusCode))
 	}
 }
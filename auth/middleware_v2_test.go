@@ -2,6 +2,8 @@ package auth
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
 	"io"
 	"net/http"
 	"net/http/httptest"
@@ -95,4 +97,81 @@ func TestRequirePermissionV2(t *testing.T) {
 
 		assert.Equal(t, http.StatusUnauthorized, w.Code)
 	})
+
+	t.Run("Invalid Scope Panics", func(t *testing.T) {
+		assert.Panics(t, func() { RequirePermissionV2(http.DefaultClient, "project", extractor, "project:raed") })
+		assert.Panics(t, func() { RequirePermissionV2(http.DefaultClient, "projects", extractor, "project:read") })
+	})
+}
+
+func TestRequireInheritedPermissionV2(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	os.Setenv("AUTH_SERVICE_URL", "http://auth-service")
+	defer os.Unsetenv("AUTH_SERVICE_URL")
+
+	// The stored recipes: r1 belongs to the editable project, r2 to another one.
+	recipeProjects := map[string]string{"r1": "p-editable", "r2": "p-other"}
+	parentIDs := func(ctx context.Context, resourceType, id string) (string, error) {
+		if resourceType != "recipe" {
+			return "", nil
+		}
+		project, ok := recipeProjects[id]
+		if !ok {
+			return "", common_errors.NewNotFoundError("recipe not found")
+		}
+		return project, nil
+	}
+	var checked []string
+	client := &http.Client{
+		Transport: RoundTripFunc(func(req *http.Request) *http.Response {
+			var check CheckPermissionRequest
+			json.NewDecoder(req.Body).Decode(&check)
+			checked = append(checked, check.Scope+"@"+check.ResourceID)
+			status := http.StatusForbidden
+			if check.ResourceID == "p-editable" {
+				status = http.StatusOK
+			}
+			return &http.Response{StatusCode: status, Body: io.NopCloser(bytes.NewBufferString(`{}`)), Header: make(http.Header)}
+		}),
+	}
+
+	r := gin.New()
+	r.Use(common_errors.Middleware())
+	recipeID := func(c *gin.Context) (string, error) { return c.Param("recipe"), nil }
+	r.PUT("/projects/:project/recipes/:recipe", RequireInheritedPermissionV2(client, "recipe", recipeID, parentIDs, "write"), func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+	serve := func(path string) int {
+		checked = nil
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("PUT", path, nil)
+		req.Header.Set("Authorization", "Bearer user-token")
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	tests := []struct {
+		name        string
+		path        string
+		wantStatus  int
+		wantChecked []string
+	}{
+		{"granted through project", "/projects/p-editable/recipes/r1", http.StatusNoContent, []string{"recipe:write@r1", "project:write@p-editable"}},
+		{"parent from stored recipe, not URL", "/projects/p-other/recipes/r1", http.StatusNoContent, []string{"recipe:write@r1", "project:write@p-editable"}},
+		{"mismatched parent in URL", "/projects/p-editable/recipes/r2", http.StatusForbidden, []string{"recipe:write@r2", "project:write@p-other"}},
+		{"unknown recipe", "/projects/p-editable/recipes/r3", http.StatusNotFound, []string{"recipe:write@r3"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wantStatus, serve(tt.path))
+			assert.Equal(t, tt.wantChecked, checked)
+		})
+	}
+
+	assert.Panics(t, func() {
+		RequireInheritedPermissionV2(client, "project", recipeID, parentIDs, "write")
+	})
+	assert.Panics(t, func() {
+		RequireInheritedPermissionV2(client, "recipe", recipeID, parentIDs, "fly")
+	})
 }
@@ -4,12 +4,20 @@ import (
 	"context"
 
 	"github.com/google/uuid"
+	"github.com/hkinc45/dev-kitchen-go-common/models"
 )
 
 // ResolvedProject is a simplified project structure that the resolver must return.
 // It must contain the ID from the auth service.
 type ResolvedProject struct {
 	AuthServiceProjectID uuid.UUID
+	// Project is the full project, if the resolver loaded it.
+	Project *models.Project
+}
+
+// NewResolvedProject returns the ResolvedProject for project as returned by the auth service.
+func NewResolvedProject(project *models.Project) *ResolvedProject {
+	return &ResolvedProject{AuthServiceProjectID: project.ID, Project: project}
 }
 
 // ProjectResolver defines the interface required by the middleware to look up a project.
@@ -17,3 +25,11 @@ type ResolvedProject struct {
 type ProjectResolver interface {
 	GetProjectByID(ctx context.Context, id uuid.UUID) (*ResolvedProject, error)
 }
+
+// ProjectResolverFunc adapts a function to a ProjectResolver.
+type ProjectResolverFunc func(ctx context.Context, id uuid.UUID) (*ResolvedProject, error)
+
+// GetProjectByID calls f.
+func (f ProjectResolverFunc) GetProjectByID(ctx context.Context, id uuid.UUID) (*ResolvedProject, error) {
+	return f(ctx, id)
+}
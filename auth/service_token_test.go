@@ -0,0 +1,41 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientCredentialsSourceTimeout(t *testing.T) {
+	release := make(chan struct{})
+	idp := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer idp.Close()
+	defer close(release)
+
+	source := &clientCredentialsSource{ctx: context.Background(), tokenURL: idp.URL, clientID: "recipe-service", timeout: 50 * time.Millisecond}
+	start := time.Now()
+	_, err := source.Token()
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestPerformTokenExchangeTimeout(t *testing.T) {
+	release := make(chan struct{})
+	idp := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer idp.Close()
+	defer close(release)
+
+	defer func(timeout time.Duration) { tokenRequestTimeout = timeout }(tokenRequestTimeout)
+	tokenRequestTimeout = 50 * time.Millisecond
+
+	_, err := PerformTokenExchange(context.Background(), idp.URL, "recipe-service", "secret", "user-token", "auth-service")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
@@ -7,6 +7,8 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+
+	"github.com/hkinc45/dev-kitchen-go-common/internal/httptransport"
 )
 
 // TokenExchangeResponse represents the successful response from a token exchange request.
@@ -22,6 +24,7 @@ type TokenExchangeResponse struct {
 // PerformTokenExchange performs a standard RFC 8693 token exchange.
 // It uses raw HTTP requests to ensure compatibility with modern Keycloak versions,
 // bypassing potential issues with the gocloak library's token exchange implementation.
+// The request times out after 10 seconds even if ctx has no deadline.
 func PerformTokenExchange(ctx context.Context, tokenURL, clientID, clientSecret, subjectToken, audience string) (*TokenExchangeResponse, error) {
 	data := url.Values{}
 	data.Set("grant_type", "urn:ietf:params:oauth:grant-type:token-exchange")
@@ -31,6 +34,8 @@ func PerformTokenExchange(ctx context.Context, tokenURL, clientID, clientSecret,
 	data.Set("subject_token_type", "urn:ietf:params:oauth:token-type:access_token")
 	data.Set("audience", audience)
 
+	ctx, cancel := context.WithTimeout(ctx, tokenRequestTimeout)
+	defer cancel()
 	req, err := http.NewRequestWithContext(ctx, "POST", tokenURL, strings.NewReader(data.Encode()))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create token exchange request: %w", err)
@@ -38,7 +43,7 @@ func PerformTokenExchange(ctx context.Context, tokenURL, clientID, clientSecret,
 
 	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := httptransport.Client().Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to perform token exchange request: %w", err)
 	}
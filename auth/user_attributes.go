@@ -6,11 +6,15 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+
+	"github.com/hkinc45/dev-kitchen-go-common/internal/httptransport"
 )
 
 // SetUserAttribute safely updates a user's attributes in Keycloak by performing a read-modify-write.
 // It first fetches the full user representation, updates the attributes, and then PUTs the entire object back.
 // This is done using manual API calls to bypass bugs in some versions of the gocloak library's UpdateUser function.
+//
+// Deprecated: Use clients.KeycloakAdmin.SetUserAttributes, which also manages the admin token.
 func SetUserAttribute(ctx context.Context, adminAPIURL, realm, userID, adminAccessToken string, attributes map[string][]string) error {
 	userURL := fmt.Sprintf("%s/admin/realms/%s/users/%s", adminAPIURL, realm, userID)
 
@@ -21,7 +25,7 @@ func SetUserAttribute(ctx context.Context, adminAPIURL, realm, userID, adminAcce
 	}
 	getReq.Header.Set("Authorization", "Bearer "+adminAccessToken)
 
-	getResp, err := http.DefaultClient.Do(getReq)
+	getResp, err := httptransport.Client().Do(getReq)
 	if err != nil {
 		return fmt.Errorf("failed to perform get user request: %w", err)
 	}
@@ -53,7 +57,7 @@ func SetUserAttribute(ctx context.Context, adminAPIURL, realm, userID, adminAcce
 	putReq.Header.Set("Content-Type", "application/json")
 	putReq.Header.Set("Authorization", "Bearer "+adminAccessToken)
 
-	putResp, err := http.DefaultClient.Do(putReq)
+	putResp, err := httptransport.Client().Do(putReq)
 	if err != nil {
 		return fmt.Errorf("failed to perform set user attribute request: %w", err)
 	}
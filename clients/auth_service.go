@@ -0,0 +1,109 @@
+package clients
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/hkinc45/dev-kitchen-go-common/auth"
+	"github.com/hkinc45/dev-kitchen-go-common/errors"
+	"github.com/hkinc45/dev-kitchen-go-common/models"
+)
+
+// AuthServiceName is the registry name of the auth-service.
+const AuthServiceName = "auth-service"
+
+// ProjectMembership is a project the user belongs to, with their roles in it.
+type ProjectMembership struct {
+	ProjectID string   `json:"project_id"`
+	Roles     []string `json:"roles"`
+}
+
+// AuthServiceClient is a typed client for the auth-service, for code paths outside the auth middleware
+// such as workers and CLIs.
+type AuthServiceClient struct {
+	client *Client
+}
+
+// NewAuthServiceClient wraps client, which must point at the auth-service. Calls to internal endpoints
+// need a client built WithServiceAuth; calls made with a user token bypass its service token.
+func NewAuthServiceClient(client *Client) *AuthServiceClient {
+	return &AuthServiceClient{client: client}
+}
+
+// GetMe returns the user owning userToken, provisioning them in the auth-service on first use.
+func (a *AuthServiceClient) GetMe(ctx context.Context, userToken string) (*models.User, error) {
+	// The call must run as the user, not as the service the client authenticates as.
+	req, err := a.client.NewRequest(WithoutServiceAuth(ctx), http.MethodGet, "/api/v1/me", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+userToken)
+
+	resp, err := a.client.HTTP.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute request to auth-service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var user models.User
+	if err := a.client.HandleResponse(resp, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// CheckPermission reports whether the subject token in check holds the scope on the resource.
+// A denial is reported as false, not as an error.
+func (a *AuthServiceClient) CheckPermission(ctx context.Context, check auth.CheckPermissionRequest) (bool, error) {
+	err := a.client.Do(ctx, http.MethodPost, "/internal/v2/auth/check", check, nil)
+	if err == nil {
+		return true, nil
+	}
+
+	if errors.IsForbidden(err) {
+		return false, nil
+	}
+	return false, fmt.Errorf("failed to check permission %s on %s:%s: %w", check.Scope, check.ResourceType, check.ResourceID, err)
+}
+
+// CheckPermissionsBatch runs several permission checks concurrently and returns their results in order.
+// It fails if any check could not be completed.
+func (a *AuthServiceClient) CheckPermissionsBatch(ctx context.Context, checks []auth.CheckPermissionRequest) ([]bool, error) {
+	calls := make([]Call, len(checks))
+	for i, check := range checks {
+		calls[i] = Call{
+			Name: fmt.Sprint(i),
+			Do: func(ctx context.Context) (interface{}, error) {
+				return a.CheckPermission(ctx, check)
+			},
+		}
+	}
+
+	res := FanOut(ctx, calls...)
+	if err := res.Err(); err != nil {
+		return nil, err
+	}
+
+	permitted := make([]bool, len(checks))
+	for i := range checks {
+		permitted[i], _ = ResultAs[bool](res, fmt.Sprint(i))
+	}
+	return permitted, nil
+}
+
+// ListUserProjects returns the projects the owner of userToken belongs to, sorted by project ID.
+func (a *AuthServiceClient) ListUserProjects(ctx context.Context, userToken string) ([]ProjectMembership, error) {
+	user, err := a.GetMe(ctx, userToken)
+	if err != nil {
+		return nil, err
+	}
+
+	projects := make([]ProjectMembership, 0, len(user.ProjectRoles))
+	for projectID, roles := range user.ProjectRoles {
+		projects = append(projects, ProjectMembership{ProjectID: projectID, Roles: roles})
+	}
+	sort.Slice(projects, func(i, j int) bool { return projects[i].ProjectID < projects[j].ProjectID })
+	return projects, nil
+}
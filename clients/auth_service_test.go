@@ -0,0 +1,49 @@
+package clients_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/hkinc45/dev-kitchen-go-common/auth"
+	"github.com/hkinc45/dev-kitchen-go-common/clients"
+	"github.com/hkinc45/dev-kitchen-go-common/clients/clientstest"
+	"github.com/hkinc45/dev-kitchen-go-common/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthServiceClient(t *testing.T) {
+	m := clientstest.New(t)
+	m.On(http.MethodGet, "/api/v1/me").Respond(http.StatusOK, models.User{
+		Username:     "alice",
+		ProjectRoles: map[string][]string{"p2": {"viewer"}, "p1": {"owner"}},
+	})
+	m.On(http.MethodPost, "/internal/v2/auth/check").RespondWith(func(req *http.Request) (*http.Response, error) {
+		var check auth.CheckPermissionRequest
+		json.NewDecoder(req.Body).Decode(&check)
+		status := http.StatusOK
+		if check.Scope == "project:delete" {
+			status = http.StatusForbidden
+		}
+		return &http.Response{StatusCode: status, Header: http.Header{}, Body: http.NoBody, Request: req}, nil
+	})
+	a := clients.NewAuthServiceClient(m.Client())
+	ctx := context.Background()
+
+	user, err := a.GetMe(ctx, "user-token")
+	assert.NoError(t, err)
+	assert.Equal(t, "alice", user.Username)
+	assert.Equal(t, "Bearer user-token", m.RequestsTo(http.MethodGet, "/api/v1/me")[0].Header.Get("Authorization"))
+
+	projects, err := a.ListUserProjects(ctx, "user-token")
+	assert.NoError(t, err)
+	assert.Equal(t, []clients.ProjectMembership{{ProjectID: "p1", Roles: []string{"owner"}}, {ProjectID: "p2", Roles: []string{"viewer"}}}, projects)
+
+	permitted, err := a.CheckPermissionsBatch(ctx, []auth.CheckPermissionRequest{
+		{ResourceType: "project", ResourceID: "p1", Scope: "project:read"},
+		{ResourceType: "project", ResourceID: "p1", Scope: "project:delete"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []bool{true, false}, permitted)
+}
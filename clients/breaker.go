@@ -0,0 +1,217 @@
+package clients
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned when a request is rejected because the circuit for its host is open.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// BreakerState is the state of a host's circuit.
+type BreakerState string
+
+const (
+	// BreakerClosed lets requests through and counts consecutive failures.
+	BreakerClosed BreakerState = "closed"
+	// BreakerOpen rejects requests immediately until OpenTimeout has elapsed.
+	BreakerOpen BreakerState = "open"
+	// BreakerHalfOpen lets a limited number of probe requests through to test whether the host recovered.
+	BreakerHalfOpen BreakerState = "half-open"
+)
+
+// BreakerConfig configures the circuit breaker transport.
+type BreakerConfig struct {
+	// FailureThreshold is the number of consecutive failures that trips the circuit. Defaults to 5.
+	FailureThreshold int
+	// OpenTimeout is how long the circuit stays open before probing the host again. Defaults to 30 seconds.
+	OpenTimeout time.Duration
+	// HalfOpenProbes is the number of concurrent probe requests allowed while half-open. Defaults to 1.
+	HalfOpenProbes int
+	// HostThresholds overrides FailureThreshold for individual hosts (as in URL.Host).
+	HostThresholds map[string]int
+	// IsFailure decides whether an outcome counts as a failure.
+	// Defaults to transport errors and 5xx responses.
+	IsFailure func(resp *http.Response, err error) bool
+	// OnStateChange, if set, is called whenever a host's circuit changes state.
+	OnStateChange func(host string, from, to BreakerState)
+}
+
+// BreakerStats is a snapshot of a host's circuit, suitable for exporting as metrics.
+type BreakerStats struct {
+	State               BreakerState
+	ConsecutiveFailures int
+	Successes           uint64
+	Failures            uint64
+	Rejections          uint64
+	Trips               uint64
+}
+
+type hostCircuit struct {
+	stats    BreakerStats
+	openedAt time.Time
+	probes   int
+}
+
+// BreakerTransport is an http.RoundTripper that trips a per-host circuit after repeated failures, so a failing
+// downstream service is rejected fast instead of tying up goroutines across all callers.
+type BreakerTransport struct {
+	base   http.RoundTripper
+	config BreakerConfig
+	now    func() time.Time
+
+	mu    sync.Mutex
+	hosts map[string]*hostCircuit
+}
+
+// NewBreakerTransport wraps base with a circuit breaker. A nil base uses http.DefaultTransport.
+func NewBreakerTransport(base http.RoundTripper, cfg BreakerConfig) *BreakerTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if cfg.FailureThreshold <= 0 {
+		cfg.FailureThreshold = 5
+	}
+	if cfg.OpenTimeout <= 0 {
+		cfg.OpenTimeout = 30 * time.Second
+	}
+	if cfg.HalfOpenProbes <= 0 {
+		cfg.HalfOpenProbes = 1
+	}
+	if cfg.IsFailure == nil {
+		cfg.IsFailure = func(resp *http.Response, err error) bool {
+			return err != nil || resp.StatusCode >= 500
+		}
+	}
+	return &BreakerTransport{
+		base:   base,
+		config: cfg,
+		now:    time.Now,
+		hosts:  make(map[string]*hostCircuit),
+	}
+}
+
+// WithCircuitBreaker wraps the client in a BreakerTransport. A request counts once against the circuit,
+// after its retries are exhausted.
+func WithCircuitBreaker(cfg BreakerConfig) Option {
+	return func(o *clientOptions) { o.breaker = &cfg }
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *BreakerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	host := req.URL.Host
+	probe, err := t.allow(host)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	// A cancelled caller says nothing about the health of the host.
+	if err != nil && req.Context().Err() != nil {
+		t.release(host, probe)
+		return resp, err
+	}
+	t.record(host, probe, t.config.IsFailure(resp, err))
+	return resp, err
+}
+
+// Stats returns a snapshot of every host's circuit.
+func (t *BreakerTransport) Stats() map[string]BreakerStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	stats := make(map[string]BreakerStats, len(t.hosts))
+	for host, c := range t.hosts {
+		stats[host] = c.stats
+	}
+	return stats
+}
+
+// allow decides whether a request to host may proceed and whether it is a half-open probe.
+func (t *BreakerTransport) allow(host string) (bool, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	c := t.circuitLocked(host)
+	switch c.stats.State {
+	case BreakerOpen:
+		if t.now().Sub(c.openedAt) < t.config.OpenTimeout {
+			c.stats.Rejections++
+			return false, fmt.Errorf("%w for host %s", ErrCircuitOpen, host)
+		}
+		t.transitionLocked(host, c, BreakerHalfOpen)
+		fallthrough
+	case BreakerHalfOpen:
+		if c.probes >= t.config.HalfOpenProbes {
+			c.stats.Rejections++
+			return false, fmt.Errorf("%w for host %s", ErrCircuitOpen, host)
+		}
+		c.probes++
+		return true, nil
+	}
+	return false, nil
+}
+
+// record updates host's circuit with the outcome of a request.
+func (t *BreakerTransport) record(host string, probe, failed bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	c := t.circuitLocked(host)
+	if probe {
+		c.probes--
+	}
+
+	if !failed {
+		c.stats.Successes++
+		c.stats.ConsecutiveFailures = 0
+		if c.stats.State == BreakerHalfOpen {
+			t.transitionLocked(host, c, BreakerClosed)
+		}
+		return
+	}
+
+	c.stats.Failures++
+	c.stats.ConsecutiveFailures++
+	threshold := t.config.FailureThreshold
+	if hostThreshold, ok := t.config.HostThresholds[host]; ok && hostThreshold > 0 {
+		threshold = hostThreshold
+	}
+	if c.stats.State == BreakerHalfOpen || (c.stats.State == BreakerClosed && c.stats.ConsecutiveFailures >= threshold) {
+		c.stats.Trips++
+		c.openedAt = t.now()
+		t.transitionLocked(host, c, BreakerOpen)
+	}
+}
+
+// release gives back a probe slot without recording an outcome.
+func (t *BreakerTransport) release(host string, probe bool) {
+	if !probe {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.circuitLocked(host).probes--
+}
+
+func (t *BreakerTransport) circuitLocked(host string) *hostCircuit {
+	c, ok := t.hosts[host]
+	if !ok {
+		c = &hostCircuit{stats: BreakerStats{State: BreakerClosed}}
+		t.hosts[host] = c
+	}
+	return c
+}
+
+func (t *BreakerTransport) transitionLocked(host string, c *hostCircuit, to BreakerState) {
+	from := c.stats.State
+	c.stats.State = to
+	slog.Info("circuit breaker state changed", "host", host, "from", from, "to", to)
+	if t.config.OnStateChange != nil {
+		t.config.OnStateChange(host, from, to)
+	}
+}
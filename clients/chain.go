@@ -0,0 +1,114 @@
+package clients
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/oauth2"
+)
+
+// Middleware wraps a transport with additional behaviour.
+type Middleware func(next http.RoundTripper) http.RoundTripper
+
+// Chain wraps base with middlewares. The first middleware is innermost, closest to the network, and the
+// last is outermost, seeing each call first. Transports should be assembled in the standard order,
+// which New also follows:
+//
+//	auth → retry → breaker → tracing → logging
+//
+// Authentication is innermost so every attempt carries a fresh credential. The breaker sits outside the
+// retries so a call counts against a host once, after its retries. Tracing records one span per call,
+// with its retry count, and logging outermost records what the caller sent and received.
+// Service-specific middleware usually goes between tracing and logging.
+func Chain(base http.RoundTripper, middlewares ...Middleware) http.RoundTripper {
+	rt := base
+	for _, mw := range middlewares {
+		rt = mw(rt)
+	}
+	return rt
+}
+
+// ServiceAuth authenticates every request with a token from ts, except requests whose context was marked
+// with WithoutServiceAuth.
+func ServiceAuth(ts oauth2.TokenSource) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return &serviceAuthTransport{oauth: &oauth2.Transport{Source: ts, Base: next}, next: next}
+	}
+}
+
+type skipServiceAuthKey struct{}
+
+// WithoutServiceAuth returns a copy of ctx whose requests ServiceAuth sends unchanged, for calls made with
+// the caller's own credentials, such as a user token, through a client built WithServiceAuth.
+func WithoutServiceAuth(ctx context.Context) context.Context {
+	return context.WithValue(ctx, skipServiceAuthKey{}, true)
+}
+
+// serviceAuthTransport is the oauth2 transport of ServiceAuth, bypassed for requests marked with
+// WithoutServiceAuth.
+type serviceAuthTransport struct {
+	oauth *oauth2.Transport
+	next  http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *serviceAuthTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if skip, _ := req.Context().Value(skipServiceAuthKey{}).(bool); skip {
+		return t.next.RoundTrip(req)
+	}
+	return t.oauth.RoundTrip(req)
+}
+
+// Retry retries idempotent requests up to maxRetries times with exponential backoff between backoffBase
+// and backoffMax, honoring server-requested delays up to maxWait in total.
+func Retry(maxRetries int, backoffBase, backoffMax, maxWait time.Duration) Middleware {
+	return RetryWith(NewRetryPolicy(RetryConfig{
+		MaxAttempts: maxRetries + 1,
+		BaseDelay:   backoffBase,
+		MaxDelay:    backoffMax,
+		MaxWait:     maxWait,
+	}))
+}
+
+// RetryWith retries idempotent requests under policy.
+func RetryWith(policy *RetryPolicy) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return &retryTransport{base: next, policy: policy, now: time.Now}
+	}
+}
+
+// CircuitBreaker trips a per-host circuit after repeated failures.
+func CircuitBreaker(cfg BreakerConfig) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return NewBreakerTransport(next, cfg)
+	}
+}
+
+// Tracing records a span for every call, see WithTracing.
+func Tracing(exporters ...SpanExporter) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return &tracingTransport{base: next, exporters: exporters}
+	}
+}
+
+// TracingWith records a span for every call through tracer as well as exporters, see WithTracer.
+func TracingWith(tracer trace.Tracer, exporters ...SpanExporter) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return &tracingTransport{base: next, exporters: exporters, tracer: tracer}
+	}
+}
+
+// Correlation propagates the correlation identifiers from the request context.
+func Correlation() Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return &correlationTransport{base: next}
+	}
+}
+
+// WithMiddleware adds service-specific middleware to the client, outside the built-in middleware
+// (after tracing in the standard order), in the order given.
+func WithMiddleware(middlewares ...Middleware) Option {
+	return func(o *clientOptions) { o.middlewares = append(o.middlewares, middlewares...) }
+}
@@ -0,0 +1,280 @@
+package clients
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hkinc45/dev-kitchen-go-common/internal/httptransport"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/oauth2"
+)
+
+// Client is a preconfigured HTTP client for calling another service at BaseURL.
+// Build it with New; the zero value is not usable.
+type Client struct {
+	BaseURL string
+	HTTP    *http.Client
+
+	errorDecoder ErrorDecoder
+}
+
+// Option configures a Client built by New.
+type Option func(*clientOptions)
+
+type clientOptions struct {
+	timeout         time.Duration
+	maxRetries      int
+	backoffBase     time.Duration
+	backoffMax      time.Duration
+	maxRetryWait    time.Duration
+	transportConfig *TransportConfig
+	transport       http.RoundTripper
+	retryPolicy     *RetryPolicy
+	breaker         *BreakerConfig
+	tokenSource     oauth2.TokenSource
+	tokenExchange   *TokenExchangeConfig
+	idempotencyKeys bool
+	hedgeDelay      time.Duration
+	cacheEntries    int
+	hostLimiter     HostLimiter
+	logging         *LoggingConfig
+	errorDecoder    ErrorDecoder
+	tracing         bool
+	spanExporters   []SpanExporter
+	tracer          trace.Tracer
+	middlewares     []Middleware
+}
+
+// WithTimeout sets the overall timeout of a call, including retries. Defaults to 10 seconds.
+func WithTimeout(d time.Duration) Option {
+	return func(o *clientOptions) { o.timeout = d }
+}
+
+// WithRetries sets how many times an idempotent request is retried after a transient failure. Defaults to 2.
+func WithRetries(n int) Option {
+	return func(o *clientOptions) { o.maxRetries = n }
+}
+
+// WithBackoff sets the base and maximum delay of the exponential backoff between retries.
+// Defaults to 100ms and 2s.
+func WithBackoff(base, max time.Duration) Option {
+	return func(o *clientOptions) {
+		o.backoffBase = base
+		o.backoffMax = max
+	}
+}
+
+// WithMaxRetryWait bounds the total time a call spends waiting between retries, including delays requested
+// by the server through Retry-After or X-RateLimit-Reset. When a requested delay would exceed the budget,
+// the throttled response is returned instead. Defaults to 30 seconds.
+func WithMaxRetryWait(d time.Duration) Option {
+	return func(o *clientOptions) { o.maxRetryWait = d }
+}
+
+func defaultClientOptions() clientOptions {
+	return clientOptions{
+		timeout:      10 * time.Second,
+		maxRetries:   2,
+		backoffBase:  100 * time.Millisecond,
+		backoffMax:   2 * time.Second,
+		maxRetryWait: 30 * time.Second,
+	}
+}
+
+// policy returns the retry policy given with WithRetryPolicy, or one built from the retry options.
+func (o *clientOptions) policy() *RetryPolicy {
+	if o.retryPolicy != nil {
+		return o.retryPolicy
+	}
+	return NewRetryPolicy(RetryConfig{
+		MaxAttempts: o.maxRetries + 1,
+		BaseDelay:   o.backoffBase,
+		MaxDelay:    o.backoffMax,
+		MaxWait:     o.maxRetryWait,
+	})
+}
+
+// WithConnectionPool gives the client its own transport with a tuned connection pool instead of the
+// shared one.
+func WithConnectionPool(maxIdleConns, maxIdleConnsPerHost int, idleConnTimeout time.Duration) Option {
+	return func(o *clientOptions) {
+		cfg := o.ensureTransportConfig()
+		cfg.MaxIdleConns = maxIdleConns
+		cfg.MaxIdleConnsPerHost = maxIdleConnsPerHost
+		cfg.IdleConnTimeout = idleConnTimeout
+	}
+}
+
+// WithTransportConfig gives the client its own transport built from cfg instead of the shared one.
+func WithTransportConfig(cfg TransportConfig) Option {
+	return func(o *clientOptions) { o.transportConfig = &cfg }
+}
+
+// ensureTransportConfig returns the client's dedicated transport config, creating it on first use.
+func (o *clientOptions) ensureTransportConfig() *TransportConfig {
+	if o.transportConfig == nil {
+		o.transportConfig = &TransportConfig{}
+	}
+	return o.transportConfig
+}
+
+// WithTransport replaces the base transport. Retries are still layered on top of it.
+func WithTransport(rt http.RoundTripper) Option {
+	return func(o *clientOptions) { o.transport = rt }
+}
+
+// WithServiceAuth authenticates every request with a token from ts, e.g. auth.NewServiceTokenSource.
+// Tokens are cached by the source and refreshed automatically when they expire.
+func WithServiceAuth(ts oauth2.TokenSource) Option {
+	return func(o *clientOptions) { o.tokenSource = ts }
+}
+
+// New creates a Client for baseURL with sane timeouts, bounded retries with exponential backoff for
+// idempotent methods, and the shared, tuned connection pool. Correlation headers and the deadline of the
+// request context are propagated on every call.
+func New(baseURL string, opts ...Option) *Client {
+	o := defaultClientOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	base := o.transport
+	if base == nil && o.transportConfig != nil {
+		base = httptransport.New(*o.transportConfig)
+	}
+	if base == nil {
+		base = httptransport.Shared()
+	}
+
+	// Middleware follows the standard order documented on Chain. Besides it, hedging and the cache sit
+	// below the retries so each attempt is hedged and revalidated, and the token exchange runs outside
+	// the breaker so a missing user token neither retries nor counts against the host. The rate limit is
+	// innermost so every request that reaches the network is paced.
+	var chain []Middleware
+	if o.hostLimiter != nil {
+		chain = append(chain, RateLimit(o.hostLimiter))
+	}
+	if o.hedgeDelay > 0 {
+		chain = append(chain, func(next http.RoundTripper) http.RoundTripper {
+			return &hedgeTransport{base: next, delay: o.hedgeDelay}
+		})
+	}
+	if o.tokenSource != nil {
+		chain = append(chain, ServiceAuth(o.tokenSource))
+	}
+	if o.cacheEntries > 0 {
+		chain = append(chain, func(next http.RoundTripper) http.RoundTripper {
+			return newCacheTransport(next, o.cacheEntries)
+		})
+	}
+	chain = append(chain, RetryWith(o.policy()))
+	if o.breaker != nil {
+		chain = append(chain, CircuitBreaker(*o.breaker))
+	}
+	if o.idempotencyKeys {
+		// Outside the retries, so every attempt carries the same key.
+		chain = append(chain, func(next http.RoundTripper) http.RoundTripper {
+			return &idempotencyTransport{base: next}
+		})
+	}
+	if o.tokenExchange != nil {
+		chain = append(chain, func(next http.RoundTripper) http.RoundTripper {
+			return &tokenExchangeTransport{
+				base:   next,
+				config: *o.tokenExchange,
+				now:    time.Now,
+				cache:  make(map[string]exchangedToken),
+			}
+		})
+	}
+	if o.tracing {
+		chain = append(chain, TracingWith(o.tracer, o.spanExporters...))
+	}
+	chain = append(chain, o.middlewares...)
+	if o.logging != nil {
+		chain = append(chain, Logging(*o.logging))
+	}
+	// Correlation headers are set once per call so every retry carries the same request ID, and outside the
+	// logging so it is logged.
+	chain = append(chain, Correlation(), func(next http.RoundTripper) http.RoundTripper {
+		return &deadlineTransport{base: next}
+	})
+	rt := Chain(base, chain...)
+
+	return &Client{
+		BaseURL: strings.TrimRight(baseURL, "/"),
+		HTTP: &http.Client{
+			Timeout:   o.timeout,
+			Transport: rt,
+		},
+		errorDecoder: o.errorDecoder,
+	}
+}
+
+// NewRequest builds a request for path relative to the client's base URL, encoding body as JSON when non-nil.
+func (c *Client) NewRequest(ctx context.Context, method, path string, body interface{}) (*http.Request, error) {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode request body: %w", err)
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.url(path), reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	return req, nil
+}
+
+// Do sends a request built from method, path and body, and decodes the response into out via HandleResponse.
+func (c *Client) Do(ctx context.Context, method, path string, body, out interface{}) error {
+	req, err := c.NewRequest(ctx, method, path, body)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to execute %s %s: %w", method, req.URL.Path, err)
+	}
+	defer resp.Body.Close()
+
+	return c.HandleResponse(resp, out)
+}
+
+// HandleResponse is HandleResponse with the client's error decoder, for responses to requests sent directly
+// through c.HTTP.
+func (c *Client) HandleResponse(resp *http.Response, successBody interface{}) error {
+	return HandleResponseWith(resp, successBody, c.errorDecoder)
+}
+
+// withErrorDecoder returns a copy of c that decodes error responses with decoder.
+func (c *Client) withErrorDecoder(decoder ErrorDecoder) *Client {
+	clone := *c
+	clone.errorDecoder = decoder
+	return &clone
+}
+
+// WithErrorDecoder converts error responses the service sends in its own format into APIErrors, instead of
+// reporting them as unknown errors.
+func WithErrorDecoder(decoder ErrorDecoder) Option {
+	return func(o *clientOptions) { o.errorDecoder = decoder }
+}
+
+// url returns the absolute URL of path relative to the client's base URL.
+func (c *Client) url(path string) string {
+	return c.BaseURL + "/" + strings.TrimLeft(path, "/")
+}
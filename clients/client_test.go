@@ -0,0 +1,615 @@
+package clients
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hkinc45/dev-kitchen-go-common/auth"
+	"github.com/hkinc45/dev-kitchen-go-common/correlation"
+	"github.com/hkinc45/dev-kitchen-go-common/errors"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+func TestMain(m *testing.M) {
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	os.Exit(m.Run())
+}
+
+func TestClientRetries(t *testing.T) {
+	t.Run("Retries Idempotent Requests", func(t *testing.T) {
+		var calls atomic.Int32
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if calls.Add(1) < 3 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.Write([]byte(`{"name":"recipe"}`))
+		}))
+		defer srv.Close()
+
+		c := New(srv.URL, WithBackoff(time.Millisecond, 5*time.Millisecond))
+		var out struct {
+			Name string `json:"name"`
+		}
+		err := c.Do(context.Background(), http.MethodGet, "/recipes/1", nil, &out)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "recipe", out.Name)
+		assert.Equal(t, int32(3), calls.Load())
+	})
+
+	t.Run("Does Not Retry POST", func(t *testing.T) {
+		var calls atomic.Int32
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer srv.Close()
+
+		c := New(srv.URL, WithBackoff(time.Millisecond, 5*time.Millisecond))
+		err := c.Do(context.Background(), http.MethodPost, "/recipes", map[string]string{"name": "x"}, nil)
+
+		assert.Error(t, err)
+		assert.Equal(t, int32(1), calls.Load())
+	})
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+func TestBreakerTransport(t *testing.T) {
+	var healthy atomic.Bool
+	var calls atomic.Int32
+	base := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		calls.Add(1)
+		status := http.StatusInternalServerError
+		if healthy.Load() {
+			status = http.StatusOK
+		}
+		return &http.Response{StatusCode: status, Body: http.NoBody, Request: req}, nil
+	})
+
+	now := time.Now()
+	var transitions []BreakerState
+	breaker := NewBreakerTransport(base, BreakerConfig{
+		FailureThreshold: 2,
+		OpenTimeout:      time.Minute,
+		OnStateChange:    func(_ string, _, to BreakerState) { transitions = append(transitions, to) },
+	})
+	breaker.now = func() time.Time { return now }
+
+	send := func() error {
+		req := httptest.NewRequest(http.MethodGet, "http://recipes.internal/recipes", nil)
+		_, err := breaker.RoundTrip(req)
+		return err
+	}
+
+	assert.NoError(t, send())
+	assert.NoError(t, send())
+	assert.ErrorIs(t, send(), ErrCircuitOpen, "circuit should trip after the threshold")
+	assert.Equal(t, int32(2), calls.Load())
+
+	// After the open timeout a single probe is let through; success closes the circuit.
+	now = now.Add(2 * time.Minute)
+	healthy.Store(true)
+	assert.NoError(t, send())
+	assert.NoError(t, send())
+
+	stats := breaker.Stats()["recipes.internal"]
+	assert.Equal(t, BreakerClosed, stats.State)
+	assert.Equal(t, uint64(1), stats.Trips)
+	assert.Equal(t, uint64(1), stats.Rejections)
+	assert.Equal(t, []BreakerState{BreakerOpen, BreakerHalfOpen, BreakerClosed}, transitions)
+}
+
+func TestWithServiceAuth(t *testing.T) {
+	var tokenRequests atomic.Int32
+	idp := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokenRequests.Add(1)
+		assert.NoError(t, r.ParseForm())
+		assert.Equal(t, "client_credentials", r.PostForm.Get("grant_type"))
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token":"svc-token","token_type":"Bearer","expires_in":300}`))
+	}))
+	defer idp.Close()
+
+	var authorizations []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorizations = append(authorizations, r.Header.Get("Authorization"))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	ts := auth.NewServiceTokenSource(context.Background(), idp.URL, "recipe-service", "secret")
+	c := New(srv.URL, WithServiceAuth(ts))
+
+	assert.NoError(t, c.Do(context.Background(), http.MethodGet, "/a", nil, nil))
+	assert.NoError(t, c.Do(context.Background(), http.MethodGet, "/b", nil, nil))
+	assert.Equal(t, int32(1), tokenRequests.Load(), "token should be cached between requests")
+
+	req, err := c.NewRequest(WithoutServiceAuth(context.Background()), http.MethodGet, "/me", nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	req.Header.Set("Authorization", "Bearer user-token")
+	resp, err := c.HTTP.Do(req)
+	if assert.NoError(t, err) {
+		resp.Body.Close()
+	}
+	assert.Equal(t, []string{"Bearer svc-token", "Bearer svc-token", "Bearer user-token"}, authorizations)
+}
+
+func TestWithTokenExchange(t *testing.T) {
+	var exchanges atomic.Int32
+	idp := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		exchanges.Add(1)
+		assert.NoError(t, r.ParseForm())
+		assert.Equal(t, "recipe-service", r.PostForm.Get("audience"))
+		w.Write([]byte(`{"access_token":"exchanged-` + r.PostForm.Get("subject_token") + `","expires_in":300}`))
+	}))
+	defer idp.Close()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer exchanged-user-token", r.Header.Get("Authorization"))
+	}))
+	defer srv.Close()
+
+	c := New(srv.URL, WithTokenExchange(TokenExchangeConfig{TokenURL: idp.URL, ClientID: "project-service", Audience: "recipe-service"}))
+
+	ctx := auth.WithUserToken(context.Background(), "user-token")
+	assert.NoError(t, c.Do(ctx, http.MethodGet, "/a", nil, nil))
+	assert.NoError(t, c.Do(ctx, http.MethodGet, "/b", nil, nil))
+	assert.Equal(t, int32(1), exchanges.Load(), "exchanged token should be cached")
+
+	err := c.Do(context.Background(), http.MethodGet, "/c", nil, nil)
+	assert.ErrorIs(t, err, ErrNoUserToken)
+}
+
+func TestCorrelationPropagation(t *testing.T) {
+	var got http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+	}))
+	defer srv.Close()
+	c := New(srv.URL)
+
+	t.Run("Copies IDs From Context", func(t *testing.T) {
+		ctx := correlation.WithIDs(context.Background(), correlation.IDs{
+			RequestID:   "req-123",
+			TraceParent: "00-abc-def-01",
+			TenantID:    "tenant-1",
+		})
+		assert.NoError(t, c.Do(ctx, http.MethodGet, "/", nil, nil))
+		assert.Equal(t, "req-123", got.Get(correlation.RequestIDHeader))
+		assert.Equal(t, "00-abc-def-01", got.Get(correlation.TraceParentHeader))
+		assert.Equal(t, "tenant-1", got.Get(correlation.TenantIDHeader))
+	})
+
+	t.Run("Generates Request ID", func(t *testing.T) {
+		assert.NoError(t, c.Do(context.Background(), http.MethodGet, "/", nil, nil))
+		assert.NotEmpty(t, got.Get(correlation.RequestIDHeader))
+		assert.Empty(t, got.Get(correlation.TenantIDHeader))
+	})
+}
+
+func TestWithTracing(t *testing.T) {
+	var calls atomic.Int32
+	var traceParent string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceParent = r.Header.Get(correlation.TraceParentHeader)
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer srv.Close()
+
+	var spans []Span
+	c := New(srv.URL,
+		WithBackoff(time.Millisecond, 5*time.Millisecond),
+		WithTracing(func(_ context.Context, span Span) { spans = append(spans, span) }),
+	)
+
+	parent := "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"
+	ctx := correlation.WithIDs(context.Background(), correlation.IDs{TraceParent: parent})
+	ctx = WithRoute(ctx, "/recipes/{id}")
+	assert.NoError(t, c.Do(ctx, http.MethodGet, "/recipes/42", nil, nil))
+
+	if assert.Len(t, spans, 1) {
+		span := spans[0]
+		assert.Equal(t, "HTTP GET /recipes/{id}", span.Name)
+		assert.Equal(t, "0af7651916cd43dd8448eb211c80319c", span.TraceID)
+		assert.Equal(t, "b7ad6b7169203331", span.ParentSpanID)
+		assert.Equal(t, http.StatusOK, span.StatusCode)
+		assert.Equal(t, 1, span.Retries)
+		assert.Equal(t, "00-0af7651916cd43dd8448eb211c80319c-"+span.SpanID+"-01", traceParent)
+	}
+}
+
+func TestWithTracer(t *testing.T) {
+	var calls atomic.Int32
+	var traceParent string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceParent = r.Header.Get(correlation.TraceParentHeader)
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer srv.Close()
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	c := New(srv.URL, WithBackoff(time.Millisecond, 5*time.Millisecond), WithTracer(provider.Tracer("clients")))
+
+	parent := "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"
+	ctx := correlation.WithIDs(context.Background(), correlation.IDs{TraceParent: parent})
+	ctx = WithRoute(ctx, "/recipes/{id}")
+	assert.NoError(t, c.Do(ctx, http.MethodGet, "/recipes/42", nil, nil))
+
+	spans := recorder.Ended()
+	if !assert.Len(t, spans, 1) {
+		return
+	}
+	span := spans[0]
+	assert.Equal(t, "HTTP GET /recipes/{id}", span.Name())
+	assert.Equal(t, oteltrace.SpanKindClient, span.SpanKind())
+	assert.Equal(t, "0af7651916cd43dd8448eb211c80319c", span.SpanContext().TraceID().String())
+	assert.Equal(t, "b7ad6b7169203331", span.Parent().SpanID().String())
+	assert.Equal(t, "00-0af7651916cd43dd8448eb211c80319c-"+span.SpanContext().SpanID().String()+"-01", traceParent)
+
+	attrs := make(map[attribute.Key]attribute.Value)
+	for _, attr := range span.Attributes() {
+		attrs[attr.Key] = attr.Value
+	}
+	assert.Equal(t, "/recipes/{id}", attrs["http.route"].AsString())
+	assert.Equal(t, int64(http.StatusOK), attrs["http.response.status_code"].AsInt64())
+	assert.Equal(t, int64(1), attrs["http.request.resend_count"].AsInt64())
+}
+
+func TestRetryAfter(t *testing.T) {
+	t.Run("Honors Retry-After", func(t *testing.T) {
+		var calls atomic.Int32
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if calls.Add(1) == 1 {
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(http.StatusTooManyRequests)
+			}
+		}))
+		defer srv.Close()
+
+		c := New(srv.URL, WithBackoff(time.Millisecond, 5*time.Millisecond))
+		assert.NoError(t, c.Do(context.Background(), http.MethodGet, "/", nil, nil))
+		assert.Equal(t, int32(2), calls.Load())
+	})
+
+	t.Run("Gives Up Beyond Budget", func(t *testing.T) {
+		var calls atomic.Int32
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			w.Header().Set("Retry-After", "120")
+			w.WriteHeader(http.StatusTooManyRequests)
+		}))
+		defer srv.Close()
+
+		c := New(srv.URL, WithMaxRetryWait(time.Second))
+		err := c.Do(context.Background(), http.MethodGet, "/", nil, nil)
+		assert.Error(t, err)
+		assert.Equal(t, int32(1), calls.Load())
+	})
+
+	t.Run("Gives Up Beyond Deadline", func(t *testing.T) {
+		var calls atomic.Int32
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			w.Header().Set("Retry-After", "2")
+			w.WriteHeader(http.StatusTooManyRequests)
+		}))
+		defer srv.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+		defer cancel()
+		c := New(srv.URL, WithMaxRetryWait(time.Minute))
+		start := time.Now()
+		err := c.Do(ctx, http.MethodGet, "/", nil, nil)
+
+		// The 429 is returned straight away instead of sleeping into the deadline.
+		assert.Error(t, err)
+		assert.NotErrorIs(t, err, context.DeadlineExceeded)
+		assert.Equal(t, http.StatusTooManyRequests, errors.StatusCode(err))
+		assert.Less(t, time.Since(start), 250*time.Millisecond)
+		assert.Equal(t, int32(1), calls.Load())
+	})
+
+	t.Run("Parses Headers", func(t *testing.T) {
+		now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+		rt := &retryTransport{now: func() time.Time { return now }}
+		delay := func(key, value string) time.Duration {
+			resp := &http.Response{Header: http.Header{}}
+			resp.Header.Set(key, value)
+			d, _ := rt.serverDelay(resp)
+			return d
+		}
+
+		assert.Equal(t, 3*time.Second, delay("Retry-After", "3"))
+		assert.Equal(t, 10*time.Second, delay("Retry-After", now.Add(10*time.Second).Format(http.TimeFormat)))
+		assert.Equal(t, 5*time.Second, delay("X-RateLimit-Reset", "5"))
+		assert.Equal(t, 20*time.Second, delay("X-RateLimit-Reset", strconv.FormatInt(now.Add(20*time.Second).Unix(), 10)))
+	})
+}
+
+func TestRetryPolicy(t *testing.T) {
+	t.Run("Budget", func(t *testing.T) {
+		var calls atomic.Int32
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer srv.Close()
+
+		policy := NewRetryPolicy(RetryConfig{BaseDelay: time.Millisecond, BudgetRatio: 0.5, BudgetBurst: 1})
+		c := New(srv.URL, WithRetryPolicy(policy))
+		assert.Error(t, c.Do(context.Background(), http.MethodGet, "/", nil, nil))
+
+		assert.Equal(t, int32(2), calls.Load())
+		assert.Equal(t, RetryStats{Calls: 1, Retries: 1, BudgetExhausted: 1}, policy.Stats())
+	})
+
+	t.Run("Per-Attempt Timeout", func(t *testing.T) {
+		var calls atomic.Int32
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if calls.Add(1) == 1 {
+				select {
+				case <-r.Context().Done():
+				case <-time.After(time.Second):
+				}
+				return
+			}
+			w.Write([]byte(`{"name":"soup"}`))
+		}))
+		defer srv.Close()
+
+		policy := NewRetryPolicy(RetryConfig{BaseDelay: time.Millisecond, PerAttemptTimeout: 50 * time.Millisecond})
+		c := New(srv.URL, WithRetryPolicy(policy))
+		var out map[string]string
+		assert.NoError(t, c.Do(context.Background(), http.MethodGet, "/", nil, &out))
+		assert.Equal(t, "soup", out["name"])
+		assert.Equal(t, int64(1), policy.Stats().Retries)
+	})
+}
+
+func TestWithIdempotencyKeys(t *testing.T) {
+	var keys []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys = append(keys, r.Header.Get(IdempotencyKeyHeader))
+		if len(keys) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	c := New(srv.URL, WithIdempotencyKeys(), WithBackoff(time.Millisecond, 5*time.Millisecond))
+
+	t.Run("Retries POST With Stable Key", func(t *testing.T) {
+		assert.NoError(t, c.Do(context.Background(), http.MethodPost, "/recipes", map[string]string{"name": "x"}, nil))
+		if assert.Len(t, keys, 2) {
+			assert.NotEmpty(t, keys[0])
+			assert.Equal(t, keys[0], keys[1])
+		}
+	})
+
+	t.Run("Uses Caller Key", func(t *testing.T) {
+		keys = nil
+		ctx := WithIdempotencyKey(context.Background(), "order-42")
+		c.Do(ctx, http.MethodPost, "/recipes", nil, nil)
+		assert.Equal(t, "order-42", keys[0])
+	})
+
+	t.Run("Header Alone Is Not Retried", func(t *testing.T) {
+		var calls atomic.Int32
+		rt := RetryWith(NewRetryPolicy(RetryConfig{BaseDelay: time.Millisecond}))(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			calls.Add(1)
+			return &http.Response{StatusCode: http.StatusServiceUnavailable, Body: http.NoBody, Request: req}, nil
+		}))
+		req := httptest.NewRequest(http.MethodPost, "http://recipes/recipes", nil)
+		req.Header.Set(IdempotencyKeyHeader, "order-42")
+
+		resp, err := rt.RoundTrip(req)
+		if !assert.NoError(t, err) {
+			return
+		}
+		resp.Body.Close()
+		assert.Equal(t, int32(1), calls.Load())
+	})
+}
+
+func TestWithHedging(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			// The first attempt stalls until the hedge wins and it is cancelled.
+			<-r.Context().Done()
+			return
+		}
+		w.Write([]byte(`{"name":"hedged"}`))
+	}))
+	defer srv.Close()
+
+	c := New(srv.URL, WithHedging(10*time.Millisecond))
+	var out struct {
+		Name string `json:"name"`
+	}
+	start := time.Now()
+	assert.NoError(t, c.Do(context.Background(), http.MethodGet, "/recipes/1", nil, &out))
+	assert.Equal(t, "hedged", out.Name)
+	assert.Equal(t, int32(2), calls.Load())
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestWithConditionalCache(t *testing.T) {
+	var full, notModified atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		full.Add(1)
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte(`["project","recipe"]`))
+	}))
+	defer srv.Close()
+
+	c := New(srv.URL, WithConditionalCache(0))
+	for i := 0; i < 3; i++ {
+		var types []string
+		assert.NoError(t, c.Do(context.Background(), http.MethodGet, "/resource-types", nil, &types))
+		assert.Equal(t, []string{"project", "recipe"}, types)
+	}
+	assert.Equal(t, int32(1), full.Load())
+	assert.Equal(t, int32(2), notModified.Load())
+}
+
+type recordingLimiter struct{ hosts []string }
+
+func (l *recordingLimiter) Wait(_ context.Context, host string) error {
+	l.hosts = append(l.hosts, host)
+	return nil
+}
+
+func TestWithRateLimit(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	t.Run("Paces Every Request", func(t *testing.T) {
+		limiter := &recordingLimiter{}
+		c := New(srv.URL, WithHostLimiter(limiter))
+		assert.NoError(t, c.Do(context.Background(), http.MethodGet, "/a", nil, nil))
+		assert.NoError(t, c.Do(context.Background(), http.MethodGet, "/b", nil, nil))
+		assert.Equal(t, []string{srv.Listener.Addr().String(), srv.Listener.Addr().String()}, limiter.hosts)
+	})
+
+	t.Run("Exhausted Budget Honors Context", func(t *testing.T) {
+		c := New(srv.URL, WithRateLimit(0, 1), WithRetries(0))
+		assert.NoError(t, c.Do(context.Background(), http.MethodGet, "/a", nil, nil))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(t, c.Do(ctx, http.MethodGet, "/a", nil, nil), context.DeadlineExceeded)
+	})
+}
+
+func TestChain(t *testing.T) {
+	var order []string
+	named := func(name string) Middleware {
+		return func(next http.RoundTripper) http.RoundTripper {
+			return roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				order = append(order, name)
+				return next.RoundTrip(req)
+			})
+		}
+	}
+	base := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		order = append(order, "base")
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: req}, nil
+	})
+
+	rt := Chain(base, named("auth"), named("retry"), named("logging"))
+	_, err := rt.RoundTrip(httptest.NewRequest(http.MethodGet, "http://svc/", nil))
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"logging", "retry", "auth", "base"}, order)
+}
+
+func TestDeadlinePropagation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var remaining time.Duration
+	r := gin.New()
+	r.Use(DeadlineMiddleware(time.Minute))
+	r.GET("/", func(c *gin.Context) {
+		deadline, ok := c.Request.Context().Deadline()
+		assert.True(t, ok)
+		remaining = time.Until(deadline)
+	})
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	ctx, cancelBudget := Budget(ctx, time.Second)
+	defer cancelBudget()
+
+	assert.NoError(t, New(srv.URL).Do(ctx, http.MethodGet, "/", nil, nil))
+	assert.Greater(t, remaining, time.Duration(0))
+	assert.LessOrEqual(t, remaining, time.Second)
+}
+
+func TestDeadlineMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var remaining time.Duration
+	var hasDeadline bool
+	r := gin.New()
+	r.Use(DeadlineMiddleware(5 * time.Second))
+	r.GET("/", func(c *gin.Context) {
+		var deadline time.Time
+		deadline, hasDeadline = c.Request.Context().Deadline()
+		remaining = time.Until(deadline)
+	})
+
+	tests := []struct {
+		name        string
+		header      http.Header
+		wantMin     time.Duration
+		wantMax     time.Duration
+		wantNoLimit bool
+	}{
+		{name: "no header", header: http.Header{}, wantNoLimit: true},
+		{name: "timeout", header: http.Header{TimeoutHeader: {"2000"}}, wantMin: time.Second, wantMax: 2 * time.Second},
+		{name: "timeout clamped", header: http.Header{TimeoutHeader: {"3600000"}}, wantMin: 4 * time.Second, wantMax: 5 * time.Second},
+		{
+			name: "timeout preferred over skewed deadline",
+			header: http.Header{
+				TimeoutHeader:  {"2000"},
+				DeadlineHeader: {time.Now().Add(-time.Minute).UTC().Format(time.RFC3339Nano)},
+			},
+			wantMin: time.Second,
+			wantMax: 2 * time.Second,
+		},
+		{
+			name:    "deadline clamped",
+			header:  http.Header{DeadlineHeader: {time.Now().Add(time.Hour).UTC().Format(time.RFC3339Nano)}},
+			wantMin: 4 * time.Second,
+			wantMax: 5 * time.Second,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header = tt.header
+			r.ServeHTTP(httptest.NewRecorder(), req)
+
+			if tt.wantNoLimit {
+				assert.False(t, hasDeadline)
+				return
+			}
+			assert.True(t, hasDeadline)
+			assert.GreaterOrEqual(t, remaining, tt.wantMin)
+			assert.LessOrEqual(t, remaining, tt.wantMax)
+		})
+	}
+}
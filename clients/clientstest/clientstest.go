@@ -0,0 +1,222 @@
+// Package clientstest provides an in-memory transport for testing code that calls other services through
+// the clients package, without starting an httptest server for every dependency.
+package clientstest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/hkinc45/dev-kitchen-go-common/clients"
+	"github.com/hkinc45/dev-kitchen-go-common/errors"
+)
+
+// BaseURL is the base URL of clients returned by Transport.Client.
+const BaseURL = "http://clientstest.invalid"
+
+// RecordedRequest is a request received by the transport.
+type RecordedRequest struct {
+	Method string
+	Path   string
+	Query  string
+	Header http.Header
+	Body   []byte
+}
+
+// DecodeJSON decodes the request body into v.
+func (r RecordedRequest) DecodeJSON(v interface{}) error {
+	return json.Unmarshal(r.Body, v)
+}
+
+// Transport is a mock http.RoundTripper answering requests from scripted routes. Requests that match no
+// route fail the test, and routes given an expected call count are checked when the test finishes.
+type Transport struct {
+	t testing.TB
+
+	mu       sync.Mutex
+	routes   []*Route
+	requests []RecordedRequest
+}
+
+// Route is a scripted response for a method and path.
+type Route struct {
+	method  string
+	pattern []string
+	respond func(req *http.Request) (*http.Response, error)
+
+	calls    int
+	expected int // -1 when the route may be called any number of times
+}
+
+// New creates a Transport bound to t.
+func New(t testing.TB) *Transport {
+	m := &Transport{t: t}
+	t.Cleanup(m.assertExpectations)
+	return m
+}
+
+// Client returns a clients.Client sending its requests to the transport. Retries are disabled so scripted
+// failures are observed exactly once; opts may override that.
+func (m *Transport) Client(opts ...clients.Option) *clients.Client {
+	opts = append([]clients.Option{clients.WithTransport(m), clients.WithRetries(0)}, opts...)
+	return clients.New(BaseURL, opts...)
+}
+
+// On scripts a route for method and path. Path segments in braces, e.g. "/recipes/{id}", match any value.
+// By default the route answers 200 OK with an empty body.
+func (m *Transport) On(method, path string) *Route {
+	r := &Route{
+		method:   method,
+		pattern:  splitPath(path),
+		expected: -1,
+	}
+	r.Respond(http.StatusOK, nil)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.routes = append(m.routes, r)
+	return r
+}
+
+// Respond answers with status and body encoded as JSON. A nil body sends no content.
+func (r *Route) Respond(status int, body interface{}) *Route {
+	var payload []byte
+	if body != nil {
+		payload, _ = json.Marshal(body)
+	}
+	r.respond = func(req *http.Request) (*http.Response, error) {
+		return newResponse(req, status, payload), nil
+	}
+	return r
+}
+
+// RespondError answers with status and an error body in the APIError format used by our services.
+func (r *Route) RespondError(status int, message string) *Route {
+	return r.Respond(status, errors.NewAPIError(status, message))
+}
+
+// RespondWith answers with the result of fn, e.g. to return a transport error.
+func (r *Route) RespondWith(fn func(req *http.Request) (*http.Response, error)) *Route {
+	r.respond = fn
+	return r
+}
+
+// Times expects the route to be called exactly n times by the end of the test.
+func (r *Route) Times(n int) *Route {
+	r.expected = n
+	return r
+}
+
+// RoundTrip implements http.RoundTripper.
+func (m *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	recorded := RecordedRequest{
+		Method: req.Method,
+		Path:   req.URL.Path,
+		Query:  req.URL.RawQuery,
+		Header: req.Header.Clone(),
+	}
+	if req.Body != nil {
+		recorded.Body, _ = io.ReadAll(req.Body)
+		req.Body.Close()
+		// Leave the body readable for RespondWith handlers.
+		req.Body = io.NopCloser(bytes.NewReader(recorded.Body))
+	}
+
+	m.mu.Lock()
+	m.requests = append(m.requests, recorded)
+	route := m.matchLocked(req.Method, req.URL.Path)
+	if route != nil {
+		route.calls++
+	}
+	m.mu.Unlock()
+
+	if route == nil {
+		m.t.Errorf("clientstest: unexpected request %s %s", req.Method, req.URL.Path)
+		return newResponse(req, http.StatusNotImplemented, []byte(`{"error":"no scripted route"}`)), nil
+	}
+	return route.respond(req)
+}
+
+// Requests returns every request received so far.
+func (m *Transport) Requests() []RecordedRequest {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]RecordedRequest(nil), m.requests...)
+}
+
+// RequestsTo returns the requests received for method and path, which may contain {param} segments.
+func (m *Transport) RequestsTo(method, path string) []RecordedRequest {
+	pattern := splitPath(path)
+	var matched []RecordedRequest
+	for _, req := range m.Requests() {
+		if req.Method == method && matchPath(pattern, splitPath(req.Path)) {
+			matched = append(matched, req)
+		}
+	}
+	return matched
+}
+
+// matchLocked returns the most recently scripted route matching the request. The caller must hold m.mu.
+func (m *Transport) matchLocked(method, path string) *Route {
+	segments := splitPath(path)
+	for i := len(m.routes) - 1; i >= 0; i-- {
+		r := m.routes[i]
+		if r.method == method && matchPath(r.pattern, segments) {
+			return r
+		}
+	}
+	return nil
+}
+
+func (m *Transport) assertExpectations() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, r := range m.routes {
+		if r.expected >= 0 && r.calls != r.expected {
+			m.t.Errorf("clientstest: expected %s /%s to be called %d time(s), got %d",
+				r.method, strings.Join(r.pattern, "/"), r.expected, r.calls)
+		}
+	}
+}
+
+func splitPath(path string) []string {
+	return strings.Split(strings.Trim(path, "/"), "/")
+}
+
+func matchPath(pattern, segments []string) bool {
+	if len(pattern) != len(segments) {
+		return false
+	}
+	for i, p := range pattern {
+		if strings.HasPrefix(p, "{") && strings.HasSuffix(p, "}") {
+			continue
+		}
+		if p != segments[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func newResponse(req *http.Request, status int, body []byte) *http.Response {
+	header := make(http.Header)
+	if len(body) > 0 {
+		header.Set("Content-Type", "application/json")
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
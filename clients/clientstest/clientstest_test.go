@@ -0,0 +1,57 @@
+package clientstest
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/hkinc45/dev-kitchen-go-common/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+type recipe struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+func TestTransport(t *testing.T) {
+	m := New(t)
+	m.On(http.MethodGet, "/recipes/{id}").Respond(http.StatusOK, recipe{ID: "r1", Name: "soup"}).Times(1)
+	m.On(http.MethodPost, "/recipes").RespondError(http.StatusUnprocessableEntity, "name is required")
+
+	c := m.Client()
+
+	var got recipe
+	assert.NoError(t, c.Do(context.Background(), http.MethodGet, "/recipes/r1", nil, &got))
+	assert.Equal(t, recipe{ID: "r1", Name: "soup"}, got)
+
+	err := c.Do(context.Background(), http.MethodPost, "/recipes", recipe{Name: ""}, nil)
+	var apiErr *errors.APIError
+	if assert.ErrorAs(t, err, &apiErr) {
+		assert.Equal(t, http.StatusUnprocessableEntity, apiErr.StatusCode)
+		assert.Equal(t, "name is required", apiErr.Message)
+	}
+
+	posts := m.RequestsTo(http.MethodPost, "/recipes")
+	if assert.Len(t, posts, 1) {
+		var sent recipe
+		assert.NoError(t, posts[0].DecodeJSON(&sent))
+		assert.Equal(t, "application/json", posts[0].Header.Get("Content-Type"))
+	}
+}
+
+// recordingTB captures failures instead of failing the enclosing test.
+type recordingTB struct {
+	testing.TB
+	failed bool
+}
+
+func (r *recordingTB) Errorf(string, ...interface{}) { r.failed = true }
+
+func TestTransportUnexpectedRequest(t *testing.T) {
+	tb := &recordingTB{TB: t}
+	m := &Transport{t: tb}
+	err := m.Client().Do(context.Background(), http.MethodGet, "/unknown", nil, nil)
+	assert.Error(t, err)
+	assert.True(t, tb.failed)
+}
@@ -0,0 +1,33 @@
+package clients
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/hkinc45/dev-kitchen-go-common/correlation"
+)
+
+// correlationTransport copies the correlation identifiers (request ID, trace context and tenant) from the
+// request context onto outbound requests, generating a request ID when the context has none.
+// Headers already set on the request take precedence.
+type correlationTransport struct {
+	base http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *correlationTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	ids := correlation.FromContext(ctx)
+	if ids.RequestID == "" {
+		ids.RequestID = uuid.NewString()
+		ctx = correlation.WithIDs(ctx, ids)
+	}
+
+	req = req.Clone(ctx)
+	correlation.Inject(ctx, func(key, value string) {
+		if req.Header.Get(key) == "" {
+			req.Header.Set(key, value)
+		}
+	})
+	return t.base.RoundTrip(req)
+}
@@ -0,0 +1,78 @@
+package clients
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Headers that carry the deadline of the overall request, so downstream services stop working on calls whose
+// caller has already given up. TimeoutHeader holds the remaining time in milliseconds and is not affected by
+// clock skew between hosts; DeadlineHeader holds the absolute deadline in RFC 3339 format and is only read
+// when TimeoutHeader is missing, e.g. from older clients.
+const (
+	TimeoutHeader  = "X-Timeout"
+	DeadlineHeader = "X-Deadline"
+)
+
+// Budget derives a context for outbound calls that expires reserve before ctx does, leaving the handler
+// time to respond after its downstream calls time out. Without a deadline on ctx, it only adds cancellation.
+//
+//	ctx, cancel := clients.Budget(c.Request.Context(), 200*time.Millisecond)
+//	defer cancel()
+func Budget(ctx context.Context, reserve time.Duration) (context.Context, context.CancelFunc) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return context.WithCancel(ctx)
+	}
+	return context.WithDeadline(ctx, deadline.Add(-reserve))
+}
+
+// DeadlineMiddleware is a Gin middleware that applies the deadline from the X-Timeout or X-Deadline header,
+// if any, to the request context. The deadline is clamped to maxBudget from now, so a caller cannot keep
+// the service busy longer than it allows, nor extend the deadline through a clock running ahead.
+func DeadlineMiddleware(maxBudget time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		budget, ok := requestBudget(c.Request.Header, time.Now())
+		if !ok {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), min(budget, maxBudget))
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// requestBudget returns the time left for the request according to its headers.
+func requestBudget(header http.Header, now time.Time) (time.Duration, bool) {
+	if ms, err := strconv.ParseInt(header.Get(TimeoutHeader), 10, 64); err == nil && ms >= 0 {
+		return time.Duration(ms) * time.Millisecond, true
+	}
+	if deadline, err := time.Parse(time.RFC3339Nano, header.Get(DeadlineHeader)); err == nil {
+		return deadline.Sub(now), true
+	}
+	return 0, false
+}
+
+// deadlineTransport sends the request context's deadline downstream in the X-Timeout and X-Deadline headers.
+type deadlineTransport struct {
+	base http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *deadlineTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	deadline, ok := req.Context().Deadline()
+	if !ok || req.Header.Get(TimeoutHeader) != "" || req.Header.Get(DeadlineHeader) != "" {
+		return t.base.RoundTrip(req)
+	}
+	req = req.Clone(req.Context())
+	req.Header.Set(TimeoutHeader, strconv.FormatInt(max(time.Until(deadline), 0).Milliseconds(), 10))
+	req.Header.Set(DeadlineHeader, deadline.UTC().Format(time.RFC3339Nano))
+	return t.base.RoundTrip(req)
+}
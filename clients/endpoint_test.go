@@ -0,0 +1,59 @@
+package clients
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testRecipe struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+func TestEndpoint(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			assert.Equal(t, "/projects/p%201/recipes/42", r.URL.EscapedPath())
+			json.NewEncoder(w).Encode(testRecipe{ID: "42", Name: "soup"})
+		case http.MethodPost:
+			var in testRecipe
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&in))
+			assert.Equal(t, "stew", in.Name)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer srv.Close()
+	c := New(srv.URL)
+
+	getRecipe := NewEndpoint[Empty, testRecipe](http.MethodGet, "/projects/{project}/recipes/{id}")
+	recipe, err := getRecipe.Call(context.Background(), c, Params{"project": "p 1", "id": "42"}, Empty{})
+	assert.NoError(t, err)
+	assert.Equal(t, testRecipe{ID: "42", Name: "soup"}, recipe)
+
+	createRecipe := NewEndpoint[testRecipe, Empty](http.MethodPost, "/recipes")
+	_, err = createRecipe.Call(context.Background(), c, nil, testRecipe{Name: "stew"})
+	assert.NoError(t, err)
+
+	_, err = getRecipe.Call(context.Background(), c, Params{"id": "42"}, Empty{})
+	assert.ErrorContains(t, err, `missing value for path parameter "project"`)
+}
+
+func TestDo(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var in testRecipe
+		json.NewDecoder(r.Body).Decode(&in)
+		in.ID = "new-id"
+		json.NewEncoder(w).Encode(in)
+	}))
+	defer srv.Close()
+
+	created, err := Do[testRecipe, testRecipe](context.Background(), New(srv.URL), http.MethodPost, "/recipes", testRecipe{Name: "stew"})
+	assert.NoError(t, err)
+	assert.Equal(t, testRecipe{ID: "new-id", Name: "stew"}, created)
+}
@@ -0,0 +1,105 @@
+package clients
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/hkinc45/dev-kitchen-go-common/errors"
+	"github.com/hkinc45/dev-kitchen-go-common/models"
+)
+
+// Meta is the "meta" member of a response envelope, as models.RespondPage writes it.
+type Meta struct {
+	Pagination *models.Pagination `json:"pagination,omitempty"`
+	Warnings   []string           `json:"warnings,omitempty"`
+	// Raw holds the whole meta object, for service-specific members.
+	Raw json.RawMessage `json:"-"`
+}
+
+// envelopeKeys are the only members a response may have to be treated as an envelope.
+var envelopeKeys = map[string]bool{"data": true, "meta": true, "links": true}
+
+// HandleEnvelope is HandleResponse for services using the {"data": ..., "meta": ...} envelope: it decodes
+// "data" into successBody and returns the meta, which is nil when absent. Bare bodies from services not
+// yet using the envelope are decoded directly into successBody, so callers can migrate ahead of them.
+func HandleEnvelope(resp *http.Response, successBody interface{}) (*Meta, error) {
+	return handleEnvelope(resp, successBody, nil)
+}
+
+func handleEnvelope(resp *http.Response, successBody interface{}, decoder ErrorDecoder) (*Meta, error) {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 && isHTML(resp) {
+		// Let HandleResponse report the HTML page consistently.
+		return nil, HandleResponse(resp, successBody)
+	}
+
+	var raw []byte
+	if err := HandleResponseWith(resp, &raw, decoder); err != nil {
+		return nil, err
+	}
+	if len(raw) == 0 {
+		return nil, nil
+	}
+
+	var members map[string]json.RawMessage
+	if json.Unmarshal(raw, &members) != nil || !isEnvelope(members) {
+		if successBody == nil {
+			return nil, nil
+		}
+		if err := json.Unmarshal(raw, successBody); err != nil {
+			return nil, errors.NewAPIError(http.StatusInternalServerError, fmt.Sprintf("failed to decode success response: %v", err))
+		}
+		return nil, nil
+	}
+
+	if data, ok := members["data"]; ok && successBody != nil {
+		if err := json.Unmarshal(data, successBody); err != nil {
+			return nil, errors.NewAPIError(http.StatusInternalServerError, fmt.Sprintf("failed to decode response data: %v", err))
+		}
+	}
+
+	rawMeta, ok := members["meta"]
+	if !ok || string(rawMeta) == "null" {
+		return nil, nil
+	}
+	meta := &Meta{Raw: rawMeta}
+	if err := json.Unmarshal(rawMeta, meta); err != nil {
+		return nil, errors.NewAPIError(http.StatusInternalServerError, fmt.Sprintf("failed to decode response meta: %v", err))
+	}
+	return meta, nil
+}
+
+func isEnvelope(members map[string]json.RawMessage) bool {
+	if _, ok := members["data"]; !ok {
+		return false
+	}
+	for key := range members {
+		if !envelopeKeys[key] {
+			return false
+		}
+	}
+	return true
+}
+
+// DoEnvelope is Do for endpoints returning the response envelope.
+func DoEnvelope[TReq, TResp any](ctx context.Context, client *Client, method, path string, req TReq) (TResp, *Meta, error) {
+	var resp TResp
+
+	var body interface{} = req
+	if _, ok := body.(Empty); ok {
+		body = nil
+	}
+	httpReq, err := client.NewRequest(ctx, method, path, body)
+	if err != nil {
+		return resp, nil, err
+	}
+	httpResp, err := client.HTTP.Do(httpReq)
+	if err != nil {
+		return resp, nil, fmt.Errorf("failed to execute %s %s: %w", method, httpReq.URL.Path, err)
+	}
+	defer httpResp.Body.Close()
+
+	meta, err := handleEnvelope(httpResp, &resp, client.errorDecoder)
+	return resp, meta, err
+}
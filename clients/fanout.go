@@ -0,0 +1,158 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Defaults for FanOut.
+const (
+	DefaultFanOutConcurrency = 8
+	DefaultFanOutTimeout     = 5 * time.Second
+)
+
+// Call is one named call of a fan-out.
+type Call struct {
+	Name string
+	// Timeout bounds this call. Defaults to the fan-out's timeout.
+	Timeout time.Duration
+	Do      func(ctx context.Context) (interface{}, error)
+}
+
+// FanOutOptions configures FanOutWith.
+type FanOutOptions struct {
+	// Concurrency caps the number of calls in flight. Defaults to DefaultFanOutConcurrency.
+	Concurrency int
+	// Timeout bounds calls that do not set their own. Defaults to DefaultFanOutTimeout.
+	Timeout time.Duration
+}
+
+// CallResult is the outcome of one call.
+type CallResult struct {
+	Value    interface{}
+	Err      error
+	Duration time.Duration
+}
+
+// FanOutResult collects the outcomes of a fan-out, keyed by call name.
+type FanOutResult struct {
+	Results map[string]CallResult
+}
+
+// Succeeded returns the values of the calls that succeeded.
+func (r *FanOutResult) Succeeded() map[string]interface{} {
+	values := make(map[string]interface{})
+	for name, res := range r.Results {
+		if res.Err == nil {
+			values[name] = res.Value
+		}
+	}
+	return values
+}
+
+// Failed returns the errors of the calls that failed.
+func (r *FanOutResult) Failed() map[string]error {
+	errs := make(map[string]error)
+	for name, res := range r.Results {
+		if res.Err != nil {
+			errs[name] = res.Err
+		}
+	}
+	return errs
+}
+
+// Partial reports whether some, but not all, calls failed, e.g. to render a dashboard with missing panels.
+func (r *FanOutResult) Partial() bool {
+	failed := len(r.Failed())
+	return failed > 0 && failed < len(r.Results)
+}
+
+// Err joins the errors of every failed call, or returns nil if all succeeded.
+func (r *FanOutResult) Err() error {
+	var errs []error
+	for name, err := range r.Failed() {
+		errs = append(errs, fmt.Errorf("%s: %w", name, err))
+	}
+	return errors.Join(errs...)
+}
+
+// ResultAs returns the value of the named call as a T, or the call's error.
+func ResultAs[T any](r *FanOutResult, name string) (T, error) {
+	var zero T
+	res, ok := r.Results[name]
+	if !ok {
+		return zero, fmt.Errorf("no call named %q in fan-out", name)
+	}
+	if res.Err != nil {
+		return zero, res.Err
+	}
+	value, ok := res.Value.(T)
+	if !ok {
+		return zero, fmt.Errorf("call %q returned %T, not %T", name, res.Value, zero)
+	}
+	return value, nil
+}
+
+// FanOut runs calls concurrently with the default options, e.g. for dashboard endpoints composing data
+// from several services. It waits for every call and never fails as a whole; inspect the result instead.
+func FanOut(ctx context.Context, calls ...Call) *FanOutResult {
+	return FanOutWith(ctx, FanOutOptions{}, calls...)
+}
+
+// FanOutWith runs calls concurrently with bounded concurrency and per-call timeouts.
+func FanOutWith(ctx context.Context, opts FanOutOptions, calls ...Call) *FanOutResult {
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = DefaultFanOutConcurrency
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultFanOutTimeout
+	}
+
+	result := &FanOutResult{Results: make(map[string]CallResult, len(calls))}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, opts.Concurrency)
+
+	for _, call := range calls {
+		wg.Add(1)
+		go func(call Call) {
+			defer wg.Done()
+
+			var res CallResult
+			select {
+			case sem <- struct{}{}:
+				res = runCall(ctx, call, opts.Timeout)
+				<-sem
+			case <-ctx.Done():
+				res.Err = ctx.Err()
+			}
+
+			mu.Lock()
+			result.Results[call.Name] = res
+			mu.Unlock()
+		}(call)
+	}
+	wg.Wait()
+	return result
+}
+
+func runCall(ctx context.Context, call Call, timeout time.Duration) (res CallResult) {
+	if call.Timeout > 0 {
+		timeout = call.Timeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	defer func() {
+		res.Duration = time.Since(start)
+		if r := recover(); r != nil {
+			res.Err = fmt.Errorf("call %s panicked: %v", call.Name, r)
+		}
+	}()
+	res.Value, res.Err = call.Do(ctx)
+	return res
+}
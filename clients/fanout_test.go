@@ -0,0 +1,31 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFanOut(t *testing.T) {
+	res := FanOutWith(context.Background(), FanOutOptions{Concurrency: 2},
+		Call{Name: "projects", Do: func(context.Context) (interface{}, error) { return []string{"p1"}, nil }},
+		Call{Name: "recipes", Do: func(context.Context) (interface{}, error) { return nil, errors.New("recipe-service down") }},
+		Call{Name: "slow", Timeout: 10 * time.Millisecond, Do: func(ctx context.Context) (interface{}, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}},
+	)
+
+	assert.True(t, res.Partial())
+	assert.Len(t, res.Succeeded(), 1)
+	assert.Len(t, res.Failed(), 2)
+	assert.ErrorIs(t, res.Failed()["slow"], context.DeadlineExceeded)
+	assert.ErrorContains(t, res.Err(), "recipe-service down")
+
+	projects, err := ResultAs[[]string](res, "projects")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"p1"}, projects)
+}
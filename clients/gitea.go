@@ -0,0 +1,177 @@
+package clients
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/hkinc45/dev-kitchen-go-common/errors"
+	"golang.org/x/oauth2"
+)
+
+// GiteaOrg is a Gitea organization.
+type GiteaOrg struct {
+	ID          int64  `json:"id,omitempty"`
+	Name        string `json:"username"`
+	FullName    string `json:"full_name,omitempty"`
+	Description string `json:"description,omitempty"`
+	// Visibility is "public", "limited" or "private".
+	Visibility string `json:"visibility,omitempty"`
+}
+
+// GiteaRepo is a Gitea repository.
+type GiteaRepo struct {
+	ID            int64  `json:"id,omitempty"`
+	Name          string `json:"name"`
+	FullName      string `json:"full_name,omitempty"`
+	Description   string `json:"description,omitempty"`
+	Private       bool   `json:"private"`
+	DefaultBranch string `json:"default_branch,omitempty"`
+	CloneURL      string `json:"clone_url,omitempty"`
+	SSHURL        string `json:"ssh_url,omitempty"`
+	// AutoInit creates an initial commit when the repository is created.
+	AutoInit bool `json:"auto_init,omitempty"`
+}
+
+// GiteaHook is a repository webhook.
+type GiteaHook struct {
+	ID     int64             `json:"id,omitempty"`
+	Type   string            `json:"type"`
+	Active bool              `json:"active"`
+	Events []string          `json:"events"`
+	Config map[string]string `json:"config"`
+}
+
+// GiteaDeployKey is a repository deploy key.
+type GiteaDeployKey struct {
+	ID       int64  `json:"id,omitempty"`
+	Title    string `json:"title"`
+	Key      string `json:"key"`
+	ReadOnly bool   `json:"read_only"`
+}
+
+// Gitea API endpoints used for provisioning.
+var (
+	giteaCreateOrg       = NewEndpoint[GiteaOrg, GiteaOrg](http.MethodPost, "/api/v1/orgs")
+	giteaGetOrg          = NewEndpoint[Empty, GiteaOrg](http.MethodGet, "/api/v1/orgs/{org}")
+	giteaDeleteOrg       = NewEndpoint[Empty, Empty](http.MethodDelete, "/api/v1/orgs/{org}")
+	giteaCreateRepo      = NewEndpoint[GiteaRepo, GiteaRepo](http.MethodPost, "/api/v1/orgs/{org}/repos")
+	giteaGetRepo         = NewEndpoint[Empty, GiteaRepo](http.MethodGet, "/api/v1/repos/{owner}/{repo}")
+	giteaDeleteRepo      = NewEndpoint[Empty, Empty](http.MethodDelete, "/api/v1/repos/{owner}/{repo}")
+	giteaCreateHook      = NewEndpoint[GiteaHook, GiteaHook](http.MethodPost, "/api/v1/repos/{owner}/{repo}/hooks")
+	giteaListHooks       = NewEndpoint[Empty, []GiteaHook](http.MethodGet, "/api/v1/repos/{owner}/{repo}/hooks")
+	giteaDeleteHook      = NewEndpoint[Empty, Empty](http.MethodDelete, "/api/v1/repos/{owner}/{repo}/hooks/{id}")
+	giteaCreateDeployKey = NewEndpoint[GiteaDeployKey, GiteaDeployKey](http.MethodPost, "/api/v1/repos/{owner}/{repo}/keys")
+	giteaDeleteDeployKey = NewEndpoint[Empty, Empty](http.MethodDelete, "/api/v1/repos/{owner}/{repo}/keys/{id}")
+)
+
+// GiteaClient covers the Gitea organization, repository, webhook and deploy-key operations used when
+// provisioning users (see models.User.GiteaOrgName). Errors are returned as *errors.APIError carrying
+// Gitea's message.
+type GiteaClient struct {
+	client *Client
+}
+
+// NewGiteaClient creates a GiteaClient for the Gitea instance at baseURL, authenticating with an access
+// token. opts are applied to the underlying Client, which retries idempotent calls by default.
+func NewGiteaClient(baseURL, token string, opts ...Option) *GiteaClient {
+	ts := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token, TokenType: "token"})
+	opts = append([]Option{WithServiceAuth(ts), WithErrorDecoder(GiteaErrorDecoder)}, opts...)
+	return &GiteaClient{client: New(baseURL, opts...)}
+}
+
+// NewGiteaClientWith wraps an existing Client, e.g. one from clientstest, that already authenticates with Gitea.
+func NewGiteaClientWith(client *Client) *GiteaClient {
+	return &GiteaClient{client: client.withErrorDecoder(GiteaErrorDecoder)}
+}
+
+// CreateOrg creates an organization.
+func (g *GiteaClient) CreateOrg(ctx context.Context, org GiteaOrg) (*GiteaOrg, error) {
+	created, err := giteaCreateOrg.Call(ctx, g.client, nil, org)
+	return ptrOrErr(created, err)
+}
+
+// GetOrg returns an organization by name.
+func (g *GiteaClient) GetOrg(ctx context.Context, name string) (*GiteaOrg, error) {
+	org, err := giteaGetOrg.Call(ctx, g.client, Params{"org": name}, Empty{})
+	return ptrOrErr(org, err)
+}
+
+// DeleteOrg deletes an organization. Gitea refuses to delete organizations that still own repositories.
+func (g *GiteaClient) DeleteOrg(ctx context.Context, name string) error {
+	_, err := giteaDeleteOrg.Call(ctx, g.client, Params{"org": name}, Empty{})
+	return err
+}
+
+// CreateRepo creates a repository in an organization.
+func (g *GiteaClient) CreateRepo(ctx context.Context, org string, repo GiteaRepo) (*GiteaRepo, error) {
+	created, err := giteaCreateRepo.Call(ctx, g.client, Params{"org": org}, repo)
+	return ptrOrErr(created, err)
+}
+
+// GetRepo returns a repository.
+func (g *GiteaClient) GetRepo(ctx context.Context, owner, name string) (*GiteaRepo, error) {
+	repo, err := giteaGetRepo.Call(ctx, g.client, Params{"owner": owner, "repo": name}, Empty{})
+	return ptrOrErr(repo, err)
+}
+
+// DeleteRepo deletes a repository.
+func (g *GiteaClient) DeleteRepo(ctx context.Context, owner, name string) error {
+	_, err := giteaDeleteRepo.Call(ctx, g.client, Params{"owner": owner, "repo": name}, Empty{})
+	return err
+}
+
+// CreateWebhook adds a JSON webhook delivering events to url, signed with secret.
+func (g *GiteaClient) CreateWebhook(ctx context.Context, owner, repo, url, secret string, events ...string) (*GiteaHook, error) {
+	hook := GiteaHook{
+		Type:   "gitea",
+		Active: true,
+		Events: events,
+		Config: map[string]string{"url": url, "content_type": "json", "secret": secret},
+	}
+	created, err := giteaCreateHook.Call(ctx, g.client, Params{"owner": owner, "repo": repo}, hook)
+	return ptrOrErr(created, err)
+}
+
+// ListWebhooks returns the webhooks of a repository.
+func (g *GiteaClient) ListWebhooks(ctx context.Context, owner, repo string) ([]GiteaHook, error) {
+	hooks, err := giteaListHooks.Call(ctx, g.client, Params{"owner": owner, "repo": repo}, Empty{})
+	return hooks, err
+}
+
+// DeleteWebhook removes a webhook from a repository.
+func (g *GiteaClient) DeleteWebhook(ctx context.Context, owner, repo string, id int64) error {
+	_, err := giteaDeleteHook.Call(ctx, g.client, Params{"owner": owner, "repo": repo, "id": strconv.FormatInt(id, 10)}, Empty{})
+	return err
+}
+
+// AddDeployKey adds an SSH deploy key to a repository.
+func (g *GiteaClient) AddDeployKey(ctx context.Context, owner, repo string, key GiteaDeployKey) (*GiteaDeployKey, error) {
+	created, err := giteaCreateDeployKey.Call(ctx, g.client, Params{"owner": owner, "repo": repo}, key)
+	return ptrOrErr(created, err)
+}
+
+// DeleteDeployKey removes a deploy key from a repository.
+func (g *GiteaClient) DeleteDeployKey(ctx context.Context, owner, repo string, id int64) error {
+	_, err := giteaDeleteDeployKey.Call(ctx, g.client, Params{"owner": owner, "repo": repo, "id": strconv.FormatInt(id, 10)}, Empty{})
+	return err
+}
+
+func ptrOrErr[T any](v T, err error) (*T, error) {
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// GiteaErrorDecoder decodes Gitea's error responses, which carry the message as {"message": "..."}.
+func GiteaErrorDecoder(respErr *ResponseError) *errors.APIError {
+	var body struct {
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(respErr.Body, &body) != nil || body.Message == "" {
+		return nil
+	}
+	return errors.NewAPIError(respErr.StatusCode, "gitea: "+body.Message)
+}
@@ -0,0 +1,32 @@
+package clients_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/hkinc45/dev-kitchen-go-common/clients"
+	"github.com/hkinc45/dev-kitchen-go-common/clients/clientstest"
+	"github.com/hkinc45/dev-kitchen-go-common/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGiteaClient(t *testing.T) {
+	m := clientstest.New(t)
+	m.On(http.MethodPost, "/api/v1/orgs/{org}/repos").Respond(http.StatusCreated, clients.GiteaRepo{ID: 7, Name: "recipes", Private: true})
+	m.On(http.MethodPost, "/api/v1/repos/{owner}/{repo}/keys").Respond(http.StatusUnprocessableEntity, map[string]string{"message": "Key content has been used as non-deploy key"})
+	g := clients.NewGiteaClientWith(m.Client())
+	ctx := context.Background()
+
+	repo, err := g.CreateRepo(ctx, "alice-org", clients.GiteaRepo{Name: "recipes", Private: true})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(7), repo.ID)
+	assert.Len(t, m.RequestsTo(http.MethodPost, "/api/v1/orgs/alice-org/repos"), 1)
+
+	_, err = g.AddDeployKey(ctx, "alice-org", "recipes", clients.GiteaDeployKey{Title: "ci", Key: "ssh-ed25519 AAAA"})
+	var apiErr *errors.APIError
+	if assert.ErrorAs(t, err, &apiErr) {
+		assert.Equal(t, http.StatusUnprocessableEntity, apiErr.StatusCode)
+		assert.Equal(t, "gitea: Key content has been used as non-deploy key", apiErr.Message)
+	}
+}
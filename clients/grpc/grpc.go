@@ -0,0 +1,155 @@
+// Package grpc provides the building blocks for dialing internal gRPC services consistently with the HTTP
+// client builder: client interceptors that authenticate calls with a service token, propagate the
+// correlation identifiers and convert error statuses into errors.APIError, and a retry policy expressed as a
+// gRPC service config:
+//
+//	creds := commongrpc.ServiceCredentials(tokenSource)
+//	conn, err := grpc.NewClient(target,
+//		grpc.WithTransportCredentials(transportCreds),
+//		grpc.WithChainUnaryInterceptor(commongrpc.UnaryClientInterceptor(creds)),
+//		grpc.WithChainStreamInterceptor(commongrpc.StreamClientInterceptor(creds)),
+//		grpc.WithDefaultServiceConfig(commongrpc.ServiceConfig(commongrpc.RetryPolicy{})),
+//	)
+package grpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hkinc45/dev-kitchen-go-common/correlation"
+	common_errors "github.com/hkinc45/dev-kitchen-go-common/errors"
+	"golang.org/x/oauth2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// Credentials implements grpc's credentials.PerRPCCredentials, attaching a service token and the
+// correlation identifiers from the call context to every RPC.
+type Credentials struct {
+	source   oauth2.TokenSource
+	insecure bool
+}
+
+// ServiceCredentials returns per-RPC credentials that authenticate with tokens from ts, e.g.
+// auth.NewServiceTokenSource. Tokens are only sent over TLS.
+func ServiceCredentials(ts oauth2.TokenSource) *Credentials {
+	return &Credentials{source: ts}
+}
+
+// AllowInsecure allows the credentials on plaintext connections, for local development only.
+func (c *Credentials) AllowInsecure() *Credentials {
+	c.insecure = true
+	return c
+}
+
+// GetRequestMetadata implements credentials.PerRPCCredentials.
+func (c *Credentials) GetRequestMetadata(ctx context.Context, _ ...string) (map[string]string, error) {
+	token, err := c.source.Token()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get service token: %w", err)
+	}
+
+	md := map[string]string{"authorization": token.Type() + " " + token.AccessToken}
+	injectCorrelation(ctx, func(key, value string) { md[key] = value })
+	return md, nil
+}
+
+// injectCorrelation writes the correlation identifiers stored in ctx as gRPC metadata, whose keys are
+// lower-case.
+func injectCorrelation(ctx context.Context, set func(key, value string)) {
+	correlation.Inject(ctx, func(key, value string) {
+		set(strings.ToLower(key), value)
+	})
+}
+
+// RequireTransportSecurity implements credentials.PerRPCCredentials.
+func (c *Credentials) RequireTransportSecurity() bool {
+	return !c.insecure
+}
+
+// UnaryClientInterceptor authenticates every call with creds, which also propagate the correlation
+// identifiers of the call context, and converts error statuses into errors.APIError (see
+// errors.FromGRPCError). With nil creds, calls are not authenticated but still carry the correlation
+// identifiers.
+func UnaryClientInterceptor(creds *Credentials) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		ctx, opts = withCallCredentials(ctx, creds, opts)
+		return common_errors.FromGRPCError(invoker(ctx, method, req, reply, cc, opts...))
+	}
+}
+
+// StreamClientInterceptor is UnaryClientInterceptor for streams; the errors of sending and receiving
+// messages are converted too.
+func StreamClientInterceptor(creds *Credentials) grpc.StreamClientInterceptor {
+	convertErrors := common_errors.StreamClientInterceptor()
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		ctx, opts = withCallCredentials(ctx, creds, opts)
+		return convertErrors(ctx, desc, cc, method, streamer, opts...)
+	}
+}
+
+// withCallCredentials adds creds to the options of a call, or the correlation identifiers to its outgoing
+// metadata if creds is nil.
+func withCallCredentials(ctx context.Context, creds *Credentials, opts []grpc.CallOption) (context.Context, []grpc.CallOption) {
+	if creds != nil {
+		return ctx, append(opts, grpc.PerRPCCredentials(creds))
+	}
+	var kv []string
+	injectCorrelation(ctx, func(key, value string) { kv = append(kv, key, value) })
+	if len(kv) == 0 {
+		return ctx, opts
+	}
+	return metadata.AppendToOutgoingContext(ctx, kv...), opts
+}
+
+// RetryPolicy configures retries with exponential backoff, mirroring the HTTP client defaults.
+type RetryPolicy struct {
+	// MaxAttempts includes the first attempt. Defaults to 3; gRPC caps it at 5.
+	MaxAttempts int
+	// InitialBackoff defaults to 100ms.
+	InitialBackoff time.Duration
+	// MaxBackoff defaults to 2s.
+	MaxBackoff time.Duration
+	// RetryableCodes are the status code names to retry. Defaults to UNAVAILABLE and RESOURCE_EXHAUSTED.
+	RetryableCodes []string
+}
+
+// ServiceConfig returns a gRPC service config, for grpc.WithDefaultServiceConfig, that applies policy to
+// every method of every service on the connection.
+func ServiceConfig(policy RetryPolicy) string {
+	if policy.MaxAttempts <= 0 {
+		policy.MaxAttempts = 3
+	}
+	if policy.InitialBackoff <= 0 {
+		policy.InitialBackoff = 100 * time.Millisecond
+	}
+	if policy.MaxBackoff <= 0 {
+		policy.MaxBackoff = 2 * time.Second
+	}
+	if len(policy.RetryableCodes) == 0 {
+		policy.RetryableCodes = []string{"UNAVAILABLE", "RESOURCE_EXHAUSTED"}
+	}
+
+	config := map[string]any{
+		"methodConfig": []any{map[string]any{
+			"name": []any{map[string]any{}},
+			"retryPolicy": map[string]any{
+				"maxAttempts":          policy.MaxAttempts,
+				"initialBackoff":       durationString(policy.InitialBackoff),
+				"maxBackoff":           durationString(policy.MaxBackoff),
+				"backoffMultiplier":    2.0,
+				"retryableStatusCodes": policy.RetryableCodes,
+			},
+		}},
+	}
+	data, _ := json.Marshal(config)
+	return string(data)
+}
+
+// durationString formats d the way the service config expects, e.g. "0.1s".
+func durationString(d time.Duration) string {
+	return fmt.Sprintf("%gs", d.Seconds())
+}
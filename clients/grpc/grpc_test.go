@@ -0,0 +1,122 @@
+package grpc
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/hkinc45/dev-kitchen-go-common/correlation"
+	common_errors "github.com/hkinc45/dev-kitchen-go-common/errors"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/oauth2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestServiceCredentials(t *testing.T) {
+	creds := ServiceCredentials(oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "svc-token"}))
+	ctx := correlation.WithIDs(context.Background(), correlation.IDs{RequestID: "req-123"})
+
+	md, err := creds.GetRequestMetadata(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "Bearer svc-token", md["authorization"])
+	assert.Equal(t, "req-123", md["x-request-id"])
+	assert.True(t, creds.RequireTransportSecurity())
+}
+
+func TestServiceConfig(t *testing.T) {
+	var config struct {
+		MethodConfig []struct {
+			RetryPolicy struct {
+				MaxAttempts    int    `json:"maxAttempts"`
+				InitialBackoff string `json:"initialBackoff"`
+			} `json:"retryPolicy"`
+		} `json:"methodConfig"`
+	}
+	assert.NoError(t, json.Unmarshal([]byte(ServiceConfig(RetryPolicy{})), &config))
+	assert.Equal(t, 3, config.MethodConfig[0].RetryPolicy.MaxAttempts)
+	assert.Equal(t, "0.1s", config.MethodConfig[0].RetryPolicy.InitialBackoff)
+}
+
+// healthServer records the metadata of health checks, failing checks of the "missing" service.
+type healthServer struct {
+	grpc_health_v1.UnimplementedHealthServer
+	md metadata.MD
+}
+
+func (s *healthServer) Check(ctx context.Context, req *grpc_health_v1.HealthCheckRequest) (*grpc_health_v1.HealthCheckResponse, error) {
+	s.md, _ = metadata.FromIncomingContext(ctx)
+	if req.Service == "missing" {
+		return nil, common_errors.NewNotFoundError("service not found")
+	}
+	return &grpc_health_v1.HealthCheckResponse{Status: grpc_health_v1.HealthCheckResponse_SERVING}, nil
+}
+
+func (s *healthServer) Watch(req *grpc_health_v1.HealthCheckRequest, stream grpc.ServerStreamingServer[grpc_health_v1.HealthCheckResponse]) error {
+	s.md, _ = metadata.FromIncomingContext(stream.Context())
+	return common_errors.NewNotFoundError("service not found")
+}
+
+func TestClientInterceptors(t *testing.T) {
+	lis := bufconn.Listen(1 << 20)
+	server := &healthServer{}
+	srv := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(common_errors.UnaryServerInterceptor()),
+		grpc.ChainStreamInterceptor(common_errors.StreamServerInterceptor()),
+	)
+	grpc_health_v1.RegisterHealthServer(srv, server)
+	go srv.Serve(lis)
+	defer srv.Stop()
+
+	dial := func(creds *Credentials) grpc_health_v1.HealthClient {
+		conn, err := grpc.NewClient("passthrough:///bufnet",
+			grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+			grpc.WithTransportCredentials(insecure.NewCredentials()),
+			grpc.WithChainUnaryInterceptor(UnaryClientInterceptor(creds)),
+			grpc.WithChainStreamInterceptor(StreamClientInterceptor(creds)),
+		)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		t.Cleanup(func() { conn.Close() })
+		return grpc_health_v1.NewHealthClient(conn)
+	}
+	ctx := correlation.WithIDs(context.Background(), correlation.IDs{RequestID: "req-123", TenantID: "acme"})
+
+	t.Run("service token and correlation", func(t *testing.T) {
+		creds := ServiceCredentials(oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "svc-token"})).AllowInsecure()
+		_, err := dial(creds).Check(ctx, &grpc_health_v1.HealthCheckRequest{})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"Bearer svc-token"}, server.md.Get("authorization"))
+		assert.Equal(t, []string{"req-123"}, server.md.Get("x-request-id"))
+		assert.Equal(t, []string{"acme"}, server.md.Get("x-tenant-id"))
+	})
+
+	t.Run("correlation without credentials", func(t *testing.T) {
+		_, err := dial(nil).Check(ctx, &grpc_health_v1.HealthCheckRequest{})
+		assert.NoError(t, err)
+		assert.Empty(t, server.md.Get("authorization"))
+		assert.Equal(t, []string{"req-123"}, server.md.Get("x-request-id"))
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		client := dial(nil)
+		_, err := client.Check(ctx, &grpc_health_v1.HealthCheckRequest{Service: "missing"})
+		assert.True(t, common_errors.IsNotFound(err), "want a not found APIError, got %v", err)
+
+		stream, err := client.Watch(ctx, &grpc_health_v1.HealthCheckRequest{})
+		if !assert.NoError(t, err) {
+			return
+		}
+		_, err = stream.Recv()
+		if apiErr, ok := common_errors.AsAPIError(err); assert.True(t, ok, "want an APIError, got %v", err) {
+			assert.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+			assert.Equal(t, []string{"req-123"}, server.md.Get("x-request-id"))
+		}
+	})
+}
@@ -0,0 +1,103 @@
+package clients
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+)
+
+// WithHedging enables hedged GET requests: when the first attempt has not completed after delay, a second
+// one is sent and whichever completes first is used. It trades extra load for lower tail latency on read
+// paths behind flaky networking, so only enable it for cheap, idempotent reads.
+func WithHedging(delay time.Duration) Option {
+	return func(o *clientOptions) { o.hedgeDelay = delay }
+}
+
+// hedgeTransport sends a second attempt of a slow GET request and returns the first to complete.
+type hedgeTransport struct {
+	base  http.RoundTripper
+	delay time.Duration
+}
+
+type hedgeResult struct {
+	attempt int
+	resp    *http.Response
+	err     error
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *hedgeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet || req.Body != nil && req.Body != http.NoBody {
+		return t.base.RoundTrip(req)
+	}
+
+	results := make(chan hedgeResult, 2)
+	var cancels []context.CancelFunc
+	send := func() {
+		ctx, cancel := context.WithCancel(req.Context())
+		attempt := len(cancels)
+		cancels = append(cancels, cancel)
+		go func() {
+			resp, err := t.base.RoundTrip(req.Clone(ctx))
+			results <- hedgeResult{attempt: attempt, resp: resp, err: err}
+		}()
+	}
+
+	send()
+	inflight := 1
+	timer := time.NewTimer(t.delay)
+	defer timer.Stop()
+
+	var err error
+	for inflight > 0 {
+		select {
+		case <-timer.C:
+			send()
+			inflight++
+		case res := <-results:
+			inflight--
+			if res.err != nil {
+				// Failures are left to the retry transport; only a pending hedge can still win.
+				cancels[res.attempt]()
+				err = res.err
+				timer.Stop()
+				continue
+			}
+			for attempt, cancel := range cancels {
+				if attempt != res.attempt {
+					cancel()
+				}
+			}
+			if inflight > 0 {
+				go discardHedge(results, inflight)
+			}
+			// The winning attempt's context must outlive the call until its body is closed.
+			res.resp.Body = &cancelOnClose{ReadCloser: res.resp.Body, cancel: cancels[res.attempt]}
+			return res.resp, nil
+		}
+	}
+	return nil, err
+}
+
+// discardHedge closes the responses of cancelled attempts still in flight after a winner was chosen.
+func discardHedge(results <-chan hedgeResult, n int) {
+	for ; n > 0; n-- {
+		res := <-results
+		if res.resp != nil {
+			res.resp.Body.Close()
+		}
+	}
+}
+
+// cancelOnClose releases an attempt's context once its response body is closed.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnClose) Close() error {
+	err := c.ReadCloser.Close()
+	c.cancel()
+	return err
+}
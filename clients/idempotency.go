@@ -0,0 +1,53 @@
+package clients
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+// IdempotencyKeyHeader carries the key a receiving service can use to deduplicate retried requests.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+type (
+	idempotencyKeyKey struct{}
+	retriableKey      struct{}
+)
+
+// WithIdempotencyKey returns a copy of ctx carrying the idempotency key for the next call, e.g. one derived
+// from the inbound request, so the same logical operation keeps its key across independent attempts.
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKeyKey{}, key)
+}
+
+// WithIdempotencyKeys attaches an Idempotency-Key header to POST and PATCH requests, using the key from the
+// request context (see WithIdempotencyKey) or a generated one, and allows those requests to be retried.
+// Nothing in this module deduplicates by the key: enable it only for services known to do so, otherwise a
+// retried request may be applied twice.
+func WithIdempotencyKeys() Option {
+	return func(o *clientOptions) { o.idempotencyKeys = true }
+}
+
+// idempotencyTransport sets the Idempotency-Key header once per call, so every retry carries the same key,
+// and marks the request as retriable for retryTransport.
+type idempotencyTransport struct {
+	base http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *idempotencyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodPost && req.Method != http.MethodPatch {
+		return t.base.RoundTrip(req)
+	}
+
+	req = req.Clone(context.WithValue(req.Context(), retriableKey{}, true))
+	if req.Header.Get(IdempotencyKeyHeader) == "" {
+		key, _ := req.Context().Value(idempotencyKeyKey{}).(string)
+		if key == "" {
+			key = uuid.NewString()
+		}
+		req.Header.Set(IdempotencyKeyHeader, key)
+	}
+	return t.base.RoundTrip(req)
+}
@@ -0,0 +1,422 @@
+package clients
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/hkinc45/dev-kitchen-go-common/auth"
+	"github.com/hkinc45/dev-kitchen-go-common/errors"
+)
+
+// KeycloakUser is a Keycloak user representation.
+type KeycloakUser struct {
+	ID               string              `json:"id,omitempty"`
+	Username         string              `json:"username"`
+	Email            string              `json:"email,omitempty"`
+	FirstName        string              `json:"firstName,omitempty"`
+	LastName         string              `json:"lastName,omitempty"`
+	Enabled          bool                `json:"enabled"`
+	EmailVerified    bool                `json:"emailVerified"`
+	Attributes       map[string][]string `json:"attributes,omitempty"`
+	CreatedTimestamp int64               `json:"createdTimestamp,omitempty"`
+}
+
+// KeycloakGroup is a Keycloak group.
+type KeycloakGroup struct {
+	ID         string              `json:"id,omitempty"`
+	Name       string              `json:"name"`
+	Path       string              `json:"path,omitempty"`
+	SubGroups  []KeycloakGroup     `json:"subGroups,omitempty"`
+	Attributes map[string][]string `json:"attributes,omitempty"`
+}
+
+// KeycloakRole is a realm or client role.
+type KeycloakRole struct {
+	ID          string `json:"id,omitempty"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Composite   bool   `json:"composite"`
+	ClientRole  bool   `json:"clientRole"`
+	ContainerID string `json:"containerId,omitempty"`
+}
+
+// KeycloakClientRep is a Keycloak client (OIDC application). ID is Keycloak's internal UUID, which the
+// admin API expects in paths; ClientID is the name applications authenticate with.
+type KeycloakClientRep struct {
+	ID                     string   `json:"id,omitempty"`
+	ClientID               string   `json:"clientId"`
+	Name                   string   `json:"name,omitempty"`
+	Enabled                bool     `json:"enabled"`
+	PublicClient           bool     `json:"publicClient"`
+	ServiceAccountsEnabled bool     `json:"serviceAccountsEnabled"`
+	RedirectURIs           []string `json:"redirectUris,omitempty"`
+}
+
+// KeycloakSession is an active user session.
+type KeycloakSession struct {
+	ID         string            `json:"id"`
+	Username   string            `json:"username"`
+	UserID     string            `json:"userId"`
+	IPAddress  string            `json:"ipAddress"`
+	Start      int64             `json:"start"`
+	LastAccess int64             `json:"lastAccess"`
+	Clients    map[string]string `json:"clients,omitempty"`
+}
+
+// KeycloakUserQuery filters ListUsers. Empty fields are not filtered on.
+type KeycloakUserQuery struct {
+	// Search matches the username, email, first or last name.
+	Search   string
+	Username string
+	Email    string
+	// Exact requires Username and Email to match exactly instead of as substrings.
+	Exact bool
+}
+
+// KeycloakConfig configures NewKeycloakAdmin.
+type KeycloakConfig struct {
+	// BaseURL is the Keycloak server, e.g. https://keycloak:8443.
+	BaseURL string
+	// Realm is the realm administered.
+	Realm string
+	// AdminRealm is the realm of the admin service account. Defaults to Realm.
+	AdminRealm string
+	// ClientID and ClientSecret identify a confidential client whose service account holds the
+	// realm-management roles needed for the calls made.
+	ClientID     string
+	ClientSecret string
+	// RateLimit caps requests per second to Keycloak, with bursts of up to RateBurst. Default to 20 and 10.
+	RateLimit float64
+	RateBurst int
+}
+
+// keycloakPageSize is the page size used when listing through the admin API's first/max paging.
+const keycloakPageSize = 100
+
+// Keycloak admin API endpoints, relative to the server.
+var (
+	kcGetUser              = NewEndpoint[Empty, KeycloakUser](http.MethodGet, "/admin/realms/{realm}/users/{id}")
+	kcUpdateUser           = NewEndpoint[KeycloakUser, Empty](http.MethodPut, "/admin/realms/{realm}/users/{id}")
+	kcDeleteUser           = NewEndpoint[Empty, Empty](http.MethodDelete, "/admin/realms/{realm}/users/{id}")
+	kcUserGroups           = NewEndpoint[Empty, []KeycloakGroup](http.MethodGet, "/admin/realms/{realm}/users/{id}/groups")
+	kcJoinGroup            = NewEndpoint[Empty, Empty](http.MethodPut, "/admin/realms/{realm}/users/{id}/groups/{group}")
+	kcLeaveGroup           = NewEndpoint[Empty, Empty](http.MethodDelete, "/admin/realms/{realm}/users/{id}/groups/{group}")
+	kcRealmRoles           = NewEndpoint[Empty, []KeycloakRole](http.MethodGet, "/admin/realms/{realm}/roles")
+	kcUserRealmRoles       = NewEndpoint[Empty, []KeycloakRole](http.MethodGet, "/admin/realms/{realm}/users/{id}/role-mappings/realm")
+	kcAddUserRealmRoles    = NewEndpoint[[]KeycloakRole, Empty](http.MethodPost, "/admin/realms/{realm}/users/{id}/role-mappings/realm")
+	kcRemoveUserRealmRoles = NewEndpoint[[]KeycloakRole, Empty](http.MethodDelete, "/admin/realms/{realm}/users/{id}/role-mappings/realm")
+	kcClientRoles          = NewEndpoint[Empty, []KeycloakRole](http.MethodGet, "/admin/realms/{realm}/clients/{client}/roles")
+	kcUserClientRoles      = NewEndpoint[Empty, []KeycloakRole](http.MethodGet, "/admin/realms/{realm}/users/{id}/role-mappings/clients/{client}")
+	kcAddUserClientRoles   = NewEndpoint[[]KeycloakRole, Empty](http.MethodPost, "/admin/realms/{realm}/users/{id}/role-mappings/clients/{client}")
+	kcUserSessions         = NewEndpoint[Empty, []KeycloakSession](http.MethodGet, "/admin/realms/{realm}/users/{id}/sessions")
+	kcLogoutUser           = NewEndpoint[Empty, Empty](http.MethodPost, "/admin/realms/{realm}/users/{id}/logout")
+	kcDeleteSession        = NewEndpoint[Empty, Empty](http.MethodDelete, "/admin/realms/{realm}/sessions/{session}")
+)
+
+// KeycloakAdmin is a typed client for the Keycloak admin REST API covering users, attributes, groups,
+// roles, clients and sessions. Errors are returned as *errors.APIError carrying Keycloak's message.
+type KeycloakAdmin struct {
+	client *Client
+	realm  string
+}
+
+// NewKeycloakAdmin creates a KeycloakAdmin that authenticates as the service account of cfg.ClientID,
+// refreshing its admin token shortly before it expires, and rate limits its calls to Keycloak.
+// opts are applied to the underlying Client.
+func NewKeycloakAdmin(cfg KeycloakConfig, opts ...Option) *KeycloakAdmin {
+	if cfg.AdminRealm == "" {
+		cfg.AdminRealm = cfg.Realm
+	}
+	if cfg.RateLimit <= 0 {
+		cfg.RateLimit = 20
+	}
+	if cfg.RateBurst <= 0 {
+		cfg.RateBurst = 10
+	}
+	baseURL := strings.TrimRight(cfg.BaseURL, "/")
+	tokenURL := fmt.Sprintf("%s/realms/%s/protocol/openid-connect/token", baseURL, url.PathEscape(cfg.AdminRealm))
+	ts := auth.NewServiceTokenSource(context.Background(), tokenURL, cfg.ClientID, cfg.ClientSecret)
+
+	opts = append([]Option{WithServiceAuth(ts), WithRateLimit(cfg.RateLimit, cfg.RateBurst), WithErrorDecoder(KeycloakErrorDecoder)}, opts...)
+	return &KeycloakAdmin{client: New(baseURL, opts...), realm: cfg.Realm}
+}
+
+// NewKeycloakAdminWith wraps an existing Client, e.g. one from clientstest, that already authenticates
+// with Keycloak.
+func NewKeycloakAdminWith(client *Client, realm string) *KeycloakAdmin {
+	return &KeycloakAdmin{client: client.withErrorDecoder(KeycloakErrorDecoder), realm: realm}
+}
+
+// params returns the path parameters of a call, including the realm. pairs alternate names and values.
+func (k *KeycloakAdmin) params(pairs ...string) Params {
+	params := Params{"realm": k.realm}
+	for i := 0; i+1 < len(pairs); i += 2 {
+		params[pairs[i]] = pairs[i+1]
+	}
+	return params
+}
+
+// GetUser returns a user by ID.
+func (k *KeycloakAdmin) GetUser(ctx context.Context, userID string) (*KeycloakUser, error) {
+	user, err := kcGetUser.Call(ctx, k.client, k.params("id", userID), Empty{})
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// FindUserByUsername returns the user with exactly the given username.
+func (k *KeycloakAdmin) FindUserByUsername(ctx context.Context, username string) (*KeycloakUser, error) {
+	users, err := k.ListUsers(ctx, KeycloakUserQuery{Username: username, Exact: true})
+	if err != nil {
+		return nil, err
+	}
+	for _, user := range users {
+		if strings.EqualFold(user.Username, username) {
+			return &user, nil
+		}
+	}
+	return nil, errors.NewNotFoundError(fmt.Sprintf("keycloak: user %q not found", username))
+}
+
+// ListUsers returns every user matching query, paging through the results.
+func (k *KeycloakAdmin) ListUsers(ctx context.Context, query KeycloakUserQuery) ([]KeycloakUser, error) {
+	values := url.Values{}
+	if query.Search != "" {
+		values.Set("search", query.Search)
+	}
+	if query.Username != "" {
+		values.Set("username", query.Username)
+	}
+	if query.Email != "" {
+		values.Set("email", query.Email)
+	}
+	if query.Exact {
+		values.Set("exact", "true")
+	}
+	return keycloakList[KeycloakUser](ctx, k.client, "/admin/realms/{realm}/users", k.params(), values)
+}
+
+// CreateUser creates a user and returns its ID.
+func (k *KeycloakAdmin) CreateUser(ctx context.Context, user KeycloakUser) (string, error) {
+	p, err := expandPath("/admin/realms/{realm}/users", k.params())
+	if err != nil {
+		return "", err
+	}
+	req, err := k.client.NewRequest(WithRoute(ctx, "/admin/realms/{realm}/users"), http.MethodPost, p, user)
+	if err != nil {
+		return "", err
+	}
+	resp, err := k.client.HTTP.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to create keycloak user %s: %w", user.Username, err)
+	}
+	defer resp.Body.Close()
+	if err := k.client.HandleResponse(resp, nil); err != nil {
+		return "", err
+	}
+
+	// Keycloak answers 201 Created with the new user's URL in Location.
+	location := resp.Header.Get("Location")
+	if location == "" {
+		return "", fmt.Errorf("keycloak created user %s without returning its location", user.Username)
+	}
+	return path.Base(location), nil
+}
+
+// UpdateUser replaces the representation of a user. Fields left empty in user are not cleared by Keycloak,
+// except attributes, which are replaced as a whole; use SetUserAttributes to change attributes alone.
+func (k *KeycloakAdmin) UpdateUser(ctx context.Context, user KeycloakUser) error {
+	_, err := kcUpdateUser.Call(ctx, k.client, k.params("id", user.ID), user)
+	return err
+}
+
+// DeleteUser deletes a user.
+func (k *KeycloakAdmin) DeleteUser(ctx context.Context, userID string) error {
+	_, err := kcDeleteUser.Call(ctx, k.client, k.params("id", userID), Empty{})
+	return err
+}
+
+// SetUserAttributes replaces a user's attributes with a read-modify-write of the full representation, so
+// fields this package does not model are preserved.
+func (k *KeycloakAdmin) SetUserAttributes(ctx context.Context, userID string, attributes map[string][]string) error {
+	p, err := expandPath("/admin/realms/{realm}/users/{id}", k.params("id", userID))
+	if err != nil {
+		return err
+	}
+	ctx = WithRoute(ctx, "/admin/realms/{realm}/users/{id}")
+
+	var user map[string]json.RawMessage
+	if err := k.client.Do(ctx, http.MethodGet, p, nil, &user); err != nil {
+		return err
+	}
+	encoded, err := json.Marshal(attributes)
+	if err != nil {
+		return fmt.Errorf("failed to encode user attributes: %w", err)
+	}
+	user["attributes"] = encoded
+	return k.client.Do(ctx, http.MethodPut, p, user, nil)
+}
+
+// ListGroups returns every group, optionally filtered by a name search.
+func (k *KeycloakAdmin) ListGroups(ctx context.Context, search string) ([]KeycloakGroup, error) {
+	values := url.Values{}
+	if search != "" {
+		values.Set("search", search)
+	}
+	return keycloakList[KeycloakGroup](ctx, k.client, "/admin/realms/{realm}/groups", k.params(), values)
+}
+
+// GetUserGroups returns the groups a user belongs to.
+func (k *KeycloakAdmin) GetUserGroups(ctx context.Context, userID string) ([]KeycloakGroup, error) {
+	groups, err := kcUserGroups.Call(ctx, k.client, k.params("id", userID), Empty{})
+	return groups, err
+}
+
+// AddUserToGroup adds a user to a group.
+func (k *KeycloakAdmin) AddUserToGroup(ctx context.Context, userID, groupID string) error {
+	_, err := kcJoinGroup.Call(ctx, k.client, k.params("id", userID, "group", groupID), Empty{})
+	return err
+}
+
+// RemoveUserFromGroup removes a user from a group.
+func (k *KeycloakAdmin) RemoveUserFromGroup(ctx context.Context, userID, groupID string) error {
+	_, err := kcLeaveGroup.Call(ctx, k.client, k.params("id", userID, "group", groupID), Empty{})
+	return err
+}
+
+// ListRealmRoles returns the roles defined in the realm.
+func (k *KeycloakAdmin) ListRealmRoles(ctx context.Context) ([]KeycloakRole, error) {
+	roles, err := kcRealmRoles.Call(ctx, k.client, k.params(), Empty{})
+	return roles, err
+}
+
+// GetUserRealmRoles returns the realm roles mapped directly to a user.
+func (k *KeycloakAdmin) GetUserRealmRoles(ctx context.Context, userID string) ([]KeycloakRole, error) {
+	roles, err := kcUserRealmRoles.Call(ctx, k.client, k.params("id", userID), Empty{})
+	return roles, err
+}
+
+// AddUserRealmRoles maps realm roles to a user. Roles must carry their ID and name, e.g. from ListRealmRoles.
+func (k *KeycloakAdmin) AddUserRealmRoles(ctx context.Context, userID string, roles ...KeycloakRole) error {
+	_, err := kcAddUserRealmRoles.Call(ctx, k.client, k.params("id", userID), roles)
+	return err
+}
+
+// RemoveUserRealmRoles removes realm role mappings from a user.
+func (k *KeycloakAdmin) RemoveUserRealmRoles(ctx context.Context, userID string, roles ...KeycloakRole) error {
+	_, err := kcRemoveUserRealmRoles.Call(ctx, k.client, k.params("id", userID), roles)
+	return err
+}
+
+// GetClient returns the client with the given clientId.
+func (k *KeycloakAdmin) GetClient(ctx context.Context, clientID string) (*KeycloakClientRep, error) {
+	reps, err := keycloakList[KeycloakClientRep](ctx, k.client, "/admin/realms/{realm}/clients", k.params(), url.Values{"clientId": {clientID}})
+	if err != nil {
+		return nil, err
+	}
+	for _, client := range reps {
+		if client.ClientID == clientID {
+			return &client, nil
+		}
+	}
+	return nil, errors.NewNotFoundError(fmt.Sprintf("keycloak: client %q not found", clientID))
+}
+
+// ListClientRoles returns the roles of a client, identified by its internal ID.
+func (k *KeycloakAdmin) ListClientRoles(ctx context.Context, clientUUID string) ([]KeycloakRole, error) {
+	roles, err := kcClientRoles.Call(ctx, k.client, k.params("client", clientUUID), Empty{})
+	return roles, err
+}
+
+// GetUserClientRoles returns the roles of a client mapped directly to a user.
+func (k *KeycloakAdmin) GetUserClientRoles(ctx context.Context, userID, clientUUID string) ([]KeycloakRole, error) {
+	roles, err := kcUserClientRoles.Call(ctx, k.client, k.params("id", userID, "client", clientUUID), Empty{})
+	return roles, err
+}
+
+// AddUserClientRoles maps roles of a client to a user.
+func (k *KeycloakAdmin) AddUserClientRoles(ctx context.Context, userID, clientUUID string, roles ...KeycloakRole) error {
+	_, err := kcAddUserClientRoles.Call(ctx, k.client, k.params("id", userID, "client", clientUUID), roles)
+	return err
+}
+
+// ListUserSessions returns the active sessions of a user.
+func (k *KeycloakAdmin) ListUserSessions(ctx context.Context, userID string) ([]KeycloakSession, error) {
+	sessions, err := kcUserSessions.Call(ctx, k.client, k.params("id", userID), Empty{})
+	return sessions, err
+}
+
+// LogoutUser ends every session of a user.
+func (k *KeycloakAdmin) LogoutUser(ctx context.Context, userID string) error {
+	_, err := kcLogoutUser.Call(ctx, k.client, k.params("id", userID), Empty{})
+	return err
+}
+
+// DeleteSession ends a single session.
+func (k *KeycloakAdmin) DeleteSession(ctx context.Context, sessionID string) error {
+	_, err := kcDeleteSession.Call(ctx, k.client, k.params("session", sessionID), Empty{})
+	return err
+}
+
+// keycloakList fetches every item of a listing using the admin API's first/max paging, failing with
+// ErrTooManyItems beyond DefaultMaxItems.
+func keycloakList[T any](ctx context.Context, client *Client, route string, params Params, query url.Values) ([]T, error) {
+	p, err := expandPath(route, params)
+	if err != nil {
+		return nil, err
+	}
+	ctx = WithRoute(ctx, route)
+
+	var all []T
+	for first := 0; ; first += keycloakPageSize {
+		values := url.Values{}
+		for key, vals := range query {
+			values[key] = vals
+		}
+		values.Set("first", strconv.Itoa(first))
+		values.Set("max", strconv.Itoa(keycloakPageSize))
+
+		var page []T
+		if err := client.Do(ctx, http.MethodGet, p+"?"+values.Encode(), nil, &page); err != nil {
+			return nil, err
+		}
+		all = append(all, page...)
+		if len(page) < keycloakPageSize {
+			return all, nil
+		}
+		if len(all) >= DefaultMaxItems {
+			return nil, ErrTooManyItems
+		}
+	}
+}
+
+// KeycloakErrorDecoder decodes Keycloak's error responses, which carry the message as {"errorMessage": "..."}
+// or as an OAuth error with a description.
+func KeycloakErrorDecoder(respErr *ResponseError) *errors.APIError {
+	var body struct {
+		ErrorMessage     string `json:"errorMessage"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if json.Unmarshal(respErr.Body, &body) != nil {
+		return nil
+	}
+	message := body.ErrorMessage
+	if message == "" {
+		message = body.ErrorDescription
+	}
+	if message == "" {
+		message = body.Error
+	}
+	if message == "" {
+		return nil
+	}
+	return errors.NewAPIError(respErr.StatusCode, "keycloak: "+message)
+}
@@ -0,0 +1,90 @@
+package clients_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/hkinc45/dev-kitchen-go-common/clients"
+	"github.com/hkinc45/dev-kitchen-go-common/clients/clientstest"
+	"github.com/hkinc45/dev-kitchen-go-common/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeycloakAdmin(t *testing.T) {
+	m := clientstest.New(t)
+	k := clients.NewKeycloakAdminWith(m.Client(), "dev-kitchen")
+	ctx := context.Background()
+
+	t.Run("Create User", func(t *testing.T) {
+		m.On(http.MethodPost, "/admin/realms/dev-kitchen/users").RespondWith(func(req *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusCreated,
+				Header:     http.Header{"Location": {clientstest.BaseURL + "/admin/realms/dev-kitchen/users/u-1"}},
+				Body:       http.NoBody,
+				Request:    req,
+			}, nil
+		})
+
+		id, err := k.CreateUser(ctx, clients.KeycloakUser{Username: "chef", Enabled: true})
+		assert.NoError(t, err)
+		assert.Equal(t, "u-1", id)
+	})
+
+	t.Run("Set Attributes Preserves Unknown Fields", func(t *testing.T) {
+		m.On(http.MethodGet, "/admin/realms/dev-kitchen/users/{id}").Respond(http.StatusOK,
+			map[string]interface{}{"id": "u-1", "username": "chef", "requiredActions": []string{"VERIFY_EMAIL"}})
+		m.On(http.MethodPut, "/admin/realms/dev-kitchen/users/{id}").Respond(http.StatusNoContent, nil)
+
+		assert.NoError(t, k.SetUserAttributes(ctx, "u-1", map[string][]string{"gitea_org": {"chef-org"}}))
+
+		puts := m.RequestsTo(http.MethodPut, "/admin/realms/dev-kitchen/users/u-1")
+		if assert.Len(t, puts, 1) {
+			var body map[string]interface{}
+			assert.NoError(t, puts[0].DecodeJSON(&body))
+			assert.Equal(t, []interface{}{"VERIFY_EMAIL"}, body["requiredActions"])
+			assert.Equal(t, map[string]interface{}{"gitea_org": []interface{}{"chef-org"}}, body["attributes"])
+		}
+	})
+
+	t.Run("Pages Through Users", func(t *testing.T) {
+		m.On(http.MethodGet, "/admin/realms/dev-kitchen/users").RespondWith(func(req *http.Request) (*http.Response, error) {
+			first, _ := strconv.Atoi(req.URL.Query().Get("first"))
+			n := 100
+			if first > 0 {
+				n = 30
+			}
+			users := make([]string, 0, n)
+			for i := 0; i < n; i++ {
+				users = append(users, fmt.Sprintf(`{"id":"u-%d","username":"user%d"}`, first+i, first+i))
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{"Content-Type": {"application/json"}},
+				Body:       io.NopCloser(strings.NewReader("[" + strings.Join(users, ",") + "]")),
+				Request:    req,
+			}, nil
+		})
+
+		users, err := k.ListUsers(ctx, clients.KeycloakUserQuery{Search: "user"})
+		assert.NoError(t, err)
+		assert.Len(t, users, 130)
+		assert.Equal(t, "u-129", users[129].ID)
+	})
+
+	t.Run("Error Message", func(t *testing.T) {
+		m.On(http.MethodPut, "/admin/realms/dev-kitchen/users/{id}/groups/{group}").
+			Respond(http.StatusBadRequest, map[string]string{"errorMessage": "Group does not exist"})
+
+		err := k.AddUserToGroup(ctx, "u-1", "g-404")
+		var apiErr *errors.APIError
+		if assert.ErrorAs(t, err, &apiErr) {
+			assert.Equal(t, http.StatusBadRequest, apiErr.StatusCode)
+			assert.Equal(t, "keycloak: Group does not exist", apiErr.Message)
+		}
+	})
+}
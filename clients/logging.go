@@ -0,0 +1,252 @@
+package clients
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/hkinc45/dev-kitchen-go-common/correlation"
+	"github.com/hkinc45/dev-kitchen-go-common/logging"
+)
+
+// redacted replaces sensitive values in logs.
+const redacted = "[REDACTED]"
+
+// defaultSensitiveHeaders are always redacted by the logging transport.
+var defaultSensitiveHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key"}
+
+// defaultSensitiveFields are always redacted from query strings, form bodies and JSON bodies.
+var defaultSensitiveFields = []string{
+	"password", "secret", "client_secret", "token", "access_token", "refresh_token", "id_token",
+	"subject_token", "actor_token", "assertion", "code",
+}
+
+// LoggingConfig configures the logging transport.
+type LoggingConfig struct {
+	// Logger receives the log records. Defaults to the request's logging.FromContext logger.
+	Logger *slog.Logger
+	// MaxBodySize caps the bytes of each body logged at debug level. Defaults to 4KiB.
+	MaxBodySize int
+	// SensitiveHeaders are redacted in addition to Authorization, Proxy-Authorization, Cookie, Set-Cookie
+	// and X-Api-Key.
+	SensitiveHeaders []string
+	// SensitiveFields are query parameters, form fields and JSON members redacted in addition to passwords,
+	// secrets and OAuth tokens, matched case-insensitively at any depth.
+	SensitiveFields []string
+}
+
+// WithLogging logs every call with its method, URL, status and duration. When the logger is enabled for debug,
+// headers and JSON or form bodies are logged too, with credentials and configured sensitive fields redacted.
+func WithLogging(cfg LoggingConfig) Option {
+	return func(o *clientOptions) { o.logging = &cfg }
+}
+
+// Logging logs every call, see WithLogging.
+func Logging(cfg LoggingConfig) Middleware {
+	if cfg.MaxBodySize <= 0 {
+		cfg.MaxBodySize = 4 << 10
+	}
+	t := &loggingTransport{
+		logger:      cfg.Logger,
+		maxBodySize: cfg.MaxBodySize,
+		headers:     make(map[string]bool),
+		fields:      make(map[string]bool),
+	}
+	for _, header := range append(defaultSensitiveHeaders, cfg.SensitiveHeaders...) {
+		t.headers[http.CanonicalHeaderKey(header)] = true
+	}
+	for _, field := range append(defaultSensitiveFields, cfg.SensitiveFields...) {
+		t.fields[strings.ToLower(field)] = true
+	}
+	return func(next http.RoundTripper) http.RoundTripper {
+		transport := *t
+		transport.base = next
+		return &transport
+	}
+}
+
+type loggingTransport struct {
+	base        http.RoundTripper
+	logger      *slog.Logger
+	maxBodySize int
+	headers     map[string]bool
+	fields      map[string]bool
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *loggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	logger := t.logger
+	if logger == nil {
+		logger = logging.FromContext(ctx)
+	}
+	if t.logger != nil || correlation.FromContext(ctx).RequestID == "" {
+		if id := req.Header.Get(correlation.RequestIDHeader); id != "" {
+			logger = logger.With("request_id", id)
+		}
+	}
+	logger = logger.With("method", req.Method, "url", t.redactURL(req.URL))
+	debug := logger.Enabled(ctx, slog.LevelDebug)
+
+	if debug {
+		attrs := []any{"headers", t.redactHeaders(req.Header)}
+		if body, ok := t.requestBody(req); ok {
+			attrs = append(attrs, "body", body)
+		}
+		logger.DebugContext(ctx, "Outbound request", attrs...)
+	}
+
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	duration := time.Since(start)
+	if err != nil {
+		logger.WarnContext(ctx, "Outbound request failed", "error", err, "duration", duration)
+		return resp, err
+	}
+
+	level := slog.LevelInfo
+	if resp.StatusCode >= 500 {
+		level = slog.LevelWarn
+	}
+	attrs := []any{"status", resp.StatusCode, "duration", duration}
+	if debug {
+		attrs = append(attrs, "headers", t.redactHeaders(resp.Header))
+		if body, ok := t.responseBody(resp); ok {
+			attrs = append(attrs, "body", body)
+		}
+	}
+	logger.Log(ctx, level, "Outbound response", attrs...)
+	return resp, nil
+}
+
+func (t *loggingTransport) redactURL(u *url.URL) string {
+	if u.RawQuery == "" {
+		return u.String()
+	}
+	redactedURL := *u
+	redactedURL.RawQuery = t.redactForm(u.Query()).Encode()
+	return redactedURL.String()
+}
+
+func (t *loggingTransport) redactHeaders(header http.Header) map[string]string {
+	out := make(map[string]string, len(header))
+	for key, values := range header {
+		if t.headers[http.CanonicalHeaderKey(key)] {
+			out[key] = redacted
+			continue
+		}
+		out[key] = strings.Join(values, ", ")
+	}
+	return out
+}
+
+func (t *loggingTransport) redactForm(values url.Values) url.Values {
+	out := make(url.Values, len(values))
+	for key, vals := range values {
+		if t.fields[strings.ToLower(key)] {
+			out[key] = []string{redacted}
+			continue
+		}
+		out[key] = vals
+	}
+	return out
+}
+
+// redactJSON redacts sensitive members of v at any depth.
+func (t *loggingTransport) redactJSON(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, member := range v {
+			if t.fields[strings.ToLower(key)] {
+				v[key] = redacted
+			} else {
+				v[key] = t.redactJSON(member)
+			}
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = t.redactJSON(item)
+		}
+	}
+	return v
+}
+
+// redactBody renders a body for logging. Bodies of other types, and truncated JSON that cannot be
+// redacted reliably, are omitted.
+func (t *loggingTransport) redactBody(contentType string, body []byte, truncated bool) (string, bool) {
+	switch mediaType := bodyMediaType(contentType); mediaType {
+	case "application/x-www-form-urlencoded":
+		values, err := url.ParseQuery(string(body))
+		if err != nil || truncated {
+			return "[form body omitted]", true
+		}
+		return t.redactForm(values).Encode(), true
+	case "application/json":
+		var v interface{}
+		if truncated || json.Unmarshal(body, &v) != nil {
+			return "[JSON body omitted]", true
+		}
+		out, err := json.Marshal(t.redactJSON(v))
+		if err != nil {
+			return "[JSON body omitted]", true
+		}
+		return string(out), true
+	}
+	return "", false
+}
+
+func (t *loggingTransport) requestBody(req *http.Request) (string, bool) {
+	if req.Body == nil || req.Body == http.NoBody || req.GetBody == nil {
+		return "", false
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return "", false
+	}
+	defer body.Close()
+	data, truncated := readPrefix(body, t.maxBodySize)
+	return t.redactBody(req.Header.Get("Content-Type"), data[:min(len(data), t.maxBodySize)], truncated)
+}
+
+// responseBody logs a prefix of the response body and hands the full body on to the caller. Only JSON and
+// form bodies are read, so event streams are never blocked on.
+func (t *loggingTransport) responseBody(resp *http.Response) (string, bool) {
+	if resp.Body == nil || resp.Body == http.NoBody {
+		return "", false
+	}
+	if bodyMediaType(resp.Header.Get("Content-Type")) == "" {
+		return "", false
+	}
+	data, truncated := readPrefix(resp.Body, t.maxBodySize)
+	resp.Body = &prefixedBody{Reader: io.MultiReader(bytes.NewReader(data), resp.Body), Closer: resp.Body}
+	return t.redactBody(resp.Header.Get("Content-Type"), data[:min(len(data), t.maxBodySize)], truncated)
+}
+
+// bodyMediaType returns the media type of a body the logging transport can redact, or "" for other types.
+func bodyMediaType(contentType string) string {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case mediaType == "application/x-www-form-urlencoded":
+		return mediaType
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		return "application/json"
+	}
+	return ""
+}
+
+// readPrefix reads up to limit+1 bytes of r, reporting whether the body is longer than limit.
+func readPrefix(r io.Reader, limit int) ([]byte, bool) {
+	data, _ := io.ReadAll(io.LimitReader(r, int64(limit)+1))
+	return data, len(data) > limit
+}
+
+type prefixedBody struct {
+	io.Reader
+	io.Closer
+}
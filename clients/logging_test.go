@@ -0,0 +1,67 @@
+package clients
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithLogging(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Set-Cookie", "session=abc")
+		w.Write([]byte(`{"access_token":"issued-token","user":{"name":"chef","api_key":"k-123"}}`))
+	}))
+	defer srv.Close()
+
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	c := New(srv.URL, WithLogging(LoggingConfig{Logger: logger, SensitiveFields: []string{"api_key"}}))
+
+	req, err := c.NewRequest(context.Background(), http.MethodPost, "/login?code=auth-code&next=home",
+		map[string]interface{}{"username": "chef", "credentials": map[string]string{"password": "hunter2"}})
+	if !assert.NoError(t, err) {
+		return
+	}
+	req.Header.Set("Authorization", "Bearer user-token")
+	resp, err := c.HTTP.Do(req)
+	if !assert.NoError(t, err) {
+		return
+	}
+	var out struct {
+		AccessToken string `json:"access_token"`
+	}
+	assert.NoError(t, HandleResponse(resp, &out))
+	assert.Equal(t, "issued-token", out.AccessToken, "the caller still receives the full body")
+
+	output := logs.String()
+	for _, secret := range []string{"user-token", "hunter2", "auth-code", "issued-token", "k-123", "session=abc"} {
+		assert.NotContains(t, output, secret)
+	}
+	assert.Contains(t, output, `"status":200`)
+	assert.Contains(t, output, `next=home`)
+	assert.Contains(t, output, `\"username\":\"chef\"`)
+	assert.Contains(t, output, `"request_id"`)
+}
+
+func TestLoggingRedactsForms(t *testing.T) {
+	rt := Logging(LoggingConfig{})(nil).(*loggingTransport)
+
+	body, ok := rt.redactBody("application/x-www-form-urlencoded", []byte("grant_type=client_credentials&client_secret=s3cret"), false)
+	assert.True(t, ok)
+	assert.False(t, strings.Contains(body, "s3cret"))
+	assert.Contains(t, body, "grant_type=client_credentials")
+
+	body, ok = rt.redactBody("application/json", []byte(`{"password":`), true)
+	assert.True(t, ok)
+	assert.Equal(t, "[JSON body omitted]", body)
+
+	_, ok = rt.redactBody("text/event-stream", []byte("data: x"), false)
+	assert.False(t, ok)
+}
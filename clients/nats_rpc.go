@@ -0,0 +1,164 @@
+package clients
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/hkinc45/dev-kitchen-go-common/correlation"
+	"github.com/hkinc45/dev-kitchen-go-common/errors"
+	"github.com/hkinc45/dev-kitchen-go-common/natsrpc"
+	"github.com/nats-io/nats.go"
+)
+
+// Doer is implemented by both Client and NATSClient, so callers can switch transports through the Registry.
+type Doer interface {
+	Do(ctx context.Context, method, path string, body, out interface{}) error
+}
+
+// NATSClient calls a service served with natsrpc.Serve using request-reply over NATS.
+type NATSClient struct {
+	prefix  string
+	timeout time.Duration
+	policy  *RetryPolicy
+	request func(ctx context.Context, msg *nats.Msg) (*nats.Msg, error)
+}
+
+// NewNATSClient creates a NATSClient for the service served under subject prefix. Of opts, the timeout and
+// retry options apply; calls are retried like idempotent HTTP requests when nobody responds, an attempt
+// times out, or the service answers with a transient status.
+func NewNATSClient(nc *nats.Conn, prefix string, opts ...Option) *NATSClient {
+	o := defaultClientOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &NATSClient{prefix: prefix, timeout: o.timeout, policy: o.policy(), request: nc.RequestMsgWithContext}
+}
+
+// Do sends a call and decodes the reply into out, mapping errors exactly like HandleResponse does for HTTP.
+func (c *NATSClient) Do(ctx context.Context, method, path string, body, out interface{}) error {
+	msg, err := newRPCRequest(ctx, c.prefix, method, path, body)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	fail := func(err error) error {
+		var apiErr *errors.APIError
+		if err == nil || stderrors.As(err, &apiErr) {
+			return err
+		}
+		return fmt.Errorf("failed to execute %s %s over NATS: %w", method, path, err)
+	}
+
+	c.policy.startCall()
+	var waited time.Duration
+	for attempt := 0; ; attempt++ {
+		err := c.attempt(ctx, msg, out)
+		if !isIdempotent(method) || !isRetryableRPC(err) || ctx.Err() != nil {
+			return fail(err)
+		}
+
+		delay := c.policy.backoff(attempt)
+		if waited+delay > c.policy.cfg.MaxWait || !c.policy.allowRetry(attempt) {
+			return fail(err)
+		}
+		waited += delay
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// attempt makes a single request, bounded by the per-attempt timeout of the policy.
+func (c *NATSClient) attempt(ctx context.Context, msg *nats.Msg, out interface{}) error {
+	if c.policy.cfg.PerAttemptTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.policy.cfg.PerAttemptTimeout)
+		defer cancel()
+	}
+	reply, err := c.request(ctx, msg)
+	if err != nil {
+		return err
+	}
+	return decodeRPCReply(reply, out)
+}
+
+// isRetryableRPC reports whether a failed attempt is transient: nobody answered in time, or the service
+// answered with a retryable error.
+func isRetryableRPC(err error) bool {
+	if err == nil {
+		return false
+	}
+	var retryable errors.RetryableError
+	if stderrors.As(err, &retryable) {
+		return retryable.Retryable()
+	}
+	return stderrors.Is(err, nats.ErrNoResponders) || stderrors.Is(err, nats.ErrTimeout) ||
+		stderrors.Is(err, context.DeadlineExceeded)
+}
+
+func newRPCRequest(ctx context.Context, prefix, method, path string, body interface{}) (*nats.Msg, error) {
+	msg := nats.NewMsg(natsrpc.Subject(prefix, path))
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode request body: %w", err)
+		}
+		msg.Data = data
+	}
+	msg.Header.Set(natsrpc.MethodHeader, method)
+	msg.Header.Set(natsrpc.PathHeader, path)
+	correlation.Inject(ctx, msg.Header.Set)
+	return msg, nil
+}
+
+// decodeRPCReply adapts a reply into an HTTP response so it goes through HandleResponse.
+func decodeRPCReply(reply *nats.Msg, out interface{}) error {
+	status, err := strconv.Atoi(reply.Header.Get(natsrpc.StatusHeader))
+	if err != nil {
+		return fmt.Errorf("invalid RPC reply on %s: missing status", reply.Subject)
+	}
+	resp := &http.Response{
+		StatusCode: status,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(bytes.NewReader(reply.Data)),
+	}
+	if len(reply.Data) == 0 {
+		resp.Body = http.NoBody
+	}
+	return HandleResponse(resp, out)
+}
+
+// Doer returns a Doer for the named service: a NATSClient when its URL uses the nats scheme, e.g.
+// RECIPE_SERVICE_URL=nats://rpc.recipe-service, otherwise an HTTP Client. Both are built with opts.
+func (r *Registry) Doer(name string, nc *nats.Conn, opts ...Option) (Doer, error) {
+	baseURL, err := r.Resolve(name)
+	if err != nil {
+		return nil, err
+	}
+	if u, err := url.Parse(baseURL); err == nil && u.Scheme == "nats" {
+		if nc == nil {
+			return nil, fmt.Errorf("service %s is configured for NATS but no connection was given", name)
+		}
+		return NewNATSClient(nc, u.Host, opts...), nil
+	}
+	return New(baseURL, opts...), nil
+}
@@ -0,0 +1,93 @@
+package clients
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/hkinc45/dev-kitchen-go-common/correlation"
+	"github.com/hkinc45/dev-kitchen-go-common/errors"
+	"github.com/hkinc45/dev-kitchen-go-common/natsrpc"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNATSRPCRoundTrip(t *testing.T) {
+	handler := func(ctx context.Context, req natsrpc.Request) (interface{}, error) {
+		assert.Equal(t, "req-123", correlation.FromContext(ctx).RequestID)
+		if req.Path == "/recipes/missing" {
+			return nil, errors.NewUnprocessableEntityError("recipe is archived")
+		}
+		var in map[string]string
+		assert.NoError(t, req.Decode(&in))
+		return map[string]string{"method": req.Method, "name": in["name"]}, nil
+	}
+
+	ctx := correlation.WithIDs(context.Background(), correlation.IDs{RequestID: "req-123"})
+	call := func(path string, out interface{}) error {
+		msg, err := newRPCRequest(ctx, "rpc.recipe-service", http.MethodPut, path, map[string]string{"name": "soup"})
+		assert.NoError(t, err)
+		return decodeRPCReply(natsrpc.Reply(msg, handler), out)
+	}
+
+	var out map[string]string
+	assert.NoError(t, call("/recipes/42", &out))
+	assert.Equal(t, map[string]string{"method": http.MethodPut, "name": "soup"}, out)
+
+	err := call("/recipes/missing", nil)
+	var apiErr *errors.APIError
+	if assert.ErrorAs(t, err, &apiErr) {
+		assert.Equal(t, http.StatusUnprocessableEntity, apiErr.StatusCode)
+		assert.Equal(t, "recipe is archived", apiErr.Message)
+	}
+
+	assert.Equal(t, "rpc.recipe-service.recipes.42", natsrpc.Subject("rpc.recipe-service", "/recipes/42"))
+}
+
+func TestNATSClientRetries(t *testing.T) {
+	handler := func(ctx context.Context, req natsrpc.Request) (interface{}, error) {
+		return map[string]string{"path": req.Path}, nil
+	}
+	newClient := func(failures int) (*NATSClient, *int) {
+		attempts := 0
+		c := NewNATSClient(nil, "rpc.recipe-service", WithBackoff(time.Millisecond, time.Millisecond))
+		c.request = func(_ context.Context, msg *nats.Msg) (*nats.Msg, error) {
+			attempts++
+			if attempts <= failures {
+				return nil, nats.ErrNoResponders
+			}
+			return natsrpc.Reply(msg, handler), nil
+		}
+		return c, &attempts
+	}
+
+	t.Run("Retries Idempotent Calls", func(t *testing.T) {
+		c, attempts := newClient(2)
+		var out map[string]string
+		assert.NoError(t, c.Do(context.Background(), http.MethodGet, "/recipes/42", nil, &out))
+		assert.Equal(t, "/recipes/42", out["path"])
+		assert.Equal(t, 3, *attempts)
+		assert.Equal(t, int64(2), c.policy.Stats().Retries)
+	})
+
+	t.Run("Does Not Retry POST", func(t *testing.T) {
+		c, attempts := newClient(1)
+		err := c.Do(context.Background(), http.MethodPost, "/recipes", nil, nil)
+		assert.ErrorIs(t, err, nats.ErrNoResponders)
+		assert.Equal(t, 1, *attempts)
+	})
+}
+
+func TestRegistryDoer(t *testing.T) {
+	r := NewRegistry(RegistryConfig{LookupEnv: func(string) (string, bool) { return "", false }})
+	r.Register("recipe-service", "nats://rpc.recipe-service")
+	r.Register("auth-service", "http://auth:8080")
+
+	_, err := r.Doer("recipe-service", nil)
+	assert.ErrorContains(t, err, "no connection")
+
+	doer, err := r.Doer("auth-service", nil)
+	assert.NoError(t, err)
+	assert.IsType(t, &Client{}, doer)
+}
@@ -0,0 +1,166 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/hkinc45/dev-kitchen-go-common/models"
+)
+
+// Defaults for Paginate.
+const (
+	DefaultPageLimit = 100
+	DefaultMaxItems  = 10000
+)
+
+// ErrTooManyItems is returned by a paginated listing that exceeds its MaxItems safety cap.
+var ErrTooManyItems = errors.New("paginated listing exceeded the max items cap")
+
+// PageParams configures a paginated listing.
+type PageParams struct {
+	// Query holds additional query parameters sent with every page request, e.g. filters.
+	Query url.Values
+	// Limit is the page size sent as the "limit" parameter. Defaults to DefaultPageLimit.
+	Limit int
+	// MaxItems caps the number of items the iterator yields before failing with ErrTooManyItems.
+	// Defaults to DefaultMaxItems.
+	MaxItems int
+}
+
+// pageEnvelope is the standard list response (see models.PageResponse). Services return items under "items"
+// (or "data"), with "next_cursor" set for cursor pagination; without a cursor, pages are requested by
+// "offset". The page size the service applied is reported as "limit". Services using the response envelope
+// report the pagination in meta.pagination instead.
+type pageEnvelope[T any] struct {
+	Items []T   `json:"items"`
+	Data  []T   `json:"data"`
+	Meta  *Meta `json:"meta"`
+	models.Pagination
+}
+
+// pagination returns the pagination of the page, from meta.pagination if present.
+func (e *pageEnvelope[T]) pagination() models.Pagination {
+	if e.Meta != nil && e.Meta.Pagination != nil {
+		return *e.Meta.Pagination
+	}
+	return e.Pagination
+}
+
+// Iterator walks a paginated listing one item at a time, fetching pages as needed.
+type Iterator[T any] struct {
+	ctx    context.Context
+	client *Client
+	path   string
+	params PageParams
+
+	offset int
+	cursor string
+	done   bool
+	buf    []T
+	seen   int
+	item   T
+	err    error
+}
+
+// Paginate returns an iterator over the items of the listing at path. It follows the limit/offset convention
+// of models.PageRequest, switching to cursor pagination when the service returns a next_cursor.
+func Paginate[T any](ctx context.Context, client *Client, path string, params PageParams) *Iterator[T] {
+	if params.Limit <= 0 {
+		params.Limit = DefaultPageLimit
+	}
+	if params.MaxItems <= 0 {
+		params.MaxItems = DefaultMaxItems
+	}
+	return &Iterator[T]{ctx: ctx, client: client, path: path, params: params}
+}
+
+// Next advances to the next item, returning false when the listing is exhausted or an error occurred.
+func (it *Iterator[T]) Next() bool {
+	if it.err != nil {
+		return false
+	}
+	for len(it.buf) == 0 {
+		if it.done {
+			return false
+		}
+		if err := it.fetch(); err != nil {
+			it.err = err
+			return false
+		}
+	}
+	if it.seen >= it.params.MaxItems {
+		it.err = fmt.Errorf("%w of %d for %s", ErrTooManyItems, it.params.MaxItems, it.path)
+		return false
+	}
+
+	it.item, it.buf = it.buf[0], it.buf[1:]
+	it.seen++
+	return true
+}
+
+// Item returns the current item.
+func (it *Iterator[T]) Item() T {
+	return it.item
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (it *Iterator[T]) Err() error {
+	return it.err
+}
+
+// All drains the iterator and returns every remaining item.
+func (it *Iterator[T]) All() ([]T, error) {
+	var items []T
+	for it.Next() {
+		items = append(items, it.Item())
+	}
+	return items, it.Err()
+}
+
+// fetch requests the next page into the buffer.
+func (it *Iterator[T]) fetch() error {
+	query := url.Values{}
+	for key, values := range it.params.Query {
+		query[key] = append([]string(nil), values...)
+	}
+	query.Set("limit", strconv.Itoa(it.params.Limit))
+	if it.cursor != "" {
+		query.Set("cursor", it.cursor)
+	} else {
+		query.Set("offset", strconv.Itoa(it.offset))
+	}
+
+	var envelope pageEnvelope[T]
+	if err := it.client.Do(it.ctx, http.MethodGet, withQuery(it.path, query), nil, &envelope); err != nil {
+		return fmt.Errorf("failed to fetch page at offset %d of %s: %w", it.offset, it.path, err)
+	}
+
+	items := envelope.Items
+	if items == nil {
+		items = envelope.Data
+	}
+	pagination := envelope.pagination()
+	it.buf = items
+	it.offset += len(items)
+
+	// A service may cap the page size below the requested limit.
+	limit := it.params.Limit
+	if pagination.Limit > 0 {
+		limit = min(limit, pagination.Limit)
+	}
+
+	switch {
+	case pagination.NextCursor != "":
+		it.cursor = pagination.NextCursor
+	case it.cursor != "":
+		// A cursor listing ends when the service stops returning a cursor.
+		it.done = true
+	default:
+		it.done = len(items) < limit || (pagination.Total != nil && int64(it.offset) >= *pagination.Total)
+	}
+	return nil
+}
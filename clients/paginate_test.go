@@ -0,0 +1,101 @@
+package clients
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/hkinc45/dev-kitchen-go-common/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPaginate(t *testing.T) {
+	items := make([]int, 25)
+	for i := range items {
+		items[i] = i
+	}
+
+	t.Run("Offset And Limit", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "veg", r.URL.Query().Get("tag"))
+			assert.Equal(t, "1", r.URL.Query().Get("archived"))
+			// Bind the request as services do, so parameters they ignore are not relied upon.
+			var req models.PageRequest
+			req.Limit, _ = strconv.Atoi(r.URL.Query().Get("limit"))
+			req.Offset, _ = strconv.Atoi(r.URL.Query().Get("offset"))
+			start := min(req.Offset, len(items))
+			end := min(start+req.PageLimit(), len(items))
+			json.NewEncoder(w).Encode(models.NewPage(items[start:end], req, int64(len(items))))
+		}))
+		defer srv.Close()
+
+		it := Paginate[int](context.Background(), New(srv.URL), "/recipes?archived=1", PageParams{Limit: 10, Query: map[string][]string{"tag": {"veg"}}})
+		got, err := it.All()
+		assert.NoError(t, err)
+		assert.Equal(t, items, got)
+	})
+
+	t.Run("Page Size Capped By Service", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var req models.PageRequest
+			req.Limit = 5 // The service caps pages at 5 items.
+			req.Offset, _ = strconv.Atoi(r.URL.Query().Get("offset"))
+			start := min(req.Offset, len(items))
+			end := min(start+req.Limit, len(items))
+			json.NewEncoder(w).Encode(models.NewPage(items[start:end], req, int64(len(items))))
+		}))
+		defer srv.Close()
+
+		got, err := Paginate[int](context.Background(), New(srv.URL), "/recipes", PageParams{Limit: 10}).All()
+		assert.NoError(t, err)
+		assert.Equal(t, items, got)
+	})
+
+	t.Run("Response Envelope", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var req models.PageRequest
+			req.Limit, _ = strconv.Atoi(r.URL.Query().Get("limit"))
+			req.Offset, _ = strconv.Atoi(r.URL.Query().Get("offset"))
+			start := min(req.Offset, len(items))
+			end := min(start+req.PageLimit(), len(items))
+			page := models.NewPage(items[start:end], req, int64(len(items)))
+			json.NewEncoder(w).Encode(models.Response[[]int]{Data: page.Items, Meta: &models.Meta{Pagination: &page.Pagination}})
+		}))
+		defer srv.Close()
+
+		got, err := Paginate[int](context.Background(), New(srv.URL), "/recipes", PageParams{Limit: 10}).All()
+		assert.NoError(t, err)
+		assert.Equal(t, items, got)
+	})
+
+	t.Run("Cursor", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start, _ := strconv.Atoi(r.URL.Query().Get("cursor"))
+			end := min(start+10, len(items))
+			next := ""
+			if end < len(items) {
+				next = strconv.Itoa(end)
+			}
+			json.NewEncoder(w).Encode(map[string]any{"data": items[start:end], "next_cursor": next})
+		}))
+		defer srv.Close()
+
+		got, err := Paginate[int](context.Background(), New(srv.URL), "/recipes", PageParams{Limit: 10}).All()
+		assert.NoError(t, err)
+		assert.Equal(t, items, got)
+	})
+
+	t.Run("Max Items Cap", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			json.NewEncoder(w).Encode(map[string]any{"items": items[:10]})
+		}))
+		defer srv.Close()
+
+		got, err := Paginate[int](context.Background(), New(srv.URL), "/recipes", PageParams{Limit: 10, MaxItems: 15}).All()
+		assert.ErrorIs(t, err, ErrTooManyItems)
+		assert.Len(t, got, 15)
+	})
+}
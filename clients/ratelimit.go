@@ -0,0 +1,73 @@
+package clients
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/hkinc45/dev-kitchen-go-common/internal/ratelimit"
+)
+
+// HostLimiter paces outbound requests per host. Wait blocks until a request to host may be sent or ctx
+// is done. Implementations backed by a shared store (e.g., Redis) let replicas share one budget against
+// a third-party API; NewHostLimiter provides the in-process token bucket.
+type HostLimiter interface {
+	Wait(ctx context.Context, host string) error
+}
+
+// localHostLimiter keeps a token bucket per host in memory.
+type localHostLimiter struct {
+	rate  float64
+	burst int
+
+	mu       sync.Mutex
+	limiters map[string]*ratelimit.Limiter
+}
+
+// NewHostLimiter creates an in-process HostLimiter allowing rate requests per second to each host, with
+// bursts of up to burst requests.
+func NewHostLimiter(rate float64, burst int) HostLimiter {
+	return &localHostLimiter{rate: rate, burst: burst, limiters: make(map[string]*ratelimit.Limiter)}
+}
+
+func (l *localHostLimiter) Wait(ctx context.Context, host string) error {
+	l.mu.Lock()
+	limiter, ok := l.limiters[host]
+	if !ok {
+		limiter = ratelimit.New(l.rate, l.burst)
+		l.limiters[host] = limiter
+	}
+	l.mu.Unlock()
+	return limiter.Wait(ctx)
+}
+
+// WithRateLimit limits requests to rate per second per host, with bursts of up to burst requests.
+// The limit is local to the process; use WithHostLimiter to share it across replicas.
+func WithRateLimit(rate float64, burst int) Option {
+	return WithHostLimiter(NewHostLimiter(rate, burst))
+}
+
+// WithHostLimiter paces every request, including retries and hedged attempts, through limiter.
+func WithHostLimiter(limiter HostLimiter) Option {
+	return func(o *clientOptions) { o.hostLimiter = limiter }
+}
+
+// RateLimit paces every request through limiter, see WithHostLimiter.
+func RateLimit(limiter HostLimiter) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return &rateLimitTransport{base: next, limiter: limiter}
+	}
+}
+
+type rateLimitTransport struct {
+	base    http.RoundTripper
+	limiter HostLimiter
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.limiter.Wait(req.Context(), req.URL.Host); err != nil {
+		return nil, fmt.Errorf("failed to wait for rate limit on %s: %w", req.URL.Host, err)
+	}
+	return t.base.RoundTrip(req)
+}
@@ -0,0 +1,88 @@
+package clients
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// ErrUnknownService is returned when a service name cannot be resolved to a base URL.
+var ErrUnknownService = errors.New("unknown service")
+
+// RegistryConfig configures how a Registry resolves service names.
+type RegistryConfig struct {
+	// KubernetesNamespace, if set, resolves services that are neither registered nor configured in the
+	// environment to their in-cluster DNS name, e.g. http://recipe-service.dev-kitchen.svc.cluster.local.
+	KubernetesNamespace string
+	// KubernetesPort is appended to in-cluster URLs when non-zero.
+	KubernetesPort int
+	// LookupEnv reads environment variables. Defaults to os.LookupEnv.
+	LookupEnv func(key string) (string, bool)
+}
+
+// Registry resolves logical service names such as "auth-service" to base URLs, so service URLs are
+// configured in one place rather than read from the environment wherever a call is made.
+//
+// A name resolves, in order, to the URL registered with Register, the <NAME>_URL environment variable
+// (e.g. AUTH_SERVICE_URL for "auth-service"), or the Kubernetes DNS name when a namespace is configured.
+type Registry struct {
+	config RegistryConfig
+
+	mu   sync.RWMutex
+	urls map[string]string
+}
+
+// NewRegistry creates an empty Registry.
+func NewRegistry(cfg RegistryConfig) *Registry {
+	if cfg.LookupEnv == nil {
+		cfg.LookupEnv = os.LookupEnv
+	}
+	return &Registry{config: cfg, urls: make(map[string]string)}
+}
+
+// Register sets the base URL of a service, overriding the environment and DNS conventions.
+func (r *Registry) Register(name, baseURL string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.urls[name] = strings.TrimRight(baseURL, "/")
+}
+
+// Resolve returns the base URL of the named service.
+func (r *Registry) Resolve(name string) (string, error) {
+	r.mu.RLock()
+	baseURL, ok := r.urls[name]
+	r.mu.RUnlock()
+	if ok {
+		return baseURL, nil
+	}
+
+	if value, ok := r.config.LookupEnv(EnvVar(name)); ok && value != "" {
+		return strings.TrimRight(value, "/"), nil
+	}
+
+	if r.config.KubernetesNamespace != "" {
+		baseURL = fmt.Sprintf("http://%s.%s.svc.cluster.local", name, r.config.KubernetesNamespace)
+		if r.config.KubernetesPort != 0 {
+			baseURL = fmt.Sprintf("%s:%d", baseURL, r.config.KubernetesPort)
+		}
+		return baseURL, nil
+	}
+
+	return "", fmt.Errorf("%w %q: register it or set %s", ErrUnknownService, name, EnvVar(name))
+}
+
+// Client returns a new Client for the named service.
+func (r *Registry) Client(name string, opts ...Option) (*Client, error) {
+	baseURL, err := r.Resolve(name)
+	if err != nil {
+		return nil, err
+	}
+	return New(baseURL, opts...), nil
+}
+
+// EnvVar returns the environment variable holding a service's base URL, e.g. AUTH_SERVICE_URL for "auth-service".
+func EnvVar(name string) string {
+	return strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(name)) + "_URL"
+}
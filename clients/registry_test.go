@@ -0,0 +1,44 @@
+package clients
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry(t *testing.T) {
+	env := map[string]string{"AUTH_SERVICE_URL": "http://auth:8080/"}
+	lookup := func(key string) (string, bool) {
+		v, ok := env[key]
+		return v, ok
+	}
+
+	t.Run("Registered And Environment", func(t *testing.T) {
+		r := NewRegistry(RegistryConfig{LookupEnv: lookup})
+		r.Register("recipe-service", "http://recipes:9000")
+
+		url, err := r.Resolve("recipe-service")
+		assert.NoError(t, err)
+		assert.Equal(t, "http://recipes:9000", url)
+
+		url, err = r.Resolve("auth-service")
+		assert.NoError(t, err)
+		assert.Equal(t, "http://auth:8080", url)
+
+		_, err = r.Resolve("vcs-service")
+		assert.ErrorIs(t, err, ErrUnknownService)
+		assert.ErrorContains(t, err, "VCS_SERVICE_URL")
+	})
+
+	t.Run("Kubernetes DNS", func(t *testing.T) {
+		r := NewRegistry(RegistryConfig{LookupEnv: lookup, KubernetesNamespace: "dev-kitchen", KubernetesPort: 8080})
+
+		url, err := r.Resolve("vcs-service")
+		assert.NoError(t, err)
+		assert.Equal(t, "http://vcs-service.dev-kitchen.svc.cluster.local:8080", url)
+
+		c, err := r.Client("auth-service")
+		assert.NoError(t, err)
+		assert.Equal(t, "http://auth:8080", c.BaseURL)
+	})
+}
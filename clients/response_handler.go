@@ -1,53 +1,89 @@
 package clients
 
 import (
-	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
 	"log/slog"
+	"mime"
 	"net/http"
 
 	"github.com/hkinc45/dev-kitchen-go-common/errors"
 )
 
+// ResponseError describes a non-2xx response that could not be decoded into a structured APIError.
+// It is wrapped by the returned APIError; use errors.As to get at the raw body.
+type ResponseError = errors.ResponseError
+
+// ErrorDecoder converts an error response in a service's own format, e.g. Gitea's {"message": "..."}, into an
+// APIError. It is consulted for error bodies that are not in the APIError format, and returns nil for bodies
+// it does not recognize, which are then reported as unknown errors. See WithErrorDecoder.
+type ErrorDecoder = errors.ResponseDecoder
+
 // HandleResponse handles decoding HTTP responses from other services.
-// It decodes either the success body or an APIError.
+// It decodes either the success body or, with errors.FromResponse, an APIError.
+//
+// The success body is decoded according to the type of successBody:
+//   - *[]byte receives the raw body.
+//   - io.Writer (e.g. *bytes.Buffer or *os.File) has the body copied into it.
+//   - *io.ReadCloser receives the body itself for streaming; the caller must close it. The response's Body
+//     is replaced with http.NoBody so closing the response does not cut the stream short.
+//   - Anything else is decoded as JSON. HTML pages, such as those served by a proxy in front of the service,
+//     produce a clear error instead of a decoding failure.
+//
+// A 204 No Content or empty response leaves successBody untouched.
 func HandleResponse(resp *http.Response, successBody interface{}) error {
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		// Read the full body to log it for debugging non-2xx responses.
-		bodyBytes, err := io.ReadAll(resp.Body)
-		if err != nil {
-			slog.Error("Error reading non-2xx response body", "err", err)
-			return errors.NewAPIError(resp.StatusCode, "failed to read error response body")
-		}
-		// Log the detailed error response.
-		slog.Warn("Downstream service returned non-2xx response", "status", resp.StatusCode, "body", string(bodyBytes))
+	return HandleResponseWith(resp, successBody, nil)
+}
 
-		// Replace the response body with a new reader so it can be read again by the JSON decoder.
-		resp.Body = io.NopCloser(bytes.NewBuffer(bodyBytes))
+// HandleResponseWith is HandleResponse with decoder converting error responses in a service-specific format.
+func HandleResponseWith(resp *http.Response, successBody interface{}, decoder ErrorDecoder) error {
+	if apiErr := errors.FromResponseWith(resp, decoder); apiErr != nil {
+		return apiErr
+	}
 
-		if resp.StatusCode == http.StatusConflict {
-			return errors.ErrConflict
+	if successBody == nil || resp.StatusCode == http.StatusNoContent || resp.Body == nil || resp.Body == http.NoBody {
+		return nil
+	}
+
+	switch target := successBody.(type) {
+	case *[]byte:
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return errors.NewAPIError(http.StatusBadGateway, fmt.Sprintf("failed to read response body: %v", err))
 		}
-		if resp.StatusCode == http.StatusNotFound {
-			return errors.NewNotFoundError("resource not found")
+		*target = data
+		return nil
+	case *io.ReadCloser:
+		*target = resp.Body
+		resp.Body = http.NoBody
+		return nil
+	case io.Writer:
+		if _, err := io.Copy(target, resp.Body); err != nil {
+			return errors.NewAPIError(http.StatusBadGateway, fmt.Sprintf("failed to read response body: %v", err))
 		}
+		return nil
+	}
 
-		var apiErr errors.APIError
-		if err := json.NewDecoder(resp.Body).Decode(&apiErr); err != nil {
-			// If we can't decode a structured error, use the raw body we already read.
-			return errors.NewAPIError(resp.StatusCode, fmt.Sprintf("unknown error: %s", string(bodyBytes)))
-		}
-		apiErr.StatusCode = resp.StatusCode // Ensure status code is set
-		return &apiErr
+	if isHTML(resp) {
+		// Services that omit Content-Type are sniffed as text/plain, so only HTML is rejected outright.
+		ct := resp.Header.Get("Content-Type")
+		slog.Warn("Downstream service returned an HTML success response", "status", resp.StatusCode, "content_type", ct, "url", errors.RedactedURL(resp))
+		return errors.NewBadGatewayError(fmt.Sprintf("expected a JSON response but got %s", ct))
 	}
 
-	if successBody != nil {
-		if err := json.NewDecoder(resp.Body).Decode(successBody); err != nil {
-			return errors.NewAPIError(http.StatusInternalServerError, fmt.Sprintf("failed to decode success response: %v", err))
+	if err := json.NewDecoder(resp.Body).Decode(successBody); err != nil {
+		if err == io.EOF {
+			// An empty body without Content-Length is treated like 204 No Content.
+			return nil
 		}
+		return errors.NewAPIError(http.StatusInternalServerError, fmt.Sprintf("failed to decode success response: %v", err))
 	}
 
 	return nil
 }
+
+func isHTML(resp *http.Response) bool {
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	return mediaType == "text/html"
+}
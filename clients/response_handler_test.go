@@ -0,0 +1,197 @@
+package clients
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/hkinc45/dev-kitchen-go-common/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func newResponse(status int, contentType, body string) *http.Response {
+	header := make(http.Header)
+	if contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+	return &http.Response{StatusCode: status, Header: header, Body: io.NopCloser(strings.NewReader(body))}
+}
+
+func TestHandleResponse(t *testing.T) {
+	t.Run("No Content", func(t *testing.T) {
+		var out map[string]string
+		resp := newResponse(http.StatusNoContent, "", "")
+		assert.NoError(t, HandleResponse(resp, &out))
+		assert.Nil(t, out)
+	})
+
+	t.Run("Raw Bytes", func(t *testing.T) {
+		var out []byte
+		resp := newResponse(http.StatusOK, "application/octet-stream", "\x00\x01")
+		assert.NoError(t, HandleResponse(resp, &out))
+		assert.Equal(t, []byte{0, 1}, out)
+	})
+
+	t.Run("Writer", func(t *testing.T) {
+		var buf bytes.Buffer
+		resp := newResponse(http.StatusOK, "text/plain", "hello")
+		assert.NoError(t, HandleResponse(resp, &buf))
+		assert.Equal(t, "hello", buf.String())
+	})
+
+	t.Run("Streaming Body", func(t *testing.T) {
+		var body io.ReadCloser
+		resp := newResponse(http.StatusOK, "application/octet-stream", "stream")
+		assert.NoError(t, HandleResponse(resp, &body))
+		resp.Body.Close()
+
+		data, err := io.ReadAll(body)
+		assert.NoError(t, err)
+		assert.Equal(t, "stream", string(data))
+	})
+
+	t.Run("HTML Success Page", func(t *testing.T) {
+		var out map[string]string
+		resp := newResponse(http.StatusOK, "text/html; charset=utf-8", "<html>login</html>")
+		err := HandleResponse(resp, &out)
+
+		var apiErr *errors.APIError
+		if assert.ErrorAs(t, err, &apiErr) {
+			assert.Equal(t, http.StatusBadGateway, apiErr.StatusCode)
+			assert.Contains(t, apiErr.Message, "text/html")
+		}
+	})
+
+	t.Run("HTML Error Page", func(t *testing.T) {
+		resp := newResponse(http.StatusBadGateway, "text/html", "<html>502 Bad Gateway</html>")
+		err := HandleResponse(resp, nil)
+
+		var apiErr *errors.APIError
+		if assert.ErrorAs(t, err, &apiErr) {
+			assert.Equal(t, http.StatusBadGateway, apiErr.StatusCode)
+			assert.NotContains(t, apiErr.Message, "<html>")
+		}
+	})
+
+	t.Run("Problem JSON", func(t *testing.T) {
+		var out map[string]string
+		resp := newResponse(http.StatusOK, "application/problem+json", `{"a":"b"}`)
+		assert.NoError(t, HandleResponse(resp, &out))
+		assert.Equal(t, "b", out["a"])
+	})
+}
+
+func TestHandleResponseErrorBodies(t *testing.T) {
+	t.Run("Structured Error", func(t *testing.T) {
+		resp := newResponse(http.StatusBadRequest, "application/json", `{"error":"name is required"}`)
+		err := HandleResponse(resp, nil)
+
+		var apiErr *errors.APIError
+		if assert.ErrorAs(t, err, &apiErr) {
+			assert.Equal(t, http.StatusBadRequest, apiErr.StatusCode)
+			assert.Equal(t, "name is required", apiErr.Message)
+		}
+	})
+
+	t.Run("Undecodable Error Keeps Raw Body", func(t *testing.T) {
+		body := strings.Repeat("x", 2000)
+		resp := newResponse(http.StatusInternalServerError, "text/plain", body)
+		err := HandleResponse(resp, nil)
+
+		var apiErr *errors.APIError
+		if assert.ErrorAs(t, err, &apiErr) {
+			assert.Contains(t, apiErr.Message, "status 500")
+			assert.Contains(t, apiErr.Message, "text/plain")
+			assert.Contains(t, apiErr.Message, "(truncated)")
+			assert.Less(t, len(apiErr.Message), 1000)
+		}
+
+		var respErr *ResponseError
+		if assert.ErrorAs(t, err, &respErr) {
+			assert.Equal(t, body, string(respErr.Body))
+		}
+	})
+}
+
+func TestHandleEnvelope(t *testing.T) {
+	t.Run("Envelope", func(t *testing.T) {
+		var out []string
+		resp := newResponse(http.StatusOK, "application/json",
+			`{"data":["a","b"],"meta":{"pagination":{"total":2},"warnings":["deprecated"],"region":"eu"}}`)
+		meta, err := HandleEnvelope(resp, &out)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"a", "b"}, out)
+		if assert.NotNil(t, meta) {
+			if assert.NotNil(t, meta.Pagination.Total) {
+				assert.Equal(t, int64(2), *meta.Pagination.Total)
+			}
+			assert.Equal(t, []string{"deprecated"}, meta.Warnings)
+			assert.Contains(t, string(meta.Raw), `"region"`)
+		}
+	})
+
+	t.Run("Bare Body", func(t *testing.T) {
+		var out struct {
+			Data string `json:"data"`
+			Name string `json:"name"`
+		}
+		resp := newResponse(http.StatusOK, "application/json", `{"data":"x","name":"soup"}`)
+		meta, err := HandleEnvelope(resp, &out)
+
+		assert.NoError(t, err)
+		assert.Nil(t, meta)
+		assert.Equal(t, "soup", out.Name)
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		resp := newResponse(http.StatusBadRequest, "application/json", `{"error":"bad"}`)
+		_, err := HandleEnvelope(resp, nil)
+		assert.ErrorContains(t, err, "bad")
+	})
+}
+
+func TestHandleResponseWith(t *testing.T) {
+	decoder := func(respErr *ResponseError) *errors.APIError {
+		if !bytes.Contains(respErr.Body, []byte(`"code"`)) {
+			return nil
+		}
+		return errors.NewUnprocessableEntityError("decoded")
+	}
+
+	t.Run("Service Format", func(t *testing.T) {
+		err := HandleResponseWith(newResponse(http.StatusBadRequest, "application/json", `{"code":"E42"}`), nil, decoder)
+		var apiErr *errors.APIError
+		if assert.ErrorAs(t, err, &apiErr) {
+			assert.Equal(t, http.StatusUnprocessableEntity, apiErr.StatusCode)
+			assert.Equal(t, "decoded", apiErr.Message)
+		}
+		var respErr *ResponseError
+		assert.ErrorAs(t, err, &respErr, "the raw response stays available")
+	})
+
+	t.Run("Unrecognized Body", func(t *testing.T) {
+		err := HandleResponseWith(newResponse(http.StatusBadRequest, "application/json", `{"other":1}`), nil, decoder)
+		assert.ErrorContains(t, err, "unknown error")
+	})
+
+	t.Run("Standard Format Wins", func(t *testing.T) {
+		err := HandleResponseWith(newResponse(http.StatusBadRequest, "application/json", `{"error":"bad","code":"E42"}`), nil, decoder)
+		assert.ErrorContains(t, err, "message: bad")
+	})
+}
+
+func TestHandleResponseProblem(t *testing.T) {
+	resp := newResponse(http.StatusUnprocessableEntity, errors.ProblemContentType,
+		`{"type":"about:blank","title":"Unprocessable Entity","status":422,"detail":"recipe is archived","details":{"recipe_id":"42"}}`)
+	err := HandleResponse(resp, nil)
+
+	var apiErr *errors.APIError
+	if assert.ErrorAs(t, err, &apiErr) {
+		assert.Equal(t, http.StatusUnprocessableEntity, apiErr.StatusCode)
+		assert.Equal(t, "recipe is archived", apiErr.Message)
+		assert.Equal(t, errors.Details{"recipe_id": "42"}, apiErr.Details)
+	}
+}
@@ -0,0 +1,139 @@
+package clients
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/hkinc45/dev-kitchen-go-common/errors"
+)
+
+// retryTransport retries idempotent requests that failed with a transport error or a transient status code.
+type retryTransport struct {
+	base   http.RoundTripper
+	policy *RetryPolicy
+	now    func() time.Time
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.policy.startCall()
+	if !isRetriable(req) || (req.Body != nil && req.GetBody == nil) {
+		recordAttempt(req.Context())
+		return t.roundTrip(req)
+	}
+
+	var waited time.Duration
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
+		}
+
+		recordAttempt(req.Context())
+		resp, err := t.roundTrip(req)
+		if !isRetryable(resp, err) || req.Context().Err() != nil {
+			return resp, err
+		}
+
+		delay := t.policy.backoff(attempt)
+		if resp != nil {
+			if serverDelay, ok := t.serverDelay(resp); ok {
+				delay = max(delay, serverDelay)
+			}
+		}
+		if waited+delay > t.policy.cfg.MaxWait {
+			// Waiting any longer than the budget allows is worse than surfacing the throttling to the caller.
+			return resp, err
+		}
+		if deadline, ok := req.Context().Deadline(); ok && t.now().Add(delay).After(deadline) {
+			// The caller's deadline expires before the next attempt could start; return the failure it can act on.
+			return resp, err
+		}
+		if !t.policy.allowRetry(attempt) {
+			return resp, err
+		}
+		waited += delay
+		if resp != nil {
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// roundTrip makes a single attempt, bounded by the per-attempt timeout of the policy.
+func (t *retryTransport) roundTrip(req *http.Request) (*http.Response, error) {
+	if t.policy.cfg.PerAttemptTimeout <= 0 {
+		return t.base.RoundTrip(req)
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), t.policy.cfg.PerAttemptTimeout)
+	resp, err := t.base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	// The attempt's context must outlive RoundTrip until the caller has read the body.
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// isRetriable reports whether req can be sent again: its method is idempotent, or the client was configured
+// with WithIdempotencyKeys. An Idempotency-Key header set by the caller alone does not make a request retriable.
+func isRetriable(req *http.Request) bool {
+	retriable, _ := req.Context().Value(retriableKey{}).(bool)
+	return isIdempotent(req.Method) || retriable
+}
+
+// isIdempotent reports whether requests with method can be retried safely.
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// isRetryable reports whether the outcome of an attempt is a transient failure.
+func isRetryable(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return errors.RetryableStatus(resp.StatusCode)
+}
+
+// serverDelay returns the delay requested by the server through Retry-After (in seconds or as an HTTP date)
+// or X-RateLimit-Reset (as a Unix timestamp or in seconds), as sent by Keycloak and Gitea when throttling.
+func (t *retryTransport) serverDelay(resp *http.Response) (time.Duration, bool) {
+	now := t.now
+	if value := resp.Header.Get("Retry-After"); value != "" {
+		if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+			return time.Duration(seconds) * time.Second, true
+		}
+		if at, err := http.ParseTime(value); err == nil {
+			return max(at.Sub(now()), 0), true
+		}
+	}
+
+	if value := resp.Header.Get("X-RateLimit-Reset"); value != "" {
+		if reset, err := strconv.ParseInt(value, 10, 64); err == nil && reset >= 0 {
+			// Values this large are Unix timestamps; smaller ones are a number of seconds.
+			if reset > 1_000_000_000 {
+				return max(time.Unix(reset, 0).Sub(now()), 0), true
+			}
+			return time.Duration(reset) * time.Second, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,128 @@
+package clients
+
+import (
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// RetryConfig configures a RetryPolicy. Zero values use the defaults noted on each field.
+type RetryConfig struct {
+	// MaxAttempts is the number of attempts per call, including the first. Defaults to 3.
+	MaxAttempts int
+	// PerAttemptTimeout bounds each attempt, so a hung attempt is retried while the call still has time.
+	// Zero leaves attempts bounded only by the call.
+	PerAttemptTimeout time.Duration
+	// BaseDelay and MaxDelay bound the full-jitter exponential backoff: the delay before retry n is drawn
+	// uniformly from zero to min(MaxDelay, BaseDelay*2^n). Default to 100ms and 2s.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// MaxWait bounds the total time a call spends waiting between attempts, including delays requested
+	// by the server through Retry-After or X-RateLimit-Reset. Defaults to 30 seconds.
+	MaxWait time.Duration
+	// BudgetRatio caps retries across all calls at that fraction of calls, e.g. 0.1 allows one retry for
+	// every ten calls, so a struggling dependency is not flooded with retries. Zero disables the budget.
+	BudgetRatio float64
+	// BudgetBurst is the number of retries the budget allows before calls have earned any. Defaults to 10.
+	BudgetBurst int
+}
+
+// RetryStats counts the work done by a RetryPolicy, for exporting as metrics.
+type RetryStats struct {
+	// Calls is the number of calls made under the policy.
+	Calls int64
+	// Retries is the number of attempts made after the first.
+	Retries int64
+	// BudgetExhausted counts retries skipped because the retry budget was spent.
+	BudgetExhausted int64
+}
+
+// RetryPolicy decides how often and when failed calls are retried. A policy is shared by every client it is
+// given to, HTTP and NATS alike, which then share its retry budget and stats.
+type RetryPolicy struct {
+	cfg RetryConfig
+
+	mu      sync.Mutex
+	balance float64
+
+	calls     atomic.Int64
+	retries   atomic.Int64
+	exhausted atomic.Int64
+}
+
+// NewRetryPolicy creates a RetryPolicy from cfg.
+func NewRetryPolicy(cfg RetryConfig) *RetryPolicy {
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 3
+	}
+	if cfg.BaseDelay <= 0 {
+		cfg.BaseDelay = 100 * time.Millisecond
+	}
+	if cfg.MaxDelay <= 0 {
+		cfg.MaxDelay = 2 * time.Second
+	}
+	if cfg.MaxWait <= 0 {
+		cfg.MaxWait = 30 * time.Second
+	}
+	if cfg.BudgetBurst <= 0 {
+		cfg.BudgetBurst = 10
+	}
+	return &RetryPolicy{cfg: cfg, balance: float64(cfg.BudgetBurst)}
+}
+
+// WithRetryPolicy retries calls under policy, replacing WithRetries, WithBackoff and WithMaxRetryWait.
+func WithRetryPolicy(policy *RetryPolicy) Option {
+	return func(o *clientOptions) { o.retryPolicy = policy }
+}
+
+// Stats returns the counters of the policy.
+func (p *RetryPolicy) Stats() RetryStats {
+	return RetryStats{
+		Calls:           p.calls.Load(),
+		Retries:         p.retries.Load(),
+		BudgetExhausted: p.exhausted.Load(),
+	}
+}
+
+// startCall records a call, earning it its share of the retry budget.
+func (p *RetryPolicy) startCall() {
+	p.calls.Add(1)
+	if p.cfg.BudgetRatio <= 0 {
+		return
+	}
+	p.mu.Lock()
+	p.balance = min(p.balance+p.cfg.BudgetRatio, float64(p.cfg.BudgetBurst))
+	p.mu.Unlock()
+}
+
+// allowRetry reports whether the attempt following attempt may be made, spending from the budget if so.
+func (p *RetryPolicy) allowRetry(attempt int) bool {
+	if attempt+1 >= p.cfg.MaxAttempts {
+		return false
+	}
+	if p.cfg.BudgetRatio > 0 {
+		p.mu.Lock()
+		ok := p.balance >= 1
+		if ok {
+			p.balance--
+		}
+		p.mu.Unlock()
+		if !ok {
+			p.exhausted.Add(1)
+			return false
+		}
+	}
+	p.retries.Add(1)
+	return true
+}
+
+// backoff returns the delay before the retry following attempt, using full-jitter exponential backoff.
+func (p *RetryPolicy) backoff(attempt int) time.Duration {
+	delay := p.cfg.BaseDelay << attempt
+	if delay <= 0 || delay > p.cfg.MaxDelay {
+		delay = p.cfg.MaxDelay
+	}
+	// Full jitter spreads out retries from concurrent callers better than a fixed fraction would.
+	return time.Duration(rand.Int64N(int64(delay) + 1))
+}
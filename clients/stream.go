@@ -0,0 +1,330 @@
+package clients
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	common_errors "github.com/hkinc45/dev-kitchen-go-common/errors"
+)
+
+// LastEventIDHeader carries the ID of the last event received when an event stream reconnects.
+const LastEventIDHeader = "Last-Event-ID"
+
+// maxEventSize caps a single line of an event stream.
+const maxEventSize = 1 << 20
+
+// Event is a Server-Sent Event.
+type Event struct {
+	ID   string
+	Type string
+	Data []byte
+}
+
+// Decode unmarshals the JSON data of the event into v.
+func (e Event) Decode(v interface{}) error {
+	if err := json.Unmarshal(e.Data, v); err != nil {
+		return fmt.Errorf("failed to decode %q event %s: %w", e.Type, e.ID, err)
+	}
+	return nil
+}
+
+// StreamOptions configures Stream, StreamJSON and LongPoll.
+type StreamOptions struct {
+	// Query holds additional query parameters sent with every request.
+	Query url.Values
+	// LastEventID resumes an event stream after the given event. For LongPoll, it is the initial cursor.
+	LastEventID string
+	// MinReconnectDelay is the delay before the first reconnect after a dropped connection, unless the
+	// server requests another with the "retry" field. Defaults to 1 second.
+	MinReconnectDelay time.Duration
+	// MaxReconnectDelay caps the exponential backoff between consecutive failed connections.
+	// Defaults to 30 seconds.
+	MaxReconnectDelay time.Duration
+	// MaxFailures stops reconnecting after that many consecutive failed connections, returning the last
+	// error. Zero reconnects until ctx is done.
+	MaxFailures int
+}
+
+func (o *StreamOptions) applyDefaults() {
+	if o.MinReconnectDelay <= 0 {
+		o.MinReconnectDelay = time.Second
+	}
+	if o.MaxReconnectDelay <= 0 {
+		o.MaxReconnectDelay = 30 * time.Second
+	}
+}
+
+// errStreamHandler wraps an error returned by a handler, which ends the stream instead of reconnecting.
+type errStreamHandler struct{ err error }
+
+func (e errStreamHandler) Error() string { return e.err.Error() }
+func (e errStreamHandler) Unwrap() error { return e.err }
+
+// errStreamDone ends a stream the server closed for good.
+var errStreamDone = errors.New("stream closed by server")
+
+// Stream consumes the Server-Sent Events stream at path, calling handler for every event until ctx is done
+// or handler returns an error. Dropped connections are reopened with exponential backoff, resuming after
+// the last event received via Last-Event-ID. The stream also ends when the server answers 204 No Content;
+// other client errors are returned as from HandleResponse.
+func Stream(ctx context.Context, client *Client, path string, opts StreamOptions, handler func(Event) error) error {
+	opts.applyDefaults()
+	lastEventID := opts.LastEventID
+	retry := opts.MinReconnectDelay
+
+	return reconnect(ctx, path, opts, &retry, func(received func()) error {
+		req, err := client.NewRequest(ctx, http.MethodGet, withQuery(path, opts.Query), nil)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Accept", "text/event-stream")
+		req.Header.Set("Cache-Control", "no-cache")
+		if lastEventID != "" {
+			req.Header.Set(LastEventIDHeader, lastEventID)
+		}
+
+		resp, err := client.streamingHTTP().Do(req)
+		if err != nil {
+			return fmt.Errorf("failed to connect to event stream %s: %w", path, err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode == http.StatusNoContent {
+			return errStreamDone
+		}
+		if resp.StatusCode != http.StatusOK {
+			return client.HandleResponse(resp, nil)
+		}
+
+		return readEvents(resp, func(event Event, serverRetry time.Duration) error {
+			if serverRetry > 0 {
+				retry = serverRetry
+			}
+			received()
+			if event.Data != nil {
+				if err := handler(event); err != nil {
+					return errStreamHandler{err}
+				}
+			}
+			// Only delivered events are resumed after.
+			if event.ID != "" {
+				lastEventID = event.ID
+			}
+			return nil
+		})
+	})
+}
+
+// StreamJSON is Stream with the data of every event decoded as JSON into T.
+func StreamJSON[T any](ctx context.Context, client *Client, path string, opts StreamOptions, handler func(T, Event) error) error {
+	return Stream(ctx, client, path, opts, func(event Event) error {
+		var v T
+		if err := event.Decode(&v); err != nil {
+			return err
+		}
+		return handler(v, event)
+	})
+}
+
+// LongPoll consumes a long-poll endpoint returning the standard list response, calling handler with the
+// items of every response and requesting the next with its "cursor". Requests are sent back to back; errors
+// are retried with the same backoff as Stream. The poll ends when ctx is done or handler returns an error.
+func LongPoll[T any](ctx context.Context, client *Client, path string, opts StreamOptions, handler func([]T) error) error {
+	opts.applyDefaults()
+	cursor := opts.LastEventID
+	retry := opts.MinReconnectDelay
+
+	return reconnect(ctx, path, opts, &retry, func(received func()) error {
+		for {
+			query := url.Values{}
+			for key, values := range opts.Query {
+				query[key] = append([]string(nil), values...)
+			}
+			if cursor != "" {
+				query.Set("cursor", cursor)
+			}
+
+			req, err := client.NewRequest(ctx, http.MethodGet, withQuery(path, query), nil)
+			if err != nil {
+				return err
+			}
+			resp, err := client.streamingHTTP().Do(req)
+			if err != nil {
+				return fmt.Errorf("failed to poll %s: %w", path, err)
+			}
+			var envelope pageEnvelope[T]
+			err = client.HandleResponse(resp, &envelope)
+			resp.Body.Close()
+			if err != nil {
+				return err
+			}
+			received()
+
+			items := envelope.Items
+			if items == nil {
+				items = envelope.Data
+			}
+			if next := envelope.pagination().NextCursor; next != "" {
+				cursor = next
+			}
+			if len(items) == 0 {
+				continue
+			}
+			if err := handler(items); err != nil {
+				return errStreamHandler{err}
+			}
+		}
+	})
+}
+
+// reconnect runs connect until it ends the stream, waiting between attempts. connect calls received
+// whenever data arrives, which resets the backoff.
+func reconnect(ctx context.Context, path string, opts StreamOptions, retry *time.Duration, connect func(received func()) error) error {
+	failures := 0
+	for {
+		err := connect(func() { failures = 0 })
+
+		var handlerErr errStreamHandler
+		switch {
+		case errors.As(err, &handlerErr):
+			return handlerErr.err
+		case errors.Is(err, errStreamDone):
+			return nil
+		case ctx.Err() != nil:
+			return ctx.Err()
+		case err != nil && !isReconnectable(err):
+			return err
+		}
+
+		failures++
+		if opts.MaxFailures > 0 && failures >= opts.MaxFailures {
+			if err == nil {
+				err = fmt.Errorf("event stream %s closed", path)
+			}
+			return fmt.Errorf("failed to reconnect to %s after %d attempts: %w", path, failures, err)
+		}
+
+		delay := streamBackoff(*retry, opts.MaxReconnectDelay, failures)
+		slog.Warn("stream disconnected, reconnecting", "path", path, "error", err, "delay", delay)
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// isReconnectable reports whether a failed connection should be retried: transport errors and dropped
+// streams are, client errors other than 408 and 429 are not.
+func isReconnectable(err error) bool {
+	var apiErr *common_errors.APIError
+	if !errors.As(err, &apiErr) {
+		return true
+	}
+	return apiErr.StatusCode >= 500 || apiErr.StatusCode == http.StatusRequestTimeout ||
+		apiErr.StatusCode == http.StatusTooManyRequests
+}
+
+// streamBackoff doubles base for every consecutive failure after the first, capped at max, with jitter
+// between 50% and 100%.
+func streamBackoff(base, max time.Duration, failures int) time.Duration {
+	delay := base << (failures - 1)
+	if delay <= 0 || delay > max {
+		delay = max
+	}
+	return delay/2 + time.Duration(rand.Int64N(int64(delay/2)+1))
+}
+
+// readEvents parses the event stream in resp, calling dispatch for every event and for every "retry"
+// field. Events are dispatched when complete, at their terminating blank line; an event with an ID but no
+// data is dispatched without data, so its ID still applies. It returns nil when the server closes the stream,
+// dropping an incomplete last event.
+func readEvents(resp *http.Response, dispatch func(event Event, retry time.Duration) error) error {
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 4096), maxEventSize)
+
+	var event Event
+	var data bytes.Buffer
+	hasData := false
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			if hasData {
+				event.Data = bytes.Clone(bytes.TrimSuffix(data.Bytes(), []byte("\n")))
+				if event.Type == "" {
+					event.Type = "message"
+				}
+				if err := dispatch(event, 0); err != nil {
+					return err
+				}
+			} else if event.ID != "" {
+				if err := dispatch(Event{ID: event.ID}, 0); err != nil {
+					return err
+				}
+			}
+			event, hasData = Event{}, false
+			data.Reset()
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			event.Type = value
+		case "data":
+			data.WriteString(value)
+			data.WriteByte('\n')
+			hasData = true
+		case "id":
+			if !strings.ContainsRune(value, 0) {
+				event.ID = value
+			}
+		case "retry":
+			if ms, err := strconv.Atoi(value); err == nil && ms > 0 {
+				if err := dispatch(Event{}, time.Duration(ms)*time.Millisecond); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read event stream: %w", err)
+	}
+	return nil
+}
+
+// withQuery appends query to path.
+func withQuery(path string, query url.Values) string {
+	if len(query) == 0 {
+		return path
+	}
+	sep := "?"
+	if strings.Contains(path, "?") {
+		sep = "&"
+	}
+	return path + sep + query.Encode()
+}
+
+// streamingHTTP returns a copy of the client's *http.Client without the overall timeout, which would
+// otherwise cut long-lived streams and polls short. Streams are bounded by their context instead.
+func (c *Client) streamingHTTP() *http.Client {
+	streaming := *c.HTTP
+	streaming.Timeout = 0
+	return &streaming
+}
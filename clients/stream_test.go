@@ -0,0 +1,150 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStream(t *testing.T) {
+	var connects atomic.Int32
+	var lastEventIDs []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lastEventIDs = append(lastEventIDs, r.Header.Get(LastEventIDHeader))
+		switch connects.Add(1) {
+		case 1:
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprint(w, ": keep-alive\n\nretry: 1\n\nid: 1\nevent: order\ndata: {\"name\":\"soup\"}\n\n")
+			fmt.Fprint(w, "id: 2\ndata: {\"name\":\n")
+			fmt.Fprint(w, "data: \"stew\"}\n\n")
+		case 2:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case 3:
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprint(w, "id: 3\ndata: {\"name\":\"pie\"}\n\n")
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer srv.Close()
+
+	type order struct {
+		Name string `json:"name"`
+	}
+	var names, types []string
+	err := StreamJSON(context.Background(), New(srv.URL, WithRetries(0)), "/events", StreamOptions{LastEventID: "0"},
+		func(o order, event Event) error {
+			names = append(names, o.Name)
+			types = append(types, event.Type)
+			return nil
+		})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"soup", "stew", "pie"}, names)
+	assert.Equal(t, []string{"order", "message", "message"}, types)
+	assert.Equal(t, []string{"0", "2", "2", "3"}, lastEventIDs)
+}
+
+func TestStreamResumesAfterDeliveredEvents(t *testing.T) {
+	var connects atomic.Int32
+	var lastEventIDs []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lastEventIDs = append(lastEventIDs, r.Header.Get(LastEventIDHeader))
+		switch connects.Add(1) {
+		case 1:
+			// The connection drops in the middle of event 2.
+			fmt.Fprint(w, "retry: 1\n\nid: 1\ndata: a\n\nid: 2\ndata: b")
+		case 2:
+			// An event without data still moves the resume point.
+			fmt.Fprint(w, "id: 2\ndata: b\n\nid: 3\n\n")
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer srv.Close()
+
+	var data []string
+	err := Stream(context.Background(), New(srv.URL, WithRetries(0)), "/events", StreamOptions{}, func(event Event) error {
+		data = append(data, string(event.Data))
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, data)
+	assert.Equal(t, []string{"", "1", "3"}, lastEventIDs)
+}
+
+func TestStreamStops(t *testing.T) {
+	t.Run("Handler Error", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, "data: x\n\n")
+		}))
+		defer srv.Close()
+
+		errStop := errors.New("stop")
+		err := Stream(context.Background(), New(srv.URL), "/events", StreamOptions{}, func(Event) error { return errStop })
+		assert.ErrorIs(t, err, errStop)
+	})
+
+	t.Run("Client Error", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"message":"forbidden"}`))
+		}))
+		defer srv.Close()
+
+		err := Stream(context.Background(), New(srv.URL), "/events", StreamOptions{}, func(Event) error { return nil })
+		assert.ErrorContains(t, err, "forbidden")
+	})
+
+	t.Run("Max Failures", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadGateway)
+		}))
+		defer srv.Close()
+
+		opts := StreamOptions{MinReconnectDelay: time.Millisecond, MaxFailures: 2}
+		err := Stream(context.Background(), New(srv.URL, WithRetries(0)), "/events", opts, func(Event) error { return nil })
+		assert.ErrorContains(t, err, "after 2 attempts")
+	})
+}
+
+func TestLongPoll(t *testing.T) {
+	var cursors []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cursor := r.URL.Query().Get("cursor")
+		cursors = append(cursors, cursor)
+		switch cursor {
+		case "":
+			w.Write([]byte(`{"items":["a","b"],"next_cursor":"c1"}`))
+		case "c1":
+			w.Write([]byte(`{"items":[],"next_cursor":"c2"}`))
+		default:
+			w.Write([]byte(`{"data":["c"],"meta":{"pagination":{"next_cursor":"c3"}}}`))
+		}
+	}))
+	defer srv.Close()
+
+	var got []string
+	calls := 0
+	errDone := errors.New("done")
+	err := LongPoll(context.Background(), New(srv.URL), "/changes", StreamOptions{}, func(items []string) error {
+		got = append(got, items...)
+		calls++
+		if calls == 2 {
+			return errDone
+		}
+		return nil
+	})
+
+	assert.ErrorIs(t, err, errDone)
+	assert.Equal(t, []string{"a", "b", "c"}, got)
+	assert.Equal(t, []string{"", "c1", "c2"}, cursors)
+}
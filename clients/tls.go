@@ -0,0 +1,69 @@
+package clients
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+)
+
+// LoadCABundle returns the system root CAs extended with the PEM certificates in files, for servers
+// signed by a private CA.
+func LoadCABundle(files ...string) (*x509.CertPool, error) {
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	for _, file := range files {
+		pem, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA bundle %s: %w", file, err)
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("failed to parse CA bundle %s: no PEM certificates found", file)
+		}
+	}
+	return pool, nil
+}
+
+// WithRootCAs verifies server certificates against pool instead of the system roots, see LoadCABundle.
+func WithRootCAs(pool *x509.CertPool) Option {
+	return func(o *clientOptions) { o.ensureTLSConfig().RootCAs = pool }
+}
+
+// WithClientCertificate presents cert to servers requiring mutual TLS. Use tls.LoadX509KeyPair to load it.
+func WithClientCertificate(cert tls.Certificate) Option {
+	return func(o *clientOptions) {
+		cfg := o.ensureTLSConfig()
+		cfg.Certificates = append(cfg.Certificates, cert)
+	}
+}
+
+// WithInsecureSkipVerify disables server certificate verification. It exists for local development
+// against self-signed certificates and must never be enabled in a deployed environment.
+func WithInsecureSkipVerify() Option {
+	return func(o *clientOptions) {
+		slog.Warn("TLS certificate verification is DISABLED for this client; never use this outside local development")
+		o.ensureTLSConfig().InsecureSkipVerify = true
+	}
+}
+
+// WithProxy sends every request through proxyURL. Without it, the HTTP_PROXY, HTTPS_PROXY and NO_PROXY
+// environment variables are honored. A nil proxyURL connects directly, ignoring the environment.
+func WithProxy(proxyURL *url.URL) Option {
+	return func(o *clientOptions) {
+		o.ensureTransportConfig().Proxy = func(*http.Request) (*url.URL, error) { return proxyURL, nil }
+	}
+}
+
+// ensureTLSConfig returns the TLS config of the client's dedicated transport, creating it on first use.
+func (o *clientOptions) ensureTLSConfig() *tls.Config {
+	cfg := o.ensureTransportConfig()
+	if cfg.TLSConfig == nil {
+		cfg.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	return cfg.TLSConfig
+}
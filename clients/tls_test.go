@@ -0,0 +1,70 @@
+package clients
+
+import (
+	"context"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTLSOptions(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	bundle := filepath.Join(t.TempDir(), "ca.pem")
+	if !assert.NoError(t, os.WriteFile(bundle, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw}), 0o600)) {
+		return
+	}
+
+	t.Run("Unknown CA", func(t *testing.T) {
+		c := New(srv.URL, WithRetries(0))
+		assert.ErrorContains(t, c.Do(context.Background(), http.MethodGet, "/", nil, nil), "certificate")
+	})
+
+	t.Run("CA Bundle", func(t *testing.T) {
+		pool, err := LoadCABundle(bundle)
+		if !assert.NoError(t, err) {
+			return
+		}
+		c := New(srv.URL, WithRootCAs(pool))
+		assert.NoError(t, c.Do(context.Background(), http.MethodGet, "/", nil, nil))
+	})
+
+	t.Run("Invalid CA Bundle", func(t *testing.T) {
+		invalid := filepath.Join(t.TempDir(), "invalid.pem")
+		assert.NoError(t, os.WriteFile(invalid, []byte("not a certificate"), 0o600))
+		_, err := LoadCABundle(invalid)
+		assert.ErrorContains(t, err, "no PEM certificates")
+	})
+
+	t.Run("Insecure Skip Verify", func(t *testing.T) {
+		c := New(srv.URL, WithInsecureSkipVerify())
+		assert.NoError(t, c.Do(context.Background(), http.MethodGet, "/", nil, nil))
+	})
+}
+
+func TestWithProxy(t *testing.T) {
+	var proxied string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = r.URL.String()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer proxy.Close()
+
+	proxyURL, err := url.Parse(proxy.URL)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	c := New("http://recipes.internal", WithProxy(proxyURL))
+	assert.NoError(t, c.Do(context.Background(), http.MethodGet, "/api/v1/recipes", nil, nil))
+	assert.Equal(t, "http://recipes.internal/api/v1/recipes", proxied)
+}
@@ -0,0 +1,100 @@
+package clients
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/hkinc45/dev-kitchen-go-common/auth"
+)
+
+// ErrNoUserToken is returned by the token exchange transport when the request context carries no user token.
+var ErrNoUserToken = errors.New("no user token in request context")
+
+// tokenExpirySkew is subtracted from an exchanged token's lifetime so it is never sent just as it expires.
+const tokenExpirySkew = 10 * time.Second
+
+// TokenExchangeConfig configures delegated calls made on behalf of the inbound user.
+type TokenExchangeConfig struct {
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	// Audience is the client ID of the target service.
+	Audience string
+}
+
+type exchangedToken struct {
+	accessToken string
+	expiresAt   time.Time
+}
+
+// tokenExchangeTransport exchanges the inbound user token from the request context (see auth.WithUserToken)
+// for a token scoped to the target audience, caching the exchanged token per user until it expires.
+type tokenExchangeTransport struct {
+	base   http.RoundTripper
+	config TokenExchangeConfig
+	now    func() time.Time
+
+	mu    sync.Mutex
+	cache map[string]exchangedToken
+}
+
+// WithTokenExchange makes every request on behalf of the user whose token is in the request context,
+// using an RFC 8693 token exchange for cfg.Audience. Requests without a user token fail with ErrNoUserToken.
+func WithTokenExchange(cfg TokenExchangeConfig) Option {
+	return func(o *clientOptions) { o.tokenExchange = &cfg }
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *tokenExchangeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	subjectToken, ok := auth.UserTokenFromContext(req.Context())
+	if !ok {
+		return nil, ErrNoUserToken
+	}
+
+	token, err := t.token(req, subjectToken)
+	if err != nil {
+		return nil, err
+	}
+
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+token)
+	return t.base.RoundTrip(req)
+}
+
+// token returns a cached exchanged token for subjectToken, performing the exchange on a miss.
+func (t *tokenExchangeTransport) token(req *http.Request, subjectToken string) (string, error) {
+	sum := sha256.Sum256([]byte(subjectToken))
+	key := hex.EncodeToString(sum[:])
+
+	t.mu.Lock()
+	cached, ok := t.cache[key]
+	t.mu.Unlock()
+	if ok && t.now().Before(cached.expiresAt) {
+		return cached.accessToken, nil
+	}
+
+	resp, err := auth.PerformTokenExchange(req.Context(), t.config.TokenURL, t.config.ClientID, t.config.ClientSecret, subjectToken, t.config.Audience)
+	if err != nil {
+		return "", fmt.Errorf("failed to exchange user token for audience %s: %w", t.config.Audience, err)
+	}
+
+	now := t.now()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	// Sweep expired entries so the cache does not grow with every user ever seen.
+	for k, v := range t.cache {
+		if !now.Before(v.expiresAt) {
+			delete(t.cache, k)
+		}
+	}
+	t.cache[key] = exchangedToken{
+		accessToken: resp.AccessToken,
+		expiresAt:   now.Add(time.Duration(resp.ExpiresIn)*time.Second - tokenExpirySkew),
+	}
+	return resp.AccessToken, nil
+}
@@ -0,0 +1,230 @@
+package clients
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/hkinc45/dev-kitchen-go-common/correlation"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Span describes a single outbound call, in the shape of an OpenTelemetry HTTP client span.
+type Span struct {
+	Name         string
+	TraceID      string
+	SpanID       string
+	ParentSpanID string
+	Method       string
+	Host         string
+	// Route is the route template of the call (see WithRoute), or the URL path when none was given.
+	Route      string
+	StatusCode int
+	// Retries is the number of attempts made after the first one.
+	Retries  int
+	Start    time.Time
+	Duration time.Duration
+	Err      error
+}
+
+// SpanExporter receives finished spans, e.g. to forward them to an OpenTelemetry exporter.
+type SpanExporter func(ctx context.Context, span Span)
+
+type routeKey struct{}
+
+type attemptsKey struct{}
+
+// WithRoute returns a copy of ctx naming the route template of the next call, e.g. "/recipes/{id}", so spans
+// group calls by endpoint rather than by concrete URL.
+func WithRoute(ctx context.Context, route string) context.Context {
+	return context.WithValue(ctx, routeKey{}, route)
+}
+
+// WithTracing records a span for every call and propagates it downstream as a child in the traceparent
+// header. Spans are passed to each exporter; without exporters they are logged at debug level.
+func WithTracing(exporters ...SpanExporter) Option {
+	return func(o *clientOptions) {
+		o.tracing = true
+		o.spanExporters = append(o.spanExporters, exporters...)
+	}
+}
+
+// WithTracer records the span of every call through an OpenTelemetry tracer, e.g. otel.Tracer("clients"),
+// so it reaches the exporters of the tracer's provider with the route and retry count as attributes. The
+// span is a child of the span in the request context or, failing that, of the incoming traceparent.
+func WithTracer(tracer trace.Tracer) Option {
+	return func(o *clientOptions) {
+		o.tracing = true
+		o.tracer = tracer
+	}
+}
+
+// tracingTransport records a span around each call, including its retries.
+type tracingTransport struct {
+	base      http.RoundTripper
+	exporters []SpanExporter
+	tracer    trace.Tracer
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *tracingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	ids := correlation.FromContext(ctx)
+
+	span := Span{
+		Method: req.Method,
+		Host:   req.URL.Host,
+		Route:  req.URL.Path,
+		SpanID: randomHex(8),
+		Start:  time.Now(),
+	}
+	if route, ok := ctx.Value(routeKey{}).(string); ok && route != "" {
+		span.Route = route
+	}
+	span.Name = fmt.Sprintf("HTTP %s %s", span.Method, span.Route)
+
+	traceID, parentID, flags := parseTraceParent(ids.TraceParent)
+	if traceID == "" {
+		traceID, flags = randomHex(16), "01"
+	}
+	span.TraceID = traceID
+	span.ParentSpanID = parentID
+
+	var otelSpan trace.Span
+	if t.tracer != nil {
+		ctx, otelSpan = t.startSpan(ctx, &span, ids.TraceParent)
+		sc := otelSpan.SpanContext()
+		span.TraceID, span.SpanID = sc.TraceID().String(), sc.SpanID().String()
+		flags = "00"
+		if sc.IsSampled() {
+			flags = "01"
+		}
+	}
+
+	attempts := new(atomic.Int32)
+	ctx = context.WithValue(ctx, attemptsKey{}, attempts)
+	ids.TraceParent = fmt.Sprintf("00-%s-%s-%s", span.TraceID, span.SpanID, flags)
+	ctx = correlation.WithIDs(ctx, ids)
+
+	req = req.Clone(ctx)
+	req.Header.Set(correlation.TraceParentHeader, ids.TraceParent)
+
+	resp, err := t.base.RoundTrip(req)
+
+	span.Duration = time.Since(span.Start)
+	span.Retries = max(int(attempts.Load())-1, 0)
+	span.Err = err
+	if resp != nil {
+		span.StatusCode = resp.StatusCode
+	}
+	if otelSpan != nil {
+		endSpan(otelSpan, span)
+	}
+	t.export(ctx, span)
+	return resp, err
+}
+
+// startSpan starts the OpenTelemetry span of a call and records its parent in span. Without a span in ctx,
+// the incoming traceparent, if any, is used as the remote parent.
+func (t *tracingTransport) startSpan(ctx context.Context, span *Span, traceParent string) (context.Context, trace.Span) {
+	parent := trace.SpanContextFromContext(ctx)
+	if !parent.IsValid() {
+		if remote, ok := remoteSpanContext(traceParent); ok {
+			parent = remote
+			ctx = trace.ContextWithRemoteSpanContext(ctx, remote)
+		}
+	}
+	span.ParentSpanID = ""
+	if parent.IsValid() {
+		span.ParentSpanID = parent.SpanID().String()
+	}
+	return t.tracer.Start(ctx, span.Name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithTimestamp(span.Start),
+		trace.WithAttributes(
+			attribute.String("http.request.method", span.Method),
+			attribute.String("server.address", span.Host),
+			attribute.String("http.route", span.Route),
+		),
+	)
+}
+
+// endSpan records the outcome of a call on its OpenTelemetry span and ends it.
+func endSpan(otelSpan trace.Span, span Span) {
+	otelSpan.SetAttributes(attribute.Int("http.request.resend_count", span.Retries))
+	if span.StatusCode != 0 {
+		otelSpan.SetAttributes(attribute.Int("http.response.status_code", span.StatusCode))
+	}
+	switch {
+	case span.Err != nil:
+		otelSpan.RecordError(span.Err)
+		otelSpan.SetStatus(codes.Error, span.Err.Error())
+	case span.StatusCode >= http.StatusInternalServerError:
+		otelSpan.SetStatus(codes.Error, http.StatusText(span.StatusCode))
+	}
+	otelSpan.End(trace.WithTimestamp(span.Start.Add(span.Duration)))
+}
+
+// remoteSpanContext parses a W3C traceparent header into the span context of a remote parent.
+func remoteSpanContext(traceParent string) (trace.SpanContext, bool) {
+	traceIDHex, spanIDHex, flags := parseTraceParent(traceParent)
+	traceID, err := trace.TraceIDFromHex(traceIDHex)
+	if err != nil {
+		return trace.SpanContext{}, false
+	}
+	spanID, err := trace.SpanIDFromHex(spanIDHex)
+	if err != nil {
+		return trace.SpanContext{}, false
+	}
+	var traceFlags trace.TraceFlags
+	if flags == "01" {
+		traceFlags = trace.FlagsSampled
+	}
+	return trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: traceFlags,
+		Remote:     true,
+	}), true
+}
+
+func (t *tracingTransport) export(ctx context.Context, span Span) {
+	if len(t.exporters) == 0 {
+		slog.Debug("outbound call", "span", span.Name, "trace_id", span.TraceID, "span_id", span.SpanID,
+			"status", span.StatusCode, "retries", span.Retries, "duration", span.Duration, "error", span.Err)
+		return
+	}
+	for _, export := range t.exporters {
+		export(ctx, span)
+	}
+}
+
+// recordAttempt counts an attempt of the call traced in ctx, if any.
+func recordAttempt(ctx context.Context) {
+	if attempts, ok := ctx.Value(attemptsKey{}).(*atomic.Int32); ok {
+		attempts.Add(1)
+	}
+}
+
+// parseTraceParent splits a W3C traceparent header into its trace ID, parent span ID and flags.
+func parseTraceParent(header string) (traceID, spanID, flags string) {
+	parts := strings.Split(header, "-")
+	if len(parts) != 4 || len(parts[1]) != 32 || len(parts[2]) != 16 {
+		return "", "", ""
+	}
+	return parts[1], parts[2], parts[3]
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
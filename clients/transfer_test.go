@@ -0,0 +1,139 @@
+package clients
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUploadMultipart(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, r.ParseMultipartForm(1<<20))
+		assert.Equal(t, "soup", r.FormValue("recipe"))
+
+		file, header, err := r.FormFile("image")
+		if assert.NoError(t, err) {
+			data, _ := io.ReadAll(file)
+			assert.Equal(t, "soup.png", header.Filename)
+			assert.Equal(t, "image/png", header.Header.Get("Content-Type"))
+			assert.Equal(t, "png-bytes", string(data))
+		}
+		w.Write([]byte(`{"id":"img-1"}`))
+	}))
+	defer srv.Close()
+
+	var sent int64
+	var out struct {
+		ID string `json:"id"`
+	}
+	err := UploadMultipart(context.Background(), New(srv.URL), "/images", map[string]string{"recipe": "soup"}, []UploadFile{{
+		Field:       "image",
+		Name:        "soup.png",
+		ContentType: "image/png",
+		Reader:      strings.NewReader("png-bytes"),
+		OnProgress:  func(n int64) { sent = n },
+	}}, &out)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "img-1", out.ID)
+	assert.Equal(t, int64(len("png-bytes")), sent)
+}
+
+// slowReader yields one chunk per read, pausing before each.
+type slowReader struct {
+	chunks []string
+	pause  time.Duration
+}
+
+func (r *slowReader) Read(p []byte) (int, error) {
+	if len(r.chunks) == 0 {
+		return 0, io.EOF
+	}
+	time.Sleep(r.pause)
+	n := copy(p, r.chunks[0])
+	r.chunks = r.chunks[1:]
+	return n, nil
+}
+
+func TestUploadMultipartOutlastsClientTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		file, _, err := r.FormFile("artifact")
+		if assert.NoError(t, err) {
+			data, _ := io.ReadAll(file)
+			assert.Equal(t, "chunk-1chunk-2chunk-3chunk-4", string(data))
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	body := &slowReader{chunks: []string{"chunk-1", "chunk-2", "chunk-3", "chunk-4"}, pause: 25 * time.Millisecond}
+	err := UploadMultipart(context.Background(), New(srv.URL, WithTimeout(50*time.Millisecond)), "/artifacts", nil,
+		[]UploadFile{{Field: "artifact", Name: "build.tar", Reader: body}}, nil)
+	assert.NoError(t, err)
+}
+
+func TestDownload(t *testing.T) {
+	content := strings.Repeat("artifact-", 1000)
+	sum := sha256.Sum256([]byte(content))
+	checksum := hex.EncodeToString(sum[:])
+
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			// Cut the first transfer short by hijacking the connection after half the content.
+			w.Header().Set("Accept-Ranges", "bytes")
+			w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(content[:len(content)/2]))
+			w.(http.Flusher).Flush()
+			conn, _, _ := w.(http.Hijacker).Hijack()
+			conn.Close()
+			return
+		}
+		http.ServeContent(w, r, "artifact", time.Time{}, strings.NewReader(content))
+	}))
+	defer srv.Close()
+	c := New(srv.URL)
+
+	t.Run("Resumes And Verifies", func(t *testing.T) {
+		var buf bytes.Buffer
+		n, err := Download(context.Background(), c, "/artifact", &buf, DownloadOptions{Checksum: checksum})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(len(content)), n)
+		assert.Equal(t, content, buf.String())
+		assert.Equal(t, int32(2), requests.Load())
+	})
+
+	t.Run("Outlasts Client Timeout", func(t *testing.T) {
+		slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for i := 0; i < 4; i++ {
+				w.Write([]byte(content[:len(content)/4]))
+				w.(http.Flusher).Flush()
+				time.Sleep(25 * time.Millisecond)
+			}
+		}))
+		defer slow.Close()
+
+		var buf bytes.Buffer
+		n, err := Download(context.Background(), New(slow.URL, WithTimeout(50*time.Millisecond)), "/artifact", &buf, DownloadOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(len(content)), n)
+	})
+
+	t.Run("Checksum Mismatch", func(t *testing.T) {
+		var buf bytes.Buffer
+		_, err := Download(context.Background(), c, "/artifact", &buf, DownloadOptions{Checksum: strings.Repeat("0", 64)})
+		assert.ErrorIs(t, err, ErrChecksumMismatch)
+	})
+}
@@ -0,0 +1,15 @@
+package clients
+
+import "github.com/hkinc45/dev-kitchen-go-common/internal/httptransport"
+
+// TransportConfig tunes the connection pool, TLS and HTTP/2 settings of a transport.
+type TransportConfig = httptransport.Config
+
+// TransportStats describes the connection pool usage of a transport, for exporting as metrics.
+type TransportStats = httptransport.Stats
+
+// SharedTransportStats returns the pool usage of the transport shared by every Client that was not given
+// its own transport, and by the auth package.
+func SharedTransportStats() TransportStats {
+	return httptransport.Shared().Stats()
+}
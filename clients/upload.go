@@ -0,0 +1,99 @@
+package clients
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+)
+
+// UploadFile is a file part of a multipart upload.
+type UploadFile struct {
+	// Field is the form field name of the part.
+	Field string
+	// Name is the file name sent to the server.
+	Name string
+	// ContentType defaults to application/octet-stream.
+	ContentType string
+	Reader      io.Reader
+	// OnProgress, if set, is called with the total number of bytes of this file sent so far.
+	OnProgress func(sent int64)
+}
+
+// UploadMultipart POSTs fields and files to path as multipart/form-data, e.g. for recipe images and build
+// artifacts, and decodes the response into out via HandleResponse. Files are streamed from their readers as
+// the request is sent rather than buffered in memory, so uploads are never retried. The client's timeout does
+// not apply, since large uploads take longer than any call; the upload is bounded by ctx only.
+func UploadMultipart(ctx context.Context, client *Client, path string, fields map[string]string, files []UploadFile, out interface{}) error {
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+
+	go func() {
+		pw.CloseWithError(writeMultipart(mw, fields, files))
+	}()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, client.url(path), pr)
+	if err != nil {
+		pr.Close()
+		return fmt.Errorf("failed to create upload request: %w", err)
+	}
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.streamingHTTP().Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to upload to %s: %w", req.URL.Path, err)
+	}
+	defer resp.Body.Close()
+
+	return client.HandleResponse(resp, out)
+}
+
+// writeMultipart writes the form to mw, returning the first error so it can be propagated through the pipe.
+func writeMultipart(mw *multipart.Writer, fields map[string]string, files []UploadFile) error {
+	for name, value := range fields {
+		if err := mw.WriteField(name, value); err != nil {
+			return fmt.Errorf("failed to write form field %s: %w", name, err)
+		}
+	}
+
+	for _, file := range files {
+		contentType := file.ContentType
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		header := make(textproto.MIMEHeader)
+		header.Set("Content-Disposition", fmt.Sprintf(`form-data; name=%q; filename=%q`, file.Field, file.Name))
+		header.Set("Content-Type", contentType)
+
+		part, err := mw.CreatePart(header)
+		if err != nil {
+			return fmt.Errorf("failed to create form part for %s: %w", file.Name, err)
+		}
+
+		var w io.Writer = part
+		if file.OnProgress != nil {
+			w = &progressWriter{w: part, onProgress: file.OnProgress}
+		}
+		if _, err := io.Copy(w, file.Reader); err != nil {
+			return fmt.Errorf("failed to stream file %s: %w", file.Name, err)
+		}
+	}
+	return mw.Close()
+}
+
+// progressWriter reports the running total of bytes written through it.
+type progressWriter struct {
+	w          io.Writer
+	sent       int64
+	onProgress func(sent int64)
+}
+
+func (p *progressWriter) Write(b []byte) (int, error) {
+	n, err := p.w.Write(b)
+	p.sent += int64(n)
+	p.onProgress(p.sent)
+	return n, err
+}
@@ -0,0 +1,203 @@
+package clients
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hkinc45/dev-kitchen-go-common/internal/httptransport"
+)
+
+// Headers set on outbound webhook deliveries.
+const (
+	WebhookSignatureHeader = "X-Dev-Kitchen-Signature"
+	WebhookEventHeader     = "X-Dev-Kitchen-Event"
+	WebhookDeliveryHeader  = "X-Dev-Kitchen-Delivery"
+)
+
+// ErrInvalidSignature is returned by VerifyWebhookSignature when a signature does not match.
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+
+// WebhookDelivery is a single notification to an external consumer.
+type WebhookDelivery struct {
+	// ID identifies the delivery across attempts; consumers use it to deduplicate.
+	ID      string
+	URL     string
+	Secret  string
+	Event   string
+	Payload []byte
+}
+
+// WebhookAttempt records the outcome of one delivery attempt.
+type WebhookAttempt struct {
+	DeliveryID string
+	Attempt    int
+	StatusCode int
+	Err        error
+	At         time.Time
+	Duration   time.Duration
+}
+
+// WebhookConfig configures a WebhookSender.
+type WebhookConfig struct {
+	// HTTP sends the deliveries. Defaults to a client over the shared transport with a 10 second timeout.
+	HTTP *http.Client
+	// MaxAttempts is the number of attempts before a delivery is dead-lettered. Defaults to 5.
+	MaxAttempts int
+	// BaseDelay is the delay before the first retry, doubling on each further attempt. Defaults to 1 second.
+	BaseDelay time.Duration
+	// MaxDelay caps the delay between attempts. Defaults to 1 minute.
+	MaxDelay time.Duration
+	// OnAttempt, if set, is called after every attempt, e.g. to persist the delivery log.
+	OnAttempt func(ctx context.Context, delivery WebhookDelivery, attempt WebhookAttempt)
+	// OnDeadLetter, if set, is called when a delivery is given up on.
+	OnDeadLetter func(ctx context.Context, delivery WebhookDelivery, err error)
+}
+
+// WebhookSender delivers signed webhooks to external consumers, retrying with exponential backoff.
+type WebhookSender struct {
+	config WebhookConfig
+	now    func() time.Time
+}
+
+// NewWebhookSender creates a WebhookSender.
+func NewWebhookSender(cfg WebhookConfig) *WebhookSender {
+	if cfg.HTTP == nil {
+		cfg.HTTP = &http.Client{Transport: httptransport.Shared(), Timeout: 10 * time.Second}
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 5
+	}
+	if cfg.BaseDelay <= 0 {
+		cfg.BaseDelay = time.Second
+	}
+	if cfg.MaxDelay <= 0 {
+		cfg.MaxDelay = time.Minute
+	}
+	return &WebhookSender{config: cfg, now: time.Now}
+}
+
+// Send delivers d, retrying transient failures (network errors, 408, 429 and 5xx) until MaxAttempts is
+// reached. Other 4xx responses are permanent. A delivery that fails for good is passed to OnDeadLetter
+// and its last error is returned.
+func (s *WebhookSender) Send(ctx context.Context, d WebhookDelivery) error {
+	var lastErr error
+	for attempt := 1; attempt <= s.config.MaxAttempts; attempt++ {
+		result := s.attempt(ctx, d, attempt)
+		if s.config.OnAttempt != nil {
+			s.config.OnAttempt(ctx, d, result)
+		}
+		if result.Err == nil {
+			return nil
+		}
+		lastErr = result.Err
+		if !isTransientWebhookFailure(result) || attempt == s.config.MaxAttempts {
+			break
+		}
+
+		delay := min(s.config.BaseDelay<<(attempt-1), s.config.MaxDelay)
+		slog.Warn("webhook delivery failed, retrying", "error", result.Err, "delivery_id", d.ID, "attempt", attempt, "delay", delay)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+
+	slog.Error("webhook delivery dead-lettered", "error", lastErr, "delivery_id", d.ID, "url", d.URL)
+	if s.config.OnDeadLetter != nil {
+		s.config.OnDeadLetter(ctx, d, lastErr)
+	}
+	return lastErr
+}
+
+func (s *WebhookSender) attempt(ctx context.Context, d WebhookDelivery, attempt int) WebhookAttempt {
+	result := WebhookAttempt{DeliveryID: d.ID, Attempt: attempt, At: s.now()}
+	defer func() { result.Duration = time.Since(result.At) }()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.URL, bytes.NewReader(d.Payload))
+	if err != nil {
+		result.Err = fmt.Errorf("failed to create webhook request: %w", err)
+		return result
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookEventHeader, d.Event)
+	req.Header.Set(WebhookDeliveryHeader, d.ID)
+	req.Header.Set(WebhookSignatureHeader, SignWebhook(d.Secret, result.At, d.Payload))
+
+	resp, err := s.config.HTTP.Do(req)
+	if err != nil {
+		result.Err = fmt.Errorf("failed to deliver webhook to %s: %w", d.URL, err)
+		return result
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	result.StatusCode = resp.StatusCode
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		result.Err = fmt.Errorf("webhook endpoint %s responded with status %d", d.URL, resp.StatusCode)
+	}
+	return result
+}
+
+func isTransientWebhookFailure(a WebhookAttempt) bool {
+	switch {
+	case a.StatusCode == 0:
+		return true
+	case a.StatusCode == http.StatusRequestTimeout, a.StatusCode == http.StatusTooManyRequests:
+		return true
+	default:
+		return a.StatusCode >= 500
+	}
+}
+
+// SignWebhook returns the signature header value for payload sent at ts, in the form "t=<unix>,v1=<hex>",
+// where v1 is the HMAC-SHA256 of "<unix>.<payload>" keyed with secret.
+func SignWebhook(secret string, ts time.Time, payload []byte) string {
+	unix := strconv.FormatInt(ts.Unix(), 10)
+	return fmt.Sprintf("t=%s,v1=%s", unix, webhookMAC(secret, unix, payload))
+}
+
+// VerifyWebhookSignature checks a signature header produced by SignWebhook, rejecting signatures older
+// than tolerance to prevent replays. A tolerance of zero disables the age check.
+func VerifyWebhookSignature(secret, header string, payload []byte, tolerance time.Duration) error {
+	var unix, mac string
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(part, "=")
+		switch key {
+		case "t":
+			unix = value
+		case "v1":
+			mac = value
+		}
+	}
+	ts, err := strconv.ParseInt(unix, 10, 64)
+	if err != nil || mac == "" {
+		return fmt.Errorf("%w: malformed header", ErrInvalidSignature)
+	}
+	if tolerance > 0 && time.Since(time.Unix(ts, 0)) > tolerance {
+		return fmt.Errorf("%w: signature expired", ErrInvalidSignature)
+	}
+	if !hmac.Equal([]byte(mac), []byte(webhookMAC(secret, unix, payload))) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+func webhookMAC(secret, unix string, payload []byte) string {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(unix))
+	h.Write([]byte("."))
+	h.Write(payload)
+	return hex.EncodeToString(h.Sum(nil))
+}
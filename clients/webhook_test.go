@@ -0,0 +1,70 @@
+package clients
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhookSender(t *testing.T) {
+	t.Run("Retries And Signs", func(t *testing.T) {
+		var calls atomic.Int32
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			assert.NoError(t, VerifyWebhookSignature("s3cret", r.Header.Get(WebhookSignatureHeader), body, time.Minute))
+			assert.Equal(t, "recipe.published", r.Header.Get(WebhookEventHeader))
+			assert.Equal(t, "dlv-1", r.Header.Get(WebhookDeliveryHeader))
+			if calls.Add(1) < 3 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+		}))
+		defer srv.Close()
+
+		var attempts []WebhookAttempt
+		sender := NewWebhookSender(WebhookConfig{
+			BaseDelay: time.Millisecond,
+			OnAttempt: func(_ context.Context, _ WebhookDelivery, a WebhookAttempt) { attempts = append(attempts, a) },
+		})
+		err := sender.Send(context.Background(), WebhookDelivery{
+			ID: "dlv-1", URL: srv.URL, Secret: "s3cret", Event: "recipe.published", Payload: []byte(`{"id":"r1"}`),
+		})
+
+		assert.NoError(t, err)
+		if assert.Len(t, attempts, 3) {
+			assert.Equal(t, http.StatusServiceUnavailable, attempts[0].StatusCode)
+			assert.Equal(t, http.StatusOK, attempts[2].StatusCode)
+		}
+	})
+
+	t.Run("Dead Letters Permanent Failure", func(t *testing.T) {
+		var calls atomic.Int32
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			w.WriteHeader(http.StatusGone)
+		}))
+		defer srv.Close()
+
+		var deadLettered bool
+		sender := NewWebhookSender(WebhookConfig{
+			BaseDelay:    time.Millisecond,
+			OnDeadLetter: func(context.Context, WebhookDelivery, error) { deadLettered = true },
+		})
+		err := sender.Send(context.Background(), WebhookDelivery{ID: "dlv-2", URL: srv.URL})
+
+		assert.Error(t, err)
+		assert.True(t, deadLettered)
+		assert.Equal(t, int32(1), calls.Load())
+	})
+
+	t.Run("Rejects Tampered Payload", func(t *testing.T) {
+		header := SignWebhook("s3cret", time.Now(), []byte(`{"id":"r1"}`))
+		err := VerifyWebhookSignature("s3cret", header, []byte(`{"id":"r2"}`), time.Minute)
+		assert.ErrorIs(t, err, ErrInvalidSignature)
+	})
+}
@@ -0,0 +1,140 @@
+// Package correlation carries request and trace identifiers across HTTP and NATS hops so a user action
+// can be traced through every service it touches.
+package correlation
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Header names used to propagate correlation identifiers.
+const (
+	RequestIDHeader   = "X-Request-ID"
+	TraceParentHeader = "traceparent"
+	TraceStateHeader  = "tracestate"
+	TenantIDHeader    = "X-Tenant-ID"
+)
+
+// maxRequestIDLen is the longest request ID accepted from clients.
+const maxRequestIDLen = 128
+
+// ValidRequestID reports whether id is acceptable as a request ID received from a client: at most 128
+// letters, digits and "-", "_", ".", ":" characters, which covers UUIDs, ULIDs and the IDs of common proxies.
+// Other values could inject content into logs and responses.
+func ValidRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLen {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-', r == '_', r == '.', r == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// IDs holds the correlation identifiers of a request.
+type IDs struct {
+	RequestID   string
+	TraceParent string
+	TraceState  string
+	TenantID    string
+}
+
+// TraceID returns the trace ID of the W3C traceparent, or "" if there is none.
+func (ids IDs) TraceID() string {
+	// traceparent is "<version>-<trace-id>-<parent-id>-<flags>".
+	parts := strings.Split(ids.TraceParent, "-")
+	if len(parts) < 4 || len(parts[1]) != 32 {
+		return ""
+	}
+	return parts[1]
+}
+
+type contextKey struct{}
+
+// WithIDs returns a copy of ctx carrying ids.
+func WithIDs(ctx context.Context, ids IDs) context.Context {
+	return context.WithValue(ctx, contextKey{}, ids)
+}
+
+// FromContext returns the correlation identifiers stored in ctx, if any.
+func FromContext(ctx context.Context) IDs {
+	ids, _ := ctx.Value(contextKey{}).(IDs)
+	return ids
+}
+
+// Inject writes the identifiers stored in ctx using set, e.g. http.Header.Set or nats.Header.Set.
+func Inject(ctx context.Context, set func(key, value string)) {
+	ids := FromContext(ctx)
+	if ids.RequestID != "" {
+		set(RequestIDHeader, ids.RequestID)
+	}
+	if ids.TraceParent != "" {
+		set(TraceParentHeader, ids.TraceParent)
+	}
+	if ids.TraceState != "" {
+		set(TraceStateHeader, ids.TraceState)
+	}
+	if ids.TenantID != "" {
+		set(TenantIDHeader, ids.TenantID)
+	}
+}
+
+// Extract reads identifiers using get, e.g. http.Header.Get or nats.Header.Get, and stores them in ctx.
+func Extract(ctx context.Context, get func(key string) string) context.Context {
+	ids := IDs{
+		RequestID:   get(RequestIDHeader),
+		TraceParent: get(TraceParentHeader),
+		TraceState:  get(TraceStateHeader),
+		TenantID:    get(TenantIDHeader),
+	}
+	if ids == (IDs{}) {
+		return ctx
+	}
+	return WithIDs(ctx, ids)
+}
+
+// Logger returns the default logger annotated with the identifiers stored in ctx.
+//
+// Deprecated: Use logging.FromContext, which also returns the request-scoped logger and uses the standard
+// field names.
+func Logger(ctx context.Context) *slog.Logger {
+	logger := slog.Default()
+	ids := FromContext(ctx)
+	if ids.RequestID != "" {
+		logger = logger.With("request_id", ids.RequestID)
+	}
+	if ids.TraceParent != "" {
+		logger = logger.With("traceparent", ids.TraceParent)
+	}
+	if ids.TenantID != "" {
+		logger = logger.With("tenant_id", ids.TenantID)
+	}
+	return logger
+}
+
+// Middleware is a Gin middleware that reads the correlation headers from the incoming request,
+// generating a request ID when it is absent or not a ValidRequestID, and stores them in the request context.
+// Handlers should pass c.Request.Context() to publishers and outbound clients.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := Extract(c.Request.Context(), c.GetHeader)
+		ids := FromContext(ctx)
+		if !ValidRequestID(ids.RequestID) {
+			ids.RequestID = uuid.NewString()
+			ctx = WithIDs(ctx, ids)
+		}
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Header(RequestIDHeader, ids.RequestID)
+		c.Next()
+	}
+}
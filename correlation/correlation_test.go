@@ -0,0 +1,88 @@
+package correlation
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("Propagates Incoming ID", func(t *testing.T) {
+		var got IDs
+		r := gin.New()
+		r.Use(Middleware())
+		r.GET("/test", func(c *gin.Context) {
+			got = FromContext(c.Request.Context())
+		})
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/test", nil)
+		req.Header.Set(RequestIDHeader, "req-123")
+		req.Header.Set(TraceParentHeader, "00-abc-def-01")
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, IDs{RequestID: "req-123", TraceParent: "00-abc-def-01"}, got)
+		assert.Equal(t, "req-123", w.Header().Get(RequestIDHeader))
+	})
+
+	t.Run("Generates Missing ID", func(t *testing.T) {
+		var got IDs
+		r := gin.New()
+		r.Use(Middleware())
+		r.GET("/test", func(c *gin.Context) {
+			got = FromContext(c.Request.Context())
+		})
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/test", nil)
+		r.ServeHTTP(w, req)
+
+		assert.NotEmpty(t, got.RequestID)
+		assert.Equal(t, got.RequestID, w.Header().Get(RequestIDHeader))
+	})
+
+	t.Run("Replaces Invalid ID", func(t *testing.T) {
+		var got IDs
+		r := gin.New()
+		r.Use(Middleware())
+		r.GET("/test", func(c *gin.Context) {
+			got = FromContext(c.Request.Context())
+		})
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/test", nil)
+		req.Header.Set(RequestIDHeader, "req-1\n level=ERROR msg=forged")
+		r.ServeHTTP(w, req)
+
+		assert.NoError(t, uuid.Validate(got.RequestID))
+		assert.Equal(t, got.RequestID, w.Header().Get(RequestIDHeader))
+	})
+}
+
+func TestValidRequestID(t *testing.T) {
+	for _, id := range []string{"req-123", "01J9Z8Q4X5T6V7W8Y9Z0A1B2C3", uuid.NewString(), "edge:1234.5_6"} {
+		assert.True(t, ValidRequestID(id), id)
+	}
+	for _, id := range []string{"", "req 123", "req-1\nforged", "<script>", strings.Repeat("a", 129)} {
+		assert.False(t, ValidRequestID(id), id)
+	}
+}
+
+func TestNATSRoundTrip(t *testing.T) {
+	ctx := WithIDs(context.Background(), IDs{RequestID: "req-123"})
+
+	msg := nats.NewMsg("recipe.created")
+	Inject(ctx, msg.Header.Set)
+
+	got := FromContext(Extract(context.Background(), msg.Header.Get))
+	assert.Equal(t, "req-123", got.RequestID)
+}
@@ -0,0 +1,78 @@
+// Package catalog defines the domain errors shared by dev-kitchen services, so clients see the same code,
+// status and details for an error whichever service raises it. Match them with errors.HasCode:
+//
+//	if errors.HasCode(err, catalog.CodeRecipeLocked) { ... }
+package catalog
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/hkinc45/dev-kitchen-go-common/errors"
+)
+
+// Codes of the shared domain errors. They are registered on import, so services cannot reuse them by accident.
+var (
+	CodeProjectNotFound    = errors.RegisterCode("PROJECT_NOT_FOUND", http.StatusNotFound, "The project does not exist.")
+	CodeRecipeNotFound     = errors.RegisterCode("RECIPE_NOT_FOUND", http.StatusNotFound, "The recipe does not exist.")
+	CodeUserNotFound       = errors.RegisterCode("USER_NOT_FOUND", http.StatusNotFound, "The user does not exist.")
+	CodeRecipeLocked       = errors.RegisterCode("RECIPE_LOCKED", http.StatusLocked, "The recipe is being edited by another user.")
+	CodeRecipeArchived     = errors.RegisterCode("RECIPE_ARCHIVED", http.StatusConflict, "The recipe is archived and read-only.")
+	CodeKYCRequired        = errors.RegisterCode("KYC_REQUIRED", http.StatusForbidden, "The user must complete identity verification first.")
+	CodeQuotaExceeded      = errors.RegisterCode("QUOTA_EXCEEDED", http.StatusTooManyRequests, "A usage quota of the tenant is exhausted.")
+	CodeProjectMemberLimit = errors.RegisterCode("PROJECT_MEMBER_LIMIT", http.StatusUnprocessableEntity, "The project has reached its member limit.")
+)
+
+// ProjectNotFound reports that the project with projectID does not exist.
+func ProjectNotFound(projectID string) *errors.APIError {
+	return errors.NewCodedError(CodeProjectNotFound, fmt.Sprintf("project %s not found", projectID)).
+		WithDetail("project_id", projectID)
+}
+
+// RecipeNotFound reports that the recipe with recipeID does not exist.
+func RecipeNotFound(recipeID string) *errors.APIError {
+	return errors.NewCodedError(CodeRecipeNotFound, fmt.Sprintf("recipe %s not found", recipeID)).
+		WithDetail("recipe_id", recipeID)
+}
+
+// UserNotFound reports that the user with userID does not exist.
+func UserNotFound(userID string) *errors.APIError {
+	return errors.NewCodedError(CodeUserNotFound, fmt.Sprintf("user %s not found", userID)).
+		WithDetail("user_id", userID)
+}
+
+// RecipeLocked reports that lockedBy holds the edit lock of the recipe until expires. It is retryable.
+func RecipeLocked(recipeID, lockedBy string, expires time.Time) *errors.APIError {
+	return errors.NewCodedError(CodeRecipeLocked, fmt.Sprintf("recipe %s is being edited by another user", recipeID)).
+		WithDetail("recipe_id", recipeID).
+		WithDetail("locked_by", lockedBy).
+		WithDetail("lock_expires_at", expires.UTC().Format(time.RFC3339)).
+		WithRetryable(true)
+}
+
+// RecipeArchived reports that the recipe with recipeID is archived and cannot be modified.
+func RecipeArchived(recipeID string) *errors.APIError {
+	return errors.NewCodedError(CodeRecipeArchived, fmt.Sprintf("recipe %s is archived", recipeID)).
+		WithDetail("recipe_id", recipeID)
+}
+
+// KYCRequired reports that the user must complete identity verification before the operation.
+func KYCRequired() *errors.APIError {
+	return errors.NewCodedError(CodeKYCRequired, "identity verification is required for this operation")
+}
+
+// QuotaExceeded reports that the tenant used up quota, which allows limit units and resets after resetIn.
+func QuotaExceeded(quota string, limit int64, resetIn time.Duration) *errors.APIError {
+	return errors.NewCodedError(CodeQuotaExceeded, fmt.Sprintf("%s quota of %d exceeded", quota, limit)).
+		WithDetail("quota", quota).
+		WithDetail("limit", limit).
+		WithRetryAfter(resetIn)
+}
+
+// ProjectMemberLimit reports that the project with projectID cannot take more than limit members.
+func ProjectMemberLimit(projectID string, limit int) *errors.APIError {
+	return errors.NewCodedError(CodeProjectMemberLimit, fmt.Sprintf("project %s has reached its limit of %d members", projectID, limit)).
+		WithDetail("project_id", projectID).
+		WithDetail("limit", limit)
+}
@@ -0,0 +1,32 @@
+package catalog
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/hkinc45/dev-kitchen-go-common/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCatalog(t *testing.T) {
+	err := fmt.Errorf("failed to load project: %w", ProjectNotFound("p-1"))
+	assert.True(t, errors.HasCode(err, CodeProjectNotFound))
+	assert.True(t, errors.IsNotFound(err))
+
+	locked := RecipeLocked("r-1", "u-2", time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC))
+	assert.Equal(t, http.StatusLocked, locked.StatusCode)
+	assert.Equal(t, "2026-01-02T03:04:05Z", locked.Details["lock_expires_at"])
+	assert.True(t, errors.IsRetryable(locked))
+	assert.Equal(t, errors.GRPCFailedPrecondition, errors.GRPCCodeOf(locked))
+
+	quota := QuotaExceeded("recipe_imports", 100, time.Hour)
+	assert.Equal(t, http.StatusTooManyRequests, quota.StatusCode)
+	assert.Equal(t, time.Hour, quota.RetryAfter)
+
+	info, ok := errors.LookupCode(CodeKYCRequired)
+	if assert.True(t, ok) {
+		assert.Equal(t, http.StatusForbidden, info.Status)
+	}
+}
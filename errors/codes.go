@@ -0,0 +1,119 @@
+package errors
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+)
+
+// ErrorCode is a stable, machine-readable identifier of an error, so clients can branch on the kind of
+// error instead of parsing messages. Codes are SCREAMING_SNAKE_CASE and unique across services.
+type ErrorCode string
+
+// Codes shared by every service.
+const (
+	CodeInvalidRequest     ErrorCode = "INVALID_REQUEST"
+	CodeValidationFailed   ErrorCode = "VALIDATION_FAILED"
+	CodeUnauthenticated    ErrorCode = "UNAUTHENTICATED"
+	CodePermissionDenied   ErrorCode = "PERMISSION_DENIED"
+	CodeResourceNotFound   ErrorCode = "RESOURCE_NOT_FOUND"
+	CodeResourceConflict   ErrorCode = "RESOURCE_CONFLICT"
+	CodeResourceGone       ErrorCode = "RESOURCE_GONE"
+	CodePreconditionFailed ErrorCode = "PRECONDITION_FAILED"
+	CodeFailedDependency   ErrorCode = "FAILED_DEPENDENCY"
+	CodeRateLimited        ErrorCode = "RATE_LIMITED"
+	CodeInternal           ErrorCode = "INTERNAL"
+	CodeUpstreamFailed     ErrorCode = "UPSTREAM_FAILED"
+	CodeServiceUnavailable ErrorCode = "SERVICE_UNAVAILABLE"
+	CodeUpstreamTimeout    ErrorCode = "UPSTREAM_TIMEOUT"
+)
+
+// CodeInfo describes a registered error code.
+type CodeInfo struct {
+	Code ErrorCode
+	// Status is the HTTP status errors with the code are usually rendered with.
+	Status      int
+	Description string
+}
+
+// sharedCodes are registered for every service.
+var sharedCodes = []CodeInfo{
+	{CodeInvalidRequest, http.StatusBadRequest, "The request is malformed."},
+	{CodeValidationFailed, http.StatusUnprocessableEntity, "The request failed validation."},
+	{CodeUnauthenticated, http.StatusUnauthorized, "The caller is not authenticated."},
+	{CodePermissionDenied, http.StatusForbidden, "The caller lacks permission for the operation."},
+	{CodeResourceNotFound, http.StatusNotFound, "The resource does not exist."},
+	{CodeResourceConflict, http.StatusConflict, "The resource already exists or was modified concurrently."},
+	{CodeResourceGone, http.StatusGone, "The resource was deleted for good."},
+	{CodePreconditionFailed, http.StatusPreconditionFailed, "A precondition of the request, such as If-Match, failed."},
+	{CodeFailedDependency, http.StatusFailedDependency, "An operation the request depends on failed."},
+	{CodeRateLimited, http.StatusTooManyRequests, "The caller sent too many requests."},
+	{CodeInternal, http.StatusInternalServerError, "An unexpected internal error occurred."},
+	{CodeUpstreamFailed, http.StatusBadGateway, "A downstream service failed."},
+	{CodeServiceUnavailable, http.StatusServiceUnavailable, "The service is temporarily unavailable."},
+	{CodeUpstreamTimeout, http.StatusGatewayTimeout, "A downstream service timed out."},
+}
+
+var (
+	codesMu sync.RWMutex
+	codes   = indexCodes(sharedCodes, func(info CodeInfo) ErrorCode { return info.Code })
+	// statusCodes maps an HTTP status to the shared code used for it by default.
+	statusCodes = indexCodes(sharedCodes, func(info CodeInfo) int { return info.Status })
+)
+
+// indexCodes builds a lookup of the shared codes. It runs during package variable initialization, so
+// errors declared as package variables, like ErrConflict, already get their codes.
+func indexCodes[K comparable](infos []CodeInfo, key func(CodeInfo) K) map[K]CodeInfo {
+	index := make(map[K]CodeInfo, len(infos))
+	for _, info := range infos {
+		index[key(info)] = info
+	}
+	return index
+}
+
+// RegisterCode registers a service-specific error code, typically from a package-level var:
+//
+//	var CodeRecipeArchived = errors.RegisterCode("RECIPE_ARCHIVED", http.StatusUnprocessableEntity, "The recipe is archived.")
+//
+// It panics if the code is already registered, so clashing codes fail at startup rather than confusing clients.
+func RegisterCode(code ErrorCode, status int, description string) ErrorCode {
+	codesMu.Lock()
+	defer codesMu.Unlock()
+	if _, ok := codes[code]; ok {
+		panic(fmt.Sprintf("errors: error code %s is already registered", code))
+	}
+	codes[code] = CodeInfo{Code: code, Status: status, Description: description}
+	return code
+}
+
+// LookupCode returns the registration of code.
+func LookupCode(code ErrorCode) (CodeInfo, bool) {
+	codesMu.RLock()
+	defer codesMu.RUnlock()
+	info, ok := codes[code]
+	return info, ok
+}
+
+// Codes returns every registered code, sorted, e.g. for documenting an API.
+func Codes() []CodeInfo {
+	codesMu.RLock()
+	defer codesMu.RUnlock()
+	infos := make([]CodeInfo, 0, len(codes))
+	for _, info := range codes {
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Code < infos[j].Code })
+	return infos
+}
+
+// HasCode reports whether the first APIError in the chain of err has code.
+func HasCode(err error, code ErrorCode) bool {
+	apiErr, ok := AsAPIError(err)
+	return ok && apiErr.Code == code
+}
+
+// codeForStatus returns the shared code for an HTTP status, or "" if there is none.
+func codeForStatus(status int) ErrorCode {
+	return statusCodes[status].Code
+}
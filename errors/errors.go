@@ -1,17 +1,41 @@
 package errors
 
 import (
+	"encoding/json"
+	stderrors "errors"
 	"fmt"
+	"log/slog"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/hkinc45/dev-kitchen-go-common/correlation"
+	"github.com/hkinc45/dev-kitchen-go-common/models"
 )
 
 // APIError represents a structured error response from a service.
 type APIError struct {
-	StatusCode int    `json:"status_code"`
-	Message    string `json:"error"`
-	Err        error  `json:"-"`
+	StatusCode int       `json:"status_code"`
+	Code       ErrorCode `json:"code,omitempty"`
+	Message    string    `json:"error"`
+	Err        error     `json:"-"`
+	// Details carries machine-usable context, e.g. the ID of the conflicting resource.
+	Details Details `json:"details,omitempty"`
+	// RetryAfter is sent in the Retry-After header of 429 and 503 responses.
+	RetryAfter time.Duration `json:"-"`
+
+	// MessageKey and MessageParams render Message per the request's language, see NewLocalizedError.
+	MessageKey    string                 `json:"-"`
+	MessageParams map[string]interface{} `json:"-"`
+
+	// kind marks the sentinel errors matched by Is.
+	kind bool
+	// retryable overrides the retryability derived from StatusCode, see WithRetryable.
+	retryable *bool
+	// stack is where the error was created, if stack capture is enabled.
+	stack []uintptr
 }
 
 func (e *APIError) Error() string {
@@ -25,84 +49,352 @@ func (e *APIError) Unwrap() error {
 	return e.Err
 }
 
+// NewAPIError creates an APIError with the shared code for statusCode, if any.
 func NewAPIError(statusCode int, message string) *APIError {
 	return &APIError{
 		StatusCode: statusCode,
+		Code:       codeForStatus(statusCode),
 		Message:    message,
+		stack:      callers(),
 	}
 }
 
 func NewAPIErrorWrap(statusCode int, message string, err error) *APIError {
 	return &APIError{
 		StatusCode: statusCode,
+		Code:       codeForStatus(statusCode),
 		Message:    message,
 		Err:        err,
+		stack:      callers(),
+	}
+}
+
+// Details is machine-usable context of an error, such as which resource conflicted or which quota was exceeded,
+// so clients need not parse messages. It is rendered as a "details" object when non-empty, in both formats,
+// and decoded by the clients package.
+type Details map[string]interface{}
+
+// withDetails merges details into the error's Details.
+func (e *APIError) withDetails(details []Details) *APIError {
+	for _, d := range details {
+		for key, value := range d {
+			if e.Details == nil {
+				e.Details = make(Details, len(d))
+			}
+			e.Details[key] = value
+		}
+	}
+	return e
+}
+
+// WithDetail returns a copy of the error with key set to value in its Details.
+func (e *APIError) WithDetail(key string, value interface{}) *APIError {
+	clone := *e
+	clone.Details = make(Details, len(e.Details)+1)
+	for k, v := range e.Details {
+		clone.Details[k] = v
+	}
+	clone.Details[key] = value
+	return &clone
+}
+
+// WithRetryAfter returns a copy of the error that asks clients to retry after d, through the Retry-After header
+// of 429 and 503 responses.
+func (e *APIError) WithRetryAfter(d time.Duration) *APIError {
+	clone := *e
+	clone.RetryAfter = d
+	return &clone
+}
+
+// NewCodedError creates an APIError with a registered code, rendered with the code's status.
+// Unregistered codes are rendered as 500 Internal Server Error.
+func NewCodedError(code ErrorCode, message string) *APIError {
+	status := http.StatusInternalServerError
+	if info, ok := LookupCode(code); ok {
+		status = info.Status
 	}
+	return &APIError{StatusCode: status, Code: code, Message: message, stack: callers()}
+}
+
+// WithCode returns a copy of the error carrying code.
+func (e *APIError) WithCode(code ErrorCode) *APIError {
+	clone := *e
+	clone.Code = code
+	return &clone
 }
 
-// Middleware is a Gin middleware for centralized error handling.
-func Middleware() gin.HandlerFunc {
+// Wrap wraps err, which may carry internal details such as SQL or hostnames, in an APIError that responds with
+// publicMessage only. The middleware logs the full chain of err. The status and code are those of the first
+// APIError in the chain of err, or 500 Internal Server Error. Wrap returns nil if err is nil.
+func Wrap(err error, publicMessage string) *APIError {
+	if err == nil {
+		return nil
+	}
+	wrapped := &APIError{StatusCode: http.StatusInternalServerError, Code: CodeInternal, Message: publicMessage, Err: err}
+	if cause, ok := AsAPIError(err); ok {
+		wrapped.StatusCode, wrapped.Code, wrapped.stack = cause.StatusCode, cause.Code, cause.stack
+		wrapped.Details, wrapped.RetryAfter = cause.Details, cause.RetryAfter
+	}
+	if wrapped.stack == nil {
+		wrapped.stack = callers()
+	}
+	return wrapped
+}
+
+// Middleware is a Gin middleware for centralized error handling. Errors are rendered in the legacy format
+// unless WithFormat selects problem details. Only the public message of an APIError is sent; errors that are
+// not APIErrors are answered with a generic 500. Every error response is logged with its internal cause, see
+// WithLogger. When a handler adds several errors, the most severe one determines the response and the others
+// are logged, reported and, WithWarnings, listed in the response.
+func Middleware(opts ...MiddlewareOption) gin.HandlerFunc {
+	cfg := newMiddlewareConfig(opts)
+
 	return func(c *gin.Context) {
 		c.Next() // Process request
 
-		if len(c.Errors) > 0 {
-			err := c.Errors.Last().Err
+		if len(c.Errors) == 0 {
+			return
+		}
+		errs := make([]error, len(c.Errors))
+		for i, ginErr := range c.Errors {
+			errs[i] = ginErr.Err
+		}
+		req := failedRequest{w: c.Writer, r: c.Request, route: c.FullPath(), clientIP: c.ClientIP()}
+		if user, ok := c.Get(UserContextKey); ok {
+			req.user, _ = user.(*models.User)
+		}
+		cfg.render(req, errs)
+	}
+}
 
-			if apiErr, ok := err.(*APIError); ok {
-				c.JSON(apiErr.StatusCode, apiErr)
-				return
-			}
+// failedRequest is a request whose errors are rendered, from Gin or plain net/http.
+type failedRequest struct {
+	w        http.ResponseWriter
+	r        *http.Request
+	route    string
+	clientIP string
+	user     *models.User
+	ids      correlation.IDs
+}
+
+// render maps, logs, reports and renders the errors of req.
+func (cfg *middlewareConfig) render(req failedRequest, errs []error) {
+	for i, err := range errs {
+		errs[i] = cfg.mapError(err)
+	}
+	err, others := primaryError(errs)
+	problem := cfg.wantsProblem(req.r.Header.Get("Accept"))
+	req.ids = requestIDs(req.r)
 
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": "An unexpected internal error occurred",
-			})
+	apiErr, ok := AsAPIError(err)
+	var extras responseExtras
+	if !ok || apiErr.Err != nil {
+		// The client gets the cause in development, and a reference to the logged cause in production.
+		if cfg.development() {
+			extras.Cause = err.Error()
+		} else {
+			extras.Reference = uuid.NewString()
 		}
 	}
+	if ok && cfg.development() {
+		extras.Stack = apiErr.StackTrace()
+	}
+	if cfg.warnings {
+		extras.Warnings = publicMessages(others)
+	}
+	extras.RequestID, extras.TraceID = req.ids.RequestID, req.ids.TraceID()
+	if req.ids.RequestID != "" {
+		req.w.Header().Set(correlation.RequestIDHeader, req.ids.RequestID)
+	}
+	cfg.logError(req, err, apiErr, others, extras.Reference)
+	if cfg.reporter != nil && StatusCode(err) >= http.StatusInternalServerError {
+		cfg.report(req, err, apiErr, others, extras.Reference)
+	}
+
+	var validationErr *ValidationError
+	if stderrors.As(err, &validationErr) {
+		if problem {
+			body := validationErr.Problem(req.r.URL.Path)
+			body.addExtras(extras)
+			req.w.Header().Set("Content-Type", ProblemContentType)
+			writeJSON(req.w, validationErr.StatusCode, body)
+			return
+		}
+		writeJSON(req.w, validationErr.StatusCode, struct {
+			*ValidationError
+			responseExtras
+		}{validationErr, extras})
+		return
+	}
+
+	if ok {
+		apiErr = cfg.localize(req, apiErr)
+		if apiErr.RetryAfter > 0 && (apiErr.StatusCode == http.StatusTooManyRequests || apiErr.StatusCode == http.StatusServiceUnavailable) {
+			req.w.Header().Set("Retry-After", strconv.Itoa(int((apiErr.RetryAfter+time.Second-1)/time.Second)))
+		}
+	}
+	if problem {
+		if !ok {
+			apiErr = NewInternalServerError("")
+		}
+		body := apiErr.Problem(req.r.URL.Path)
+		body.addExtras(extras)
+		req.w.Header().Set("Content-Type", ProblemContentType)
+		writeJSON(req.w, apiErr.StatusCode, body)
+		return
+	}
+
+	if ok {
+		writeJSON(req.w, apiErr.StatusCode, struct {
+			*APIError
+			responseExtras
+		}{apiErr, extras})
+		return
+	}
+
+	writeJSON(req.w, http.StatusInternalServerError, struct {
+		Code    ErrorCode `json:"code"`
+		Message string    `json:"error"`
+		responseExtras
+	}{CodeInternal, "An unexpected internal error occurred", extras})
 }
 
-// Pre-defined error types
-var (
-	ErrConflict = NewAPIError(http.StatusConflict, "resource already exists")
-)
+// writeJSON writes body as JSON with the given status, as application/json unless a content type is set.
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		slog.Error("Failed to marshal error response", "error", err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	if w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	}
+	w.WriteHeader(status)
+	_, _ = w.Write(data)
+}
+
+// responseExtras are the optional members of error responses.
+type responseExtras struct {
+	// RequestID and TraceID let users quote an identifier to support that leads to logs and traces.
+	RequestID string   `json:"request_id,omitempty"`
+	TraceID   string   `json:"trace_id,omitempty"`
+	Warnings  []string `json:"warnings,omitempty"`
+	// Reference identifies the log entry of a withheld cause in production, for support requests.
+	Reference string `json:"reference,omitempty"`
+	// Cause and Stack are sent in development only.
+	Cause string   `json:"cause,omitempty"`
+	Stack []string `json:"stack,omitempty"`
+}
+
+// primaryError returns the most severe of errs, the one with the highest status, preferring the last on ties,
+// and the others in the order they were added.
+func primaryError(errs []error) (error, []error) {
+	primary := len(errs) - 1
+	for i := len(errs) - 2; i >= 0; i-- {
+		if StatusCode(errs[i]) > StatusCode(errs[primary]) {
+			primary = i
+		}
+	}
+	var others []error
+	for i, err := range errs {
+		if i != primary {
+			others = append(others, err)
+		}
+	}
+	return errs[primary], others
+}
+
+// requestIDs returns the correlation IDs of the request, falling back to its headers when the correlation
+// middleware did not run. A request ID that is not a correlation.ValidRequestID is replaced with a generated
+// one, so it is never echoed to the client or written to the logs.
+func requestIDs(r *http.Request) correlation.IDs {
+	ids := correlation.FromContext(r.Context())
+	if ids.RequestID == "" {
+		ids.RequestID = r.Header.Get(correlation.RequestIDHeader)
+	}
+	if ids.RequestID != "" && !correlation.ValidRequestID(ids.RequestID) {
+		ids.RequestID = uuid.NewString()
+	}
+	if ids.TraceParent == "" {
+		ids.TraceParent = r.Header.Get(correlation.TraceParentHeader)
+	}
+	return ids
+}
 
-// Helper functions for common errors
+// publicMessages returns the messages of the APIErrors among errs; other errors are internal and left out.
+func publicMessages(errs []error) []string {
+	var messages []string
+	for _, err := range errs {
+		if apiErr, ok := AsAPIError(err); ok {
+			messages = append(messages, apiErr.Message)
+		}
+	}
+	return messages
+}
+
+// Helper functions for common errors. Each takes optional Details, merged into the error's Details.
+
+func NewConflictError(message string, details ...Details) *APIError {
+	return NewAPIError(http.StatusConflict, message).withDetails(details)
+}
+
+func NewBadRequestError(message string, details ...Details) *APIError {
+	return NewAPIError(http.StatusBadRequest, message).withDetails(details)
+}
+
+func NewNotFoundError(message string, details ...Details) *APIError {
+	return NewAPIError(http.StatusNotFound, message).withDetails(details)
+}
+
+func NewUnauthorizedError(message string, details ...Details) *APIError {
+	return NewAPIError(http.StatusUnauthorized, message).withDetails(details)
+}
 
-func NewConflictError(message string) *APIError {
-	return NewAPIError(http.StatusConflict, message)
+func NewForbiddenError(message string, details ...Details) *APIError {
+	return NewAPIError(http.StatusForbidden, message).withDetails(details)
 }
 
-func NewBadRequestError(message string) *APIError {
-	return NewAPIError(http.StatusBadRequest, message)
+// NewGoneError creates a 410 Gone error, for resources that were deleted for good.
+func NewGoneError(message string, details ...Details) *APIError {
+	return NewAPIError(http.StatusGone, message).withDetails(details)
 }
 
-func NewNotFoundError(message string) *APIError {
-	return NewAPIError(http.StatusNotFound, message)
+// NewPreconditionFailedError creates a 412 Precondition Failed error, e.g. for a stale If-Match ETag.
+func NewPreconditionFailedError(message string, details ...Details) *APIError {
+	return NewAPIError(http.StatusPreconditionFailed, message).withDetails(details)
 }
 
-func NewUnauthorizedError(message string) *APIError {
-	return NewAPIError(http.StatusUnauthorized, message)
+func NewInternalServerError(message string, details ...Details) *APIError {
+	return NewAPIError(http.StatusInternalServerError, "An unexpected internal error occurred").withDetails(details)
 }
 
-func NewForbiddenError(message string) *APIError {
-	return NewAPIError(http.StatusForbidden, message)
+func NewUnprocessableEntityError(message string, details ...Details) *APIError {
+	return NewAPIError(http.StatusUnprocessableEntity, message).withDetails(details)
 }
 
-func NewInternalServerError(message string) *APIError {
-	return NewAPIError(http.StatusInternalServerError, "An unexpected internal error occurred")
+// NewFailedDependencyError creates a 424 Failed Dependency error, for requests that failed because an earlier
+// operation they depend on did.
+func NewFailedDependencyError(message string, details ...Details) *APIError {
+	return NewAPIError(http.StatusFailedDependency, message).withDetails(details)
 }
 
-func NewUnprocessableEntityError(message string) *APIError {
-	return NewAPIError(http.StatusUnprocessableEntity, message)
+// NewRateLimitedError creates a retryable 429 Too Many Requests error. Use WithRetryAfter to tell clients when
+// to come back.
+func NewRateLimitedError(message string, details ...Details) *APIError {
+	return NewAPIError(http.StatusTooManyRequests, message).withDetails(details)
 }
 
-func NewBadGatewayError(message string) *APIError {
-	return NewAPIError(http.StatusBadGateway, message)
+func NewBadGatewayError(message string, details ...Details) *APIError {
+	return NewAPIError(http.StatusBadGateway, message).withDetails(details)
 }
 
-func NewServiceUnavailableError(message string) *APIError {
-	return NewAPIError(http.StatusServiceUnavailable, message)
+// NewServiceUnavailableError creates a retryable 503 Service Unavailable error. Use WithRetryAfter to tell
+// clients when to come back.
+func NewServiceUnavailableError(message string, details ...Details) *APIError {
+	return NewAPIError(http.StatusServiceUnavailable, message).withDetails(details)
 }
 
-func NewGatewayTimeoutError(message string) *APIError {
-	return NewAPIError(http.StatusGatewayTimeout, message)
+func NewGatewayTimeoutError(message string, details ...Details) *APIError {
+	return NewAPIError(http.StatusGatewayTimeout, message).withDetails(details)
 }
@@ -1,15 +1,42 @@
 package errors
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
 	"testing"
+	"testing/fstest"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/hkinc45/dev-kitchen-go-common/correlation"
+	"github.com/hkinc45/dev-kitchen-go-common/logging"
+	"github.com/hkinc45/dev-kitchen-go-common/models"
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	grpccodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/test/bufconn"
 )
 
+func TestMain(m *testing.M) {
+	// Silence the error middleware's request logs
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	os.Exit(m.Run())
+}
+
 func TestAPIError(t *testing.T) {
 	innerErr := errors.New("database connection failed")
 	apiErr := NewAPIErrorWrap(http.StatusInternalServerError, "internal server error", innerErr)
@@ -53,3 +80,865 @@ func TestMiddleware(t *testing.T) {
 		assert.Contains(t, w.Body.String(), "An unexpected internal error occurred")
 	})
 }
+
+func TestMiddlewareProblemFormat(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	serve := func(format Format, accept string, err error) *httptest.ResponseRecorder {
+		r := gin.New()
+		r.Use(Middleware(WithFormat(format)))
+		r.GET("/recipes/42", func(c *gin.Context) {
+			c.Error(err)
+		})
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/recipes/42", nil)
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Problem", func(t *testing.T) {
+		w := serve(FormatProblem, "", NewNotFoundError("recipe not found"))
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Equal(t, ProblemContentType, w.Header().Get("Content-Type"))
+		assert.JSONEq(t, `{"type":"about:blank","title":"Not Found","status":404,"detail":"recipe not found","instance":"/recipes/42","code":"RESOURCE_NOT_FOUND"}`, w.Body.String())
+	})
+
+	t.Run("Problem Hides Unexpected Errors", func(t *testing.T) {
+		w := serve(FormatProblem, "", errors.New("database password is hunter2"))
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.NotContains(t, w.Body.String(), "hunter2")
+		assert.Contains(t, w.Body.String(), "An unexpected internal error occurred")
+	})
+
+	t.Run("Negotiate", func(t *testing.T) {
+		w := serve(FormatNegotiate, ProblemContentType, NewConflictError("exists"))
+		assert.Equal(t, ProblemContentType, w.Header().Get("Content-Type"))
+
+		w = serve(FormatNegotiate, "application/json", NewConflictError("exists"))
+		assert.JSONEq(t, `{"status_code":409,"code":"RESOURCE_CONFLICT","error":"exists"}`, w.Body.String())
+	})
+}
+
+func TestErrorCodes(t *testing.T) {
+	t.Run("Shared Codes Follow Status", func(t *testing.T) {
+		assert.Equal(t, CodeResourceNotFound, NewNotFoundError("missing").Code)
+		assert.Equal(t, CodePermissionDenied, NewForbiddenError("no").Code)
+		assert.Equal(t, CodeResourceConflict, ErrConflict.Code)
+		assert.Equal(t, ErrorCode(""), NewAPIError(http.StatusTeapot, "teapot").Code)
+	})
+
+	t.Run("Registered Codes", func(t *testing.T) {
+		code := RegisterCode("TEST_RECIPE_ARCHIVED", http.StatusUnprocessableEntity, "The recipe is archived.")
+		err := NewCodedError(code, "recipe is archived")
+
+		assert.Equal(t, http.StatusUnprocessableEntity, err.StatusCode)
+		assert.Equal(t, code, err.Code)
+		assert.Panics(t, func() { RegisterCode(code, http.StatusConflict, "duplicate") })
+		assert.Panics(t, func() { RegisterCode(CodeResourceNotFound, http.StatusNotFound, "duplicate") })
+	})
+
+	t.Run("Unregistered Codes", func(t *testing.T) {
+		assert.Equal(t, http.StatusInternalServerError, NewCodedError("TEST_UNKNOWN", "x").StatusCode)
+	})
+}
+
+func TestValidationError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	type ingredient struct {
+		Name string `json:"name" binding:"required"`
+	}
+	type createRecipe struct {
+		Title       string       `json:"title" binding:"required,max=8"`
+		Servings    int          `json:"servings" binding:"min=1"`
+		Ingredients []ingredient `json:"ingredients" binding:"dive"`
+	}
+
+	serve := func(format Format, body string) *httptest.ResponseRecorder {
+		r := gin.New()
+		r.Use(Middleware(WithFormat(format)))
+		r.POST("/recipes", func(c *gin.Context) {
+			var req createRecipe
+			if err := c.ShouldBindJSON(&req); err != nil {
+				c.Error(NewBindingError(err))
+			}
+		})
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/recipes", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Validator Errors", func(t *testing.T) {
+		w := serve(FormatLegacy, `{"title":"Too long a title","servings":0,"ingredients":[{"name":""}]}`)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		assert.JSONEq(t, `{
+			"status_code": 422,
+			"code": "VALIDATION_FAILED",
+			"error": "request validation failed",
+			"fields": [
+				{"field": "Title", "rule": "max", "param": "8", "message": "must be at most 8"},
+				{"field": "Servings", "rule": "min", "param": "1", "message": "must be at least 1"},
+				{"field": "Ingredients[0].Name", "rule": "required", "message": "is required"}
+			]
+		}`, w.Body.String())
+	})
+
+	t.Run("Problem Format", func(t *testing.T) {
+		w := serve(FormatProblem, `{"title":"Soup","servings":"two"}`)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		assert.Equal(t, ProblemContentType, w.Header().Get("Content-Type"))
+		assert.Contains(t, w.Body.String(), `"fields":[{"field":"servings","rule":"type","param":"int","message":"must be of type int"}]`)
+	})
+
+	t.Run("Malformed Body", func(t *testing.T) {
+		w := serve(FormatLegacy, `{"title":`)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "malformed request body")
+	})
+}
+
+func TestErrorKinds(t *testing.T) {
+	t.Run("Is Matches Wrapped Errors", func(t *testing.T) {
+		err := fmt.Errorf("failed to get recipe 42: %w", NewNotFoundError("recipe not found"))
+
+		assert.True(t, errors.Is(err, ErrNotFound))
+		assert.True(t, IsNotFound(err))
+		assert.False(t, IsConflict(err))
+		assert.Equal(t, http.StatusNotFound, StatusCode(err))
+	})
+
+	t.Run("Only Sentinels Are Kinds", func(t *testing.T) {
+		assert.False(t, errors.Is(NewNotFoundError("a"), NewNotFoundError("b")))
+		assert.True(t, IsConflict(ErrConflict))
+	})
+
+	t.Run("Validation Errors", func(t *testing.T) {
+		err := fmt.Errorf("failed to create recipe: %w", NewValidationError(FieldViolation{Field: "title", Rule: "required"}))
+
+		assert.True(t, IsUnprocessable(err))
+		apiErr, ok := AsAPIError(err)
+		if assert.True(t, ok) {
+			assert.Equal(t, CodeValidationFailed, apiErr.Code)
+		}
+	})
+
+	t.Run("Unavailable", func(t *testing.T) {
+		assert.True(t, IsUnavailable(NewGatewayTimeoutError("slow")))
+		assert.False(t, IsUnavailable(errors.New("plain")))
+		assert.Equal(t, http.StatusInternalServerError, StatusCode(errors.New("plain")))
+	})
+}
+
+func TestWrap(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var logs bytes.Buffer
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+
+	cause := errors.New(`pq: duplicate key value violates unique constraint "recipes_slug_key"`)
+
+	t.Run("Responds With Public Message Only", func(t *testing.T) {
+		r := gin.New()
+		r.Use(Middleware())
+		r.POST("/recipes", func(c *gin.Context) {
+			c.Error(Wrap(fmt.Errorf("failed to insert recipe: %w", cause), "could not save the recipe"))
+		})
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/recipes", nil)
+		r.ServeHTTP(w, req)
+
+		var body map[string]interface{}
+		if !assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body)) {
+			return
+		}
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.Equal(t, "could not save the recipe", body["error"])
+		assert.NotContains(t, w.Body.String(), "recipes_slug_key")
+		assert.Contains(t, logs.String(), "recipes_slug_key")
+		assert.Contains(t, logs.String(), "level=ERROR")
+		assert.Contains(t, logs.String(), "error_reference="+body["reference"].(string))
+	})
+
+	t.Run("Keeps Status Of Wrapped APIError", func(t *testing.T) {
+		err := Wrap(NewAPIErrorWrap(http.StatusConflict, "slug taken", cause), "a recipe with this name already exists")
+
+		assert.Equal(t, http.StatusConflict, err.StatusCode)
+		assert.Equal(t, CodeResourceConflict, err.Code)
+		assert.True(t, errors.Is(err, cause))
+		assert.True(t, IsConflict(err))
+	})
+
+	t.Run("Nil", func(t *testing.T) {
+		assert.Nil(t, Wrap(nil, "unused"))
+	})
+}
+
+func TestStackCapture(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	serve := func(opts ...MiddlewareOption) *httptest.ResponseRecorder {
+		r := gin.New()
+		r.Use(Middleware(opts...))
+		r.GET("/recipes", func(c *gin.Context) {
+			c.Error(NewBadGatewayError("recipe store unavailable"))
+		})
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/recipes", nil)
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Disabled By Default", func(t *testing.T) {
+		assert.Nil(t, NewNotFoundError("missing").StackTrace())
+		assert.NotContains(t, serve(WithDevMode(true)).Body.String(), "stack")
+	})
+
+	SetStackCapture(true)
+	defer SetStackCapture(false)
+
+	t.Run("Captured", func(t *testing.T) {
+		stack := NewNotFoundError("missing").StackTrace()
+		if assert.NotEmpty(t, stack) {
+			assert.Contains(t, strings.Join(stack, "\n"), "TestStackCapture")
+		}
+		assert.Nil(t, ErrNotFound.StackTrace())
+	})
+
+	t.Run("Dev Mode Only", func(t *testing.T) {
+		assert.NotContains(t, serve().Body.String(), "stack")
+		assert.Contains(t, serve(WithDevMode(true)).Body.String(), `"stack":[`)
+		assert.Contains(t, serve(WithDevMode(true), WithFormat(FormatProblem)).Body.String(), `"stack":[`)
+	})
+}
+
+func TestGRPCMapping(t *testing.T) {
+	assert.Equal(t, GRPCOK, GRPCCodeOf(nil))
+	assert.Equal(t, GRPCNotFound, GRPCCodeOf(fmt.Errorf("failed to get recipe: %w", NewNotFoundError("missing"))))
+	assert.Equal(t, GRPCInvalidArgument, GRPCCodeOf(NewValidationError()))
+	assert.Equal(t, GRPCUnknown, GRPCCodeOf(errors.New("plain")))
+	assert.Equal(t, "An unexpected internal error occurred", PublicMessage(errors.New("secret")))
+
+	apiErr := FromGRPC(GRPCAlreadyExists, "exists", "")
+	assert.True(t, IsConflict(apiErr))
+	assert.Equal(t, CodeResourceConflict, apiErr.Code)
+	assert.Equal(t, CodeRateLimited, FromGRPC(GRPCResourceExhausted, "slow down", CodeRateLimited).Code)
+	assert.Equal(t, http.StatusInternalServerError, FromGRPC(99, "odd", "").StatusCode)
+}
+
+// grpcHealthServer fails the health checks of a service with the error registered for its name.
+type grpcHealthServer struct {
+	grpc_health_v1.UnimplementedHealthServer
+	errs map[string]error
+}
+
+func (s *grpcHealthServer) Check(_ context.Context, req *grpc_health_v1.HealthCheckRequest) (*grpc_health_v1.HealthCheckResponse, error) {
+	if err := s.errs[req.Service]; err != nil {
+		return nil, err
+	}
+	return &grpc_health_v1.HealthCheckResponse{Status: grpc_health_v1.HealthCheckResponse_SERVING}, nil
+}
+
+func (s *grpcHealthServer) Watch(req *grpc_health_v1.HealthCheckRequest, stream grpc.ServerStreamingServer[grpc_health_v1.HealthCheckResponse]) error {
+	return s.errs[req.Service]
+}
+
+func TestGRPCInterceptors(t *testing.T) {
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(UnaryServerInterceptor()),
+		grpc.ChainStreamInterceptor(StreamServerInterceptor()),
+	)
+	grpc_health_v1.RegisterHealthServer(srv, &grpcHealthServer{errs: map[string]error{
+		"missing": fmt.Errorf("failed to load recipe: %w", NewNotFoundError("recipe not found").WithCode(CodeResourceNotFound)),
+		"busy":    NewServiceUnavailableError("try later").WithRetryAfter(30 * time.Second),
+		"broken":  errors.New("connection refused by db-1.internal"),
+	}})
+	go srv.Serve(lis)
+	defer srv.Stop()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithChainUnaryInterceptor(UnaryClientInterceptor()),
+		grpc.WithChainStreamInterceptor(StreamClientInterceptor()),
+	)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer conn.Close()
+	client := grpc_health_v1.NewHealthClient(conn)
+	ctx := context.Background()
+
+	tests := []struct {
+		service        string
+		wantStatus     int
+		wantCode       ErrorCode
+		wantMessage    string
+		wantRetryAfter time.Duration
+	}{
+		{"missing", http.StatusNotFound, CodeResourceNotFound, "recipe not found", 0},
+		{"busy", http.StatusServiceUnavailable, CodeServiceUnavailable, "try later", 30 * time.Second},
+		{"broken", http.StatusInternalServerError, CodeInternal, NewInternalServerError("").Message, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.service, func(t *testing.T) {
+			_, err := client.Check(ctx, &grpc_health_v1.HealthCheckRequest{Service: tt.service})
+			apiErr, ok := AsAPIError(err)
+			if !assert.True(t, ok, "want an APIError, got %v", err) {
+				return
+			}
+			assert.Equal(t, tt.wantStatus, apiErr.StatusCode)
+			assert.Equal(t, tt.wantCode, apiErr.Code)
+			assert.Equal(t, tt.wantMessage, apiErr.Message)
+			assert.Equal(t, tt.wantRetryAfter, apiErr.RetryAfter)
+			assert.NotContains(t, apiErr.Error(), "db-1.internal", "causes must not reach clients")
+
+			stream, err := client.Watch(ctx, &grpc_health_v1.HealthCheckRequest{Service: tt.service})
+			if !assert.NoError(t, err) {
+				return
+			}
+			_, err = stream.Recv()
+			if apiErr, ok := AsAPIError(err); assert.True(t, ok, "want an APIError, got %v", err) {
+				assert.Equal(t, tt.wantStatus, apiErr.StatusCode)
+			}
+		})
+	}
+
+	_, err = client.Check(ctx, &grpc_health_v1.HealthCheckRequest{Service: "healthy"})
+	assert.NoError(t, err)
+	assert.Equal(t, io.EOF, FromGRPCError(io.EOF))
+	assert.Equal(t, grpccodes.DeadlineExceeded, ToStatus(fmt.Errorf("failed to load: %w", context.DeadlineExceeded)).Code())
+}
+
+func TestReporter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var reports []Report
+	r := gin.New()
+	r.Use(Middleware(WithReporter(ReporterFunc(func(ctx context.Context, report Report) {
+		reports = append(reports, report)
+	}))))
+	r.GET("/recipes/:id", func(c *gin.Context) {
+		switch c.Param("id") {
+		case "missing":
+			c.Error(NewNotFoundError("recipe not found"))
+		default:
+			c.Error(errors.New("connection refused"))
+		}
+	})
+
+	for _, id := range []string{"missing", "42"} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/recipes/"+id+"?token=secret", nil)
+		req.Header.Set("X-Request-ID", "req-1")
+		r.ServeHTTP(w, req)
+	}
+
+	if assert.Len(t, reports, 1) {
+		report := reports[0]
+		assert.EqualError(t, report.Err, "connection refused")
+		assert.Equal(t, http.StatusInternalServerError, report.Status)
+		assert.Equal(t, "/recipes/:id", report.Route)
+		assert.Equal(t, "/recipes/42", report.Path)
+		assert.Equal(t, "req-1", report.RequestID)
+	}
+}
+
+func TestLocalizedErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	bundle := NewBundle()
+	err := bundle.LoadFS(fstest.MapFS{
+		"locales/en.json": {Data: []byte(`{"recipe.not_found": "Recipe {id} was not found", "recipe.archived": "Recipe is archived"}`)},
+		"locales/de.json": {Data: []byte(`{"recipe.not_found": "Rezept {id} wurde nicht gefunden"}`)},
+	}, "locales")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	serve := func(acceptLanguage string, apiErr *APIError) *httptest.ResponseRecorder {
+		r := gin.New()
+		r.Use(Middleware(WithBundle(bundle)))
+		r.GET("/recipes/42", func(c *gin.Context) {
+			c.Error(apiErr)
+		})
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/recipes/42", nil)
+		req.Header.Set("Accept-Language", acceptLanguage)
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	notFound := NewLocalizedError(http.StatusNotFound, "recipe.not_found", map[string]interface{}{"id": 42})
+
+	t.Run("Accept-Language", func(t *testing.T) {
+		w := serve("de-CH, en;q=0.5", notFound)
+		assert.Contains(t, w.Body.String(), "Rezept 42 wurde nicht gefunden")
+		assert.Equal(t, "de", w.Header().Get("Content-Language"))
+	})
+
+	t.Run("Falls Back To English", func(t *testing.T) {
+		assert.Contains(t, serve("fr", notFound).Body.String(), "Recipe 42 was not found")
+		assert.Contains(t, serve("de", NewConflictError("archived").Localized("recipe.archived", nil)).Body.String(), "Recipe is archived")
+	})
+
+	t.Run("Unknown Key Keeps Message", func(t *testing.T) {
+		w := serve("de", NewConflictError("recipe already exists").Localized("recipe.exists", nil))
+		assert.Contains(t, w.Body.String(), "recipe already exists")
+	})
+}
+
+func TestRetryable(t *testing.T) {
+	assert.True(t, IsRetryable(fmt.Errorf("failed to list recipes: %w", NewServiceUnavailableError("maintenance"))))
+	assert.True(t, IsRetryable(NewRateLimitedError("slow down")))
+	assert.False(t, IsRetryable(NewNotFoundError("missing")))
+	assert.False(t, IsRetryable(NewInternalServerError("")))
+	assert.True(t, IsRetryable(NewInternalServerError("").WithRetryable(true)))
+	assert.False(t, IsRetryable(NewServiceUnavailableError("gone for good").WithRetryable(false)))
+	assert.False(t, IsRetryable(errors.New("plain")))
+	assert.False(t, IsRetryable(NewValidationError()))
+
+	// Network-like errors are recognized through net.Error without knowing APIError.
+	var netErr net.Error
+	if assert.True(t, errors.As(fmt.Errorf("failed to get recipe: %w", NewGatewayTimeoutError("upstream timed out")), &netErr)) {
+		assert.True(t, netErr.Timeout())
+		assert.True(t, netErr.Temporary())
+	}
+	assert.False(t, NewServiceUnavailableError("maintenance").Timeout())
+	assert.True(t, NewServiceUnavailableError("maintenance").Temporary())
+	assert.False(t, NewServiceUnavailableError("gone for good").WithRetryable(false).Temporary())
+	assert.False(t, NewRateLimitedError("slow down").Temporary())
+	assert.False(t, NewNotFoundError("missing").Timeout())
+}
+
+func TestMultipleErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var reports []Report
+	serve := func(opts ...MiddlewareOption) *httptest.ResponseRecorder {
+		r := gin.New()
+		r.Use(Middleware(opts...))
+		r.POST("/recipes/import", func(c *gin.Context) {
+			c.Error(NewNotFoundError("ingredient 7 not found"))
+			c.Error(NewBadGatewayError("nutrition service unavailable"))
+			c.Error(NewConflictError("recipe 3 already exists"))
+		})
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/recipes/import", nil)
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Most Severe Error Wins", func(t *testing.T) {
+		w := serve(WithReporter(ReporterFunc(func(ctx context.Context, report Report) {
+			reports = append(reports, report)
+		})))
+
+		assert.Equal(t, http.StatusBadGateway, w.Code)
+		assert.JSONEq(t, `{"status_code":502,"code":"UPSTREAM_FAILED","error":"nutrition service unavailable"}`, w.Body.String())
+		if assert.Len(t, reports, 1) {
+			assert.Len(t, reports[0].Others, 2)
+		}
+	})
+
+	t.Run("Warnings", func(t *testing.T) {
+		w := serve(WithWarnings(true))
+		assert.Contains(t, w.Body.String(), `"warnings":["ingredient 7 not found","recipe 3 already exists"]`)
+
+		w = serve(WithWarnings(true), WithFormat(FormatProblem))
+		assert.Contains(t, w.Body.String(), `"warnings":["ingredient 7 not found","recipe 3 already exists"]`)
+	})
+}
+
+func TestErrorLogging(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	userID := uuid.New()
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set(UserContextKey, &models.User{ID: userID})
+	})
+	r.Use(Middleware(WithLogger(logger)))
+	r.GET("/recipes/:id", func(c *gin.Context) {
+		c.Error(NewAPIErrorWrap(http.StatusNotFound, "recipe not found", errors.New("sql: no rows in result set")))
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/recipes/42", nil)
+	req.Header.Set("X-Request-ID", "req-7")
+	r.ServeHTTP(w, req)
+
+	var entry map[string]interface{}
+	if !assert.NoError(t, json.Unmarshal(logs.Bytes(), &entry)) {
+		return
+	}
+	assert.Equal(t, "WARN", entry["level"])
+	assert.Equal(t, float64(http.StatusNotFound), entry["status"])
+	assert.Equal(t, "RESOURCE_NOT_FOUND", entry["code"])
+	assert.Equal(t, "/recipes/:id", entry["route"])
+	assert.Equal(t, "req-7", entry["request_id"])
+	assert.Equal(t, userID.String(), entry["user_id"])
+	assert.Contains(t, entry["error"], "sql: no rows in result set")
+}
+
+func TestErrorLoggingUsesRequestLogger(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&logs, nil)).With("service", "recipe-service")
+
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(logger)
+
+	r := gin.New()
+	r.Use(correlation.Middleware(), logging.Middleware(), Middleware())
+	r.GET("/recipes/:id", func(c *gin.Context) {
+		c.Error(NewInternalServerError("boom"))
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/recipes/42", nil)
+	req.Header.Set("X-Request-ID", "req-7")
+	r.ServeHTTP(w, req)
+
+	var entry map[string]interface{}
+	if !assert.NoError(t, json.Unmarshal(logs.Bytes(), &entry)) {
+		return
+	}
+	assert.Equal(t, "recipe-service", entry["service"])
+	assert.Equal(t, "req-7", entry["request_id"])
+	assert.Equal(t, 1, bytes.Count(logs.Bytes(), []byte(`"request_id"`)), "the request ID must not be repeated")
+}
+
+func TestConstructors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("Statuses And Codes", func(t *testing.T) {
+		assert.Equal(t, CodeResourceGone, NewGoneError("deleted").Code)
+		assert.Equal(t, http.StatusPreconditionFailed, NewPreconditionFailedError("stale etag").StatusCode)
+		assert.Equal(t, CodeFailedDependency, NewFailedDependencyError("step 1 failed").Code)
+		assert.True(t, IsRetryable(NewRateLimitedError("slow down").WithRetryAfter(time.Second)))
+	})
+
+	t.Run("Details", func(t *testing.T) {
+		err := NewConflictError("recipe already exists", Details{"recipe_id": "42"}, Details{"field": "slug"})
+		assert.Equal(t, Details{"recipe_id": "42", "field": "slug"}, err.Details)
+		assert.Nil(t, NewConflictError("recipe already exists").Details)
+	})
+
+	t.Run("Retry-After", func(t *testing.T) {
+		r := gin.New()
+		r.Use(Middleware())
+		r.GET("/quota", func(c *gin.Context) {
+			c.Error(NewRateLimitedError("quota exceeded", Details{"quota": "imports"}).WithRetryAfter(1500 * time.Millisecond))
+		})
+		r.GET("/conflict", func(c *gin.Context) {
+			c.Error(NewConflictError("locked").WithRetryAfter(time.Second))
+		})
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/quota", nil)
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Equal(t, "2", w.Header().Get("Retry-After"))
+		assert.JSONEq(t, `{"status_code":429,"code":"RATE_LIMITED","error":"quota exceeded","details":{"quota":"imports"}}`, w.Body.String())
+
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest("GET", "/conflict", nil)
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Empty(t, w.Header().Get("Retry-After"), "Retry-After is only sent with 429 and 503")
+	})
+}
+
+func TestDetails(t *testing.T) {
+	err := NewConflictError("recipe already exists").WithDetail("recipe_id", "42")
+	assert.Equal(t, Details{"recipe_id": "42"}, err.Details)
+	assert.Equal(t, Details{"recipe_id": "42", "slug": "soup"}, err.WithDetail("slug", "soup").Details)
+	assert.Len(t, err.Details, 1, "WithDetail must not modify the original")
+	assert.Equal(t, err.Details, Wrap(err, "name taken").Details)
+
+	problem := err.Problem("/recipes")
+	data, marshalErr := json.Marshal(problem)
+	if !assert.NoError(t, marshalErr) {
+		return
+	}
+	assert.Contains(t, string(data), `"details":{"recipe_id":"42"}`)
+
+	var decoded Problem
+	if assert.NoError(t, json.Unmarshal(data, &decoded)) {
+		assert.Equal(t, Details{"recipe_id": "42"}, decoded.APIError().Details)
+	}
+}
+
+func TestModes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	serve := func(opts ...MiddlewareOption) map[string]interface{} {
+		r := gin.New()
+		r.Use(Middleware(opts...))
+		r.GET("/recipes", func(c *gin.Context) {
+			c.Error(fmt.Errorf("failed to list recipes: %w", errors.New("dial tcp 10.0.0.5:5432: connection refused")))
+		})
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/recipes", nil)
+		r.ServeHTTP(w, req)
+
+		var body map[string]interface{}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		return body
+	}
+
+	t.Run("Production", func(t *testing.T) {
+		assert.Equal(t, ModeProduction, CurrentMode())
+		body := serve()
+		assert.NotEmpty(t, body["reference"])
+		assert.Nil(t, body["cause"])
+	})
+
+	t.Run("Development", func(t *testing.T) {
+		SetMode(ModeDevelopment)
+		defer SetMode(ModeProduction)
+		defer SetStackCapture(false)
+
+		body := serve()
+		assert.Equal(t, "failed to list recipes: dial tcp 10.0.0.5:5432: connection refused", body["cause"])
+		assert.Nil(t, body["reference"])
+
+		assert.Nil(t, serve(WithDevMode(false))["cause"])
+	})
+}
+
+var errRecipeMissing = errors.New("recipe missing")
+
+func TestMappers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.Use(Middleware(
+		WithMapper(func(err error) *APIError {
+			if errors.Is(err, errRecipeMissing) {
+				return NewNotFoundError("recipe not found")
+			}
+			return nil
+		}),
+		WithMapper(func(err error) *APIError {
+			return NewBadGatewayError("upstream failed")
+		}),
+	))
+	r.GET("/recipes/:id", func(c *gin.Context) {
+		switch c.Param("id") {
+		case "missing":
+			c.Error(fmt.Errorf("failed to load recipe: %w", errRecipeMissing))
+		case "explicit":
+			c.Error(NewConflictError("already exists"))
+		default:
+			c.Error(errors.New("sdk: boom"))
+		}
+	})
+
+	serve := func(id string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/recipes/"+id, nil)
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := serve("missing")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), "recipe not found")
+	assert.Equal(t, http.StatusBadGateway, serve("other").Code)
+	assert.Equal(t, http.StatusConflict, serve("explicit").Code)
+}
+
+func TestFromResponse(t *testing.T) {
+	response := func(status int, contentType, body string) *http.Response {
+		return &http.Response{
+			StatusCode: status,
+			Header:     http.Header{"Content-Type": []string{contentType}},
+			Body:       io.NopCloser(strings.NewReader(body)),
+		}
+	}
+
+	assert.Nil(t, FromResponse(response(http.StatusOK, "application/json", `{}`)))
+
+	apiErr := FromResponse(response(http.StatusNotFound, "application/json", `{"error":"recipe 42 not found"}`))
+	assert.Equal(t, "recipe 42 not found", apiErr.Message)
+	assert.Equal(t, CodeResourceNotFound, apiErr.Code)
+
+	apiErr = FromResponse(response(http.StatusConflict, "text/plain", "duplicate"))
+	assert.True(t, IsConflict(apiErr))
+	assert.Equal(t, "resource already exists", apiErr.Message)
+
+	apiErr = FromResponse(response(http.StatusTeapot, "text/plain", "short and stout"))
+	var respErr *ResponseError
+	if assert.ErrorAs(t, apiErr, &respErr) {
+		assert.Equal(t, "short and stout", string(respErr.Body))
+	}
+}
+
+func TestRedactedURL(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "https://svc.internal/recipes/42?access_token=secret#top", nil)
+	req.URL.User = url.UserPassword("admin", "hunter2")
+
+	assert.Equal(t, "https://svc.internal/recipes/42", RedactedURL(&http.Response{Request: req}))
+	assert.Empty(t, RedactedURL(&http.Response{}))
+}
+
+func TestLogSampling(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	var logs bytes.Buffer
+	r := gin.New()
+	r.Use(Middleware(WithLogSampling(2), WithLogger(slog.New(slog.NewTextHandler(&logs, nil))), func(cfg *middlewareConfig) {
+		cfg.sampler.now = func() time.Time { return now }
+	}))
+	r.GET("/recipes", func(c *gin.Context) {
+		c.Error(NewServiceUnavailableError("database down"))
+	})
+
+	serve := func(n int) {
+		for i := 0; i < n; i++ {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/recipes", nil)
+			r.ServeHTTP(w, req)
+			assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		}
+	}
+
+	serve(5)
+	assert.Equal(t, 2, strings.Count(logs.String(), "Request failed"))
+
+	now = now.Add(time.Minute)
+	logs.Reset()
+	serve(1)
+	assert.Contains(t, logs.String(), "suppressed=3")
+}
+
+func TestRequestIDs(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	traceParent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	for _, format := range []Format{FormatLegacy, FormatProblem} {
+		r := gin.New()
+		r.Use(correlation.Middleware(), Middleware(WithFormat(format)))
+		r.GET("/recipes/:id", func(c *gin.Context) {
+			c.Error(NewNotFoundError("recipe not found"))
+		})
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/recipes/1", nil)
+		req.Header.Set(correlation.RequestIDHeader, "req-123")
+		req.Header.Set(correlation.TraceParentHeader, traceParent)
+		r.ServeHTTP(w, req)
+
+		var body map[string]interface{}
+		if !assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body)) {
+			return
+		}
+		assert.Equal(t, "req-123", body["request_id"])
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", body["trace_id"])
+		assert.Equal(t, "req-123", w.Header().Get(correlation.RequestIDHeader))
+	}
+
+	// Without the correlation middleware the request headers are used.
+	r := gin.New()
+	r.Use(Middleware())
+	r.GET("/recipes/:id", func(c *gin.Context) {
+		c.Error(NewNotFoundError("recipe not found"))
+	})
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/recipes/1", nil)
+	req.Header.Set(correlation.RequestIDHeader, "req-456")
+	r.ServeHTTP(w, req)
+	assert.Contains(t, w.Body.String(), `"request_id":"req-456"`)
+	assert.Equal(t, "req-456", w.Header().Get(correlation.RequestIDHeader))
+
+	// Invalid client IDs are replaced with a generated one, the same in the response and its header.
+	for _, id := range []string{"<img src=x>", strings.Repeat("r", 200)} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/recipes/1", nil)
+		req.Header.Set(correlation.RequestIDHeader, id)
+		r.ServeHTTP(w, req)
+
+		var body map[string]interface{}
+		if !assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body)) {
+			return
+		}
+		generated, _ := body["request_id"].(string)
+		assert.NoError(t, uuid.Validate(generated))
+		assert.Equal(t, generated, w.Header().Get(correlation.RequestIDHeader))
+	}
+}
+
+func TestHTTPHandler(t *testing.T) {
+	var logs bytes.Buffer
+	h := NewHTTPHandler(WithFormat(FormatProblem), WithLogger(slog.New(slog.NewTextHandler(&logs, nil))))
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /recipes/{id}", h.Handle(func(w http.ResponseWriter, r *http.Request) error {
+		if r.PathValue("id") == "1" {
+			w.WriteHeader(http.StatusNoContent)
+			return nil
+		}
+		return NewNotFoundError("recipe not found")
+	}))
+	mux.Handle("GET /internal", h.Handle(func(w http.ResponseWriter, r *http.Request) error {
+		return fmt.Errorf("failed to query: %w", io.ErrUnexpectedEOF)
+	}))
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/recipes/1", nil))
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	w = httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/recipes/2", nil)
+	req.Header.Set(correlation.RequestIDHeader, "req-123")
+	mux.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, ProblemContentType, w.Header().Get("Content-Type"))
+	var problem Problem
+	if !assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem)) {
+		return
+	}
+	assert.Equal(t, CodeResourceNotFound, problem.Code)
+	assert.Equal(t, "recipe not found", problem.Detail)
+	assert.Equal(t, "/recipes/2", problem.Instance)
+	assert.Equal(t, "req-123", problem.RequestID)
+	assert.Contains(t, logs.String(), `route="GET /recipes/{id}"`)
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/internal", nil))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.NotContains(t, w.Body.String(), "unexpected EOF")
+
+	// WriteError serves http.Handler middleware and ignores nil errors.
+	w = httptest.NewRecorder()
+	h.WriteError(w, httptest.NewRequest("GET", "/", nil), nil)
+	assert.Equal(t, 0, w.Body.Len())
+	h.WriteError(w, httptest.NewRequest("GET", "/", nil), nil, NewUnauthorizedError("missing token"))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
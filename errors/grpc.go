@@ -0,0 +1,276 @@
+package errors
+
+import (
+	"context"
+	stderrors "errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/hkinc45/dev-kitchen-go-common/logging"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	grpccodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// GRPCCode is a gRPC status code. The values match google.golang.org/grpc/codes. Services usually do not
+// convert codes themselves but install the interceptors below, which translate APIErrors to and from gRPC
+// statuses:
+//
+//	server := grpc.NewServer(
+//		grpc.ChainUnaryInterceptor(errors.UnaryServerInterceptor()),
+//		grpc.ChainStreamInterceptor(errors.StreamServerInterceptor()),
+//	)
+type GRPCCode uint32
+
+// gRPC status codes, as defined by the gRPC specification.
+const (
+	GRPCOK                 GRPCCode = 0
+	GRPCCanceled           GRPCCode = 1
+	GRPCUnknown            GRPCCode = 2
+	GRPCInvalidArgument    GRPCCode = 3
+	GRPCDeadlineExceeded   GRPCCode = 4
+	GRPCNotFound           GRPCCode = 5
+	GRPCAlreadyExists      GRPCCode = 6
+	GRPCPermissionDenied   GRPCCode = 7
+	GRPCResourceExhausted  GRPCCode = 8
+	GRPCFailedPrecondition GRPCCode = 9
+	GRPCAborted            GRPCCode = 10
+	GRPCOutOfRange         GRPCCode = 11
+	GRPCUnimplemented      GRPCCode = 12
+	GRPCInternal           GRPCCode = 13
+	GRPCUnavailable        GRPCCode = 14
+	GRPCDataLoss           GRPCCode = 15
+	GRPCUnauthenticated    GRPCCode = 16
+)
+
+// httpToGRPC maps HTTP statuses to gRPC codes, following the mapping of google.rpc.Code.
+var httpToGRPC = map[int]GRPCCode{
+	http.StatusBadRequest:          GRPCInvalidArgument,
+	http.StatusUnauthorized:        GRPCUnauthenticated,
+	http.StatusForbidden:           GRPCPermissionDenied,
+	http.StatusNotFound:            GRPCNotFound,
+	http.StatusConflict:            GRPCAlreadyExists,
+	http.StatusPreconditionFailed:  GRPCFailedPrecondition,
+	http.StatusUnprocessableEntity: GRPCInvalidArgument,
+	http.StatusLocked:              GRPCFailedPrecondition,
+	http.StatusTooManyRequests:     GRPCResourceExhausted,
+	499:                            GRPCCanceled, // Client Closed Request
+	http.StatusInternalServerError: GRPCInternal,
+	http.StatusNotImplemented:      GRPCUnimplemented,
+	http.StatusBadGateway:          GRPCUnavailable,
+	http.StatusServiceUnavailable:  GRPCUnavailable,
+	http.StatusGatewayTimeout:      GRPCDeadlineExceeded,
+}
+
+// grpcToHTTP maps gRPC codes back to HTTP statuses.
+var grpcToHTTP = map[GRPCCode]int{
+	GRPCOK:                 http.StatusOK,
+	GRPCCanceled:           499,
+	GRPCUnknown:            http.StatusInternalServerError,
+	GRPCInvalidArgument:    http.StatusBadRequest,
+	GRPCDeadlineExceeded:   http.StatusGatewayTimeout,
+	GRPCNotFound:           http.StatusNotFound,
+	GRPCAlreadyExists:      http.StatusConflict,
+	GRPCPermissionDenied:   http.StatusForbidden,
+	GRPCResourceExhausted:  http.StatusTooManyRequests,
+	GRPCFailedPrecondition: http.StatusPreconditionFailed,
+	GRPCAborted:            http.StatusConflict,
+	GRPCOutOfRange:         http.StatusBadRequest,
+	GRPCUnimplemented:      http.StatusNotImplemented,
+	GRPCInternal:           http.StatusInternalServerError,
+	GRPCUnavailable:        http.StatusServiceUnavailable,
+	GRPCDataLoss:           http.StatusInternalServerError,
+	GRPCUnauthenticated:    http.StatusUnauthorized,
+}
+
+// GRPCCodeOf returns the gRPC code for err: OK for nil, the code matching the status of the first APIError in
+// its chain, or Unknown.
+func GRPCCodeOf(err error) GRPCCode {
+	if err == nil {
+		return GRPCOK
+	}
+	apiErr, ok := AsAPIError(err)
+	if !ok {
+		return GRPCUnknown
+	}
+	if code, ok := httpToGRPC[apiErr.StatusCode]; ok {
+		return code
+	}
+	if apiErr.StatusCode >= http.StatusInternalServerError {
+		return GRPCInternal
+	}
+	return GRPCUnknown
+}
+
+// PublicMessage returns the message of err that is safe to send to clients: the message of the first APIError
+// in its chain, or the generic internal error message.
+func PublicMessage(err error) string {
+	if apiErr, ok := AsAPIError(err); ok {
+		return apiErr.Message
+	}
+	return NewInternalServerError("").Message
+}
+
+// FromGRPC converts a gRPC status received from another service into an APIError, so callers match it with
+// the same kinds as HTTP errors. code is the error code sent as a detail, if any.
+func FromGRPC(grpcCode GRPCCode, message string, code ErrorCode) *APIError {
+	status, ok := grpcToHTTP[grpcCode]
+	if !ok {
+		status = http.StatusInternalServerError
+	}
+	apiErr := NewAPIError(status, message)
+	if code != "" {
+		apiErr.Code = code
+	}
+	return apiErr
+}
+
+// ErrorInfoDomain is the domain of the google.rpc.ErrorInfo detail that carries the error code of an APIError
+// in a gRPC status.
+const ErrorInfoDomain = "dev-kitchen"
+
+// ToStatus converts err into the gRPC status sent to clients: the code of GRPCCodeOf and the PublicMessage,
+// with the error code of its APIError as an ErrorInfo detail and its RetryAfter as a RetryInfo detail.
+// Context errors become Canceled and DeadlineExceeded, and errors that already are gRPC statuses without an
+// APIError are kept.
+func ToStatus(err error) *status.Status {
+	if err == nil {
+		return status.New(grpccodes.OK, "")
+	}
+	apiErr, ok := AsAPIError(err)
+	if !ok {
+		if st, ok := status.FromError(err); ok {
+			return st
+		}
+		if stderrors.Is(err, context.Canceled) || stderrors.Is(err, context.DeadlineExceeded) {
+			return status.FromContextError(err)
+		}
+		return status.New(grpccodes.Code(GRPCCodeOf(err)), PublicMessage(err))
+	}
+
+	st := status.New(grpccodes.Code(GRPCCodeOf(err)), apiErr.Message)
+	var details []protoadapt.MessageV1
+	if apiErr.Code != "" {
+		details = append(details, &errdetails.ErrorInfo{Reason: string(apiErr.Code), Domain: ErrorInfoDomain})
+	}
+	if apiErr.RetryAfter > 0 {
+		details = append(details, &errdetails.RetryInfo{RetryDelay: durationpb.New(apiErr.RetryAfter)})
+	}
+	if len(details) == 0 {
+		return st
+	}
+	withDetails, err := st.WithDetails(details...)
+	if err != nil {
+		return st
+	}
+	return withDetails
+}
+
+// FromStatus converts a gRPC status received from another service into an APIError, restoring the error code
+// and RetryAfter sent by ToStatus. It returns nil for OK.
+func FromStatus(st *status.Status) *APIError {
+	if st.Code() == grpccodes.OK {
+		return nil
+	}
+	var code ErrorCode
+	var retryAfter time.Duration
+	for _, detail := range st.Details() {
+		switch detail := detail.(type) {
+		case *errdetails.ErrorInfo:
+			if detail.GetDomain() == ErrorInfoDomain {
+				code = ErrorCode(detail.GetReason())
+			}
+		case *errdetails.RetryInfo:
+			retryAfter = detail.GetRetryDelay().AsDuration()
+		}
+	}
+	apiErr := FromGRPC(GRPCCode(st.Code()), st.Message(), code)
+	apiErr.RetryAfter = retryAfter
+	apiErr.Err = st.Err()
+	return apiErr
+}
+
+// FromGRPCError converts an error returned by a gRPC call into an APIError wrapping it, so callers match
+// it with the same kinds as HTTP errors. Errors that are not gRPC statuses, such as io.EOF at the end of a
+// stream, are returned unchanged.
+func FromGRPCError(err error) error {
+	if err == nil || err == io.EOF {
+		return err
+	}
+	if _, ok := AsAPIError(err); ok {
+		return err
+	}
+	st, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+	return FromStatus(st)
+}
+
+// UnaryServerInterceptor translates the errors returned by unary handlers into gRPC statuses with ToStatus,
+// logging server errors with their cause, which is not sent to the client.
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		resp, err := handler(ctx, req)
+		if err != nil {
+			return resp, grpcServerError(ctx, info.FullMethod, err)
+		}
+		return resp, nil
+	}
+}
+
+// StreamServerInterceptor is UnaryServerInterceptor for streaming handlers.
+func StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := handler(srv, ss); err != nil {
+			return grpcServerError(ss.Context(), info.FullMethod, err)
+		}
+		return nil
+	}
+}
+
+// grpcServerError converts a handler error into a status error, logging it if it is a server error.
+func grpcServerError(ctx context.Context, method string, err error) error {
+	st := ToStatus(err)
+	switch st.Code() {
+	case grpccodes.Unknown, grpccodes.Internal, grpccodes.Unavailable, grpccodes.DataLoss, grpccodes.Unimplemented:
+		logging.FromContext(ctx).Error("gRPC request failed", "error", err, "method", method, "grpc_code", st.Code().String())
+	}
+	return st.Err()
+}
+
+// UnaryClientInterceptor translates the gRPC statuses returned by calls into APIErrors with FromGRPCError.
+func UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return FromGRPCError(invoker(ctx, method, req, reply, cc, opts...))
+	}
+}
+
+// StreamClientInterceptor is UnaryClientInterceptor for streams, translating the errors of opening the stream
+// and of sending and receiving messages.
+func StreamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		stream, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			return nil, FromGRPCError(err)
+		}
+		return &apiErrorClientStream{ClientStream: stream}, nil
+	}
+}
+
+// apiErrorClientStream translates the errors of a client stream into APIErrors.
+type apiErrorClientStream struct {
+	grpc.ClientStream
+}
+
+func (s *apiErrorClientStream) SendMsg(m interface{}) error {
+	return FromGRPCError(s.ClientStream.SendMsg(m))
+}
+
+func (s *apiErrorClientStream) RecvMsg(m interface{}) error {
+	return FromGRPCError(s.ClientStream.RecvMsg(m))
+}
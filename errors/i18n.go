@@ -0,0 +1,143 @@
+package errors
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+	"sync"
+
+	"golang.org/x/text/language"
+)
+
+// Bundle holds translated error messages, keyed by message key, for the error middleware to render per the
+// request's Accept-Language. Messages are templates whose "{name}" placeholders are replaced by the params of
+// the error. English is the fallback language.
+type Bundle struct {
+	mu       sync.RWMutex
+	messages map[language.Tag]map[string]string
+	// tags lists the languages of messages, English first, in the order matcher was built with.
+	tags    []language.Tag
+	matcher language.Matcher
+}
+
+// NewBundle creates an empty Bundle.
+func NewBundle() *Bundle {
+	b := &Bundle{
+		messages: map[language.Tag]map[string]string{language.English: {}},
+		tags:     []language.Tag{language.English},
+	}
+	b.matcher = language.NewMatcher(b.tags)
+	return b
+}
+
+// AddMessages adds messages for lang, replacing existing messages with the same keys.
+func (b *Bundle) AddMessages(lang language.Tag, messages map[string]string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.messages[lang] == nil {
+		b.messages[lang] = make(map[string]string, len(messages))
+		// English stays first so it is the fallback of the matcher.
+		b.tags = append(b.tags, lang)
+		b.matcher = language.NewMatcher(b.tags)
+	}
+	for key, message := range messages {
+		b.messages[lang][key] = message
+	}
+}
+
+// LoadFS loads every "<language>.json" file in dir of fsys, e.g. "locales/de.json", each a flat JSON object
+// of message keys to messages. It works with embed.FS and os.DirFS.
+func (b *Bundle) LoadFS(fsys fs.FS, dir string) error {
+	files, err := fs.Glob(fsys, path.Join(dir, "*.json"))
+	if err != nil {
+		return fmt.Errorf("failed to list message files in %s: %w", dir, err)
+	}
+	for _, file := range files {
+		lang, err := language.Parse(strings.TrimSuffix(path.Base(file), ".json"))
+		if err != nil {
+			return fmt.Errorf("failed to parse language of message file %s: %w", file, err)
+		}
+		data, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return fmt.Errorf("failed to read message file %s: %w", file, err)
+		}
+		var messages map[string]string
+		if err := json.Unmarshal(data, &messages); err != nil {
+			return fmt.Errorf("failed to decode message file %s: %w", file, err)
+		}
+		b.AddMessages(lang, messages)
+	}
+	return nil
+}
+
+// Localize renders the message for key in the language best matching acceptLanguage, falling back to English.
+// It reports false if neither has a message for key.
+func (b *Bundle) Localize(acceptLanguage, key string, params map[string]interface{}) (string, language.Tag, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	tags, _, _ := language.ParseAcceptLanguage(acceptLanguage)
+	_, index, _ := b.matcher.Match(tags...)
+	lang := b.tags[index]
+
+	message, ok := b.messages[lang][key]
+	if !ok {
+		lang = language.English
+		if message, ok = b.messages[lang][key]; !ok {
+			return "", lang, false
+		}
+	}
+	return expand(message, params), lang, true
+}
+
+// expand replaces the "{name}" placeholders of message with params.
+func expand(message string, params map[string]interface{}) string {
+	if len(params) == 0 {
+		return message
+	}
+	pairs := make([]string, 0, 2*len(params))
+	for name, value := range params {
+		pairs = append(pairs, "{"+name+"}", fmt.Sprint(value))
+	}
+	return strings.NewReplacer(pairs...).Replace(message)
+}
+
+// NewLocalizedError creates an APIError whose message is rendered from key and params per the request's
+// Accept-Language by a middleware configured WithBundle. Until then, and if the bundle lacks key, the message
+// is key itself.
+func NewLocalizedError(statusCode int, key string, params map[string]interface{}) *APIError {
+	apiErr := NewAPIError(statusCode, key)
+	apiErr.stack = callers()
+	apiErr.MessageKey, apiErr.MessageParams = key, params
+	return apiErr
+}
+
+// Localized returns a copy of the error rendered from key and params when the middleware has a bundle, keeping
+// its message as the fallback.
+func (e *APIError) Localized(key string, params map[string]interface{}) *APIError {
+	clone := *e
+	clone.MessageKey, clone.MessageParams = key, params
+	return &clone
+}
+
+// WithBundle renders the messages of localized errors from bundle.
+func WithBundle(bundle *Bundle) MiddlewareOption {
+	return func(cfg *middlewareConfig) { cfg.bundle = bundle }
+}
+
+// localize returns apiErr with its message rendered for the request, or apiErr itself if it is not localized.
+func (cfg *middlewareConfig) localize(req failedRequest, apiErr *APIError) *APIError {
+	if cfg.bundle == nil || apiErr.MessageKey == "" {
+		return apiErr
+	}
+	message, lang, ok := cfg.bundle.Localize(req.r.Header.Get("Accept-Language"), apiErr.MessageKey, apiErr.MessageParams)
+	if !ok {
+		return apiErr
+	}
+	req.w.Header().Set("Content-Language", lang.String())
+	clone := *apiErr
+	clone.Message = message
+	return &clone
+}
@@ -0,0 +1,91 @@
+package errors
+
+import (
+	stderrors "errors"
+	"net/http"
+)
+
+// Sentinel kinds of errors. Any APIError with the same status matches its kind under errors.Is, however deeply
+// it is wrapped, so callers need not compare status codes:
+//
+//	if errors.Is(err, commonerrors.ErrNotFound) { ... }
+//
+// The sentinels are shared; create errors to return with the New* functions instead of returning these.
+var (
+	ErrBadRequest         = newKind(http.StatusBadRequest, "bad request")
+	ErrUnauthorized       = newKind(http.StatusUnauthorized, "unauthorized")
+	ErrForbidden          = newKind(http.StatusForbidden, "forbidden")
+	ErrNotFound           = newKind(http.StatusNotFound, "resource not found")
+	ErrConflict           = newKind(http.StatusConflict, "resource already exists")
+	ErrUnprocessable      = newKind(http.StatusUnprocessableEntity, "unprocessable entity")
+	ErrRateLimited        = newKind(http.StatusTooManyRequests, "too many requests")
+	ErrInternal           = newKind(http.StatusInternalServerError, "An unexpected internal error occurred")
+	ErrBadGateway         = newKind(http.StatusBadGateway, "bad gateway")
+	ErrServiceUnavailable = newKind(http.StatusServiceUnavailable, "service unavailable")
+	ErrGatewayTimeout     = newKind(http.StatusGatewayTimeout, "gateway timeout")
+)
+
+func newKind(status int, message string) *APIError {
+	kind := NewAPIError(status, message)
+	kind.kind, kind.stack = true, nil
+	return kind
+}
+
+// Is reports whether target is the sentinel kind of the error, e.g. ErrNotFound for any 404 error.
+func (e *APIError) Is(target error) bool {
+	kind, ok := target.(*APIError)
+	return ok && kind.kind && kind.StatusCode == e.StatusCode
+}
+
+// As lets errors.As find a ValidationError as an *APIError too.
+func (e *ValidationError) As(target interface{}) bool {
+	if apiErr, ok := target.(**APIError); ok {
+		*apiErr = &e.APIError
+		return true
+	}
+	return false
+}
+
+// AsAPIError returns the first APIError in the chain of err, including that of a ValidationError.
+func AsAPIError(err error) (*APIError, bool) {
+	var apiErr *APIError
+	if stderrors.As(err, &apiErr) {
+		return apiErr, true
+	}
+	return nil, false
+}
+
+// StatusCode returns the HTTP status of the first APIError in the chain of err, or 500 if there is none.
+func StatusCode(err error) int {
+	if apiErr, ok := AsAPIError(err); ok {
+		return apiErr.StatusCode
+	}
+	return http.StatusInternalServerError
+}
+
+// IsBadRequest reports whether err is a 400 Bad Request error.
+func IsBadRequest(err error) bool { return stderrors.Is(err, ErrBadRequest) }
+
+// IsUnauthorized reports whether err is a 401 Unauthorized error.
+func IsUnauthorized(err error) bool { return stderrors.Is(err, ErrUnauthorized) }
+
+// IsForbidden reports whether err is a 403 Forbidden error.
+func IsForbidden(err error) bool { return stderrors.Is(err, ErrForbidden) }
+
+// IsNotFound reports whether err is a 404 Not Found error.
+func IsNotFound(err error) bool { return stderrors.Is(err, ErrNotFound) }
+
+// IsConflict reports whether err is a 409 Conflict error.
+func IsConflict(err error) bool { return stderrors.Is(err, ErrConflict) }
+
+// IsUnprocessable reports whether err is a 422 Unprocessable Entity error, including a ValidationError.
+func IsUnprocessable(err error) bool { return stderrors.Is(err, ErrUnprocessable) }
+
+// IsRateLimited reports whether err is a 429 Too Many Requests error.
+func IsRateLimited(err error) bool { return stderrors.Is(err, ErrRateLimited) }
+
+// IsUnavailable reports whether err is a 502, 503 or 504 error, i.e. a dependency is failing or down.
+func IsUnavailable(err error) bool {
+	return stderrors.Is(err, ErrBadGateway) || stderrors.Is(err, ErrServiceUnavailable) ||
+		stderrors.Is(err, ErrGatewayTimeout)
+}
@@ -0,0 +1,108 @@
+package errors
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/hkinc45/dev-kitchen-go-common/correlation"
+	"github.com/hkinc45/dev-kitchen-go-common/logging"
+)
+
+// UserContextKey is the gin context key under which the auth middleware stores the *models.User of the request.
+// The auth package sets the user under this key, so both sides stay in step.
+const UserContextKey = "user"
+
+// WithLogger logs error responses to logger instead of the request logger (see logging.FromContext).
+func WithLogger(logger *slog.Logger) MiddlewareOption {
+	return func(cfg *middlewareConfig) { cfg.logger = logger }
+}
+
+// logError emits one structured entry for an error response: the status, code, route, request and user IDs,
+// the full internal chain of err, the error reference sent to the client, the stack of apiErr, its first
+// APIError, if captured, and the other errors of the request. Server errors are logged at error level, client
+// errors with an internal cause at warn level and other client errors at info level. WithLogSampling, repeated
+// entries are counted instead.
+func (cfg *middlewareConfig) logError(req failedRequest, err error, apiErr *APIError, others []error, reference string) {
+	ctx := req.r.Context()
+	ids := req.ids
+	logger := cfg.logger
+	if logger == nil {
+		// The request logger already carries the correlation IDs of the context; only the IDs read from
+		// the headers or generated for the response are added below.
+		logger = logging.FromContext(ctx)
+		known := correlation.FromContext(ctx)
+		if known.RequestID == ids.RequestID {
+			ids.RequestID = ""
+		}
+		if known.TraceParent == ids.TraceParent {
+			ids.TraceParent = ""
+		}
+		if known.TenantID == ids.TenantID {
+			ids.TenantID = ""
+		}
+	}
+
+	status := StatusCode(err)
+	code := CodeInternal
+	if apiErr != nil {
+		code = apiErr.Code
+	}
+	suppressed := 0
+	if cfg.sampler != nil {
+		var ok bool
+		if ok, suppressed = cfg.sampler.allow(sampleKey{code: code, route: req.route}); !ok {
+			return
+		}
+	}
+
+	level := slog.LevelError
+	if apiErr != nil {
+		switch {
+		case status >= http.StatusInternalServerError:
+		case apiErr.Err != nil:
+			level = slog.LevelWarn
+		default:
+			level = slog.LevelInfo
+		}
+	}
+
+	attrs := []any{
+		"status", status,
+		"code", code,
+		"method", req.r.Method,
+		"route", req.route,
+		"path", req.r.URL.Path,
+		"error", err,
+	}
+	if ids.RequestID != "" {
+		attrs = append(attrs, "request_id", ids.RequestID)
+	}
+	if traceID := ids.TraceID(); traceID != "" {
+		attrs = append(attrs, "trace_id", traceID)
+	}
+	if ids.TenantID != "" {
+		attrs = append(attrs, "tenant_id", ids.TenantID)
+	}
+	if reference != "" {
+		attrs = append(attrs, "error_reference", reference)
+	}
+	if req.user != nil {
+		attrs = append(attrs, "user_id", req.user.ID.String())
+	}
+	if apiErr != nil {
+		if stack := apiErr.StackTrace(); stack != nil {
+			attrs = append(attrs, "stack", stack)
+		}
+	}
+	if suppressed > 0 {
+		attrs = append(attrs, "suppressed", suppressed)
+	}
+	if len(others) > 0 {
+		otherErrors := make([]string, len(others))
+		for i, other := range others {
+			otherErrors[i] = other.Error()
+		}
+		attrs = append(attrs, "other_errors", otherErrors)
+	}
+	logger.Log(ctx, level, "Request failed", attrs...)
+}
@@ -0,0 +1,39 @@
+package errors
+
+// Mapper converts an error of a service's own types, e.g. a domain error or an error of a third-party SDK,
+// into an APIError. It returns nil for errors it does not recognize.
+type Mapper func(err error) *APIError
+
+// WithMapper registers mapper with the middleware, so handlers can add internal errors as they are instead of
+// converting them in every handler:
+//
+//	errors.Middleware(errors.WithMapper(func(err error) *errors.APIError {
+//		if stderrors.Is(err, sql.ErrNoRows) {
+//			return errors.NewNotFoundError("resource not found")
+//		}
+//		return nil
+//	}))
+//
+// Mappers are consulted in the order they were registered, for errors that have no APIError in their chain.
+// The mapped error wraps the original, which is logged as its cause.
+func WithMapper(mapper Mapper) MiddlewareOption {
+	return func(cfg *middlewareConfig) { cfg.mappers = append(cfg.mappers, mapper) }
+}
+
+// mapError returns err converted by the first mapper that recognizes it, or err itself.
+func (cfg *middlewareConfig) mapError(err error) error {
+	if _, ok := AsAPIError(err); ok {
+		return err
+	}
+	for _, mapper := range cfg.mappers {
+		if apiErr := mapper(err); apiErr != nil {
+			if apiErr.Err == nil {
+				clone := *apiErr
+				clone.Err = err
+				apiErr = &clone
+			}
+			return apiErr
+		}
+	}
+	return err
+}
@@ -0,0 +1,50 @@
+package errors
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// ModeEnv is the environment variable selecting the mode: "development" or "production", the default.
+const ModeEnv = "ERRORS_MODE"
+
+// Mode selects how verbosely the error middleware renders errors.
+type Mode int32
+
+const (
+	// ModeProduction withholds internal causes from clients, sending an error reference that is also logged
+	// instead, so support can find the cause a user reports.
+	ModeProduction Mode = iota
+	// ModeDevelopment sends internal cause chains and stack traces to clients.
+	ModeDevelopment
+)
+
+var mode atomic.Int32
+
+func init() {
+	switch strings.ToLower(os.Getenv(ModeEnv)) {
+	case "development", "dev":
+		mode.Store(int32(ModeDevelopment))
+	}
+	enabled, err := strconv.ParseBool(os.Getenv(StackEnv))
+	if err != nil {
+		enabled = CurrentMode() == ModeDevelopment
+	}
+	captureStacks.Store(enabled)
+}
+
+// SetMode sets the mode of every error middleware not configured WithDevMode, overriding ERRORS_MODE. The
+// development mode also enables stack capture.
+func SetMode(m Mode) {
+	mode.Store(int32(m))
+	if m == ModeDevelopment {
+		SetStackCapture(true)
+	}
+}
+
+// CurrentMode returns the mode set by ERRORS_MODE or SetMode.
+func CurrentMode() Mode {
+	return Mode(mode.Load())
+}
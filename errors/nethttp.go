@@ -0,0 +1,56 @@
+package errors
+
+import (
+	"net"
+	"net/http"
+)
+
+// HandlerFunc is a net/http handler that returns its error instead of writing it.
+type HandlerFunc func(w http.ResponseWriter, r *http.Request) error
+
+// HTTPHandler renders errors of plain net/http handlers exactly like Middleware does for Gin, for services not
+// using Gin. It is safe for concurrent use.
+//
+//	h := errors.NewHTTPHandler(errors.WithFormat(errors.FormatProblem))
+//	mux.Handle("GET /recipes/{id}", h.Handle(getRecipe))
+type HTTPHandler struct {
+	cfg *middlewareConfig
+}
+
+// NewHTTPHandler creates an HTTPHandler configured with the options of Middleware.
+func NewHTTPHandler(opts ...MiddlewareOption) *HTTPHandler {
+	return &HTTPHandler{cfg: newMiddlewareConfig(opts)}
+}
+
+// Handle adapts fn to an http.Handler that renders the error fn returns, if any.
+func (h *HTTPHandler) Handle(fn HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := fn(w, r); err != nil {
+			h.WriteError(w, r, err)
+		}
+	})
+}
+
+// WriteError logs, reports and writes the response for the errors of r, for use in http.Handler middleware.
+// As with several errors added to a Gin context, the most severe one determines the response. WriteError does
+// nothing if errs holds no error.
+func (h *HTTPHandler) WriteError(w http.ResponseWriter, r *http.Request, errs ...error) {
+	var nonNil []error
+	for _, err := range errs {
+		if err != nil {
+			nonNil = append(nonNil, err)
+		}
+	}
+	if len(nonNil) == 0 {
+		return
+	}
+	h.cfg.render(failedRequest{w: w, r: r, route: r.Pattern, clientIP: remoteIP(r)}, nonNil)
+}
+
+// remoteIP returns the host of the remote address of r.
+func remoteIP(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
@@ -0,0 +1,148 @@
+package errors
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// ProblemContentType is the media type of RFC 7807 problem details.
+const ProblemContentType = "application/problem+json"
+
+// Problem is an RFC 7807 problem details object.
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	// Code is the machine-readable error code, as an extension member.
+	Code ErrorCode `json:"code,omitempty"`
+	// Details is the machine-usable context of the error, as an extension member.
+	Details Details `json:"details,omitempty"`
+	// Fields lists the violations of a ValidationError, as an extension member.
+	Fields []FieldViolation `json:"fields,omitempty"`
+	// RequestID and TraceID identify the request for support.
+	RequestID string `json:"request_id,omitempty"`
+	TraceID   string `json:"trace_id,omitempty"`
+	// Warnings are the messages of less severe errors of the request, sent WithWarnings only.
+	Warnings []string `json:"warnings,omitempty"`
+	// Reference identifies the log entry of a withheld internal cause, sent in production.
+	Reference string `json:"reference,omitempty"`
+	// Cause and Stack are the internal cause chain and stack trace of the error, sent in development only.
+	Cause string   `json:"cause,omitempty"`
+	Stack []string `json:"stack,omitempty"`
+}
+
+// addExtras sets the optional members of an error response.
+func (p *Problem) addExtras(extras responseExtras) {
+	p.RequestID, p.TraceID = extras.RequestID, extras.TraceID
+	p.Warnings, p.Reference, p.Cause, p.Stack = extras.Warnings, extras.Reference, extras.Cause, extras.Stack
+}
+
+// Problem renders the error as problem details for the request at instance.
+func (e *APIError) Problem(instance string) Problem {
+	return Problem{
+		Type:     "about:blank",
+		Title:    http.StatusText(e.StatusCode),
+		Status:   e.StatusCode,
+		Detail:   e.Message,
+		Instance: instance,
+		Code:     e.Code,
+		Details:  e.Details,
+	}
+}
+
+// Problem renders the error as problem details, including the field violations.
+func (e *ValidationError) Problem(instance string) Problem {
+	problem := e.APIError.Problem(instance)
+	problem.Fields = e.Fields
+	return problem
+}
+
+// APIError converts problem details received from another service back into an APIError.
+func (p Problem) APIError() *APIError {
+	message := p.Detail
+	if message == "" {
+		message = p.Title
+	}
+	apiErr := NewAPIError(p.Status, message)
+	if p.Code != "" {
+		apiErr.Code = p.Code
+	}
+	apiErr.Details = p.Details
+	return apiErr
+}
+
+// Format selects how the error middleware renders errors.
+type Format int
+
+const (
+	// FormatLegacy renders {"status_code": ..., "error": ...}.
+	FormatLegacy Format = iota
+	// FormatProblem renders RFC 7807 problem details as application/problem+json.
+	FormatProblem
+	// FormatNegotiate renders problem details to clients that accept application/problem+json, and the
+	// legacy format to everyone else, so existing consumers keep working while new ones opt in.
+	FormatNegotiate
+)
+
+// MiddlewareOption configures Middleware.
+type MiddlewareOption func(*middlewareConfig)
+
+type middlewareConfig struct {
+	format   Format
+	devMode  *bool
+	reporter Reporter
+	bundle   *Bundle
+	warnings bool
+	logger   *slog.Logger
+	mappers  []Mapper
+	sampler  *logSampler
+}
+
+func newMiddlewareConfig(opts []MiddlewareOption) *middlewareConfig {
+	cfg := &middlewareConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// WithFormat selects the response format. Defaults to FormatLegacy.
+func WithFormat(format Format) MiddlewareOption {
+	return func(cfg *middlewareConfig) { cfg.format = format }
+}
+
+// WithDevMode overrides the global mode (see SetMode) for the middleware: enabled includes the internal cause
+// chain and captured stack trace of errors in responses. Never enable it in production: causes and stacks
+// reveal queries, hosts and source paths.
+func WithDevMode(enabled bool) MiddlewareOption {
+	return func(cfg *middlewareConfig) { cfg.devMode = &enabled }
+}
+
+// development reports whether the middleware renders errors verbosely.
+func (cfg *middlewareConfig) development() bool {
+	if cfg.devMode != nil {
+		return *cfg.devMode
+	}
+	return CurrentMode() == ModeDevelopment
+}
+
+// WithWarnings lists the public messages of the other errors of a request, besides the most severe one that
+// determines the response, in a "warnings" array.
+func WithWarnings(enabled bool) MiddlewareOption {
+	return func(cfg *middlewareConfig) { cfg.warnings = enabled }
+}
+
+// wantsProblem reports whether the response to a request with the given Accept header is rendered as
+// problem details.
+func (cfg *middlewareConfig) wantsProblem(accept string) bool {
+	switch cfg.format {
+	case FormatProblem:
+		return true
+	case FormatNegotiate:
+		return strings.Contains(accept, ProblemContentType)
+	}
+	return false
+}
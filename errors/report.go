@@ -0,0 +1,69 @@
+package errors
+
+import (
+	"context"
+)
+
+// Report describes a server error handled by the error middleware.
+type Report struct {
+	// Err is the error added to the gin context, with its full internal chain.
+	Err    error
+	Status int
+	Method string
+	// Route is the matched route pattern, e.g. "/recipes/:id", which groups reports better than Path.
+	Route string
+	// Path is the request path. The query string is left out, as it may carry tokens or personal data.
+	Path      string
+	RequestID string
+	TenantID  string
+	ClientIP  string
+	UserAgent string
+	// Stack is where the error was created, if stack capture is enabled.
+	Stack []string
+	// Others are the less severe errors the handler added alongside Err.
+	Others []error
+	// Reference is the error reference sent to the client in place of a withheld cause, if any.
+	Reference string
+}
+
+// Reporter sends server errors to an error tracker such as Sentry or Rollbar. It is called synchronously for
+// every 5xx response, so implementations that do I/O should hand the report off to a background sender.
+type Reporter interface {
+	Report(ctx context.Context, report Report)
+}
+
+// ReporterFunc adapts a function to a Reporter.
+type ReporterFunc func(ctx context.Context, report Report)
+
+// Report calls f.
+func (f ReporterFunc) Report(ctx context.Context, report Report) {
+	f(ctx, report)
+}
+
+// WithReporter reports every 5xx error handled by the middleware to reporter.
+func WithReporter(reporter Reporter) MiddlewareOption {
+	return func(cfg *middlewareConfig) { cfg.reporter = reporter }
+}
+
+// report sends a server error to the configured reporter. apiErr is the first APIError in the chain of err,
+// if any, others the other errors of the request and reference the error reference sent to the client.
+func (cfg *middlewareConfig) report(req failedRequest, err error, apiErr *APIError, others []error, reference string) {
+	ctx := req.r.Context()
+	report := Report{
+		Err:       err,
+		Status:    StatusCode(err),
+		Method:    req.r.Method,
+		Route:     req.route,
+		Path:      req.r.URL.Path,
+		RequestID: req.ids.RequestID,
+		TenantID:  req.ids.TenantID,
+		ClientIP:  req.clientIP,
+		UserAgent: req.r.UserAgent(),
+		Others:    others,
+		Reference: reference,
+	}
+	if apiErr != nil {
+		report.Stack = apiErr.StackTrace()
+	}
+	cfg.reporter.Report(ctx, report)
+}
@@ -0,0 +1,137 @@
+package errors
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+)
+
+// Limits applied to error response bodies.
+const (
+	// maxErrorBodySize caps how much of an error response is buffered.
+	maxErrorBodySize = 64 * 1024
+	// maxErrorBodyExcerpt caps how much of an error response is included in messages and logs.
+	maxErrorBodyExcerpt = 512
+)
+
+// ResponseError describes a non-2xx response that could not be decoded into a structured APIError.
+// It is wrapped by the returned APIError; use errors.As to get at the raw body.
+type ResponseError struct {
+	StatusCode  int
+	ContentType string
+	// Body holds the response body, up to 64 KiB.
+	Body []byte
+}
+
+func (e *ResponseError) Error() string {
+	return fmt.Sprintf("status %d, content-type %q, body: %s", e.StatusCode, e.ContentType, e.truncatedBody())
+}
+
+// truncatedBody returns the start of the body, for messages and logs.
+func (e *ResponseError) truncatedBody() string {
+	if len(e.Body) <= maxErrorBodyExcerpt {
+		return string(e.Body)
+	}
+	return string(e.Body[:maxErrorBodyExcerpt]) + "...(truncated)"
+}
+
+// ResponseDecoder converts an error response in a service's own format, e.g. Gitea's {"message": "..."}, into
+// an APIError. It is consulted for error bodies that are neither problem details nor in the APIError format,
+// and returns nil for bodies it does not recognize, which are then reported as unknown errors.
+type ResponseDecoder func(respErr *ResponseError) *APIError
+
+// FromResponse builds an APIError from a non-2xx response, reading up to 64 KiB of its body. The body is
+// decoded as problem details or as an APIError; HTML pages from proxies and bodies in other formats produce
+// an error with the status and an excerpt of the body, wrapping a ResponseError with the raw body. It returns
+// nil for 2xx responses.
+func FromResponse(resp *http.Response) *APIError {
+	return FromResponseWith(resp, nil)
+}
+
+// FromResponseWith is FromResponse with decoder converting error responses in a service-specific format.
+func FromResponseWith(resp *http.Response, decoder ResponseDecoder) *APIError {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+
+	// Buffer the error body, up to a cap, so it can be logged and reported even if decoding fails.
+	bodyBytes, err := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+	if err != nil {
+		slog.Error("Error reading non-2xx response body", "err", err)
+		return NewAPIErrorWrap(resp.StatusCode, "failed to read error response body", err)
+	}
+	respErr := &ResponseError{
+		StatusCode:  resp.StatusCode,
+		ContentType: resp.Header.Get("Content-Type"),
+		Body:        bodyBytes,
+	}
+
+	switch mediaType(resp) {
+	case "text/html":
+		// Proxies and load balancers answer with HTML pages; logging or returning them verbatim is noise.
+		slog.Warn("Downstream service returned an HTML error page", "status", resp.StatusCode, "url", RedactedURL(resp))
+		return NewAPIErrorWrap(resp.StatusCode, fmt.Sprintf("upstream returned an HTML error page (status %d), likely from a proxy", resp.StatusCode), respErr)
+	case ProblemContentType:
+		slog.Warn("Downstream service returned non-2xx response", "status", resp.StatusCode, "body", respErr.truncatedBody())
+		var problem Problem
+		if err := json.Unmarshal(bodyBytes, &problem); err == nil && (problem.Detail != "" || problem.Title != "") {
+			problem.Status = resp.StatusCode
+			return problem.APIError()
+		}
+	default:
+		slog.Warn("Downstream service returned non-2xx response", "status", resp.StatusCode, "body", respErr.truncatedBody())
+	}
+
+	var apiErr APIError
+	if err := json.Unmarshal(bodyBytes, &apiErr); err == nil && apiErr.Message != "" {
+		apiErr.StatusCode = resp.StatusCode // Ensure status code is set
+		if apiErr.Code == "" {
+			apiErr.Code = codeForStatus(resp.StatusCode)
+		}
+		return &apiErr
+	}
+	if decoder != nil {
+		if decoded := decoder(respErr); decoded != nil {
+			if decoded.StatusCode == 0 {
+				decoded.StatusCode = resp.StatusCode
+			}
+			if decoded.Err == nil {
+				decoded.Err = respErr
+			}
+			return decoded
+		}
+	}
+	switch resp.StatusCode {
+	case http.StatusNotFound:
+		return NewAPIErrorWrap(http.StatusNotFound, "resource not found", respErr)
+	case http.StatusConflict:
+		return NewAPIErrorWrap(http.StatusConflict, "resource already exists", respErr)
+	}
+	// If we can't decode a structured error, report what we received instead.
+	return NewAPIErrorWrap(resp.StatusCode, fmt.Sprintf("unknown error (status %d, content-type %q): %s",
+		resp.StatusCode, respErr.ContentType, respErr.truncatedBody()), respErr)
+}
+
+// mediaType returns the media type of the response body.
+func mediaType(resp *http.Response) string {
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	return mediaType
+}
+
+// RedactedURL returns the URL of the request that produced resp for logging, without credentials, query and
+// fragment, which may carry tokens or personal data.
+func RedactedURL(resp *http.Response) string {
+	if resp.Request == nil || resp.Request.URL == nil {
+		return ""
+	}
+	u := *resp.Request.URL
+	u.User = nil
+	u.RawQuery = ""
+	u.ForceQuery = false
+	u.Fragment = ""
+	u.RawFragment = ""
+	return u.String()
+}
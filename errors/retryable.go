@@ -0,0 +1,62 @@
+package errors
+
+import (
+	stderrors "errors"
+	"net/http"
+)
+
+// RetryableError is implemented by errors that know whether retrying the failed operation may succeed. The HTTP
+// and NATS clients and the worker all decide retries with IsRetryable, so an error means the same everywhere.
+type RetryableError interface {
+	error
+	Retryable() bool
+}
+
+// RetryableStatus reports whether a response with status is usually transient: 408 Request Timeout, 429 Too
+// Many Requests, 502 Bad Gateway, 503 Service Unavailable and 504 Gateway Timeout.
+func RetryableStatus(status int) bool {
+	switch status {
+	case http.StatusRequestTimeout, http.StatusTooManyRequests, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// Retryable reports whether retrying may succeed: as set by WithRetryable, or else per RetryableStatus.
+func (e *APIError) Retryable() bool {
+	if e.retryable != nil {
+		return *e.retryable
+	}
+	return RetryableStatus(e.StatusCode)
+}
+
+// WithRetryable returns a copy of the error that reports retryable, e.g. for a 500 known to be transient.
+func (e *APIError) WithRetryable(retryable bool) *APIError {
+	clone := *e
+	clone.retryable = &retryable
+	return &clone
+}
+
+// Timeout reports whether the error is a 408 Request Timeout or 504 Gateway Timeout, so that code checking
+// for net.Error or an interface{ Timeout() bool } treats it like a network timeout.
+func (e *APIError) Timeout() bool {
+	return e.StatusCode == http.StatusRequestTimeout || e.StatusCode == http.StatusGatewayTimeout
+}
+
+// Temporary reports whether the error is a retryable 502 Bad Gateway, 503 Service Unavailable or 504 Gateway
+// Timeout, the network-like failures that standard library and third-party retry logic know as temporary.
+func (e *APIError) Temporary() bool {
+	switch e.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return e.Retryable()
+	}
+	return false
+}
+
+// IsRetryable reports whether the first RetryableError in the chain of err is retryable. Errors without one
+// are not.
+func IsRetryable(err error) bool {
+	var retryable RetryableError
+	return stderrors.As(err, &retryable) && retryable.Retryable()
+}
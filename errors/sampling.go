@@ -0,0 +1,79 @@
+package errors
+
+import (
+	"sync"
+	"time"
+)
+
+// maxSampledKeys bounds the code and route pairs tracked by a logSampler before stale ones are pruned.
+const maxSampledKeys = 1024
+
+// WithLogSampling logs at most perMinute error responses per minute for each error code and route. Further
+// responses in the minute are counted instead, and the count is logged as "suppressed" with the first entry of
+// the next minute, so an outage does not flood the logs with identical lines. Reporters still see every error.
+func WithLogSampling(perMinute int) MiddlewareOption {
+	return func(cfg *middlewareConfig) {
+		if perMinute > 0 {
+			cfg.sampler = &logSampler{limit: perMinute, window: time.Minute, now: time.Now, keys: make(map[sampleKey]*sampleWindow)}
+		}
+	}
+}
+
+type sampleKey struct {
+	code  ErrorCode
+	route string
+}
+
+type sampleWindow struct {
+	start      time.Time
+	logged     int
+	suppressed int
+}
+
+// logSampler limits log entries per key and window.
+type logSampler struct {
+	limit  int
+	window time.Duration
+	now    func() time.Time
+
+	mu   sync.Mutex
+	keys map[sampleKey]*sampleWindow
+}
+
+// allow reports whether an entry for key may be logged and, if so, how many entries for key were suppressed
+// since the last one.
+func (s *logSampler) allow(key sampleKey) (bool, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	w, ok := s.keys[key]
+	if !ok {
+		if len(s.keys) >= maxSampledKeys {
+			s.prune(now)
+		}
+		w = &sampleWindow{start: now}
+		s.keys[key] = w
+	}
+	suppressed := 0
+	if now.Sub(w.start) >= s.window {
+		suppressed = w.suppressed
+		*w = sampleWindow{start: now}
+	}
+	if w.logged >= s.limit {
+		w.suppressed++
+		return false, 0
+	}
+	w.logged++
+	return true, suppressed
+}
+
+// prune drops keys whose window has passed. Their suppressed counts are lost, which only happens when more
+// than maxSampledKeys pairs fail at once.
+func (s *logSampler) prune(now time.Time) {
+	for key, w := range s.keys {
+		if now.Sub(w.start) >= s.window {
+			delete(s.keys, key)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package errors
+
+import (
+	"fmt"
+	"runtime"
+	"sync/atomic"
+)
+
+// StackEnv is the environment variable that enables stack capture when set to a true value, e.g. "1", or
+// disables it when set to a false one. Capture defaults to on in development mode only.
+const StackEnv = "ERRORS_CAPTURE_STACK"
+
+// maxStackDepth caps the frames captured per error.
+const maxStackDepth = 32
+
+// captureStacks is set from StackEnv, defaulting to on in development mode, see init in mode.go.
+var captureStacks atomic.Bool
+
+// SetStackCapture enables or disables capturing the stack of the caller when an APIError is created, overriding
+// ERRORS_CAPTURE_STACK. Capture costs a few microseconds per error, so it is off by default.
+func SetStackCapture(enabled bool) {
+	captureStacks.Store(enabled)
+}
+
+// callers captures the stack above the function calling callers, or returns nil if capture is disabled.
+func callers() []uintptr {
+	if !captureStacks.Load() {
+		return nil
+	}
+	pcs := make([]uintptr, maxStackDepth)
+	// Skip runtime.Callers, callers and the constructor calling it.
+	n := runtime.Callers(3, pcs)
+	return pcs[:n]
+}
+
+// StackTrace returns the stack captured when the error was created, one "function (file:line)" per frame, or
+// nil if stack capture was disabled.
+func (e *APIError) StackTrace() []string {
+	if len(e.stack) == 0 {
+		return nil
+	}
+	var trace []string
+	frames := runtime.CallersFrames(e.stack)
+	for {
+		frame, more := frames.Next()
+		trace = append(trace, fmt.Sprintf("%s (%s:%d)", frame.Function, frame.File, frame.Line))
+		if !more {
+			return trace
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package errors
+
+import (
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// FieldViolation describes why a single field of a request failed validation.
+type FieldViolation struct {
+	// Field is the path of the field, e.g. "ingredients[0].name".
+	Field string `json:"field"`
+	// Rule is the validation rule that failed, e.g. "required" or "max".
+	Rule string `json:"rule"`
+	// Param is the parameter of the rule, e.g. "64" for max=64.
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+// ValidationError is a 422 Unprocessable Entity error listing every field that failed validation.
+type ValidationError struct {
+	APIError
+	Fields []FieldViolation `json:"fields"`
+}
+
+// NewValidationError creates a ValidationError for the given violations.
+func NewValidationError(fields ...FieldViolation) *ValidationError {
+	return &ValidationError{
+		APIError: APIError{
+			StatusCode: http.StatusUnprocessableEntity,
+			Code:       CodeValidationFailed,
+			Message:    "request validation failed",
+		},
+		Fields: fields,
+	}
+}
+
+// NewBindingError converts the error returned by gin's ShouldBind* methods into an API error: validator errors
+// and JSON values of the wrong type become a ValidationError, anything else, like malformed JSON, a 400
+// Bad Request error.
+//
+//	if err := c.ShouldBindJSON(&req); err != nil {
+//		c.Error(errors.NewBindingError(err))
+//		return
+//	}
+//
+// Field paths use the Go field names unless a tag name function is registered on the validator.
+func NewBindingError(err error) error {
+	var validationErrs validator.ValidationErrors
+	if stderrors.As(err, &validationErrs) {
+		fields := make([]FieldViolation, 0, len(validationErrs))
+		for _, fe := range validationErrs {
+			fields = append(fields, FieldViolation{
+				Field:   fieldPath(fe.Namespace()),
+				Rule:    fe.Tag(),
+				Param:   fe.Param(),
+				Message: violationMessage(fe),
+			})
+		}
+		return NewValidationError(fields...)
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if stderrors.As(err, &typeErr) && typeErr.Field != "" {
+		return NewValidationError(FieldViolation{
+			Field:   typeErr.Field,
+			Rule:    "type",
+			Param:   typeErr.Type.String(),
+			Message: fmt.Sprintf("must be of type %s", typeErr.Type),
+		})
+	}
+
+	return NewAPIErrorWrap(http.StatusBadRequest, "malformed request body", err)
+}
+
+// fieldPath strips the name of the top-level struct from a validator namespace.
+func fieldPath(namespace string) string {
+	if _, path, ok := strings.Cut(namespace, "."); ok {
+		return path
+	}
+	return namespace
+}
+
+// violationMessage describes a failed rule in words.
+func violationMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required", "required_if", "required_unless", "required_with", "required_without":
+		return "is required"
+	case "min", "gte":
+		return fmt.Sprintf("must be at least %s", fe.Param())
+	case "max", "lte":
+		return fmt.Sprintf("must be at most %s", fe.Param())
+	case "gt":
+		return fmt.Sprintf("must be greater than %s", fe.Param())
+	case "lt":
+		return fmt.Sprintf("must be less than %s", fe.Param())
+	case "len":
+		return fmt.Sprintf("must have length %s", fe.Param())
+	case "oneof":
+		return fmt.Sprintf("must be one of: %s", fe.Param())
+	case "enum":
+		return "must be a known value"
+	case "email", "url", "uuid", "uuid4":
+		return fmt.Sprintf("must be a valid %s", fe.Tag())
+	}
+	if fe.Param() != "" {
+		return fmt.Sprintf("failed the %s=%s rule", fe.Tag(), fe.Param())
+	}
+	return fmt.Sprintf("failed the %s rule", fe.Tag())
+}
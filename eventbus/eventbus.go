@@ -0,0 +1,188 @@
+// Package eventbus is a typed facade over the worker package that hides stream, subject and durable
+// naming behind the central naming scheme shared by all dev-kitchen services.
+//
+// An event named "recipe.created" is published on the subject "dev-kitchen.events.recipe.created",
+// stored in the stream "DEV_KITCHEN_RECIPE" (one stream per domain, the first name token), and consumed
+// by the durable "<service>-recipe-created".
+package eventbus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/hkinc45/dev-kitchen-go-common/worker"
+	"github.com/nats-io/nats.go"
+)
+
+// SubjectPrefix is the root of every event subject.
+const SubjectPrefix = "dev-kitchen.events"
+
+// Event is implemented by event payloads. EventName returns the dotted event name, e.g. "recipe.created".
+type Event interface {
+	EventName() string
+}
+
+// IdentifiedEvent is implemented by events that carry a unique ID. Publish uses it as the Nats-Msg-Id, so
+// JetStream drops retried publishes of the same event within the stream's duplicate window, while distinct
+// events with identical payloads are both delivered.
+type IdentifiedEvent interface {
+	Event
+	EventID() string
+}
+
+// Subject returns the NATS subject for an event name.
+func Subject(name string) string {
+	return SubjectPrefix + "." + name
+}
+
+// StreamName returns the stream that stores events of the name's domain.
+func StreamName(name string) string {
+	domain, _, _ := strings.Cut(name, ".")
+	domain = strings.NewReplacer("-", "_", "*", "", ">", "").Replace(domain)
+	return "DEV_KITCHEN_" + strings.ToUpper(domain)
+}
+
+// DurableName returns the durable consumer name used by service for an event name.
+func DurableName(service, name string) string {
+	r := strings.NewReplacer(".", "-", "*", "any", ">", "all")
+	return service + "-" + r.Replace(name)
+}
+
+// Config holds the configuration for a Manager.
+type Config struct {
+	// Service is the name of the consuming service, used to build durable consumer names.
+	Service   string
+	JetStream nats.JetStreamContext
+	// Inline dispatches events in-process instead of through NATS. See worker.PublisherConfig.Inline.
+	Inline bool
+	// MsgID, if set, derives the Nats-Msg-Id of events that are not IdentifiedEvents. See
+	// worker.PublisherConfig.MsgID. worker.ContentMsgID only suits events whose payloads are unique, since
+	// identical events published within the duplicate window are dropped.
+	MsgID func(subject string, data []byte) string
+}
+
+// Manager owns the publisher and subscribers of a service.
+type Manager struct {
+	config    Config
+	publisher *worker.Publisher
+	mu        sync.Mutex
+	subs      []*worker.PullSubscriber
+}
+
+// NewManager creates a new Manager.
+func NewManager(cfg Config) (*Manager, error) {
+	if cfg.Service == "" {
+		return nil, errors.New("eventbus requires a service name")
+	}
+	publisher, err := worker.NewPublisher(worker.PublisherConfig{
+		JetStream: cfg.JetStream,
+		Inline:    cfg.Inline,
+		MsgID:     cfg.MsgID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Manager{config: cfg, publisher: publisher}, nil
+}
+
+// SubscribeOptions tunes a subscription.
+type SubscribeOptions[T any] struct {
+	// LockingKey, if set, serializes processing of events that share a key (see worker.Handler).
+	LockingKey func(event T) string
+	// Worker overrides worker settings such as MaxConcurrent. Stream, subject, durable and handler are
+	// always set by the event bus.
+	Worker worker.Config
+}
+
+// Subscribe consumes events of the given name, decoding each into T before calling handler.
+func Subscribe[T any](m *Manager, name string, handler func(ctx context.Context, event T) error, opts ...SubscribeOptions[T]) error {
+	var opt SubscribeOptions[T]
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	h := &typedHandler[T]{handle: handler, lockingKey: opt.LockingKey}
+
+	if m.config.Inline {
+		m.publisher.Register(Subject(name), h)
+		return nil
+	}
+
+	stream := StreamName(name)
+	if _, err := worker.EnsureStream(m.config.JetStream, &nats.StreamConfig{
+		Name:     stream,
+		Subjects: []string{Subject(strings.SplitN(name, ".", 2)[0] + ".>")},
+	}); err != nil {
+		return err
+	}
+
+	cfg := opt.Worker
+	cfg.StreamName = stream
+	cfg.Subject = Subject(name)
+	cfg.DurableName = DurableName(m.config.Service, name)
+	cfg.Handler = h
+	cfg.JetStream = m.config.JetStream
+
+	sub, err := worker.NewPullSubscriber(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to event %s: %w", name, err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.subs = append(m.subs, sub)
+	return nil
+}
+
+// Publish publishes event on the subject derived from its name, with its ID as the Nats-Msg-Id if it is an
+// IdentifiedEvent.
+func Publish[T Event](ctx context.Context, m *Manager, event T) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event %s: %w", event.EventName(), err)
+	}
+	msg := nats.NewMsg(Subject(event.EventName()))
+	msg.Data = data
+	if identified, ok := any(event).(IdentifiedEvent); ok && identified.EventID() != "" {
+		msg.Header.Set(nats.MsgIdHdr, identified.EventID())
+	}
+	return m.publisher.PublishMsg(ctx, msg)
+}
+
+// Stop stops every subscriber started by the manager.
+func (m *Manager) Stop() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, sub := range m.subs {
+		sub.Stop()
+	}
+	m.subs = nil
+}
+
+// typedHandler adapts a typed event handler to worker.Handler.
+type typedHandler[T any] struct {
+	handle     func(ctx context.Context, event T) error
+	lockingKey func(event T) string
+}
+
+func (h *typedHandler[T]) Process(ctx context.Context, msg *nats.Msg) error {
+	var event T
+	if err := json.Unmarshal(msg.Data, &event); err != nil {
+		return fmt.Errorf("failed to decode event on subject %s: %w", msg.Subject, err)
+	}
+	return h.handle(ctx, event)
+}
+
+func (h *typedHandler[T]) GetLockingKey(msg *nats.Msg) (string, error) {
+	if h.lockingKey == nil {
+		return "", nil
+	}
+	var event T
+	if err := json.Unmarshal(msg.Data, &event); err != nil {
+		return "", fmt.Errorf("failed to decode event on subject %s: %w", msg.Subject, err)
+	}
+	return h.lockingKey(event), nil
+}
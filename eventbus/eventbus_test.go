@@ -0,0 +1,93 @@
+package eventbus
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/hkinc45/dev-kitchen-go-common/worker"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMain(m *testing.M) {
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	os.Exit(m.Run())
+}
+
+type recipeCreated struct {
+	ID string `json:"id"`
+}
+
+func (recipeCreated) EventName() string { return "recipe.created" }
+
+func TestNaming(t *testing.T) {
+	assert.Equal(t, "dev-kitchen.events.recipe.created", Subject("recipe.created"))
+	assert.Equal(t, "DEV_KITCHEN_RECIPE", StreamName("recipe.created"))
+	assert.Equal(t, "DEV_KITCHEN_VCS_CONNECTION", StreamName("vcs-connection.linked"))
+	assert.Equal(t, "recipe-service-recipe-created", DurableName("recipe-service", "recipe.created"))
+}
+
+func TestInlineRoundTrip(t *testing.T) {
+	m, err := NewManager(Config{Service: "test-service", Inline: true})
+	assert.NoError(t, err)
+
+	var got []recipeCreated
+	err = Subscribe(m, "recipe.created", func(ctx context.Context, event recipeCreated) error {
+		got = append(got, event)
+		return nil
+	})
+	assert.NoError(t, err)
+
+	assert.NoError(t, Publish(context.Background(), m, recipeCreated{ID: "r-1"}))
+	assert.Equal(t, []recipeCreated{{ID: "r-1"}}, got)
+}
+
+type recipeUpdated struct {
+	EventUUID string `json:"event_id"`
+	ID        string `json:"id"`
+}
+
+func (recipeUpdated) EventName() string { return "recipe.updated" }
+
+func (e recipeUpdated) EventID() string { return e.EventUUID }
+
+// fakeJetStream records published messages; other JetStreamContext methods are not implemented.
+type fakeJetStream struct {
+	nats.JetStreamContext
+	published []*nats.Msg
+}
+
+func (js *fakeJetStream) PublishMsg(msg *nats.Msg, _ ...nats.PubOpt) (*nats.PubAck, error) {
+	js.published = append(js.published, msg)
+	return &nats.PubAck{Sequence: uint64(len(js.published))}, nil
+}
+
+func TestPublishMsgID(t *testing.T) {
+	publishedIDs := func(cfg Config, events ...Event) []string {
+		js := &fakeJetStream{}
+		cfg.Service, cfg.JetStream = "test-service", js
+		m, err := NewManager(cfg)
+		if !assert.NoError(t, err) {
+			return nil
+		}
+		for _, event := range events {
+			assert.NoError(t, Publish(context.Background(), m, event))
+		}
+		ids := make([]string, 0, len(js.published))
+		for _, msg := range js.published {
+			ids = append(ids, msg.Header.Get(nats.MsgIdHdr))
+		}
+		return ids
+	}
+
+	// Identical payloads are distinct events unless a MsgID is configured.
+	assert.Equal(t, []string{"", ""}, publishedIDs(Config{}, recipeCreated{ID: "r-1"}, recipeCreated{ID: "r-1"}))
+	assert.Equal(t, []string{"e-1", "e-2"}, publishedIDs(Config{}, recipeUpdated{EventUUID: "e-1", ID: "r-1"}, recipeUpdated{EventUUID: "e-2", ID: "r-1"}))
+
+	ids := publishedIDs(Config{MsgID: worker.ContentMsgID}, recipeCreated{ID: "r-1"}, recipeUpdated{EventUUID: "e-1"})
+	assert.Equal(t, worker.ContentMsgID(Subject("recipe.created"), []byte(`{"id":"r-1"}`)), ids[0])
+	assert.Equal(t, "e-1", ids[1], "event IDs take precedence over MsgID")
+}
@@ -0,0 +1,166 @@
+// Package httptransport provides the tuned, shared HTTP transport used by the auth and client packages,
+// so outbound calls reuse a single connection pool instead of each creating their own.
+package httptransport
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Config tunes a transport. Zero values use the defaults noted on each field.
+type Config struct {
+	// MaxIdleConns caps idle connections across all hosts. Defaults to 100.
+	MaxIdleConns int
+	// MaxIdleConnsPerHost caps idle connections per host; net/http's default of 2 forces constant
+	// reconnects between busy services. Defaults to 20.
+	MaxIdleConnsPerHost int
+	// IdleConnTimeout closes connections idle for longer. Defaults to 90 seconds.
+	IdleConnTimeout time.Duration
+	// TLSConfig is cloned into the transport. Defaults to TLS 1.2 or newer.
+	TLSConfig *tls.Config
+	// DisableHTTP2 keeps connections on HTTP/1.1.
+	DisableHTTP2 bool
+	// Proxy selects the proxy for a request. Defaults to the HTTP_PROXY, HTTPS_PROXY and NO_PROXY
+	// environment variables.
+	Proxy func(*http.Request) (*url.URL, error)
+}
+
+// Stats describes the connection pool usage of a transport.
+type Stats struct {
+	// OpenConns is the number of connections currently open.
+	OpenConns int64
+	// Dials is the number of connections opened since the transport was created.
+	Dials int64
+	// ReusedConns counts requests served on a pooled connection.
+	ReusedConns int64
+	// NewConns counts requests that had to wait for a new connection.
+	NewConns int64
+}
+
+// Transport is an http.RoundTripper over a tuned *http.Transport that records pool usage.
+type Transport struct {
+	*http.Transport
+
+	openConns   atomic.Int64
+	dials       atomic.Int64
+	reusedConns atomic.Int64
+	newConns    atomic.Int64
+}
+
+// New creates a Transport tuned with cfg.
+func New(cfg Config) *Transport {
+	if cfg.MaxIdleConns <= 0 {
+		cfg.MaxIdleConns = 100
+	}
+	if cfg.MaxIdleConnsPerHost <= 0 {
+		cfg.MaxIdleConnsPerHost = 20
+	}
+	if cfg.IdleConnTimeout <= 0 {
+		cfg.IdleConnTimeout = 90 * time.Second
+	}
+	if cfg.Proxy == nil {
+		cfg.Proxy = http.ProxyFromEnvironment
+	}
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if cfg.TLSConfig != nil {
+		tlsConfig = cfg.TLSConfig.Clone()
+	}
+
+	t := &Transport{}
+	dialer := &net.Dialer{
+		Timeout:   5 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+	t.Transport = &http.Transport{
+		Proxy: cfg.Proxy,
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			conn, err := dialer.DialContext(ctx, network, addr)
+			if err != nil {
+				return nil, err
+			}
+			t.dials.Add(1)
+			t.openConns.Add(1)
+			return &trackedConn{Conn: conn, open: &t.openConns}, nil
+		},
+		TLSClientConfig:       tlsConfig,
+		ForceAttemptHTTP2:     !cfg.DisableHTTP2,
+		MaxIdleConns:          cfg.MaxIdleConns,
+		MaxIdleConnsPerHost:   cfg.MaxIdleConnsPerHost,
+		IdleConnTimeout:       cfg.IdleConnTimeout,
+		TLSHandshakeTimeout:   5 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+	if cfg.DisableHTTP2 {
+		// A non-nil, empty TLSNextProto map disables HTTP/2 negotiation.
+		t.Transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+	return t
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	trace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			if info.Reused {
+				t.reusedConns.Add(1)
+			} else {
+				t.newConns.Add(1)
+			}
+		},
+	}
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+	return t.Transport.RoundTrip(req)
+}
+
+// Stats returns the current pool usage.
+func (t *Transport) Stats() Stats {
+	return Stats{
+		OpenConns:   t.openConns.Load(),
+		Dials:       t.dials.Load(),
+		ReusedConns: t.reusedConns.Load(),
+		NewConns:    t.newConns.Load(),
+	}
+}
+
+var (
+	sharedOnce   sync.Once
+	shared       *Transport
+	sharedClient *http.Client
+)
+
+// Shared returns the process-wide transport with default settings.
+func Shared() *Transport {
+	sharedOnce.Do(func() {
+		shared = New(Config{})
+		sharedClient = &http.Client{Transport: shared}
+	})
+	return shared
+}
+
+// Client returns the process-wide *http.Client over the shared transport. It has no timeout of its own;
+// callers bound requests with their context.
+func Client() *http.Client {
+	Shared()
+	return sharedClient
+}
+
+// trackedConn decrements the open connection count when closed.
+type trackedConn struct {
+	net.Conn
+	open   *atomic.Int64
+	closed atomic.Bool
+}
+
+func (c *trackedConn) Close() error {
+	if c.closed.CompareAndSwap(false, true) {
+		c.open.Add(-1)
+	}
+	return c.Conn.Close()
+}
@@ -0,0 +1,36 @@
+package httptransport
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransportStats(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	transport := New(Config{})
+	client := &http.Client{Transport: transport}
+	for i := 0; i < 3; i++ {
+		resp, err := client.Get(srv.URL)
+		if assert.NoError(t, err) {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+	}
+
+	stats := transport.Stats()
+	assert.Equal(t, int64(1), stats.Dials, "sequential requests should share one connection")
+	assert.Equal(t, int64(1), stats.OpenConns)
+	assert.Equal(t, int64(1), stats.NewConns)
+	assert.Equal(t, int64(2), stats.ReusedConns)
+
+	transport.CloseIdleConnections()
+	assert.Equal(t, int64(0), transport.Stats().OpenConns)
+}
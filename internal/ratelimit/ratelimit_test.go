@@ -0,0 +1,34 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimiter(t *testing.T) {
+	now := time.Unix(0, 0)
+	l := New(2, 2)
+	l.now = func() time.Time { return now }
+	l.last = now
+
+	t.Run("Burst", func(t *testing.T) {
+		assert.True(t, l.Allow())
+		assert.True(t, l.Allow())
+		assert.False(t, l.Allow())
+	})
+
+	t.Run("Refill", func(t *testing.T) {
+		now = now.Add(500 * time.Millisecond)
+		assert.True(t, l.Allow())
+		assert.False(t, l.Allow())
+	})
+
+	t.Run("Wait Honors Context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		assert.ErrorIs(t, l.Wait(ctx), context.Canceled)
+	})
+}
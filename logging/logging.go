@@ -0,0 +1,119 @@
+// Package logging sets up slog with the fields every service logs and carries request-scoped loggers in
+// contexts, so that records of one request or message share its request, trace, tenant and user IDs.
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/hkinc45/dev-kitchen-go-common/correlation"
+)
+
+// Standard field keys.
+const (
+	KeyService   = "service"
+	KeyEnv       = "env"
+	KeyRequestID = "request_id"
+	KeyTraceID   = "trace_id"
+	KeyTenantID  = "tenant_id"
+	KeyUserID    = "user_id"
+)
+
+// Config configures the logger of a service.
+type Config struct {
+	// Service is the name of the service, e.g. "recipe-service".
+	Service string
+	// Env is the deployment environment, e.g. "production". Defaults to the ENV environment variable.
+	Env string
+	// Level is the minimum level logged. Defaults to info, or to the LOG_LEVEL environment variable
+	// ("debug", "info", "warn" or "error").
+	Level slog.Leveler
+	// Format is "json" or "text". Defaults to "json".
+	Format string
+	// Output defaults to os.Stderr.
+	Output io.Writer
+}
+
+// New creates a logger for cfg, with the service and env fields on every record.
+func New(cfg Config) *slog.Logger {
+	if cfg.Env == "" {
+		cfg.Env = os.Getenv("ENV")
+	}
+	if cfg.Level == nil {
+		var level slog.Level
+		if err := level.UnmarshalText([]byte(os.Getenv("LOG_LEVEL"))); err != nil {
+			level = slog.LevelInfo
+		}
+		cfg.Level = level
+	}
+	if cfg.Output == nil {
+		cfg.Output = os.Stderr
+	}
+
+	opts := &slog.HandlerOptions{Level: cfg.Level}
+	var handler slog.Handler
+	if strings.EqualFold(cfg.Format, "text") {
+		handler = slog.NewTextHandler(cfg.Output, opts)
+	} else {
+		handler = slog.NewJSONHandler(cfg.Output, opts)
+	}
+
+	logger := slog.New(handler)
+	if cfg.Service != "" {
+		logger = logger.With(KeyService, cfg.Service)
+	}
+	if cfg.Env != "" {
+		logger = logger.With(KeyEnv, cfg.Env)
+	}
+	return logger
+}
+
+// Setup creates a logger for cfg and makes it the slog default, so that the common packages log through it.
+// Call it first thing in main.
+func Setup(cfg Config) *slog.Logger {
+	logger := New(cfg)
+	slog.SetDefault(logger)
+	return logger
+}
+
+type contextKey struct{}
+
+// WithLogger returns a copy of ctx carrying logger.
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, logger)
+}
+
+// With returns a copy of ctx whose logger has the given attributes added, e.g. the user ID once known.
+func With(ctx context.Context, args ...any) context.Context {
+	return WithLogger(ctx, FromContext(ctx).With(args...))
+}
+
+// FromContext returns the logger of ctx, stored by Middleware or WithLogger. Without one, it returns the
+// default logger with the request, trace and tenant IDs of the correlation identifiers of ctx.
+func FromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(contextKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return withIDs(slog.Default(), correlation.FromContext(ctx))
+}
+
+// withIDs adds the standard fields of ids to logger.
+func withIDs(logger *slog.Logger, ids correlation.IDs) *slog.Logger {
+	var args []any
+	if ids.RequestID != "" {
+		args = append(args, KeyRequestID, ids.RequestID)
+	}
+	if traceID := ids.TraceID(); traceID != "" {
+		args = append(args, KeyTraceID, traceID)
+	}
+	if ids.TenantID != "" {
+		args = append(args, KeyTenantID, ids.TenantID)
+	}
+	if len(args) == 0 {
+		return logger
+	}
+	return logger.With(args...)
+}
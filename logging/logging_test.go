@@ -0,0 +1,116 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hkinc45/dev-kitchen-go-common/correlation"
+	"github.com/stretchr/testify/assert"
+)
+
+const traceParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+// lastRecord decodes the last JSON record written to buf.
+func lastRecord(t *testing.T, buf *bytes.Buffer) map[string]any {
+	t.Helper()
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	var record map[string]any
+	assert.NoError(t, json.Unmarshal(lines[len(lines)-1], &record))
+	return record
+}
+
+// useDefault makes a JSON logger writing to the returned buffer the slog default for the test.
+func useDefault(t *testing.T, cfg Config) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	cfg.Output = &buf
+	prev := slog.Default()
+	t.Cleanup(func() { slog.SetDefault(prev) })
+	Setup(cfg)
+	return &buf
+}
+
+func TestNew(t *testing.T) {
+	t.Run("adds service and env", func(t *testing.T) {
+		var buf bytes.Buffer
+		New(Config{Service: "recipe-service", Env: "staging", Output: &buf}).Info("hello")
+
+		record := lastRecord(t, &buf)
+		assert.Equal(t, "recipe-service", record[KeyService])
+		assert.Equal(t, "staging", record[KeyEnv])
+		assert.Equal(t, "hello", record["msg"])
+	})
+
+	t.Run("level from environment", func(t *testing.T) {
+		t.Setenv("LOG_LEVEL", "warn")
+		var buf bytes.Buffer
+		logger := New(Config{Output: &buf})
+		logger.Info("dropped")
+		assert.Empty(t, buf.String())
+		logger.Warn("kept")
+		assert.Contains(t, buf.String(), "kept")
+	})
+
+	t.Run("text format", func(t *testing.T) {
+		var buf bytes.Buffer
+		New(Config{Service: "svc", Format: "text", Output: &buf}).Info("hello")
+		assert.Contains(t, buf.String(), "service=svc")
+	})
+}
+
+func TestFromContext(t *testing.T) {
+	buf := useDefault(t, Config{Service: "svc"})
+
+	t.Run("correlation identifiers", func(t *testing.T) {
+		ctx := correlation.WithIDs(context.Background(), correlation.IDs{RequestID: "req-1", TraceParent: traceParent, TenantID: "tenant-1"})
+		FromContext(ctx).Info("hello")
+
+		record := lastRecord(t, buf)
+		assert.Equal(t, "svc", record[KeyService])
+		assert.Equal(t, "req-1", record[KeyRequestID])
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", record[KeyTraceID])
+		assert.Equal(t, "tenant-1", record[KeyTenantID])
+	})
+
+	t.Run("stored logger and added fields", func(t *testing.T) {
+		ctx := WithLogger(context.Background(), slog.Default().With(KeyRequestID, "req-2"))
+		ctx = With(ctx, KeyUserID, "user-1")
+		FromContext(ctx).Info("hello")
+
+		record := lastRecord(t, buf)
+		assert.Equal(t, "req-2", record[KeyRequestID])
+		assert.Equal(t, "user-1", record[KeyUserID])
+	})
+}
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	buf := useDefault(t, Config{Service: "svc"})
+
+	r := gin.New()
+	r.Use(correlation.Middleware(), Middleware())
+	r.GET("/", func(c *gin.Context) {
+		FromContext(With(c.Request.Context(), KeyUserID, "user-1")).Info("handled")
+		c.Status(http.StatusNoContent)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(correlation.RequestIDHeader, "req-1")
+	req.Header.Set(correlation.TraceParentHeader, traceParent)
+	req.Header.Set(correlation.TenantIDHeader, "tenant-1")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	record := lastRecord(t, buf)
+	assert.Equal(t, "handled", record["msg"])
+	assert.Equal(t, "svc", record[KeyService])
+	assert.Equal(t, "req-1", record[KeyRequestID])
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", record[KeyTraceID])
+	assert.Equal(t, "tenant-1", record[KeyTenantID])
+	assert.Equal(t, "user-1", record[KeyUserID])
+}
@@ -0,0 +1,23 @@
+package logging
+
+import (
+	"log/slog"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hkinc45/dev-kitchen-go-common/correlation"
+)
+
+// Middleware is a Gin middleware that stores a request-scoped logger in the request context, carrying the
+// request, trace and tenant IDs. Use it after correlation.Middleware, and get the logger in handlers with
+// FromContext(c.Request.Context()). The auth middleware adds the user ID once the user is known.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		if correlation.FromContext(ctx) == (correlation.IDs{}) {
+			ctx = correlation.Extract(ctx, c.GetHeader)
+		}
+		logger := withIDs(slog.Default(), correlation.FromContext(ctx))
+		c.Request = c.Request.WithContext(WithLogger(ctx, logger))
+		c.Next()
+	}
+}
@@ -0,0 +1,224 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+)
+
+// TokenClaims are the claims of the Keycloak access tokens and Requesting Party Tokens (RPTs) accepted by the
+// services. Decode them with ClaimsFromIDToken.
+type TokenClaims struct {
+	Subject         string   `json:"sub"`
+	Issuer          string   `json:"iss"`
+	Audience        Audience `json:"aud"`
+	AuthorizedParty string   `json:"azp,omitempty"`
+	ExpiresAt       int64    `json:"exp,omitempty"`
+	IssuedAt        int64    `json:"iat,omitempty"`
+	// Scope is the space-separated list of granted scopes, see Scopes.
+	Scope string `json:"scope,omitempty"`
+	// ACR is the authentication context class reference, e.g. "1" for password and "2" for 2FA logins.
+	ACR               string `json:"acr,omitempty"`
+	PreferredUsername string `json:"preferred_username,omitempty"`
+	Email             string `json:"email,omitempty"`
+	EmailVerified     bool   `json:"email_verified,omitempty"`
+
+	RealmAccess    RoleClaims            `json:"realm_access,omitzero"`
+	ResourceAccess map[string]RoleClaims `json:"resource_access,omitempty"`
+	// Authorization holds the permissions granted by an RPT; it is nil for plain access tokens.
+	Authorization *AuthorizationClaims `json:"authorization,omitempty"`
+
+	// GiteaOrgName is the Gitea organization of the user, mapped from their Keycloak attribute.
+	GiteaOrgName string `json:"gitea_org_name,omitempty"`
+	// Extra holds the claims without a field above, for service-specific mappers.
+	Extra map[string]interface{} `json:"-"`
+}
+
+// RoleClaims are the roles granted by the realm or a client.
+type RoleClaims struct {
+	Roles []string `json:"roles,omitempty"`
+}
+
+// AuthorizationClaims are the permissions of an RPT.
+type AuthorizationClaims struct {
+	Permissions []TokenPermission `json:"permissions"`
+}
+
+// TokenPermission grants scopes on a resource.
+type TokenPermission struct {
+	ResourceID   string   `json:"rsid"`
+	ResourceName string   `json:"rsname,omitempty"`
+	Scopes       []string `json:"scopes,omitempty"`
+}
+
+// Audience is the "aud" claim, which is a single string or an array of strings.
+type Audience []string
+
+// UnmarshalJSON decodes a string or an array of strings.
+func (a *Audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = Audience{single}
+		return nil
+	}
+	var multiple []string
+	if err := json.Unmarshal(data, &multiple); err != nil {
+		return fmt.Errorf("invalid audience: %w", err)
+	}
+	*a = multiple
+	return nil
+}
+
+// Contains reports whether clientID is one of the audiences.
+func (a Audience) Contains(clientID string) bool {
+	return slices.Contains(a, clientID)
+}
+
+// ClaimsFromIDToken decodes the claims of a verified token.
+func ClaimsFromIDToken(token *oidc.IDToken) (*TokenClaims, error) {
+	var claims TokenClaims
+	if err := token.Claims(&claims); err != nil {
+		return nil, fmt.Errorf("failed to decode token claims: %w", err)
+	}
+	return &claims, nil
+}
+
+// UnmarshalJSON decodes the claims, collecting those without a field in Extra. The profile claims (scope,
+// acr, preferred_username, email, email_verified and gitea_org_name) are decoded leniently, since they come
+// from mappers that may change shape: a multivalued attribute mapped as an array sets the field to its first
+// value, a boolean mapped as a string is parsed, and any other unexpected value leaves the field empty and is
+// kept in Extra instead of failing the token.
+func (c *TokenClaims) UnmarshalJSON(data []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	type tokenClaims TokenClaims
+	var decoded tokenClaims
+	lenient := map[string]func(json.RawMessage) bool{
+		"scope":              stringClaim(&decoded.Scope),
+		"acr":                stringClaim(&decoded.ACR),
+		"preferred_username": stringClaim(&decoded.PreferredUsername),
+		"email":              stringClaim(&decoded.Email),
+		"email_verified":     boolClaim(&decoded.EmailVerified),
+		"gitea_org_name":     stringClaim(&decoded.GiteaOrgName),
+	}
+	strict := make(map[string]json.RawMessage)
+	for name, value := range raw {
+		if decode, ok := lenient[name]; ok {
+			if decode(value) {
+				continue
+			}
+		} else if slices.Contains(knownClaims, name) {
+			strict[name] = value
+			continue
+		}
+		var v interface{}
+		if err := json.Unmarshal(value, &v); err != nil {
+			return err
+		}
+		if decoded.Extra == nil {
+			decoded.Extra = make(map[string]interface{})
+		}
+		decoded.Extra[name] = v
+	}
+
+	strictData, err := json.Marshal(strict)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(strictData, &decoded); err != nil {
+		return err
+	}
+	*c = TokenClaims(decoded)
+	return nil
+}
+
+// knownClaims are the JSON names of the fields of TokenClaims.
+var knownClaims = []string{
+	"sub", "iss", "aud", "azp", "exp", "iat", "scope", "acr", "preferred_username", "email", "email_verified",
+	"realm_access", "resource_access", "authorization", "gitea_org_name",
+}
+
+// stringClaim decodes a string, or the first value of an array of strings, into dst, reporting whether the
+// value had one of these shapes.
+func stringClaim(dst *string) func(json.RawMessage) bool {
+	return func(value json.RawMessage) bool {
+		if err := json.Unmarshal(value, dst); err == nil {
+			return true
+		}
+		var values []string
+		if err := json.Unmarshal(value, &values); err != nil {
+			return false
+		}
+		if len(values) > 0 {
+			*dst = values[0]
+		}
+		return true
+	}
+}
+
+// boolClaim decodes a boolean, or a string holding one, into dst, reporting whether the value had one of
+// these shapes.
+func boolClaim(dst *bool) func(json.RawMessage) bool {
+	return func(value json.RawMessage) bool {
+		if err := json.Unmarshal(value, dst); err == nil {
+			return true
+		}
+		var s string
+		if err := json.Unmarshal(value, &s); err != nil {
+			return false
+		}
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return false
+		}
+		*dst = b
+		return true
+	}
+}
+
+// Expiry returns the expiry time of the token.
+func (c *TokenClaims) Expiry() time.Time {
+	return time.Unix(c.ExpiresAt, 0)
+}
+
+// Scopes returns the granted scopes.
+func (c *TokenClaims) Scopes() []string {
+	return strings.Fields(c.Scope)
+}
+
+// HasScope reports whether scope was granted.
+func (c *TokenClaims) HasScope(scope string) bool {
+	return slices.Contains(c.Scopes(), scope)
+}
+
+// HasRealmRole reports whether the token grants the realm role.
+func (c *TokenClaims) HasRealmRole(role string) bool {
+	return slices.Contains(c.RealmAccess.Roles, role)
+}
+
+// HasClientRole reports whether the token grants the role of the client with the given ID.
+func (c *TokenClaims) HasClientRole(clientID, role string) bool {
+	return slices.Contains(c.ResourceAccess[clientID].Roles, role)
+}
+
+// HasPermission reports whether the RPT grants scope on the resource with the given ID or name.
+func (c *TokenClaims) HasPermission(resource, scope string) bool {
+	if c.Authorization == nil {
+		return false
+	}
+	for _, permission := range c.Authorization.Permissions {
+		if (permission.ResourceID == resource || permission.ResourceName == resource) &&
+			slices.Contains(permission.Scopes, scope) {
+			return true
+		}
+	}
+	return false
+}
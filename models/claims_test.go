@@ -0,0 +1,87 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenClaims(t *testing.T) {
+	data := `{
+		"sub": "f3c1",
+		"iss": "https://keycloak.example.com/realms/dev-kitchen",
+		"aud": "recipe-service",
+		"azp": "web",
+		"exp": 1767323045,
+		"scope": "openid profile email",
+		"acr": "2",
+		"preferred_username": "ada",
+		"realm_access": {"roles": ["internal-comm"]},
+		"resource_access": {"recipe-service": {"roles": ["editor"]}},
+		"authorization": {"permissions": [{"rsid": "r1", "rsname": "project:42", "scopes": ["project:read"]}]},
+		"gitea_org_name": "ada-org",
+		"tenant": "acme"
+	}`
+	var claims TokenClaims
+	if !assert.NoError(t, json.Unmarshal([]byte(data), &claims)) {
+		return
+	}
+	assert.Equal(t, "f3c1", claims.Subject)
+	assert.Equal(t, Audience{"recipe-service"}, claims.Audience)
+	assert.True(t, claims.Audience.Contains("recipe-service"))
+	assert.Equal(t, time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC), claims.Expiry().UTC())
+	assert.True(t, claims.HasScope("email"))
+	assert.False(t, claims.HasScope("offline_access"))
+	assert.True(t, claims.HasRealmRole("internal-comm"))
+	assert.True(t, claims.HasClientRole("recipe-service", "editor"))
+	assert.False(t, claims.HasClientRole("auth-service", "editor"))
+	assert.True(t, claims.HasPermission("project:42", "project:read"))
+	assert.False(t, claims.HasPermission("project:42", "project:write"))
+	assert.Equal(t, "ada-org", claims.GiteaOrgName)
+	assert.Equal(t, map[string]interface{}{"tenant": "acme"}, claims.Extra)
+
+	if assert.NoError(t, json.Unmarshal([]byte(`{"aud": ["a", "b"]}`), &claims)) {
+		assert.Equal(t, Audience{"a", "b"}, claims.Audience)
+		assert.Nil(t, claims.Extra)
+		assert.False(t, claims.HasPermission("project:42", "project:read"))
+	}
+	assert.Error(t, json.Unmarshal([]byte(`{"aud": 1}`), &claims))
+}
+
+func TestTokenClaimsLenientProfileClaims(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      string
+		want      TokenClaims
+		wantExtra map[string]interface{}
+	}{
+		{
+			name: "multivalued attribute",
+			data: `{"sub": "f3c1", "gitea_org_name": ["ada-org", "ada-old"]}`,
+			want: TokenClaims{Subject: "f3c1", GiteaOrgName: "ada-org"},
+		},
+		{
+			name: "boolean mapped as string",
+			data: `{"sub": "f3c1", "email_verified": "true"}`,
+			want: TokenClaims{Subject: "f3c1", EmailVerified: true},
+		},
+		{
+			name:      "unexpected shape kept in Extra",
+			data:      `{"sub": "f3c1", "acr": {"level": 2}, "email_verified": "maybe"}`,
+			want:      TokenClaims{Subject: "f3c1"},
+			wantExtra: map[string]interface{}{"acr": map[string]interface{}{"level": float64(2)}, "email_verified": "maybe"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var claims TokenClaims
+			if !assert.NoError(t, json.Unmarshal([]byte(tt.data), &claims)) {
+				return
+			}
+			tt.want.Extra = tt.wantExtra
+			assert.Equal(t, tt.want, claims)
+		})
+	}
+}
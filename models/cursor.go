@@ -0,0 +1,111 @@
+package models
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidCursor is returned for cursors that were tampered with, signed with another key, or made for
+// another list or sort. Respond to it with 400 Bad Request.
+var ErrInvalidCursor = stderrors.New("invalid cursor")
+
+// minCursorKeyLen is the shortest HMAC key NewCursorCodec accepts.
+const minCursorKeyLen = 32
+
+// Cursor is the position after the last item of a page: its sort key values, in the order of the sort, and
+// its ID as the tie-breaker. Repositories continue with e.g.
+//
+//	WHERE (created_at, id) < ($1, $2) ORDER BY created_at DESC, id DESC
+type Cursor struct {
+	// List is the list the cursor was made for, set by Encode from the codec (see CursorCodec.ForList).
+	List string `json:"l,omitempty"`
+	// Sort is the PageRequest.Sort the cursor was made for.
+	Sort string `json:"s,omitempty"`
+	// Keys are the sort key values of the last item, formatted by the service, e.g. times as RFC 3339.
+	Keys []string `json:"k,omitempty"`
+	ID   string   `json:"id"`
+}
+
+// CursorCodec encodes cursors into tokens signed with HMAC-SHA256, so that clients cannot forge positions.
+// The payload is base64-encoded JSON that clients can read, so the sort keys must not hold anything clients
+// may not see. All instances of a service must share the key.
+type CursorCodec struct {
+	key  []byte
+	list string
+}
+
+// NewCursorCodec creates a CursorCodec signing with key, which must be at least 32 bytes.
+func NewCursorCodec(key []byte) (*CursorCodec, error) {
+	if len(key) < minCursorKeyLen {
+		return nil, fmt.Errorf("cursor key must be at least %d bytes, got %d", minCursorKeyLen, len(key))
+	}
+	return &CursorCodec{key: key}, nil
+}
+
+// ForList returns a codec with the same key whose cursors are bound to list, e.g. "recipes" or
+// "projects/<id>/recipes": cursors encoded for one list are ErrInvalidCursor when decoded for another.
+func (c *CursorCodec) ForList(list string) *CursorCodec {
+	return &CursorCodec{key: c.key, list: list}
+}
+
+// Encode returns the token of cursor, for PageResponse.NextCursor.
+func (c *CursorCodec) Encode(cursor Cursor) (string, error) {
+	cursor.List = c.list
+	payload, err := json.Marshal(cursor)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode cursor: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(c.mac(payload)), nil
+}
+
+// Decode returns the cursor of token, or ErrInvalidCursor if its signature does not match or it was made for
+// another list.
+func (c *CursorCodec) Decode(token string) (Cursor, error) {
+	encodedPayload, encodedMAC, ok := strings.Cut(token, ".")
+	if !ok {
+		return Cursor{}, ErrInvalidCursor
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(encodedMAC)
+	if err != nil || !hmac.Equal(mac, c.mac(payload)) {
+		return Cursor{}, ErrInvalidCursor
+	}
+	var cursor Cursor
+	if err := json.Unmarshal(payload, &cursor); err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+	if cursor.List != c.list {
+		return Cursor{}, fmt.Errorf("%w: made for list %q, not %q", ErrInvalidCursor, cursor.List, c.list)
+	}
+	return cursor, nil
+}
+
+// DecodeRequest returns the cursor of req and true, or false if req has none. Cursors made for another sort
+// than that of req are ErrInvalidCursor.
+func (c *CursorCodec) DecodeRequest(req PageRequest) (Cursor, bool, error) {
+	if req.Cursor == "" {
+		return Cursor{}, false, nil
+	}
+	cursor, err := c.Decode(req.Cursor)
+	if err != nil {
+		return Cursor{}, false, err
+	}
+	if cursor.Sort != req.Sort {
+		return Cursor{}, false, fmt.Errorf("%w: made for sort %q, not %q", ErrInvalidCursor, cursor.Sort, req.Sort)
+	}
+	return cursor, true, nil
+}
+
+func (c *CursorCodec) mac(payload []byte) []byte {
+	mac := hmac.New(sha256.New, c.key)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
@@ -0,0 +1,80 @@
+package models
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCursorCodec(t *testing.T) {
+	_, err := NewCursorCodec([]byte("short"))
+	assert.Error(t, err)
+
+	codec, err := NewCursorCodec(bytes.Repeat([]byte("k"), 32))
+	if !assert.NoError(t, err) {
+		return
+	}
+	cursor := Cursor{Sort: "-created_at", Keys: []string{"2026-01-02T03:04:05Z"}, ID: "42"}
+	token, err := codec.Encode(cursor)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NotContains(t, token, "created_at")
+	assert.LessOrEqual(t, len(token), 512, "tokens must fit PageRequest.Cursor")
+
+	decoded, err := codec.Decode(token)
+	if assert.NoError(t, err) {
+		assert.Equal(t, cursor, decoded)
+	}
+
+	// Tampered tokens and tokens of other keys are rejected.
+	payload, mac, _ := strings.Cut(token, ".")
+	tampered := []byte(payload)
+	tampered[len(tampered)/2] ^= 1
+	for _, bad := range []string{string(tampered) + "." + mac, payload, payload + ".AAAA", "", "..."} {
+		_, err := codec.Decode(bad)
+		assert.ErrorIs(t, err, ErrInvalidCursor, bad)
+	}
+	other, _ := NewCursorCodec(bytes.Repeat([]byte("o"), 32))
+	_, err = other.Decode(token)
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+
+	decoded, ok, err := codec.DecodeRequest(PageRequest{Cursor: token, Sort: "-created_at"})
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, cursor, decoded)
+	_, ok, err = codec.DecodeRequest(PageRequest{})
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	_, _, err = codec.DecodeRequest(PageRequest{Cursor: token, Sort: "name"})
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+}
+
+func TestCursorCodecForList(t *testing.T) {
+	codec, err := NewCursorCodec(bytes.Repeat([]byte("k"), 32))
+	if !assert.NoError(t, err) {
+		return
+	}
+	recipes := codec.ForList("recipes")
+	projects := codec.ForList("projects")
+
+	token, err := recipes.Encode(Cursor{ID: "42"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	decoded, err := recipes.Decode(token)
+	if assert.NoError(t, err) {
+		assert.Equal(t, Cursor{List: "recipes", ID: "42"}, decoded)
+	}
+
+	// Cursors are bound to the list they were made for, even under the same key.
+	for _, other := range []*CursorCodec{projects, codec} {
+		_, err := other.Decode(token)
+		assert.ErrorIs(t, err, ErrInvalidCursor)
+	}
+	unbound, _ := codec.Encode(Cursor{ID: "42"})
+	_, err = recipes.Decode(unbound)
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+}
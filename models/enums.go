@@ -0,0 +1,111 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"slices"
+)
+
+// AccountType is the kind of account of a User.
+type AccountType string
+
+const (
+	AccountTypePersonal AccountType = "personal"
+	AccountTypeBusiness AccountType = "business"
+)
+
+// AccountTypes are the valid account types.
+var AccountTypes = []AccountType{AccountTypePersonal, AccountTypeBusiness}
+
+// IsValid reports whether t is one of AccountTypes.
+func (t AccountType) IsValid() bool {
+	return slices.Contains(AccountTypes, t)
+}
+
+// UnmarshalJSON decodes a JSON string, rejecting unknown account types.
+func (t *AccountType) UnmarshalJSON(data []byte) error {
+	return unmarshalEnum(data, t, "account type")
+}
+
+// Value stores the account type as text.
+func (t AccountType) Value() (driver.Value, error) {
+	return enumValue(t, "account type")
+}
+
+// Scan reads an account type from a text column. Unknown values are kept as they are, see IsValid.
+func (t *AccountType) Scan(src interface{}) error {
+	return scanEnum(src, t, "account type")
+}
+
+// KycStatus is the state of the know-your-customer verification of a User.
+type KycStatus string
+
+const (
+	KycStatusUnverified KycStatus = "unverified"
+	KycStatusPending    KycStatus = "pending"
+	KycStatusVerified   KycStatus = "verified"
+)
+
+// KycStatuses are the valid KYC statuses.
+var KycStatuses = []KycStatus{KycStatusUnverified, KycStatusPending, KycStatusVerified}
+
+// IsValid reports whether s is one of KycStatuses.
+func (s KycStatus) IsValid() bool {
+	return slices.Contains(KycStatuses, s)
+}
+
+// UnmarshalJSON decodes a JSON string, rejecting unknown KYC statuses.
+func (s *KycStatus) UnmarshalJSON(data []byte) error {
+	return unmarshalEnum(data, s, "KYC status")
+}
+
+// Value stores the KYC status as text.
+func (s KycStatus) Value() (driver.Value, error) {
+	return enumValue(s, "KYC status")
+}
+
+// Scan reads a KYC status from a text column. Unknown values are kept as they are, see IsValid.
+func (s *KycStatus) Scan(src interface{}) error {
+	return scanEnum(src, s, "KYC status")
+}
+
+// enum is a string type with a fixed set of values. JSON decoding and Value reject unknown values; Scan keeps
+// them, so rows written by a newer version can still be read.
+type enum interface {
+	~string
+	IsValid() bool
+}
+
+func unmarshalEnum[E enum](data []byte, e *E, name string) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("invalid %s: %w", name, err)
+	}
+	if !E(s).IsValid() {
+		return fmt.Errorf("invalid %s %q", name, s)
+	}
+	*e = E(s)
+	return nil
+}
+
+func enumValue[E enum](e E, name string) (driver.Value, error) {
+	if !e.IsValid() {
+		return nil, fmt.Errorf("invalid %s %q", name, string(e))
+	}
+	return string(e), nil
+}
+
+func scanEnum[E enum](src interface{}, e *E, name string) error {
+	var s string
+	switch src := src.(type) {
+	case string:
+		s = src
+	case []byte:
+		s = string(src)
+	default:
+		return fmt.Errorf("failed to scan %T into %s", src, name)
+	}
+	*e = E(s)
+	return nil
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnums(t *testing.T) {
+	assert.True(t, AccountTypeBusiness.IsValid())
+	assert.False(t, AccountType("enterprise").IsValid())
+	assert.True(t, KycStatusVerified.IsValid())
+	assert.False(t, KycStatus("").IsValid())
+
+	// The JSON of users is unchanged by the typed fields.
+	data := `{"id":"00000000-0000-0000-0000-000000000000","keycloak_id":"","username":"ada","email":"ada@example.com",` +
+		`"created_at":"0001-01-01T00:00:00Z","updated_at":"0001-01-01T00:00:00Z","account_type":"business","kyc_status":"pending"}`
+	var user User
+	if !assert.NoError(t, json.Unmarshal([]byte(data), &user)) {
+		return
+	}
+	assert.Equal(t, ptr(AccountTypeBusiness), user.AccountType)
+	assert.Equal(t, ptr(KycStatusPending), user.KycStatus)
+	encoded, err := json.Marshal(user)
+	if assert.NoError(t, err) {
+		assert.JSONEq(t, data, string(encoded))
+	}
+
+	assert.Error(t, json.Unmarshal([]byte(`{"account_type":"enterprise"}`), &user))
+	assert.Error(t, json.Unmarshal([]byte(`{"kyc_status":"approved"}`), &user))
+
+	// Values that bypass JSON decoding are rejected by validation.
+	user.AccountType = ptr(AccountType("enterprise"))
+	assert.Error(t, user.Validate())
+	user.AccountType = ptr(AccountTypePersonal)
+	assert.NoError(t, user.Validate())
+
+	assert.NoError(t, json.Unmarshal([]byte(`{"kyc_status":null}`), &user))
+	assert.Nil(t, user.KycStatus)
+
+	value, err := KycStatusVerified.Value()
+	if assert.NoError(t, err) {
+		assert.Equal(t, "verified", value)
+	}
+	_, err = KycStatus("approved").Value()
+	assert.Error(t, err)
+
+	var accountType AccountType
+	if assert.NoError(t, accountType.Scan([]byte("personal"))) {
+		assert.Equal(t, AccountTypePersonal, accountType)
+	}
+	if assert.NoError(t, accountType.Scan("enterprise")) {
+		assert.Equal(t, AccountType("enterprise"), accountType)
+	}
+	assert.Error(t, accountType.Scan(nil))
+}
@@ -0,0 +1,246 @@
+package models
+
+import (
+	stderrors "errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidListQuery is wrapped by the errors of ListSchema.Parse. Respond to them with 400 Bad Request.
+var ErrInvalidListQuery = stderrors.New("invalid list query")
+
+// FilterOp is a comparison of a filter, given as filter[field][op]=value; filter[field]=value is FilterEq.
+type FilterOp string
+
+const (
+	FilterEq  FilterOp = "eq"
+	FilterNe  FilterOp = "ne"
+	FilterLt  FilterOp = "lt"
+	FilterLte FilterOp = "lte"
+	FilterGt  FilterOp = "gt"
+	FilterGte FilterOp = "gte"
+	// FilterIn matches any of comma-separated values.
+	FilterIn FilterOp = "in"
+)
+
+// filterSQL are the SQL operators of the filter ops.
+var filterSQL = map[FilterOp]string{
+	FilterEq: "=", FilterNe: "<>", FilterLt: "<", FilterLte: "<=", FilterGt: ">", FilterGte: ">=", FilterIn: "IN",
+}
+
+// FieldSpec allows a field of a list endpoint to be filtered, sorted or selected.
+type FieldSpec struct {
+	// Column is the SQL column of the field. It is written into queries verbatim, so it must never come from
+	// the request.
+	Column string
+	// Filters are the allowed filter ops; none means the field cannot be filtered.
+	Filters []FilterOp
+	// Parse converts filter values, e.g. to int or time.Time, rejecting malformed ones. Values stay strings
+	// without it.
+	Parse      func(value string) (interface{}, error)
+	Sortable   bool
+	Selectable bool
+}
+
+// ListSchema is the allowlist of the fields of a list endpoint, by query parameter name:
+//
+//	var recipeList = models.ListSchema{
+//		Fields: map[string]models.FieldSpec{
+//			"status":     {Column: "status", Filters: []models.FilterOp{models.FilterEq, models.FilterIn}},
+//			"created_at": {Column: "created_at", Sortable: true, Filters: []models.FilterOp{models.FilterGte}},
+//			"name":       {Column: "name", Sortable: true, Selectable: true},
+//		},
+//		DefaultSort: "-created_at",
+//	}
+type ListSchema struct {
+	Fields map[string]FieldSpec
+	// DefaultSort applies when the request has no sort parameter.
+	DefaultSort string
+}
+
+// Filter is a parsed filter.
+type Filter struct {
+	Field  string
+	Column string
+	Op     FilterOp
+	Values []interface{}
+}
+
+// SortColumn is a parsed sort field.
+type SortColumn struct {
+	Field  string
+	Column string
+	Desc   bool
+}
+
+// ListQuery is a list request checked against a ListSchema. It holds columns from the schema only, never
+// from the request, and keeps values apart for query parameters, so Where and OrderBy are safe to put in SQL.
+type ListQuery struct {
+	Filters []Filter
+	Sort    []SortColumn
+	// Columns are the selected columns, nil for all.
+	Columns []string
+	Page    PageRequest
+}
+
+// Parse parses the filter[...], sort, fields, limit, offset and cursor parameters of query. Unknown fields,
+// disallowed ops, malformed values and other parameters are errors wrapping ErrInvalidListQuery.
+func (s ListSchema) Parse(query url.Values) (*ListQuery, error) {
+	q := &ListQuery{}
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	sortParam := s.DefaultSort
+	for _, key := range keys {
+		values := query[key]
+		switch key {
+		case "sort":
+			sortParam = strings.Join(values, ",")
+		case "fields":
+			columns, err := s.parseFields(strings.Join(values, ","))
+			if err != nil {
+				return nil, err
+			}
+			q.Columns = columns
+		case "limit", "offset":
+			n, err := strconv.Atoi(values[len(values)-1])
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s must be a number", ErrInvalidListQuery, key)
+			}
+			if key == "limit" {
+				q.Page.Limit = n
+			} else {
+				q.Page.Offset = n
+			}
+		case "cursor":
+			q.Page.Cursor = values[len(values)-1]
+		default:
+			filter, err := s.parseFilter(key, values)
+			if err != nil {
+				return nil, err
+			}
+			q.Filters = append(q.Filters, filter)
+		}
+	}
+
+	q.Page.Sort = sortParam
+	for _, field := range q.Page.SortFields() {
+		spec, ok := s.Fields[field.Field]
+		if !ok || !spec.Sortable {
+			return nil, fmt.Errorf("%w: cannot sort by %q", ErrInvalidListQuery, field.Field)
+		}
+		q.Sort = append(q.Sort, SortColumn{Field: field.Field, Column: spec.Column, Desc: field.Desc})
+	}
+	if err := q.Page.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidListQuery, err)
+	}
+	return q, nil
+}
+
+func (s ListSchema) parseFields(param string) ([]string, error) {
+	var columns []string
+	for _, field := range strings.Split(param, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		spec, ok := s.Fields[field]
+		if !ok || !spec.Selectable {
+			return nil, fmt.Errorf("%w: cannot select %q", ErrInvalidListQuery, field)
+		}
+		if !slices.Contains(columns, spec.Column) {
+			columns = append(columns, spec.Column)
+		}
+	}
+	return columns, nil
+}
+
+// parseFilter parses filter[field]=value or filter[field][op]=value.
+func (s ListSchema) parseFilter(key string, values []string) (Filter, error) {
+	inner, ok := strings.CutPrefix(key, "filter[")
+	if !ok {
+		return Filter{}, fmt.Errorf("%w: unknown parameter %q", ErrInvalidListQuery, key)
+	}
+	inner, ok = strings.CutSuffix(inner, "]")
+	field, opName, hasOp := strings.Cut(inner, "][")
+	op := FilterEq
+	if hasOp {
+		op = FilterOp(opName)
+	}
+	if !ok || strings.ContainsAny(field, "[]") || strings.ContainsAny(opName, "[]") {
+		return Filter{}, fmt.Errorf("%w: malformed parameter %q", ErrInvalidListQuery, key)
+	}
+
+	spec, known := s.Fields[field]
+	if !known || len(spec.Filters) == 0 {
+		return Filter{}, fmt.Errorf("%w: cannot filter by %q", ErrInvalidListQuery, field)
+	}
+	if !slices.Contains(spec.Filters, op) {
+		return Filter{}, fmt.Errorf("%w: cannot filter %q with %q", ErrInvalidListQuery, field, op)
+	}
+	if len(values) != 1 {
+		return Filter{}, fmt.Errorf("%w: %s given more than once", ErrInvalidListQuery, key)
+	}
+
+	raw := []string{values[0]}
+	if op == FilterIn {
+		raw = strings.Split(values[0], ",")
+	}
+	filter := Filter{Field: field, Column: spec.Column, Op: op}
+	for _, value := range raw {
+		var parsed interface{} = value
+		if spec.Parse != nil {
+			var err error
+			if parsed, err = spec.Parse(value); err != nil {
+				return Filter{}, fmt.Errorf("%w: invalid %s value %q: %v", ErrInvalidListQuery, field, value, err)
+			}
+		}
+		filter.Values = append(filter.Values, parsed)
+	}
+	return filter, nil
+}
+
+// Where returns the filters as a SQL condition joined by AND, with PostgreSQL placeholders numbered from
+// firstArg, and their arguments. It returns "" and nil without filters.
+//
+//	where, args := q.Where(1)
+//	if where != "" {
+//		sql += " WHERE " + where
+//	}
+func (q *ListQuery) Where(firstArg int) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+	for _, filter := range q.Filters {
+		placeholders := make([]string, len(filter.Values))
+		for i, value := range filter.Values {
+			placeholders[i] = "$" + strconv.Itoa(firstArg+len(args))
+			args = append(args, value)
+		}
+		if filter.Op == FilterIn {
+			conditions = append(conditions, filter.Column+" IN ("+strings.Join(placeholders, ", ")+")")
+			continue
+		}
+		conditions = append(conditions, filter.Column+" "+filterSQL[filter.Op]+" "+placeholders[0])
+	}
+	return strings.Join(conditions, " AND "), args
+}
+
+// OrderBy returns the sort as a SQL ORDER BY list, e.g. "created_at DESC, name ASC", or "" without one.
+func (q *ListQuery) OrderBy() string {
+	terms := make([]string, len(q.Sort))
+	for i, column := range q.Sort {
+		direction := "ASC"
+		if column.Desc {
+			direction = "DESC"
+		}
+		terms[i] = column.Column + " " + direction
+	}
+	return strings.Join(terms, ", ")
+}
@@ -0,0 +1,74 @@
+package models
+
+import (
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var recipeList = ListSchema{
+	Fields: map[string]FieldSpec{
+		"status":     {Column: "r.status", Filters: []FilterOp{FilterEq, FilterIn}, Selectable: true},
+		"servings":   {Column: "r.servings", Filters: []FilterOp{FilterGte, FilterLte}, Parse: func(v string) (interface{}, error) { return strconv.Atoi(v) }},
+		"created_at": {Column: "r.created_at", Sortable: true},
+		"name":       {Column: "r.name", Sortable: true, Selectable: true},
+	},
+	DefaultSort: "-created_at",
+}
+
+func TestListQuery(t *testing.T) {
+	query, _ := url.ParseQuery("filter[status][in]=draft,published&filter[servings][gte]=4&sort=name,-created_at&fields=name,status&limit=10")
+	q, err := recipeList.Parse(query)
+	if !assert.NoError(t, err) {
+		return
+	}
+	where, args := q.Where(2)
+	assert.Equal(t, "r.servings >= $2 AND r.status IN ($3, $4)", where)
+	assert.Equal(t, []interface{}{4, "draft", "published"}, args)
+	assert.Equal(t, "r.name ASC, r.created_at DESC", q.OrderBy())
+	assert.Equal(t, []string{"r.name", "r.status"}, q.Columns)
+	assert.Equal(t, 10, q.Page.PageLimit())
+
+	q, err = recipeList.Parse(url.Values{"filter[status]": {"draft"}})
+	if assert.NoError(t, err) {
+		where, args = q.Where(1)
+		assert.Equal(t, "r.status = $1", where)
+		assert.Equal(t, []interface{}{"draft"}, args)
+		assert.Equal(t, "r.created_at DESC", q.OrderBy())
+		assert.Nil(t, q.Columns)
+	}
+
+	q, err = recipeList.Parse(url.Values{})
+	if assert.NoError(t, err) {
+		where, args = q.Where(1)
+		assert.Empty(t, where)
+		assert.Nil(t, args)
+	}
+
+	for _, raw := range []string{
+		"filter[owner_id]=1",                // not allowlisted
+		"filter[name]=x",                    // not filterable
+		"filter[status][gt]=draft",          // op not allowed
+		"filter[servings][gte]=four",        // unparsable value
+		"filter[status]=a&filter[status]=b", // repeated
+		"sort=servings",                     // not sortable
+		"fields=servings",                   // not selectable
+		"limit=1000",                        // out of bounds
+		"limit=ten",
+		"search=x",
+	} {
+		query, _ := url.ParseQuery(raw)
+		_, err := recipeList.Parse(query)
+		assert.ErrorIs(t, err, ErrInvalidListQuery, raw)
+	}
+	for _, query := range []url.Values{
+		{"filter[status]);DROP TABLE recipes--": {"x"}},
+		{"filter[status][eq][x]": {"x"}},
+		{"sort": {"name;DROP TABLE recipes"}},
+	} {
+		_, err := recipeList.Parse(query)
+		assert.ErrorIs(t, err, ErrInvalidListQuery, query)
+	}
+}
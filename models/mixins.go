@@ -0,0 +1,61 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Audited records who created and last updated a model, and when. Embed it so that its fields are flattened
+// in JSON and scanned from the created_at, created_by, updated_at and updated_by columns by pgx's
+// RowToStructByName, sqlx and GORM alike:
+//
+//	type Ingredient struct {
+//		ID   uuid.UUID `json:"id" db:"id"`
+//		Name string    `json:"name" db:"name"`
+//		models.Audited
+//		models.SoftDeletable
+//	}
+//
+// Unknown creators and updaters, e.g. of rows migrated from before auditing, are omitted from JSON.
+type Audited struct {
+	CreatedAt time.Time `json:"created_at" db:"created_at" gorm:"column:created_at"`
+	CreatedBy uuid.UUID `json:"created_by,omitzero" db:"created_by" gorm:"column:created_by;type:uuid"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at" gorm:"column:updated_at"`
+	UpdatedBy uuid.UUID `json:"updated_by,omitzero" db:"updated_by" gorm:"column:updated_by;type:uuid"`
+}
+
+// MarkCreated records the creation of the model by userID at now, which also counts as its last update.
+func (a *Audited) MarkCreated(userID uuid.UUID, now time.Time) {
+	a.CreatedAt, a.CreatedBy = now, userID
+	a.UpdatedAt, a.UpdatedBy = now, userID
+}
+
+// MarkUpdated records an update of the model by userID at now.
+func (a *Audited) MarkUpdated(userID uuid.UUID, now time.Time) {
+	a.UpdatedAt, a.UpdatedBy = now, userID
+}
+
+// SoftDeletable marks a model deleted without removing its row. DeletedAt is nil for live models and omitted
+// from their JSON. Queries must filter with "deleted_at IS NULL" themselves; GORM users who want its automatic
+// filtering declare a gorm.DeletedAt field instead.
+type SoftDeletable struct {
+	DeletedAt *time.Time `json:"deleted_at,omitempty" db:"deleted_at" gorm:"column:deleted_at;index"`
+}
+
+// IsDeleted reports whether the model is soft-deleted.
+func (s *SoftDeletable) IsDeleted() bool {
+	return s.DeletedAt != nil
+}
+
+// MarkDeleted soft-deletes the model at now. Deleting a deleted model keeps the first deletion time.
+func (s *SoftDeletable) MarkDeleted(now time.Time) {
+	if s.DeletedAt == nil {
+		s.DeletedAt = &now
+	}
+}
+
+// Restore undoes a soft delete.
+func (s *SoftDeletable) Restore() {
+	s.DeletedAt = nil
+}
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+type ingredient struct {
+	Name string `json:"name"`
+	Audited
+	SoftDeletable
+}
+
+func TestMixins(t *testing.T) {
+	creator, editor := uuid.New(), uuid.New()
+	created := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	var flour ingredient
+	flour.Name = "flour"
+	flour.MarkCreated(creator, created)
+	flour.MarkUpdated(editor, created.Add(time.Hour))
+	assert.Equal(t, creator, flour.CreatedBy)
+	assert.Equal(t, editor, flour.UpdatedBy)
+	assert.Equal(t, created.Add(time.Hour), flour.UpdatedAt)
+
+	data, err := json.Marshal(flour)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.JSONEq(t, `{"name":"flour","created_at":"2026-01-02T03:04:05Z","created_by":"`+creator.String()+
+		`","updated_at":"2026-01-02T04:04:05Z","updated_by":"`+editor.String()+`"}`, string(data))
+
+	assert.False(t, flour.IsDeleted())
+	flour.MarkDeleted(created.Add(2 * time.Hour))
+	flour.MarkDeleted(created.Add(3 * time.Hour))
+	if assert.True(t, flour.IsDeleted()) {
+		assert.Equal(t, created.Add(2*time.Hour), *flour.DeletedAt)
+	}
+	data, err = json.Marshal(flour)
+	if assert.NoError(t, err) {
+		assert.Contains(t, string(data), `"deleted_at":"2026-01-02T05:04:05Z"`)
+	}
+	flour.Restore()
+	assert.False(t, flour.IsDeleted())
+
+	// Unknown creators are omitted.
+	data, err = json.Marshal(Audited{})
+	if assert.NoError(t, err) {
+		assert.NotContains(t, string(data), "created_by")
+	}
+}
@@ -0,0 +1,205 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ErrCurrencyMismatch is returned by arithmetic on amounts of different currencies.
+var ErrCurrencyMismatch = stderrors.New("currency mismatch")
+
+// ErrAmountOverflow is returned by arithmetic whose result does not fit in int64 minor units.
+var ErrAmountOverflow = stderrors.New("amount overflow")
+
+// Currency is an ISO 4217 currency code such as "EUR".
+type Currency string
+
+// Valid reports whether c has the form of an ISO 4217 code: three uppercase letters.
+func (c Currency) Valid() bool {
+	if len(c) != 3 {
+		return false
+	}
+	for _, r := range c {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// minorDigits are the ISO 4217 minor unit digits of the currencies that do not have 2.
+var minorDigits = map[Currency]int{
+	"BHD": 3, "CLP": 0, "IQD": 3, "ISK": 0, "JOD": 3, "JPY": 0, "KRW": 0, "KWD": 3,
+	"LYD": 3, "OMR": 3, "PYG": 0, "TND": 3, "UGX": 0, "VND": 0, "XAF": 0, "XOF": 0,
+}
+
+// MinorDigits returns the number of digits of the minor unit of c, e.g. 2 for EUR cents and 0 for JPY.
+func (c Currency) MinorDigits() int {
+	if digits, ok := minorDigits[c]; ok {
+		return digits
+	}
+	return 2
+}
+
+// Money is an amount of money in integer minor units, e.g. cents, so that arithmetic is exact. Never pass
+// amounts as float64. Money is rendered in JSON as {"amount": 1999, "currency": "EUR"} for 19.99 EUR.
+type Money struct {
+	Amount   int64    `json:"amount"`
+	Currency Currency `json:"currency"`
+}
+
+// NewMoney returns amount minor units of currency.
+func NewMoney(amount int64, currency Currency) Money {
+	return Money{Amount: amount, Currency: currency}
+}
+
+// ParseMoney parses the decimal notation of String, e.g. "19.99 EUR".
+func ParseMoney(s string) (Money, error) {
+	number, currency, ok := strings.Cut(strings.TrimSpace(s), " ")
+	m := Money{Currency: Currency(currency)}
+	if !ok || !m.Currency.Valid() {
+		return Money{}, fmt.Errorf("invalid money %q: want \"<amount> <currency>\"", s)
+	}
+	sign := ""
+	if rest, ok := strings.CutPrefix(number, "-"); ok {
+		sign, number = "-", rest
+	}
+	whole, fraction, hasPoint := strings.Cut(number, ".")
+	digits := m.Currency.MinorDigits()
+	if !isDigits(whole) || (hasPoint && !isDigits(fraction)) || len(fraction) > digits {
+		return Money{}, fmt.Errorf("invalid money %q: want at most %d decimals", s, digits)
+	}
+	// The sign is parsed with the digits so that math.MinInt64 minor units, whose magnitude overflows int64,
+	// can be read back.
+	units, err := strconv.ParseInt(sign+whole+fraction+strings.Repeat("0", digits-len(fraction)), 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("invalid money %q: %w", s, err)
+	}
+	m.Amount = units
+	return m, nil
+}
+
+// isDigits reports whether s is a non-empty string of ASCII digits.
+func isDigits(s string) bool {
+	return s != "" && strings.Trim(s, "0123456789") == ""
+}
+
+// String renders m in decimal notation, e.g. "19.99 EUR" or "-5 JPY".
+func (m Money) String() string {
+	digits := m.Currency.MinorDigits()
+	sign, amount := "", m.Amount
+	if amount < 0 {
+		sign, amount = "-", -amount
+	}
+	// uint64 also holds the magnitude of math.MinInt64, whose negation overflows.
+	abs := strconv.FormatUint(uint64(amount), 10)
+	if digits == 0 {
+		return sign + abs + " " + string(m.Currency)
+	}
+	if len(abs) <= digits {
+		abs = strings.Repeat("0", digits-len(abs)+1) + abs
+	}
+	return sign + abs[:len(abs)-digits] + "." + abs[len(abs)-digits:] + " " + string(m.Currency)
+}
+
+// IsZero reports whether the amount is zero.
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+// IsNegative reports whether the amount is below zero.
+func (m Money) IsNegative() bool {
+	return m.Amount < 0
+}
+
+// Add returns m + other. Both must have the same currency.
+func (m Money) Add(other Money) (Money, error) {
+	if m.Currency != other.Currency {
+		return Money{}, fmt.Errorf("failed to add %s to %s: %w", other.Currency, m.Currency, ErrCurrencyMismatch)
+	}
+	sum := m.Amount + other.Amount
+	if (other.Amount > 0 && sum < m.Amount) || (other.Amount < 0 && sum > m.Amount) {
+		return Money{}, fmt.Errorf("failed to add %s to %s: %w", other, m, ErrAmountOverflow)
+	}
+	return Money{Amount: sum, Currency: m.Currency}, nil
+}
+
+// Sub returns m - other. Both must have the same currency.
+func (m Money) Sub(other Money) (Money, error) {
+	if other.Amount == math.MinInt64 {
+		return Money{}, fmt.Errorf("failed to subtract %s from %s: %w", other, m, ErrAmountOverflow)
+	}
+	return m.Add(other.Neg())
+}
+
+// Mul returns m times n, e.g. the price of n items.
+func (m Money) Mul(n int64) (Money, error) {
+	product := m.Amount * n
+	if m.Amount != 0 && (product/m.Amount != n || (m.Amount == -1 && n == math.MinInt64)) {
+		return Money{}, fmt.Errorf("failed to multiply %s by %d: %w", m, n, ErrAmountOverflow)
+	}
+	return Money{Amount: product, Currency: m.Currency}, nil
+}
+
+// Neg returns -m.
+func (m Money) Neg() Money {
+	return Money{Amount: -m.Amount, Currency: m.Currency}
+}
+
+// Cmp compares m and other, returning -1, 0 or +1. Both must have the same currency.
+func (m Money) Cmp(other Money) (int, error) {
+	if m.Currency != other.Currency {
+		return 0, fmt.Errorf("failed to compare %s to %s: %w", other.Currency, m.Currency, ErrCurrencyMismatch)
+	}
+	switch {
+	case m.Amount < other.Amount:
+		return -1, nil
+	case m.Amount > other.Amount:
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// UnmarshalJSON decodes {"amount": ..., "currency": ...}, rejecting invalid currency codes.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	type money Money
+	var decoded money
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	if !decoded.Currency.Valid() {
+		return fmt.Errorf("invalid currency %q", decoded.Currency)
+	}
+	*m = Money(decoded)
+	return nil
+}
+
+// Value stores m in a text column in the notation of String, e.g. "19.99 EUR". Map Amount and Currency to
+// columns of their own to query by amount.
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
+
+// Scan reads the notation of String from a text column.
+func (m *Money) Scan(src interface{}) error {
+	var s string
+	switch src := src.(type) {
+	case string:
+		s = src
+	case []byte:
+		s = string(src)
+	default:
+		return fmt.Errorf("failed to scan %T into Money", src)
+	}
+	parsed, err := ParseMoney(s)
+	if err != nil {
+		return fmt.Errorf("failed to scan Money: %w", err)
+	}
+	*m = parsed
+	return nil
+}
@@ -0,0 +1,90 @@
+package models
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMoney(t *testing.T) {
+	price := NewMoney(1999, "EUR")
+	assert.Equal(t, "19.99 EUR", price.String())
+	assert.Equal(t, "-0.05 EUR", NewMoney(-5, "EUR").String())
+	assert.Equal(t, "1500 JPY", NewMoney(1500, "JPY").String())
+	assert.Equal(t, "1.250 KWD", NewMoney(1250, "KWD").String())
+
+	total, err := price.Mul(3)
+	if assert.NoError(t, err) {
+		assert.Equal(t, NewMoney(5997, "EUR"), total)
+	}
+	change, err := NewMoney(10000, "EUR").Sub(total)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "40.03 EUR", change.String())
+	}
+	cmp, err := price.Cmp(total)
+	assert.NoError(t, err)
+	assert.Equal(t, -1, cmp)
+
+	_, err = price.Add(NewMoney(100, "USD"))
+	assert.ErrorIs(t, err, ErrCurrencyMismatch)
+	_, err = NewMoney(math.MaxInt64, "EUR").Add(NewMoney(1, "EUR"))
+	assert.ErrorIs(t, err, ErrAmountOverflow)
+	_, err = NewMoney(math.MaxInt64/2+1, "EUR").Mul(2)
+	assert.ErrorIs(t, err, ErrAmountOverflow)
+	_, err = NewMoney(0, "EUR").Sub(NewMoney(math.MinInt64, "EUR"))
+	assert.ErrorIs(t, err, ErrAmountOverflow)
+}
+
+func TestParseMoney(t *testing.T) {
+	for s, want := range map[string]Money{
+		"19.99 EUR":                 NewMoney(1999, "EUR"),
+		"19.9 EUR":                  NewMoney(1990, "EUR"),
+		"-3 USD":                    NewMoney(-300, "USD"),
+		"1500 JPY":                  NewMoney(1500, "JPY"),
+		"-92233720368547758.08 EUR": NewMoney(math.MinInt64, "EUR"),
+		"92233720368547758.07 EUR":  NewMoney(math.MaxInt64, "EUR"),
+	} {
+		got, err := ParseMoney(s)
+		if assert.NoError(t, err, s) {
+			assert.Equal(t, want, got, s)
+		}
+	}
+	for _, s := range []string{"19.999 EUR", "19.99", "19.99 eur", "--1 EUR", "1.5 JPY", "abc EUR", ".5 EUR", "1. EUR", "1. JPY", "-92233720368547758.09 EUR"} {
+		_, err := ParseMoney(s)
+		assert.Error(t, err, s)
+	}
+
+	// The extremes survive a round trip through String.
+	for _, m := range []Money{NewMoney(math.MinInt64, "EUR"), NewMoney(math.MaxInt64, "EUR"), NewMoney(math.MinInt64, "JPY")} {
+		got, err := ParseMoney(m.String())
+		if assert.NoError(t, err, m.String()) {
+			assert.Equal(t, m, got)
+		}
+	}
+}
+
+func TestMoneyEncoding(t *testing.T) {
+	price := NewMoney(1999, "EUR")
+	data, err := json.Marshal(price)
+	if assert.NoError(t, err) {
+		assert.JSONEq(t, `{"amount":1999,"currency":"EUR"}`, string(data))
+	}
+	var decoded Money
+	if assert.NoError(t, json.Unmarshal(data, &decoded)) {
+		assert.Equal(t, price, decoded)
+	}
+	assert.Error(t, json.Unmarshal([]byte(`{"amount":1,"currency":"euro"}`), &decoded))
+	assert.Error(t, json.Unmarshal([]byte(`{"amount":1.5,"currency":"EUR"}`), &decoded))
+
+	value, err := price.Value()
+	if assert.NoError(t, err) {
+		assert.Equal(t, "19.99 EUR", value)
+	}
+	var scanned Money
+	if assert.NoError(t, scanned.Scan([]byte("19.99 EUR"))) {
+		assert.Equal(t, price, scanned)
+	}
+	assert.Error(t, scanned.Scan(int64(1999)))
+}
@@ -0,0 +1,81 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// Optional is a field of a partial update that tells an absent field from an explicit null and from a value,
+// which pointers cannot. Tag fields with omitzero to omit absent ones when marshaling:
+//
+//	FirstName models.Optional[string] `json:"first_name,omitzero"`
+//
+// The zero Optional is absent.
+type Optional[T any] struct {
+	set   bool
+	null  bool
+	value T
+}
+
+// Some returns an Optional holding value.
+func Some[T any](value T) Optional[T] {
+	return Optional[T]{set: true, value: value}
+}
+
+// Null returns an Optional that is an explicit null.
+func Null[T any]() Optional[T] {
+	return Optional[T]{set: true, null: true}
+}
+
+// IsSet reports whether the field was present, as null or a value.
+func (o Optional[T]) IsSet() bool {
+	return o.set
+}
+
+// IsNull reports whether the field was an explicit null.
+func (o Optional[T]) IsNull() bool {
+	return o.set && o.null
+}
+
+// Get returns the value and whether there is one, that is, the field was present and not null.
+func (o Optional[T]) Get() (T, bool) {
+	return o.value, o.set && !o.null
+}
+
+// IsZero reports whether the field is absent, for the omitzero JSON option.
+func (o Optional[T]) IsZero() bool {
+	return !o.set
+}
+
+// Ptr returns nil for null and absent fields and a pointer to the value otherwise.
+func (o Optional[T]) Ptr() *T {
+	if value, ok := o.Get(); ok {
+		return &value
+	}
+	return nil
+}
+
+// MarshalJSON encodes the value, or null for null and absent fields.
+func (o Optional[T]) MarshalJSON() ([]byte, error) {
+	if _, ok := o.Get(); !ok {
+		return []byte("null"), nil
+	}
+	return json.Marshal(o.value)
+}
+
+// UnmarshalJSON is only called for present fields, so it marks the field set.
+func (o *Optional[T]) UnmarshalJSON(data []byte) error {
+	*o = Optional[T]{set: true}
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		o.null = true
+		return nil
+	}
+	return json.Unmarshal(data, &o.value)
+}
+
+// applyTo sets *field per o: absent fields keep it, null clears it and values replace it.
+func (o Optional[T]) applyTo(field **T) {
+	if o.set {
+		*field = o.Ptr()
+	}
+}
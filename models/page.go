@@ -0,0 +1,99 @@
+package models
+
+import (
+	"strings"
+)
+
+const (
+	// DefaultPageLimit is the page size of list endpoints when the request sets none.
+	DefaultPageLimit = 20
+	// MaxPageLimit is the largest page size a client may request.
+	MaxPageLimit = 100
+)
+
+// PageRequest is the pagination of a list request, bound from the query string:
+//
+//	GET /recipes?limit=50&offset=100&sort=-created_at,name
+//
+// Offset and Cursor are exclusive; cursor pagination uses the NextCursor of the previous page.
+type PageRequest struct {
+	Limit  int    `json:"limit,omitempty" form:"limit" binding:"omitempty,min=1,max=100"`
+	Offset int    `json:"offset,omitempty" form:"offset" binding:"omitempty,min=0"`
+	Cursor string `json:"cursor,omitempty" form:"cursor" binding:"omitempty,max=512,excluded_with=Offset"`
+	// Sort is a comma-separated list of fields, each prefixed with "-" for descending order.
+	Sort string `json:"sort,omitempty" form:"sort" binding:"omitempty,max=256"`
+}
+
+// Validate checks the bounds of the request, for requests not bound with gin's ShouldBindQuery: a limit of
+// 1 to MaxPageLimit, a non-negative offset, and a cursor only without an offset.
+func (p PageRequest) Validate() error {
+	return validate.Struct(p)
+}
+
+// PageLimit returns Limit, or DefaultPageLimit if it is not set.
+func (p PageRequest) PageLimit() int {
+	if p.Limit <= 0 {
+		return DefaultPageLimit
+	}
+	return min(p.Limit, MaxPageLimit)
+}
+
+// SortField is one field of PageRequest.Sort.
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// SortFields parses Sort. Callers must check the fields against those they can sort by.
+func (p PageRequest) SortFields() []SortField {
+	var fields []SortField
+	for _, field := range strings.Split(p.Sort, ",") {
+		field = strings.TrimSpace(field)
+		desc := strings.HasPrefix(field, "-")
+		field = strings.TrimPrefix(field, "-")
+		if field == "" {
+			continue
+		}
+		fields = append(fields, SortField{Field: field, Desc: desc})
+	}
+	return fields
+}
+
+// Pagination describes a page of a list: its position and how to get the next one.
+type Pagination struct {
+	// Total is the number of items across all pages, omitted when it is not counted, as is usual with cursors.
+	Total      *int64 `json:"total,omitempty"`
+	Limit      int    `json:"limit"`
+	Offset     int    `json:"offset,omitempty"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// PageResponse is a page of a list response. Items is never null in JSON.
+type PageResponse[T any] struct {
+	Items []T `json:"items"`
+	Pagination
+}
+
+// NewPage creates the response to an offset-paginated request with total items in all pages.
+func NewPage[T any](items []T, req PageRequest, total int64) PageResponse[T] {
+	if items == nil {
+		items = []T{}
+	}
+	return PageResponse[T]{Items: items, Pagination: Pagination{Total: &total, Limit: req.PageLimit(), Offset: req.Offset}}
+}
+
+// NewCursorPage creates the response to a cursor-paginated request; nextCursor is empty on the last page.
+func NewCursorPage[T any](items []T, req PageRequest, nextCursor string) PageResponse[T] {
+	if items == nil {
+		items = []T{}
+	}
+	return PageResponse[T]{Items: items, Pagination: Pagination{Limit: req.PageLimit(), NextCursor: nextCursor}}
+}
+
+// HasMore reports whether there is a page after this one.
+func (p PageResponse[T]) HasMore() bool {
+	if p.NextCursor != "" {
+		return true
+	}
+	return p.Total != nil && int64(p.Offset+len(p.Items)) < *p.Total
+}
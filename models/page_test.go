@@ -0,0 +1,52 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPageRequest(t *testing.T) {
+	assert.NoError(t, PageRequest{}.Validate())
+	assert.NoError(t, PageRequest{Limit: 100, Offset: 200, Sort: "-created_at,name"}.Validate())
+	assert.NoError(t, PageRequest{Limit: 10, Cursor: "abc"}.Validate())
+
+	for _, req := range []PageRequest{
+		{Limit: 101},
+		{Limit: -1},
+		{Offset: -1},
+		{Offset: 10, Cursor: "abc"},
+	} {
+		err := req.Validate()
+		var validationErrs validator.ValidationErrors
+		assert.ErrorAs(t, err, &validationErrs, "%+v", req)
+	}
+
+	assert.Equal(t, DefaultPageLimit, PageRequest{}.PageLimit())
+	assert.Equal(t, 50, PageRequest{Limit: 50}.PageLimit())
+	assert.Equal(t, []SortField{{Field: "created_at", Desc: true}, {Field: "name"}},
+		PageRequest{Sort: " -created_at, name,,"}.SortFields())
+	assert.Nil(t, PageRequest{}.SortFields())
+}
+
+func TestPageResponse(t *testing.T) {
+	page := NewPage([]string{"a", "b"}, PageRequest{Limit: 2, Offset: 2}, 5)
+	data, err := json.Marshal(page)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.JSONEq(t, `{"items":["a","b"],"total":5,"limit":2,"offset":2}`, string(data))
+	assert.True(t, page.HasMore())
+	assert.False(t, NewPage([]string{"e"}, PageRequest{Limit: 2, Offset: 4}, 5).HasMore())
+
+	empty := NewCursorPage[string](nil, PageRequest{}, "")
+	data, err = json.Marshal(empty)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.JSONEq(t, `{"items":[],"limit":20}`, string(data))
+	assert.False(t, empty.HasMore())
+	assert.True(t, NewCursorPage([]string{"a"}, PageRequest{}, "next").HasMore())
+}
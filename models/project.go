@@ -0,0 +1,81 @@
+package models
+
+import (
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hkinc45/dev-kitchen-go-common/resource_types"
+)
+
+// ProjectVisibility controls who can see a project.
+type ProjectVisibility string
+
+const (
+	// VisibilityPrivate projects are visible to their members only.
+	VisibilityPrivate ProjectVisibility = "private"
+	// VisibilityInternal projects are visible to every signed-in user.
+	VisibilityInternal ProjectVisibility = "internal"
+	// VisibilityPublic projects are visible to everyone.
+	VisibilityPublic ProjectVisibility = "public"
+)
+
+// Project roles, as found in User.ProjectRoles.
+const (
+	ProjectRoleOwner  = "owner"
+	ProjectRoleAdmin  = "admin"
+	ProjectRoleEditor = "editor"
+	ProjectRoleViewer = "viewer"
+)
+
+// Project represents a project as the auth-service returns it.
+// It is the canonical representation of a project across all services.
+type Project struct {
+	ID          uuid.UUID         `json:"id"`
+	OwnerID     uuid.UUID         `json:"owner_id" binding:"required"`
+	Name        string            `json:"name" binding:"required,max=255"`
+	Slug        string            `json:"slug" binding:"required,max=63"`
+	Description *string           `json:"description,omitempty" binding:"omitempty,max=2000"`
+	Visibility  ProjectVisibility `json:"visibility" binding:"required,oneof=private internal public"`
+	Members     []ProjectMember   `json:"members,omitempty" binding:"dive"`
+	CreatedAt   time.Time         `json:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at"`
+}
+
+// ProjectMember is a user belonging to a project, with their roles in it.
+type ProjectMember struct {
+	UserID   uuid.UUID `json:"user_id" binding:"required"`
+	Username string    `json:"username,omitempty"`
+	Roles    []string  `json:"roles" binding:"required,min=1,dive,oneof=owner admin editor viewer"`
+	JoinedAt time.Time `json:"joined_at"`
+}
+
+// ResourceType returns resource_types.Project, the resource type of projects in permission checks.
+func (p *Project) ResourceType() string {
+	return resource_types.Project
+}
+
+// Validate checks the project: an owner, a name, a slug of lowercase letters, digits and dashes, a known
+// visibility, and at least one known role for each member.
+func (p *Project) Validate() error {
+	return validate.Struct(p)
+}
+
+// Member returns the member of the project with the given user ID.
+func (p *Project) Member(userID uuid.UUID) (ProjectMember, bool) {
+	for _, member := range p.Members {
+		if member.UserID == userID {
+			return member, true
+		}
+	}
+	return ProjectMember{}, false
+}
+
+// HasRole reports whether the user with the given ID has role in the project. The owner has every role.
+func (p *Project) HasRole(userID uuid.UUID, role string) bool {
+	if userID == p.OwnerID {
+		return true
+	}
+	member, ok := p.Member(userID)
+	return ok && (slices.Contains(member.Roles, role) || slices.Contains(member.Roles, ProjectRoleOwner))
+}
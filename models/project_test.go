@@ -0,0 +1,102 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProject(t *testing.T) {
+	owner, editor, stranger := uuid.New(), uuid.New(), uuid.New()
+	project := &Project{
+		ID:         uuid.New(),
+		OwnerID:    owner,
+		Name:       "Sourdough",
+		Slug:       "sourdough-2",
+		Visibility: VisibilityPrivate,
+		Members: []ProjectMember{
+			{UserID: owner, Roles: []string{ProjectRoleOwner}},
+			{UserID: editor, Roles: []string{ProjectRoleEditor}},
+		},
+	}
+	assert.NoError(t, project.Validate())
+
+	assert.True(t, project.HasRole(owner, ProjectRoleAdmin))
+	assert.True(t, project.HasRole(editor, ProjectRoleEditor))
+	assert.False(t, project.HasRole(editor, ProjectRoleAdmin))
+	assert.False(t, project.HasRole(stranger, ProjectRoleViewer))
+
+	data, err := json.Marshal(project)
+	if !assert.NoError(t, err) {
+		return
+	}
+	var decoded Project
+	if assert.NoError(t, json.Unmarshal(data, &decoded)) {
+		assert.Equal(t, project.Members, decoded.Members)
+		assert.Contains(t, string(data), `"visibility":"private"`)
+	}
+
+}
+
+func TestProjectValidate(t *testing.T) {
+	owner := uuid.New()
+	tests := []struct {
+		name    string
+		project Project
+		// wantNamespace is the namespace of the failing field, or empty if the project is valid.
+		wantNamespace string
+	}{
+		{
+			name:    "valid",
+			project: Project{OwnerID: owner, Name: "Sourdough", Slug: "sourdough-2", Visibility: VisibilityPublic},
+		},
+		{
+			name:          "slug with spaces and capitals",
+			project:       Project{OwnerID: owner, Name: "Sourdough", Slug: "Sour Dough", Visibility: VisibilityPublic},
+			wantNamespace: "Project.Slug",
+		},
+		{
+			name:          "unknown visibility",
+			project:       Project{OwnerID: owner, Name: "Sourdough", Slug: "sourdough", Visibility: "secret"},
+			wantNamespace: "Project.Visibility",
+		},
+		{
+			name:          "missing owner",
+			project:       Project{Name: "Sourdough", Slug: "sourdough", Visibility: VisibilityPrivate},
+			wantNamespace: "Project.OwnerID",
+		},
+		{
+			name: "member with unknown role",
+			project: Project{
+				OwnerID: owner, Name: "Sourdough", Slug: "sourdough", Visibility: VisibilityPrivate,
+				Members: []ProjectMember{{UserID: owner, Roles: []string{"cook"}}},
+			},
+			wantNamespace: "Project.Members[0].Roles[0]",
+		},
+		{
+			name: "member without roles",
+			project: Project{
+				OwnerID: owner, Name: "Sourdough", Slug: "sourdough", Visibility: VisibilityPrivate,
+				Members: []ProjectMember{{UserID: owner, Roles: []string{}}},
+			},
+			wantNamespace: "Project.Members[0].Roles",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.project.Validate()
+			if tt.wantNamespace == "" {
+				assert.NoError(t, err)
+				return
+			}
+			var validationErrs validator.ValidationErrors
+			if assert.ErrorAs(t, err, &validationErrs) && assert.Len(t, validationErrs, 1) {
+				assert.Equal(t, tt.wantNamespace, validationErrs[0].Namespace())
+			}
+		})
+	}
+}
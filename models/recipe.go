@@ -0,0 +1,71 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hkinc45/dev-kitchen-go-common/resource_types"
+)
+
+// RecipeStatus is the lifecycle state of a recipe.
+type RecipeStatus string
+
+const (
+	// RecipeStatusDraft recipes are being written and have no published version yet.
+	RecipeStatusDraft RecipeStatus = "draft"
+	// RecipeStatusPublished recipes have at least one published version.
+	RecipeStatusPublished RecipeStatus = "published"
+	// RecipeStatusArchived recipes are read-only.
+	RecipeStatusArchived RecipeStatus = "archived"
+)
+
+// Recipe represents a recipe, the resource_types.Recipe resource, in recipe events and APIs.
+// It is the canonical representation of a recipe across all services.
+type Recipe struct {
+	ID          uuid.UUID    `json:"id"`
+	ProjectID   uuid.UUID    `json:"project_id" binding:"required"`
+	Name        string       `json:"name" binding:"required,max=255"`
+	Description *string      `json:"description,omitempty" binding:"omitempty,max=2000"`
+	Status      RecipeStatus `json:"status" binding:"required,oneof=draft published archived"`
+	// LatestVersion is the semantic version of the newest RecipeVersion, empty for drafts.
+	LatestVersion string    `json:"latest_version,omitempty" binding:"omitempty,semver"`
+	CreatedBy     uuid.UUID `json:"created_by" binding:"required"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// RecipeVersion is an immutable version of the spec of a recipe.
+type RecipeVersion struct {
+	ID        uuid.UUID `json:"id"`
+	RecipeID  uuid.UUID `json:"recipe_id" binding:"required"`
+	ProjectID uuid.UUID `json:"project_id" binding:"required"`
+	// Version is a semantic version such as "1.4.0", unique per recipe.
+	Version string `json:"version" binding:"required,semver"`
+	// Spec is the recipe specification, passed through as is.
+	Spec      json.RawMessage `json:"spec" binding:"required"`
+	Changelog *string         `json:"changelog,omitempty" binding:"omitempty,max=2000"`
+	CreatedBy uuid.UUID       `json:"created_by" binding:"required"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// ResourceType returns resource_types.Recipe, the resource type of recipes in permission checks.
+func (r *Recipe) ResourceType() string {
+	return resource_types.Recipe
+}
+
+// Validate checks the recipe: a project and author, a name, a known status and, once a version is published,
+// a semantic latest version.
+func (r *Recipe) Validate() error {
+	return validate.Struct(r)
+}
+
+// Editable reports whether the recipe accepts changes, that is, it is not archived.
+func (r *Recipe) Editable() bool {
+	return r.Status != RecipeStatusArchived
+}
+
+// Validate checks the version: a recipe and project, a semantic version and a spec that is valid JSON.
+func (v *RecipeVersion) Validate() error {
+	return validate.Struct(v)
+}
@@ -0,0 +1,145 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/hkinc45/dev-kitchen-go-common/resource_types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecipe(t *testing.T) {
+	recipe := &Recipe{
+		ID:            uuid.New(),
+		ProjectID:     uuid.New(),
+		Name:          "Sourdough",
+		Status:        RecipeStatusPublished,
+		LatestVersion: "1.4.0",
+		CreatedBy:     uuid.New(),
+	}
+	assert.NoError(t, recipe.Validate())
+	assert.Equal(t, resource_types.Recipe, recipe.ResourceType())
+	assert.True(t, recipe.Editable())
+
+	recipe.Status = RecipeStatusArchived
+	assert.False(t, recipe.Editable())
+}
+
+func TestRecipeValidate(t *testing.T) {
+	project, author := uuid.New(), uuid.New()
+	tests := []struct {
+		name      string
+		recipe    Recipe
+		wantField string
+		wantTag   string
+	}{
+		{
+			name:   "draft without versions",
+			recipe: Recipe{ProjectID: project, Name: "Sourdough", Status: RecipeStatusDraft, CreatedBy: author},
+		},
+		{
+			name:   "published with a prerelease version",
+			recipe: Recipe{ProjectID: project, Name: "Sourdough", Status: RecipeStatusPublished, LatestVersion: "2.0.0-rc.1", CreatedBy: author},
+		},
+		{
+			name:      "version with a v prefix",
+			recipe:    Recipe{ProjectID: project, Name: "Sourdough", Status: RecipeStatusPublished, LatestVersion: "v1", CreatedBy: author},
+			wantField: "LatestVersion",
+			wantTag:   "semver",
+		},
+		{
+			name:      "unknown status",
+			recipe:    Recipe{ProjectID: project, Name: "Sourdough", Status: "deleted", CreatedBy: author},
+			wantField: "Status",
+			wantTag:   "oneof",
+		},
+		{
+			name:      "missing project",
+			recipe:    Recipe{Name: "Sourdough", Status: RecipeStatusDraft, CreatedBy: author},
+			wantField: "ProjectID",
+			wantTag:   "required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.recipe.Validate()
+			if tt.wantField == "" {
+				assert.NoError(t, err)
+				return
+			}
+			var validationErrs validator.ValidationErrors
+			if assert.ErrorAs(t, err, &validationErrs) && assert.Len(t, validationErrs, 1) {
+				assert.Equal(t, tt.wantField, validationErrs[0].StructField())
+				assert.Equal(t, tt.wantTag, validationErrs[0].Tag())
+			}
+		})
+	}
+}
+
+func TestRecipeVersion(t *testing.T) {
+	version := &RecipeVersion{
+		ID:        uuid.New(),
+		RecipeID:  uuid.New(),
+		ProjectID: uuid.New(),
+		Version:   "2.0.0-rc.1",
+		Spec:      json.RawMessage(`{"steps":[{"name":"knead"}]}`),
+		CreatedBy: uuid.New(),
+	}
+	data, err := json.Marshal(version)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Contains(t, string(data), `"spec":{"steps":[{"name":"knead"}]}`)
+}
+
+func TestRecipeVersionValidate(t *testing.T) {
+	recipe, project, author := uuid.New(), uuid.New(), uuid.New()
+	spec := json.RawMessage(`{"steps":[{"name":"knead"}]}`)
+	tests := []struct {
+		name      string
+		version   RecipeVersion
+		wantField string
+		wantTag   string
+	}{
+		{
+			name:    "valid",
+			version: RecipeVersion{RecipeID: recipe, ProjectID: project, Version: "1.4.0", Spec: spec, CreatedBy: author},
+		},
+		{
+			name:      "version that is not semantic",
+			version:   RecipeVersion{RecipeID: recipe, ProjectID: project, Version: "latest", Spec: spec, CreatedBy: author},
+			wantField: "Version",
+			wantTag:   "semver",
+		},
+		{
+			name:      "truncated spec",
+			version:   RecipeVersion{RecipeID: recipe, ProjectID: project, Version: "1.4.0", Spec: json.RawMessage(`{"steps":`), CreatedBy: author},
+			wantField: "Spec",
+			wantTag:   "json",
+		},
+		{
+			name:      "missing spec",
+			version:   RecipeVersion{RecipeID: recipe, ProjectID: project, Version: "1.4.0", CreatedBy: author},
+			wantField: "Spec",
+			wantTag:   "required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.version.Validate()
+			if tt.wantField == "" {
+				assert.NoError(t, err)
+				return
+			}
+			var validationErrs validator.ValidationErrors
+			if assert.ErrorAs(t, err, &validationErrs) && assert.Len(t, validationErrs, 1) {
+				assert.Equal(t, tt.wantField, validationErrs[0].StructField())
+				assert.Equal(t, tt.wantTag, validationErrs[0].Tag())
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Response is the envelope of successful responses: {"data": ..., "meta": {...}}. Errors are rendered by the
+// error middleware instead.
+type Response[T any] struct {
+	Data T     `json:"data"`
+	Meta *Meta `json:"meta,omitempty"`
+}
+
+// Meta is the metadata of a Response.
+type Meta struct {
+	Pagination *Pagination `json:"pagination,omitempty"`
+	// Warnings are messages about the request that did not prevent it, e.g. ignored or deprecated parameters.
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// Respond writes data and meta, which may be nil, in a Response with the given status.
+func Respond[T any](c *gin.Context, status int, data T, meta *Meta) {
+	c.JSON(status, Response[T]{Data: data, Meta: meta})
+}
+
+// RespondOK writes data and meta, which may be nil, in a 200 OK Response.
+//
+//	models.RespondOK(c, recipe.Public(), nil)
+func RespondOK[T any](c *gin.Context, data T, meta *Meta) {
+	Respond(c, http.StatusOK, data, meta)
+}
+
+// RespondCreated writes data in a 201 Created Response.
+func RespondCreated[T any](c *gin.Context, data T) {
+	Respond(c, http.StatusCreated, data, nil)
+}
+
+// RespondPage writes the items of page in a 200 OK Response, with its pagination in the meta, along with
+// warnings, if any.
+func RespondPage[T any](c *gin.Context, page PageResponse[T], warnings ...string) {
+	pagination := page.Pagination
+	RespondOK(c, page.Items, &Meta{Pagination: &pagination, Warnings: warnings})
+}
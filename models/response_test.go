@@ -0,0 +1,45 @@
+package models
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRespond(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.GET("/recipes/1", func(c *gin.Context) {
+		RespondOK(c, map[string]string{"name": "Sourdough"}, nil)
+	})
+	r.POST("/recipes", func(c *gin.Context) {
+		RespondCreated(c, map[string]string{"name": "Focaccia"})
+	})
+	r.GET("/recipes", func(c *gin.Context) {
+		RespondPage(c, NewPage([]string{"a", "b"}, PageRequest{Limit: 2}, 3), "sort=rating is deprecated")
+	})
+
+	serve := func(method, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, nil)
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := serve("GET", "/recipes/1")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"data":{"name":"Sourdough"}}`, w.Body.String())
+
+	w = serve("POST", "/recipes")
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.JSONEq(t, `{"data":{"name":"Focaccia"}}`, w.Body.String())
+
+	w = serve("GET", "/recipes")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"data":["a","b"],"meta":{"pagination":{"total":3,"limit":2},"warnings":["sort=rating is deprecated"]}}`,
+		w.Body.String())
+}
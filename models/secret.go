@@ -0,0 +1,64 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hkinc45/dev-kitchen-go-common/resource_types"
+)
+
+// SecretMetadata describes a secret, the resource_types.Secret resource, for listings and audit events. It
+// never holds the secret value, which only the secret store hands out.
+type SecretMetadata struct {
+	ID uuid.UUID `json:"id"`
+	// ProjectID is the project the secret belongs to, nil for personal secrets.
+	ProjectID *uuid.UUID `json:"project_id,omitempty"`
+	// StoreID is the resource_types.UserSecretStore holding the secret.
+	StoreID uuid.UUID `json:"store_id" binding:"required"`
+	// KeyName is the name the secret is looked up by, e.g. "STRIPE_API_KEY".
+	KeyName string `json:"key_name" binding:"required,max=255"`
+	// Version increases with every rotation, starting at 1.
+	Version        int             `json:"version" binding:"min=1"`
+	RotationPolicy *RotationPolicy `json:"rotation_policy,omitempty"`
+	LastRotatedAt  *time.Time      `json:"last_rotated_at,omitempty"`
+	Audited
+}
+
+// RotationPolicy says how often a secret is rotated.
+type RotationPolicy struct {
+	IntervalDays int `json:"interval_days" binding:"min=1"`
+}
+
+// ResourceType returns resource_types.Secret, the resource type of secrets in permission checks.
+func (s *SecretMetadata) ResourceType() string {
+	return resource_types.Secret
+}
+
+// StoreResourceType returns resource_types.UserSecretStore, the resource type of the store of the secret.
+func (s *SecretMetadata) StoreResourceType() string {
+	return resource_types.UserSecretStore
+}
+
+// Validate checks the metadata: a store, a key name, a positive version and rotation interval.
+func (s *SecretMetadata) Validate() error {
+	return validate.Struct(s)
+}
+
+// NextRotation returns when the secret is due for rotation, counting from its last rotation or creation, and
+// false if it has no rotation policy.
+func (s *SecretMetadata) NextRotation() (time.Time, bool) {
+	if s.RotationPolicy == nil {
+		return time.Time{}, false
+	}
+	since := s.CreatedAt
+	if s.LastRotatedAt != nil {
+		since = *s.LastRotatedAt
+	}
+	return since.AddDate(0, 0, s.RotationPolicy.IntervalDays), true
+}
+
+// RotationDue reports whether the secret is due for rotation at now.
+func (s *SecretMetadata) RotationDue(now time.Time) bool {
+	next, ok := s.NextRotation()
+	return ok && !now.Before(next)
+}
@@ -0,0 +1,56 @@
+package models
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hkinc45/dev-kitchen-go-common/resource_types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSecretMetadata(t *testing.T) {
+	created := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	secret := &SecretMetadata{
+		ID:             uuid.New(),
+		StoreID:        uuid.New(),
+		KeyName:        "STRIPE_API_KEY",
+		Version:        1,
+		RotationPolicy: &RotationPolicy{IntervalDays: 30},
+	}
+	secret.MarkCreated(uuid.New(), created)
+	assert.NoError(t, secret.Validate())
+	assert.Equal(t, resource_types.Secret, secret.ResourceType())
+	assert.Equal(t, resource_types.UserSecretStore, secret.StoreResourceType())
+
+	next, ok := secret.NextRotation()
+	assert.True(t, ok)
+	assert.Equal(t, created.AddDate(0, 0, 30), next)
+	assert.False(t, secret.RotationDue(created.AddDate(0, 0, 29)))
+	assert.True(t, secret.RotationDue(created.AddDate(0, 0, 30)))
+
+	rotated := created.AddDate(0, 0, 30)
+	secret.LastRotatedAt, secret.Version = &rotated, 2
+	assert.False(t, secret.RotationDue(created.AddDate(0, 0, 31)))
+
+	secret.RotationPolicy = nil
+	_, ok = secret.NextRotation()
+	assert.False(t, ok)
+
+	secret.Version = 0
+	assert.Error(t, secret.Validate())
+
+	// The metadata has no room for the value of the secret.
+	data, err := json.Marshal(secret)
+	if assert.NoError(t, err) {
+		assert.NotContains(t, string(data), `"value"`)
+	}
+	typ := reflect.TypeOf(SecretMetadata{})
+	for i := 0; i < typ.NumField(); i++ {
+		name := strings.ToLower(typ.Field(i).Name)
+		assert.NotContains(t, []string{"value", "secret", "plaintext", "data"}, name)
+	}
+}
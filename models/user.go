@@ -12,20 +12,20 @@ import (
 type User struct {
 	ID                 uuid.UUID           `json:"id"`
 	KeycloakID         string              `json:"keycloak_id"`
-	Username           string              `json:"username"`
-	Email              string              `json:"email"`
+	Username           string              `json:"username" binding:"required,max=255"`
+	Email              string              `json:"email" binding:"required,email"`
 	CreatedAt          time.Time           `json:"created_at"`
 	UpdatedAt          time.Time           `json:"updated_at"`
-	FirstName          *string             `json:"first_name,omitempty"`
-	LastName           *string             `json:"last_name,omitempty"`
-	PhoneNumber        *string             `json:"phone_number,omitempty"`
-	AccountType        *string             `json:"account_type,omitempty"` // e.g., "personal" or "business"
+	FirstName          *string             `json:"first_name,omitempty" binding:"omitempty,max=255"`
+	LastName           *string             `json:"last_name,omitempty" binding:"omitempty,max=255"`
+	PhoneNumber        *string             `json:"phone_number,omitempty" binding:"omitempty,e164"`
+	AccountType        *AccountType        `json:"account_type,omitempty" binding:"omitempty,enum"`
 	StreetAddress      *string             `json:"street_address,omitempty"`
 	City               *string             `json:"city,omitempty"`
 	State              *string             `json:"state,omitempty"`
 	PostalCode         *string             `json:"postal_code,omitempty"`
-	Country            *string             `json:"country,omitempty"`
-	KycStatus          *string             `json:"kyc_status,omitempty"` // e.g., "unverified", "pending", "verified"
+	Country            *string             `json:"country,omitempty" binding:"omitempty,iso3166_1_alpha2"`
+	KycStatus          *KycStatus          `json:"kyc_status,omitempty" binding:"omitempty,enum"`
 	GiteaOrgName       *string             `json:"gitea_org_name,omitempty"`
 	Roles              []string            `json:"roles,omitempty"`
 	ProjectRoles       map[string][]string `json:"project_roles,omitempty"`
@@ -33,3 +33,11 @@ type User struct {
 	SyncStatus         string              `json:"sync_status,omitempty" db:"sync_status"`
 	SyncLockedAt       *time.Time          `json:"sync_locked_at,omitempty" db:"sync_locked_at"`
 }
+
+// Validate checks the profile fields of the user against the rules shared by all services: a username, a valid
+// email, an E.164 phone number (e.g. "+4915123456789"), a known account type and KYC status and an ISO 3166-1
+// alpha-2 country code. Roles, project permissions and the sync state are owned by the auth-service and not
+// checked.
+func (u *User) Validate() error {
+	return validate.Struct(u)
+}
@@ -0,0 +1,43 @@
+package models
+
+// UserPatch is a partial update of a User, as sent to PATCH endpoints. Absent fields are left alone, null clears
+// a field and a value replaces it.
+type UserPatch struct {
+	Username      Optional[string]      `json:"username,omitzero"`
+	Email         Optional[string]      `json:"email,omitzero"`
+	FirstName     Optional[string]      `json:"first_name,omitzero"`
+	LastName      Optional[string]      `json:"last_name,omitzero"`
+	PhoneNumber   Optional[string]      `json:"phone_number,omitzero"`
+	AccountType   Optional[AccountType] `json:"account_type,omitzero"`
+	StreetAddress Optional[string]      `json:"street_address,omitzero"`
+	City          Optional[string]      `json:"city,omitzero"`
+	State         Optional[string]      `json:"state,omitzero"`
+	PostalCode    Optional[string]      `json:"postal_code,omitzero"`
+	Country       Optional[string]      `json:"country,omitzero"`
+}
+
+// Apply applies the patch to user. Username and email cannot be cleared: null empties them, which
+// user.Validate rejects, so validate the user after applying:
+//
+//	patch.Apply(&user)
+//	if err := user.Validate(); err != nil {
+//		c.Error(errors.NewBindingError(err))
+//		return
+//	}
+func (p UserPatch) Apply(user *User) {
+	if p.Username.IsSet() {
+		user.Username, _ = p.Username.Get()
+	}
+	if p.Email.IsSet() {
+		user.Email, _ = p.Email.Get()
+	}
+	p.FirstName.applyTo(&user.FirstName)
+	p.LastName.applyTo(&user.LastName)
+	p.PhoneNumber.applyTo(&user.PhoneNumber)
+	p.AccountType.applyTo(&user.AccountType)
+	p.StreetAddress.applyTo(&user.StreetAddress)
+	p.City.applyTo(&user.City)
+	p.State.applyTo(&user.State)
+	p.PostalCode.applyTo(&user.PostalCode)
+	p.Country.applyTo(&user.Country)
+}
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOptional(t *testing.T) {
+	var fields struct {
+		Absent Optional[int] `json:"absent,omitzero"`
+		Null   Optional[int] `json:"null,omitzero"`
+		Zero   Optional[int] `json:"zero,omitzero"`
+	}
+	if !assert.NoError(t, json.Unmarshal([]byte(`{"null":null,"zero":0}`), &fields)) {
+		return
+	}
+	assert.False(t, fields.Absent.IsSet())
+	assert.True(t, fields.Null.IsSet())
+	assert.True(t, fields.Null.IsNull())
+	value, ok := fields.Zero.Get()
+	assert.True(t, ok)
+	assert.Equal(t, 0, value)
+	assert.Nil(t, fields.Null.Ptr())
+
+	data, err := json.Marshal(fields)
+	if assert.NoError(t, err) {
+		assert.JSONEq(t, `{"null":null,"zero":0}`, string(data))
+	}
+	assert.Error(t, json.Unmarshal([]byte(`{"zero":"0"}`), &fields))
+}
+
+func TestUserPatch(t *testing.T) {
+	user := User{
+		Username:  "ada",
+		Email:     "ada@example.com",
+		FirstName: ptr("Ada"),
+		LastName:  ptr("Lovelace"),
+		City:      ptr("London"),
+	}
+
+	var patch UserPatch
+	if !assert.NoError(t, json.Unmarshal([]byte(`{"last_name":null,"city":"Paris","country":"FR"}`), &patch)) {
+		return
+	}
+	patch.Apply(&user)
+	assert.Equal(t, "ada", user.Username)
+	assert.Equal(t, ptr("Ada"), user.FirstName)
+	assert.Nil(t, user.LastName)
+	assert.Equal(t, ptr("Paris"), user.City)
+	assert.Equal(t, ptr("FR"), user.Country)
+	assert.NoError(t, user.Validate())
+
+	UserPatch{Email: Null[string]()}.Apply(&user)
+	assert.Empty(t, user.Email)
+	assert.Error(t, user.Validate())
+
+	data, err := json.Marshal(UserPatch{City: Some("Berlin"), State: Null[string]()})
+	if assert.NoError(t, err) {
+		assert.JSONEq(t, `{"city":"Berlin","state":null}`, string(data))
+	}
+}
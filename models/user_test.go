@@ -0,0 +1,68 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+)
+
+func ptr[T any](v T) *T { return &v }
+
+func TestUserValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		user User
+		// wantTag is the failing validation tag, or empty if the user is valid.
+		wantTag string
+	}{
+		{
+			name: "complete profile",
+			user: User{
+				Username:    "ada",
+				Email:       "ada@example.com",
+				PhoneNumber: ptr("+4915123456789"),
+				AccountType: ptr(AccountTypeBusiness),
+				KycStatus:   ptr(KycStatusPending),
+				Country:     ptr("DE"),
+			},
+		},
+		{name: "optional fields unset", user: User{Username: "ada", Email: "ada@example.com"}},
+		{name: "missing username", user: User{Email: "ada@example.com"}, wantTag: "required"},
+		{name: "malformed email", user: User{Username: "ada", Email: "not-an-email"}, wantTag: "email"},
+		{
+			name:    "national phone number",
+			user:    User{Username: "ada", Email: "ada@example.com", PhoneNumber: ptr("015123456789")},
+			wantTag: "e164",
+		},
+		{
+			name:    "unknown account type",
+			user:    User{Username: "ada", Email: "ada@example.com", AccountType: ptr(AccountType("enterprise"))},
+			wantTag: "enum",
+		},
+		{
+			name:    "unknown KYC status",
+			user:    User{Username: "ada", Email: "ada@example.com", KycStatus: ptr(KycStatus("approved"))},
+			wantTag: "enum",
+		},
+		{
+			name:    "country name instead of code",
+			user:    User{Username: "ada", Email: "ada@example.com", Country: ptr("Germany")},
+			wantTag: "iso3166_1_alpha2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.user.Validate()
+			if tt.wantTag == "" {
+				assert.NoError(t, err)
+				return
+			}
+			var validationErrs validator.ValidationErrors
+			if assert.ErrorAs(t, err, &validationErrs) && assert.Len(t, validationErrs, 1) {
+				assert.Equal(t, tt.wantTag, validationErrs[0].Tag())
+			}
+		})
+	}
+}
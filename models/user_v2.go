@@ -0,0 +1,183 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// UserV2MediaType selects UserV2 in the Accept and Content-Type headers; plain application/json is User.
+const UserV2MediaType = "application/vnd.dev-kitchen.user.v2+json"
+
+// OrgProviderGitea is the provider of Gitea organizations.
+const OrgProviderGitea = "gitea"
+
+// UserV2 is the next representation of a user, with the address grouped, typed enums and organization
+// memberships. Services move to it one at a time: convert with User.ToV2 and UserV2.ToV1 and negotiate the
+// representation with RespondUser and DecodeUser.
+type UserV2 struct {
+	ID            uuid.UUID           `json:"id"`
+	KeycloakID    string              `json:"keycloak_id"`
+	Username      string              `json:"username"`
+	Email         string              `json:"email"`
+	FirstName     *string             `json:"first_name,omitempty"`
+	LastName      *string             `json:"last_name,omitempty"`
+	PhoneNumber   *string             `json:"phone_number,omitempty"`
+	AccountType   *AccountType        `json:"account_type,omitempty"`
+	KycStatus     *KycStatus          `json:"kyc_status,omitempty"`
+	Address       *Address            `json:"address,omitempty"`
+	Organizations []OrgMembership     `json:"organizations,omitempty"`
+	Roles         []string            `json:"roles,omitempty"`
+	ProjectRoles  map[string][]string `json:"project_roles,omitempty"`
+	// ProjectPermissions and the sync state are passed through unchanged from User.
+	ProjectPermissions json.RawMessage `json:"project_permissions,omitempty"`
+	SyncStatus         string          `json:"sync_status,omitempty"`
+	SyncLockedAt       *time.Time      `json:"sync_locked_at,omitempty"`
+	CreatedAt          time.Time       `json:"created_at"`
+	UpdatedAt          time.Time       `json:"updated_at"`
+}
+
+// Address is a postal address.
+type Address struct {
+	Street     *string `json:"street,omitempty"`
+	City       *string `json:"city,omitempty"`
+	State      *string `json:"state,omitempty"`
+	PostalCode *string `json:"postal_code,omitempty"`
+	// Country is an ISO 3166-1 alpha-2 code.
+	Country *string `json:"country,omitempty"`
+}
+
+// IsZero reports whether no part of the address is set.
+func (a Address) IsZero() bool {
+	return a.Street == nil && a.City == nil && a.State == nil && a.PostalCode == nil && a.Country == nil
+}
+
+// OrgMembership is an organization of the user at an external provider, such as Gitea.
+type OrgMembership struct {
+	Provider string `json:"provider"`
+	Name     string `json:"name"`
+}
+
+// ToV2 converts u to a UserV2. Converting back with ToV1 yields an equal User.
+func (u *User) ToV2() *UserV2 {
+	v2 := &UserV2{
+		ID:                 u.ID,
+		KeycloakID:         u.KeycloakID,
+		Username:           u.Username,
+		Email:              u.Email,
+		FirstName:          u.FirstName,
+		LastName:           u.LastName,
+		PhoneNumber:        u.PhoneNumber,
+		AccountType:        u.AccountType,
+		KycStatus:          u.KycStatus,
+		Roles:              u.Roles,
+		ProjectRoles:       u.ProjectRoles,
+		ProjectPermissions: u.ProjectPermissions,
+		SyncStatus:         u.SyncStatus,
+		SyncLockedAt:       u.SyncLockedAt,
+		CreatedAt:          u.CreatedAt,
+		UpdatedAt:          u.UpdatedAt,
+	}
+	address := Address{
+		Street:     u.StreetAddress,
+		City:       u.City,
+		State:      u.State,
+		PostalCode: u.PostalCode,
+		Country:    u.Country,
+	}
+	if !address.IsZero() {
+		v2.Address = &address
+	}
+	if u.GiteaOrgName != nil {
+		v2.Organizations = []OrgMembership{{Provider: OrgProviderGitea, Name: *u.GiteaOrgName}}
+	}
+	return v2
+}
+
+// ToV1 converts u to a User. User has room for one Gitea organization only, the first; other organizations
+// are dropped.
+func (u *UserV2) ToV1() *User {
+	v1 := &User{
+		ID:                 u.ID,
+		KeycloakID:         u.KeycloakID,
+		Username:           u.Username,
+		Email:              u.Email,
+		FirstName:          u.FirstName,
+		LastName:           u.LastName,
+		PhoneNumber:        u.PhoneNumber,
+		AccountType:        u.AccountType,
+		KycStatus:          u.KycStatus,
+		Roles:              u.Roles,
+		ProjectRoles:       u.ProjectRoles,
+		ProjectPermissions: u.ProjectPermissions,
+		SyncStatus:         u.SyncStatus,
+		SyncLockedAt:       u.SyncLockedAt,
+		CreatedAt:          u.CreatedAt,
+		UpdatedAt:          u.UpdatedAt,
+	}
+	if u.Address != nil {
+		v1.StreetAddress = u.Address.Street
+		v1.City = u.Address.City
+		v1.State = u.Address.State
+		v1.PostalCode = u.Address.PostalCode
+		v1.Country = u.Address.Country
+	}
+	for _, org := range u.Organizations {
+		if org.Provider == OrgProviderGitea {
+			name := org.Name
+			v1.GiteaOrgName = &name
+			break
+		}
+	}
+	return v1
+}
+
+// WantsUserV2 reports whether a request with the given Accept header asks for UserV2. A quality value of 0
+// ("application/vnd.dev-kitchen.user.v2+json;q=0") explicitly refuses it.
+func WantsUserV2(accept string) bool {
+	for _, mediaRange := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(mediaRange)
+		if err != nil || mediaType != UserV2MediaType {
+			continue
+		}
+		if q, ok := params["q"]; ok {
+			quality, err := strconv.ParseFloat(q, 64)
+			return err == nil && quality > 0
+		}
+		return true
+	}
+	return false
+}
+
+// RespondUser writes user with the given status as UserV2 if the request accepts UserV2MediaType, and as User
+// otherwise.
+func RespondUser(c *gin.Context, status int, user *User) {
+	if WantsUserV2(c.GetHeader("Accept")) {
+		c.Header("Content-Type", UserV2MediaType)
+		c.JSON(status, user.ToV2())
+		return
+	}
+	c.JSON(status, user)
+}
+
+// DecodeUser decodes a user sent with the given Content-Type: UserV2 for UserV2MediaType, User otherwise.
+func DecodeUser(contentType string, data []byte) (*User, error) {
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil && mediaType == UserV2MediaType {
+		var v2 UserV2
+		if err := json.Unmarshal(data, &v2); err != nil {
+			return nil, fmt.Errorf("failed to decode user v2: %w", err)
+		}
+		return v2.ToV1(), nil
+	}
+	var user User
+	if err := json.Unmarshal(data, &user); err != nil {
+		return nil, fmt.Errorf("failed to decode user: %w", err)
+	}
+	return &user, nil
+}
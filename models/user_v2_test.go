@@ -0,0 +1,87 @@
+package models
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUserV2Conversion(t *testing.T) {
+	// Every field of User survives the round trip, so new fields must be carried by UserV2 as well.
+	var user User
+	userValue := reflect.ValueOf(&user).Elem()
+	for i := 0; i < userValue.NumField(); i++ {
+		fillField(userValue.Field(i), userValue.Type().Field(i).Name)
+	}
+	v2 := user.ToV2()
+	assert.Equal(t, user.City, v2.Address.City)
+	assert.Equal(t, []OrgMembership{{Provider: OrgProviderGitea, Name: *user.GiteaOrgName}}, v2.Organizations)
+	assert.Equal(t, &user, v2.ToV1())
+
+	empty := (&User{Username: "ada"}).ToV2()
+	assert.Nil(t, empty.Address)
+	assert.Nil(t, empty.Organizations)
+	assert.Equal(t, &User{Username: "ada"}, empty.ToV1())
+}
+
+func TestUserNegotiation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	user := &User{Username: "ada", City: ptr("London"), GiteaOrgName: ptr("ada-org")}
+	r := gin.New()
+	r.GET("/me", func(c *gin.Context) {
+		RespondUser(c, http.StatusOK, user)
+	})
+	serve := func(accept string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/me", nil)
+		req.Header.Set("Accept", accept)
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := serve("application/json")
+	assert.Contains(t, w.Header().Get("Content-Type"), "application/json")
+	assert.Contains(t, w.Body.String(), `"city":"London"`)
+
+	w = serve("application/json;q=0.5, " + UserV2MediaType)
+	assert.Equal(t, UserV2MediaType, w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), `"address":{"city":"London"}`)
+	assert.Contains(t, w.Body.String(), `"organizations":[{"provider":"gitea","name":"ada-org"}]`)
+
+	decoded, err := DecodeUser(UserV2MediaType+"; charset=utf-8", w.Body.Bytes())
+	if assert.NoError(t, err) {
+		assert.Equal(t, user.City, decoded.City)
+		assert.Equal(t, user.GiteaOrgName, decoded.GiteaOrgName)
+	}
+	data, _ := json.Marshal(user)
+	decoded, err = DecodeUser("application/json", data)
+	if assert.NoError(t, err) {
+		assert.Equal(t, user.City, decoded.City)
+	}
+	_, err = DecodeUser(UserV2MediaType, []byte(`{"address":"London"}`))
+	assert.Error(t, err)
+}
+
+func TestWantsUserV2(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   bool
+	}{
+		{UserV2MediaType, true},
+		{"application/json, " + UserV2MediaType + ";q=0.1", true},
+		{UserV2MediaType + ";q=0", false},
+		{UserV2MediaType + "; q=0.000, application/json", false},
+		{UserV2MediaType + ";q=high", false},
+		{"application/json", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, WantsUserV2(tt.accept), tt.accept)
+	}
+}
@@ -0,0 +1,64 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UserPublic is the subset of a User that other users may see, e.g. as project members or recipe authors.
+type UserPublic struct {
+	ID          uuid.UUID    `json:"id"`
+	Username    string       `json:"username"`
+	FirstName   *string      `json:"first_name,omitempty"`
+	LastName    *string      `json:"last_name,omitempty"`
+	AccountType *AccountType `json:"account_type,omitempty"`
+	CreatedAt   time.Time    `json:"created_at"`
+}
+
+// UserPrivate is what a user may see of themselves: UserPublic plus their contact details, address, KYC status
+// and roles. Internal bookkeeping such as the Keycloak ID and sync state is left out.
+type UserPrivate struct {
+	UserPublic
+	Email         string              `json:"email"`
+	PhoneNumber   *string             `json:"phone_number,omitempty"`
+	StreetAddress *string             `json:"street_address,omitempty"`
+	City          *string             `json:"city,omitempty"`
+	State         *string             `json:"state,omitempty"`
+	PostalCode    *string             `json:"postal_code,omitempty"`
+	Country       *string             `json:"country,omitempty"`
+	KycStatus     *KycStatus          `json:"kyc_status,omitempty"`
+	Roles         []string            `json:"roles,omitempty"`
+	ProjectRoles  map[string][]string `json:"project_roles,omitempty"`
+	UpdatedAt     time.Time           `json:"updated_at"`
+}
+
+// Public returns the view of u for other users. Respond with it, never with the User itself.
+func (u *User) Public() UserPublic {
+	return UserPublic{
+		ID:          u.ID,
+		Username:    u.Username,
+		FirstName:   u.FirstName,
+		LastName:    u.LastName,
+		AccountType: u.AccountType,
+		CreatedAt:   u.CreatedAt,
+	}
+}
+
+// Private returns the view of u for u themselves.
+func (u *User) Private() UserPrivate {
+	return UserPrivate{
+		UserPublic:    u.Public(),
+		Email:         u.Email,
+		PhoneNumber:   u.PhoneNumber,
+		StreetAddress: u.StreetAddress,
+		City:          u.City,
+		State:         u.State,
+		PostalCode:    u.PostalCode,
+		Country:       u.Country,
+		KycStatus:     u.KycStatus,
+		Roles:         u.Roles,
+		ProjectRoles:  u.ProjectRoles,
+		UpdatedAt:     u.UpdatedAt,
+	}
+}
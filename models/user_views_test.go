@@ -0,0 +1,112 @@
+package models
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// userFieldVisibility classifies every field of User. Adding a field to User fails TestUserViews until it is
+// classified here and, unless internal, added to the view and its mapper.
+var userFieldVisibility = map[string]string{
+	"ID":                 "public",
+	"Username":           "public",
+	"FirstName":          "public",
+	"LastName":           "public",
+	"AccountType":        "public",
+	"CreatedAt":          "public",
+	"Email":              "private",
+	"PhoneNumber":        "private",
+	"StreetAddress":      "private",
+	"City":               "private",
+	"State":              "private",
+	"PostalCode":         "private",
+	"Country":            "private",
+	"KycStatus":          "private",
+	"Roles":              "private",
+	"ProjectRoles":       "private",
+	"UpdatedAt":          "private",
+	"KeycloakID":         "internal",
+	"GiteaOrgName":       "internal",
+	"ProjectPermissions": "internal",
+	"SyncStatus":         "internal",
+	"SyncLockedAt":       "internal",
+}
+
+// viewFields returns the names of the fields of a view type, flattening embedded structs.
+func viewFields(typ reflect.Type) []string {
+	var names []string
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if field.Anonymous {
+			names = append(names, viewFields(field.Type)...)
+			continue
+		}
+		names = append(names, field.Name)
+	}
+	return names
+}
+
+func TestUserViews(t *testing.T) {
+	var public, private []string
+	userType := reflect.TypeOf(User{})
+	for i := 0; i < userType.NumField(); i++ {
+		name := userType.Field(i).Name
+		switch userFieldVisibility[name] {
+		case "public":
+			public = append(public, name)
+			private = append(private, name)
+		case "private":
+			private = append(private, name)
+		case "internal":
+		default:
+			t.Errorf("User.%s is not classified in userFieldVisibility", name)
+		}
+	}
+	assert.ElementsMatch(t, public, viewFields(reflect.TypeOf(UserPublic{})))
+	assert.ElementsMatch(t, private, viewFields(reflect.TypeOf(UserPrivate{})))
+
+	// The mappers copy every field of the views: fill every field of a user and compare by name.
+	var user User
+	userValue := reflect.ValueOf(&user).Elem()
+	for i := 0; i < userValue.NumField(); i++ {
+		fillField(userValue.Field(i), userType.Field(i).Name)
+	}
+	for _, view := range []interface{}{user.Public(), user.Private()} {
+		viewValue := reflect.ValueOf(view)
+		for _, name := range viewFields(viewValue.Type()) {
+			assert.Equal(t, userValue.FieldByName(name).Interface(), viewValue.FieldByName(name).Interface(),
+				"%T.%s", view, name)
+		}
+	}
+}
+
+// fillField sets v to a non-zero value derived from name.
+func fillField(v reflect.Value, name string) {
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(strings.ToLower(name))
+	case reflect.Ptr:
+		v.Set(reflect.New(v.Type().Elem()))
+		fillField(v.Elem(), name)
+	case reflect.Slice:
+		v.Set(reflect.MakeSlice(v.Type(), 1, 1))
+		fillField(v.Index(0), name)
+	case reflect.Map:
+		v.Set(reflect.MakeMap(v.Type()))
+		elem := reflect.New(v.Type().Elem()).Elem()
+		fillField(elem, name)
+		v.SetMapIndex(reflect.ValueOf(name), elem)
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			v.Index(i).Set(reflect.ValueOf(byte(len(name) + i)).Convert(v.Type().Elem()))
+		}
+	case reflect.Struct:
+		// time.Time
+		if setter, ok := v.Addr().Interface().(interface{ UnmarshalText([]byte) error }); ok {
+			_ = setter.UnmarshalText([]byte("2026-01-02T03:04:05Z"))
+		}
+	}
+}
@@ -0,0 +1,58 @@
+// Package models holds the canonical types shared by the services. The Validate methods of the models check
+// the same "binding" tags gin checks when binding requests, and their validator.ValidationErrors convert to a
+// 422 response with errors.NewBindingError.
+package models
+
+import (
+	"encoding/json"
+	"regexp"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// validate checks the "binding" tags of models, the tags gin validates when binding requests, and the rules
+// added by RegisterValidations.
+var validate = newValidator()
+
+// slugPattern matches URL slugs such as "my-project-2".
+var slugPattern = regexp.MustCompile(`^[a-z0-9]+(?:-[a-z0-9]+)*$`)
+
+func newValidator() *validator.Validate {
+	v := validator.New(validator.WithRequiredStructEnabled())
+	v.SetTagName("binding")
+	RegisterValidations(v)
+	return v
+}
+
+// RegisterValidations registers the rules of the models that the built-in tags cannot express, such as the
+// format of project slugs and the "enum" tag, which checks values through their IsValid method, with v.
+// Register them with gin's validator before binding models that use them:
+//
+//	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+//		models.RegisterValidations(v)
+//	}
+func RegisterValidations(v *validator.Validate) {
+	_ = v.RegisterValidation("enum", validateEnum)
+	v.RegisterStructValidation(validateProject, Project{})
+	v.RegisterStructValidation(validateRecipeVersion, RecipeVersion{})
+}
+
+// validateEnum checks fields of types with an IsValid method, such as AccountType and KycStatus.
+func validateEnum(fl validator.FieldLevel) bool {
+	e, ok := fl.Field().Interface().(interface{ IsValid() bool })
+	return ok && e.IsValid()
+}
+
+func validateProject(sl validator.StructLevel) {
+	project := sl.Current().Interface().(Project)
+	if project.Slug != "" && !slugPattern.MatchString(project.Slug) {
+		sl.ReportError(project.Slug, "Slug", "Slug", "slug", "")
+	}
+}
+
+func validateRecipeVersion(sl validator.StructLevel) {
+	version := sl.Current().Interface().(RecipeVersion)
+	if len(version.Spec) > 0 && !json.Valid(version.Spec) {
+		sl.ReportError(version.Spec, "Spec", "Spec", "json", "")
+	}
+}
@@ -0,0 +1,84 @@
+package models
+
+import (
+	"log/slog"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/hkinc45/dev-kitchen-go-common/resource_types"
+)
+
+// VCSProvider is a version control hosting provider.
+type VCSProvider string
+
+const (
+	VCSProviderGitea  VCSProvider = "gitea"
+	VCSProviderGitHub VCSProvider = "github"
+	VCSProviderGitLab VCSProvider = "gitlab"
+)
+
+// VCSConnectionStatus is the state of a VCSConnection.
+type VCSConnectionStatus string
+
+const (
+	// VCSConnectionPending connections wait for the installation to be confirmed by the provider.
+	VCSConnectionPending VCSConnectionStatus = "pending"
+	VCSConnectionActive  VCSConnectionStatus = "active"
+	// VCSConnectionSuspended connections were suspended at the provider and may be resumed there.
+	VCSConnectionSuspended VCSConnectionStatus = "suspended"
+	VCSConnectionRevoked   VCSConnectionStatus = "revoked"
+)
+
+// SecretRef points at a secret in a secret store by its SecretMetadata ID. It holds no secret material, so
+// models referencing secrets are safe to render and log.
+type SecretRef struct {
+	SecretID uuid.UUID `json:"secret_id" binding:"required"`
+	// Version pins a version of the secret; 0 is the latest.
+	Version int `json:"version,omitempty" binding:"min=0"`
+}
+
+// VCSConnection links a project to an organization or repository at a VCS provider, the
+// resource_types.VCSConnection resource. Its webhook secret is referenced, never embedded, so its JSON is safe
+// to return to clients.
+type VCSConnection struct {
+	ID        uuid.UUID   `json:"id"`
+	ProjectID uuid.UUID   `json:"project_id" binding:"required"`
+	Provider  VCSProvider `json:"provider" binding:"required,oneof=gitea github gitlab"`
+	// ExternalOrg is the organization at the provider; ExternalRepo is empty for connections to a whole
+	// organization.
+	ExternalOrg  string `json:"external_org" binding:"required,max=255"`
+	ExternalRepo string `json:"external_repo,omitempty" binding:"omitempty,max=255"`
+	// InstallationID is the provider's ID of the app installation, e.g. of a GitHub App.
+	InstallationID string              `json:"installation_id,omitempty" binding:"omitempty,max=255"`
+	Status         VCSConnectionStatus `json:"status" binding:"required,oneof=pending active suspended revoked"`
+	// Scopes are the permissions granted at the provider, e.g. "contents:read".
+	Scopes           []string   `json:"scopes,omitempty"`
+	WebhookSecretRef *SecretRef `json:"webhook_secret_ref,omitempty"`
+	Audited
+}
+
+// ResourceType returns resource_types.VCSConnection, the resource type of VCS connections in permission checks.
+func (v *VCSConnection) ResourceType() string {
+	return resource_types.VCSConnection
+}
+
+// Validate checks the connection: a project, a known provider and status and an organization.
+func (v *VCSConnection) Validate() error {
+	return validate.Struct(v)
+}
+
+// HasScope reports whether the provider granted scope.
+func (v *VCSConnection) HasScope(scope string) bool {
+	return slices.Contains(v.Scopes, scope)
+}
+
+// LogValue logs the connection by its identity and status only.
+func (v *VCSConnection) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("id", v.ID.String()),
+		slog.String("provider", string(v.Provider)),
+		slog.String("external_org", v.ExternalOrg),
+		slog.String("external_repo", v.ExternalRepo),
+		slog.String("status", string(v.Status)),
+	)
+}
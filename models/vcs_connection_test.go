@@ -0,0 +1,50 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/hkinc45/dev-kitchen-go-common/resource_types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVCSConnection(t *testing.T) {
+	secretID := uuid.New()
+	conn := &VCSConnection{
+		ID:               uuid.New(),
+		ProjectID:        uuid.New(),
+		Provider:         VCSProviderGitHub,
+		ExternalOrg:      "dev-kitchen",
+		ExternalRepo:     "recipes",
+		InstallationID:   "4242",
+		Status:           VCSConnectionActive,
+		Scopes:           []string{"contents:read", "metadata:read"},
+		WebhookSecretRef: &SecretRef{SecretID: secretID},
+	}
+	assert.NoError(t, conn.Validate())
+	assert.Equal(t, resource_types.VCSConnection, conn.ResourceType())
+	assert.True(t, conn.HasScope("contents:read"))
+	assert.False(t, conn.HasScope("contents:write"))
+
+	data, err := json.Marshal(conn)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Contains(t, string(data), `"webhook_secret_ref":{"secret_id":"`+secretID.String()+`"}`)
+	var decoded VCSConnection
+	if assert.NoError(t, json.Unmarshal(data, &decoded)) {
+		assert.Equal(t, conn.WebhookSecretRef, decoded.WebhookSecretRef)
+	}
+
+	var logs bytes.Buffer
+	slog.New(slog.NewTextHandler(&logs, nil)).Info("connected", "connection", conn)
+	assert.Contains(t, logs.String(), "connection.provider=github")
+	assert.NotContains(t, logs.String(), secretID.String())
+	assert.NotContains(t, logs.String(), "4242")
+
+	conn.Provider = "bitbucket"
+	assert.Error(t, conn.Validate())
+}
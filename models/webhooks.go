@@ -0,0 +1,20 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// DecodeWebhook decodes a webhook payload of an external provider into T, one of the Gitea* and Keycloak*
+// event types. Decoding is strict about the fields the services rely on, which must be present and of the
+// right type, and tolerant of fields it does not know, which providers add in new versions.
+func DecodeWebhook[T any](data []byte) (*T, error) {
+	var event T
+	if err := json.Unmarshal(data, &event); err != nil {
+		return nil, fmt.Errorf("failed to decode %T webhook: %w", event, err)
+	}
+	if err := validate.Struct(&event); err != nil {
+		return nil, fmt.Errorf("invalid %T webhook: %w", event, err)
+	}
+	return &event, nil
+}
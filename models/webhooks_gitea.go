@@ -0,0 +1,128 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// GiteaEventHeader is the header naming the event of a Gitea webhook, see ParseGiteaWebhook.
+const GiteaEventHeader = "X-Gitea-Event"
+
+// Gitea webhook events.
+const (
+	GiteaEventPush        = "push"
+	GiteaEventPullRequest = "pull_request"
+)
+
+// GiteaUser is a user or organization in Gitea webhooks.
+type GiteaUser struct {
+	ID       int64  `json:"id"`
+	Login    string `json:"login" binding:"required"`
+	FullName string `json:"full_name,omitempty"`
+	Email    string `json:"email,omitempty"`
+}
+
+// GiteaRepository is the repository of a Gitea webhook.
+type GiteaRepository struct {
+	ID            int64     `json:"id" binding:"required"`
+	Name          string    `json:"name" binding:"required"`
+	FullName      string    `json:"full_name" binding:"required"`
+	Owner         GiteaUser `json:"owner"`
+	Private       bool      `json:"private"`
+	HTMLURL       string    `json:"html_url,omitempty"`
+	CloneURL      string    `json:"clone_url,omitempty"`
+	DefaultBranch string    `json:"default_branch,omitempty"`
+}
+
+// GiteaCommitAuthor is the author or committer of a commit.
+type GiteaCommitAuthor struct {
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	Username string `json:"username,omitempty"`
+}
+
+// GiteaCommit is a commit of a push.
+type GiteaCommit struct {
+	ID        string            `json:"id" binding:"required"`
+	Message   string            `json:"message"`
+	URL       string            `json:"url,omitempty"`
+	Author    GiteaCommitAuthor `json:"author"`
+	Committer GiteaCommitAuthor `json:"committer"`
+	Timestamp time.Time         `json:"timestamp"`
+	Added     []string          `json:"added,omitempty"`
+	Removed   []string          `json:"removed,omitempty"`
+	Modified  []string          `json:"modified,omitempty"`
+}
+
+// GiteaPushEvent is the payload of a Gitea push webhook.
+type GiteaPushEvent struct {
+	// Ref is the pushed ref, e.g. "refs/heads/main".
+	Ref        string          `json:"ref" binding:"required"`
+	Before     string          `json:"before" binding:"required"`
+	After      string          `json:"after" binding:"required"`
+	CompareURL string          `json:"compare_url,omitempty"`
+	Commits    []GiteaCommit   `json:"commits" binding:"dive"`
+	HeadCommit *GiteaCommit    `json:"head_commit,omitempty"`
+	Repository GiteaRepository `json:"repository"`
+	Pusher     GiteaUser       `json:"pusher"`
+	Sender     GiteaUser       `json:"sender"`
+}
+
+// Branch returns the pushed branch, or "" if a tag was pushed.
+func (e *GiteaPushEvent) Branch() string {
+	branch, ok := strings.CutPrefix(e.Ref, "refs/heads/")
+	if !ok {
+		return ""
+	}
+	return branch
+}
+
+// Deleted reports whether the push deleted the ref.
+func (e *GiteaPushEvent) Deleted() bool {
+	return strings.Trim(e.After, "0") == ""
+}
+
+// GiteaBranch is the head or base of a pull request.
+type GiteaBranch struct {
+	Label string `json:"label,omitempty"`
+	Ref   string `json:"ref" binding:"required"`
+	Sha   string `json:"sha" binding:"required"`
+}
+
+// GiteaPullRequest is the pull request of a Gitea pull request webhook.
+type GiteaPullRequest struct {
+	ID             int64       `json:"id" binding:"required"`
+	Number         int64       `json:"number" binding:"required"`
+	Title          string      `json:"title"`
+	Body           string      `json:"body,omitempty"`
+	State          string      `json:"state" binding:"required,oneof=open closed"`
+	HTMLURL        string      `json:"html_url,omitempty"`
+	Merged         bool        `json:"merged"`
+	MergeCommitSHA *string     `json:"merge_commit_sha,omitempty"`
+	User           GiteaUser   `json:"user"`
+	Head           GiteaBranch `json:"head"`
+	Base           GiteaBranch `json:"base"`
+}
+
+// GiteaPullRequestEvent is the payload of a Gitea pull request webhook.
+type GiteaPullRequestEvent struct {
+	// Action is e.g. "opened", "closed", "reopened", "edited" or "synchronized".
+	Action      string           `json:"action" binding:"required"`
+	Number      int64            `json:"number" binding:"required"`
+	PullRequest GiteaPullRequest `json:"pull_request"`
+	Repository  GiteaRepository  `json:"repository"`
+	Sender      GiteaUser        `json:"sender"`
+}
+
+// ParseGiteaWebhook decodes the payload of a Gitea webhook with the event of the GiteaEventHeader into a
+// *GiteaPushEvent or a *GiteaPullRequestEvent. Other events are an error.
+func ParseGiteaWebhook(event string, data []byte) (interface{}, error) {
+	switch event {
+	case GiteaEventPush:
+		return DecodeWebhook[GiteaPushEvent](data)
+	case GiteaEventPullRequest:
+		return DecodeWebhook[GiteaPullRequestEvent](data)
+	}
+	return nil, fmt.Errorf("unsupported Gitea event %q", event)
+}
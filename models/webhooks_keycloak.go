@@ -0,0 +1,107 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Keycloak user event types consumed by the services.
+const (
+	KeycloakEventRegister      = "REGISTER"
+	KeycloakEventUpdateProfile = "UPDATE_PROFILE"
+	KeycloakEventUpdateEmail   = "UPDATE_EMAIL"
+	KeycloakEventDeleteAccount = "DELETE_ACCOUNT"
+)
+
+// Keycloak admin event operation types.
+const (
+	KeycloakOperationCreate = "CREATE"
+	KeycloakOperationUpdate = "UPDATE"
+	KeycloakOperationDelete = "DELETE"
+	KeycloakOperationAction = "ACTION"
+)
+
+// KeycloakEvent is a user event forwarded by the Keycloak event listener, e.g. a profile update.
+type KeycloakEvent struct {
+	ID string `json:"id,omitempty"`
+	// Time is in milliseconds since the epoch, see At.
+	Time      int64             `json:"time" binding:"required"`
+	Type      string            `json:"type" binding:"required"`
+	RealmID   string            `json:"realmId" binding:"required"`
+	ClientID  string            `json:"clientId,omitempty"`
+	UserID    string            `json:"userId,omitempty"`
+	SessionID string            `json:"sessionId,omitempty"`
+	IPAddress string            `json:"ipAddress,omitempty"`
+	Error     string            `json:"error,omitempty"`
+	Details   map[string]string `json:"details,omitempty"`
+}
+
+// At returns the time of the event.
+func (e *KeycloakEvent) At() time.Time {
+	return time.UnixMilli(e.Time)
+}
+
+// IsUserUpdate reports whether the event changed the profile of its user.
+func (e *KeycloakEvent) IsUserUpdate() bool {
+	return e.Error == "" && (e.Type == KeycloakEventUpdateProfile || e.Type == KeycloakEventUpdateEmail)
+}
+
+// KeycloakAuthDetails identify who performed an admin event.
+type KeycloakAuthDetails struct {
+	RealmID   string `json:"realmId"`
+	ClientID  string `json:"clientId,omitempty"`
+	UserID    string `json:"userId,omitempty"`
+	IPAddress string `json:"ipAddress,omitempty"`
+}
+
+// KeycloakAdminEvent is an admin event forwarded by the Keycloak event listener, e.g. an administrator
+// updating a user.
+type KeycloakAdminEvent struct {
+	ID string `json:"id,omitempty"`
+	// Time is in milliseconds since the epoch, see At.
+	Time          int64               `json:"time" binding:"required"`
+	RealmID       string              `json:"realmId" binding:"required"`
+	AuthDetails   KeycloakAuthDetails `json:"authDetails"`
+	OperationType string              `json:"operationType" binding:"required,oneof=CREATE UPDATE DELETE ACTION"`
+	// ResourceType is e.g. "USER" or "GROUP_MEMBERSHIP".
+	ResourceType string `json:"resourceType" binding:"required"`
+	// ResourcePath is e.g. "users/<id>".
+	ResourcePath string `json:"resourcePath" binding:"required"`
+	// Representation is the JSON of the resource after the operation, if the listener includes it.
+	Representation string `json:"representation,omitempty"`
+	Error          string `json:"error,omitempty"`
+}
+
+// At returns the time of the event.
+func (e *KeycloakAdminEvent) At() time.Time {
+	return time.UnixMilli(e.Time)
+}
+
+// UserID returns the ID of the user the event is about, or "" if it is not about a user.
+func (e *KeycloakAdminEvent) UserID() string {
+	rest, ok := strings.CutPrefix(e.ResourcePath, "users/")
+	if !ok {
+		return ""
+	}
+	id, _, _ := strings.Cut(rest, "/")
+	return id
+}
+
+// IsUserUpdate reports whether the event created or updated a user.
+func (e *KeycloakAdminEvent) IsUserUpdate() bool {
+	return e.Error == "" && e.ResourceType == "USER" &&
+		(e.OperationType == KeycloakOperationCreate || e.OperationType == KeycloakOperationUpdate)
+}
+
+// DecodeRepresentation decodes Representation into v.
+func (e *KeycloakAdminEvent) DecodeRepresentation(v interface{}) error {
+	if e.Representation == "" {
+		return fmt.Errorf("admin event %s has no representation", e.ID)
+	}
+	if err := json.Unmarshal([]byte(e.Representation), v); err != nil {
+		return fmt.Errorf("failed to decode admin event representation: %w", err)
+	}
+	return nil
+}
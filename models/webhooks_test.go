@@ -0,0 +1,91 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const giteaRepository = `{"id": 7, "name": "recipes", "full_name": "dev-kitchen/recipes",
+	"owner": {"id": 2, "login": "dev-kitchen"}, "default_branch": "main", "stars_count": 3}`
+
+func TestGiteaWebhooks(t *testing.T) {
+	push := `{
+		"ref": "refs/heads/main",
+		"before": "1111111111111111111111111111111111111111",
+		"after": "2222222222222222222222222222222222222222",
+		"commits": [{"id": "2222222222222222222222222222222222222222", "message": "Add focaccia",
+			"author": {"name": "Ada", "email": "ada@example.com"}, "timestamp": "2026-01-02T03:04:05Z",
+			"added": ["focaccia.yaml"], "verification": null}],
+		"repository": ` + giteaRepository + `,
+		"pusher": {"id": 1, "login": "ada"},
+		"sender": {"id": 1, "login": "ada"},
+		"total_commits": 1
+	}`
+	event, err := ParseGiteaWebhook(GiteaEventPush, []byte(push))
+	if assert.NoError(t, err) && assert.IsType(t, &GiteaPushEvent{}, event) {
+		pushEvent := event.(*GiteaPushEvent)
+		assert.Equal(t, "main", pushEvent.Branch())
+		assert.False(t, pushEvent.Deleted())
+		assert.Equal(t, "dev-kitchen/recipes", pushEvent.Repository.FullName)
+		assert.Equal(t, []string{"focaccia.yaml"}, pushEvent.Commits[0].Added)
+	}
+
+	deleteTag := `{"ref": "refs/tags/v1", "before": "1111", "after": "0000000000000000000000000000000000000000",
+		"commits": [], "repository": ` + giteaRepository + `, "pusher": {"login": "ada"}, "sender": {"login": "ada"}}`
+	pushEvent, err := DecodeWebhook[GiteaPushEvent]([]byte(deleteTag))
+	if assert.NoError(t, err) {
+		assert.Empty(t, pushEvent.Branch())
+		assert.True(t, pushEvent.Deleted())
+	}
+
+	pr := `{
+		"action": "opened",
+		"number": 5,
+		"pull_request": {"id": 50, "number": 5, "title": "Add focaccia", "state": "open", "merged": false,
+			"user": {"login": "ada"}, "head": {"ref": "focaccia", "sha": "2222"}, "base": {"ref": "main", "sha": "1111"}},
+		"repository": ` + giteaRepository + `,
+		"sender": {"login": "ada"}
+	}`
+	event, err = ParseGiteaWebhook(GiteaEventPullRequest, []byte(pr))
+	if assert.NoError(t, err) && assert.IsType(t, &GiteaPullRequestEvent{}, event) {
+		assert.Equal(t, "focaccia", event.(*GiteaPullRequestEvent).PullRequest.Head.Ref)
+	}
+
+	// Missing and mistyped fields are rejected.
+	_, err = ParseGiteaWebhook(GiteaEventPush, []byte(`{"ref": "refs/heads/main", "repository": `+giteaRepository+`}`))
+	assert.Error(t, err)
+	_, err = ParseGiteaWebhook(GiteaEventPullRequest, []byte(`{"action": "opened", "number": "5"}`))
+	assert.Error(t, err)
+	_, err = ParseGiteaWebhook("issues", []byte(`{}`))
+	assert.Error(t, err)
+}
+
+func TestKeycloakWebhooks(t *testing.T) {
+	event, err := DecodeWebhook[KeycloakEvent]([]byte(`{"time": 1767323045000, "type": "UPDATE_PROFILE",
+		"realmId": "dev-kitchen", "userId": "u1", "details": {"updated_first_name": "Ada"}, "authSessionId": "s"}`))
+	if assert.NoError(t, err) {
+		assert.True(t, event.IsUserUpdate())
+		assert.Equal(t, int64(1767323045), event.At().Unix())
+	}
+
+	admin, err := DecodeWebhook[KeycloakAdminEvent]([]byte(`{"time": 1767323045000, "realmId": "dev-kitchen",
+		"authDetails": {"realmId": "master", "userId": "admin"}, "operationType": "UPDATE", "resourceType": "USER",
+		"resourcePath": "users/u1", "representation": "{\"username\":\"ada\",\"email\":\"ada@example.com\"}"}`))
+	if assert.NoError(t, err) {
+		assert.True(t, admin.IsUserUpdate())
+		assert.Equal(t, "u1", admin.UserID())
+		var user struct {
+			Username string `json:"username"`
+		}
+		if assert.NoError(t, admin.DecodeRepresentation(&user)) {
+			assert.Equal(t, "ada", user.Username)
+		}
+	}
+
+	_, err = DecodeWebhook[KeycloakAdminEvent]([]byte(`{"time": 1, "realmId": "r", "operationType": "PATCH",
+		"resourceType": "USER", "resourcePath": "users/u1"}`))
+	assert.Error(t, err)
+	_, err = DecodeWebhook[KeycloakEvent]([]byte(`{"type": "LOGIN", "realmId": "r"}`))
+	assert.Error(t, err)
+}
@@ -0,0 +1,117 @@
+// Package natsrpc serves request-reply calls over core NATS, for internal calls that should avoid the
+// overhead of an HTTP hop. Requests mirror HTTP: a method and path, a JSON body, and a status code in the
+// reply, so clients.NATSClient maps replies through the same error handling as HTTP responses.
+//
+// A call to path "/recipes/42" on a service served under subject prefix "rpc.recipe-service" is sent to
+// subject "rpc.recipe-service.recipes.42".
+package natsrpc
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hkinc45/dev-kitchen-go-common/correlation"
+	"github.com/hkinc45/dev-kitchen-go-common/errors"
+	"github.com/hkinc45/dev-kitchen-go-common/logging"
+	"github.com/nats-io/nats.go"
+)
+
+// Headers carrying the HTTP-like parts of a call.
+const (
+	MethodHeader = "Dev-Kitchen-Rpc-Method"
+	PathHeader   = "Dev-Kitchen-Rpc-Path"
+	StatusHeader = "Dev-Kitchen-Rpc-Status"
+)
+
+// DefaultHandlerTimeout bounds each call handled by Serve.
+const DefaultHandlerTimeout = 30 * time.Second
+
+// Request is an incoming call.
+type Request struct {
+	Method string
+	Path   string
+	Header nats.Header
+	Data   []byte
+}
+
+// Decode decodes the JSON request body into v.
+func (r Request) Decode(v interface{}) error {
+	if len(r.Data) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(r.Data, v); err != nil {
+		return errors.NewBadRequestError(fmt.Sprintf("invalid request body: %v", err))
+	}
+	return nil
+}
+
+// Handler handles a call. The returned value is sent as the JSON reply; an *errors.APIError is sent
+// with its status code, and any other error as a generic 500.
+type Handler func(ctx context.Context, req Request) (interface{}, error)
+
+// Subject returns the subject a call to path is sent on under prefix.
+func Subject(prefix, path string) string {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return prefix
+	}
+	return prefix + "." + strings.ReplaceAll(path, "/", ".")
+}
+
+// Serve subscribes handler to every call under prefix, load-balanced across instances in queue.
+func Serve(nc *nats.Conn, prefix, queue string, handler Handler) (*nats.Subscription, error) {
+	sub, err := nc.QueueSubscribe(prefix+".>", queue, func(msg *nats.Msg) {
+		if err := msg.RespondMsg(Reply(msg, handler)); err != nil {
+			slog.Error("failed to send RPC reply", "error", err, "subject", msg.Subject)
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to subscribe RPC handler on %s: %w", prefix, err)
+	}
+	return sub, nil
+}
+
+// Reply runs handler for msg and builds the reply message.
+func Reply(msg *nats.Msg, handler Handler) *nats.Msg {
+	ctx := correlation.Extract(context.Background(), msg.Header.Get)
+	ctx, cancel := context.WithTimeout(ctx, DefaultHandlerTimeout)
+	defer cancel()
+
+	req := Request{
+		Method: msg.Header.Get(MethodHeader),
+		Path:   msg.Header.Get(PathHeader),
+		Header: msg.Header,
+		Data:   msg.Data,
+	}
+	result, err := handler(ctx, req)
+
+	reply := nats.NewMsg(msg.Reply)
+	status := http.StatusOK
+	var body interface{} = result
+	if err != nil {
+		var apiErr *errors.APIError
+		if stderrors.As(err, &apiErr) {
+			status, body = apiErr.StatusCode, apiErr
+		} else {
+			logging.FromContext(ctx).Error("RPC handler failed", "error", err, "method", req.Method, "path", req.Path)
+			status, body = http.StatusInternalServerError, errors.NewInternalServerError(err.Error())
+		}
+	}
+
+	if body != nil {
+		data, marshalErr := json.Marshal(body)
+		if marshalErr != nil {
+			status, data = http.StatusInternalServerError, []byte(`{"error":"failed to encode reply"}`)
+		}
+		reply.Data = data
+	}
+	reply.Header.Set(StatusHeader, strconv.Itoa(status))
+	return reply
+}
@@ -0,0 +1,86 @@
+package resource_types
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+)
+
+var (
+	// hierarchyMu guards types, actions and parents.
+	hierarchyMu sync.RWMutex
+	// parents maps resource types to the type they belong to. Permissions granted on a parent are inherited by
+	// its children.
+	parents = map[string]string{
+		Recipe:        Project,
+		VCSConnection: Project,
+		Secret:        UserSecretStore,
+	}
+)
+
+// RegisterParent declares that resources of type child belong to a resource of type parent, for types added
+// with RegisterType. Call it during initialization. It panics if child already has a parent or if the
+// declaration creates a cycle.
+func RegisterParent(child, parent string) {
+	hierarchyMu.Lock()
+	defer hierarchyMu.Unlock()
+	if existing, ok := parents[child]; ok {
+		panic(fmt.Sprintf("resource_types: %q already belongs to %q", child, existing))
+	}
+	for ancestor := parent; ancestor != ""; ancestor = parents[ancestor] {
+		if ancestor == child {
+			panic(fmt.Sprintf("resource_types: making %q belong to %q creates a cycle", child, parent))
+		}
+	}
+	parents[child] = parent
+}
+
+// Parent returns the type of the resources that resources of type t belong to, and false for top-level types.
+func Parent(t string) (string, bool) {
+	hierarchyMu.RLock()
+	defer hierarchyMu.RUnlock()
+	parent, ok := parents[t]
+	return parent, ok
+}
+
+// Ancestors returns the parent of t, its parent and so on up to a top-level type, e.g. [project] for recipe.
+func Ancestors(t string) []string {
+	hierarchyMu.RLock()
+	defer hierarchyMu.RUnlock()
+	var ancestors []string
+	for parent, ok := parents[t]; ok; parent, ok = parents[parent] {
+		ancestors = append(ancestors, parent)
+	}
+	return ancestors
+}
+
+// Children returns the types whose resources belong directly to a resource of type t, sorted.
+func Children(t string) []string {
+	hierarchyMu.RLock()
+	defer hierarchyMu.RUnlock()
+	var children []string
+	for child, parent := range parents {
+		if parent == t {
+			children = append(children, child)
+		}
+	}
+	sort.Strings(children)
+	return children
+}
+
+// Hierarchy returns the parent of every type that has one, for declaring resources to the auth-service.
+func Hierarchy() map[string]string {
+	hierarchyMu.RLock()
+	defer hierarchyMu.RUnlock()
+	hierarchy := make(map[string]string, len(parents))
+	for child, parent := range parents {
+		hierarchy[child] = parent
+	}
+	return hierarchy
+}
+
+// InheritsFrom reports whether permissions on resources of type ancestor apply to resources of type t.
+func InheritsFrom(t, ancestor string) bool {
+	return slices.Contains(Ancestors(t), ancestor)
+}
@@ -0,0 +1,27 @@
+package resource_types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHierarchy(t *testing.T) {
+	parent, ok := Parent(Recipe)
+	assert.True(t, ok)
+	assert.Equal(t, Project, parent)
+	_, ok = Parent(Project)
+	assert.False(t, ok)
+	assert.Equal(t, []string{Recipe, VCSConnection}, Children(Project))
+	assert.True(t, InheritsFrom(Secret, UserSecretStore))
+	assert.False(t, InheritsFrom(Project, Recipe))
+
+	RegisterType("recipe-run")
+	RegisterParent("recipe-run", Recipe)
+	assert.NoError(t, ValidateType("recipe-run"))
+	assert.Equal(t, []string{Recipe, Project}, Ancestors("recipe-run"))
+	assert.Equal(t, Recipe, Hierarchy()["recipe-run"])
+
+	assert.Panics(t, func() { RegisterParent(Recipe, Secret) })
+	assert.Panics(t, func() { RegisterParent(Project, "recipe-run") })
+}
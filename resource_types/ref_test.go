@@ -0,0 +1,69 @@
+package resource_types
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRef(t *testing.T) {
+	id := uuid.MustParse("0b7c6f1e-8d2a-4f43-9a57-2f1a4c9e3b10")
+	ref := NewRef(Project, id)
+	assert.Equal(t, "project:0b7c6f1e-8d2a-4f43-9a57-2f1a4c9e3b10", ref.String())
+	assert.Equal(t, "project:read", ref.Scope(ActionRead))
+	assert.NoError(t, ref.Validate())
+
+	parsed, err := ParseRef(ref.String())
+	if assert.NoError(t, err) {
+		assert.Equal(t, ref, parsed)
+	}
+	parsed, err = ParseRef("user-secret-store:" + id.String())
+	if assert.NoError(t, err) {
+		assert.Equal(t, UserSecretStore, parsed.Type)
+	}
+	for _, s := range []string{"", "project", "project:42", "projects:" + id.String(), "project:" + uuid.Nil.String()} {
+		_, err := ParseRef(s)
+		assert.Error(t, err, s)
+	}
+
+	event := struct {
+		Resource Ref            `json:"resource"`
+		Parents  map[Ref]string `json:"parents,omitempty"`
+	}{Resource: ref}
+	data, err := json.Marshal(event)
+	if assert.NoError(t, err) {
+		assert.JSONEq(t, `{"resource":"project:0b7c6f1e-8d2a-4f43-9a57-2f1a4c9e3b10"}`, string(data))
+	}
+	event.Resource = Ref{}
+	if assert.NoError(t, json.Unmarshal(data, &event)) {
+		assert.Equal(t, ref, event.Resource)
+	}
+	assert.Error(t, json.Unmarshal([]byte(`{"resource":"recipe:nope"}`), &event))
+	assert.True(t, Ref{}.IsZero())
+}
+
+func TestRefDecoding(t *testing.T) {
+	type event struct {
+		Resource Ref `json:"resource"`
+	}
+
+	// An unset ref round-trips.
+	data, err := json.Marshal(event{})
+	if assert.NoError(t, err) {
+		assert.JSONEq(t, `{"resource":""}`, string(data))
+	}
+	decoded := event{Resource: NewRef(Project, uuid.New())}
+	if assert.NoError(t, json.Unmarshal(data, &decoded)) {
+		assert.True(t, decoded.Resource.IsZero())
+	}
+
+	// Types registered only by other services decode, and are rejected by Validate.
+	id := uuid.New()
+	if assert.NoError(t, json.Unmarshal([]byte(`{"resource":"pipeline:`+id.String()+`"}`), &decoded)) {
+		assert.Equal(t, Ref{Type: "pipeline", ID: id}, decoded.Resource)
+		assert.Error(t, decoded.Resource.Validate())
+	}
+	assert.Error(t, json.Unmarshal([]byte(`{"resource":":`+id.String()+`"}`), &decoded))
+}
@@ -0,0 +1,39 @@
+package resource_types
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScopes(t *testing.T) {
+	assert.Equal(t, "project:read", Scope(Project, ActionRead))
+	assert.Equal(t, "user-secret-store:manage-members", Scope(UserSecretStore, ActionManageMembers))
+
+	resourceType, action, err := ParseScope("vcs-connection:write")
+	if assert.NoError(t, err) {
+		assert.Equal(t, VCSConnection, resourceType)
+		assert.Equal(t, ActionWrite, action)
+	}
+
+	for _, scope := range []string{"project:reed", "projects:read", "project", "", ":read", "project:"} {
+		assert.Error(t, ValidateScope(scope), scope)
+	}
+	assert.NoError(t, ValidateType(Recipe))
+	assert.Error(t, ValidateType("recipes"))
+}
+
+func TestRegisterAction(t *testing.T) {
+	assert.Error(t, ValidateScope("recipe:execute"))
+	RegisterAction("execute")
+	RegisterAction("execute")
+	assert.NoError(t, ValidateScope("recipe:execute"))
+	assert.Equal(t, 1, strings.Count(strings.Join(Actions(), " "), "execute"))
+
+	// The accessors return copies, so callers cannot register behind the lock.
+	types := Types()
+	types[0] = "hijacked"
+	assert.Equal(t, Project, Types()[0])
+	assert.Error(t, ValidateType("hijacked"))
+}
@@ -0,0 +1,175 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// backpressureEntry is the value stored in the backpressure bucket for an overloaded consumer.
+type backpressureEntry struct {
+	Subject string        `json:"subject"`
+	Delay   time.Duration `json:"delay"`
+}
+
+var (
+	wildcardKeyReplacer = strings.NewReplacer("*", "_any_", ">", "_all_")
+	invalidKVKeyChars   = regexp.MustCompile(`[^-/_=.a-zA-Z0-9]`)
+)
+
+// Backpressure lets overloaded consumers ask publishers to slow down, using a JetStream KV bucket as the
+// signal channel. Consumers raise a per-subject delay (see Config.Backpressure); publishers configured with
+// PublisherConfig.Backpressure wait that long before publishing to a matching subject.
+type Backpressure struct {
+	kv      nats.KeyValue
+	watcher nats.KeyWatcher
+	mu      sync.RWMutex
+	signals map[string]backpressureEntry
+}
+
+// NewBackpressure creates a Backpressure signal on the given bucket and starts watching it for changes.
+func NewBackpressure(kv nats.KeyValue) (*Backpressure, error) {
+	watcher, err := kv.WatchAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to watch backpressure bucket: %w", err)
+	}
+	b := &Backpressure{
+		kv:      kv,
+		watcher: watcher,
+		signals: make(map[string]backpressureEntry),
+	}
+	go b.watch()
+	return b, nil
+}
+
+// Raise signals that consumers of subject (wildcards allowed) are overloaded and publishers should wait delay.
+func (b *Backpressure) Raise(subject string, delay time.Duration) error {
+	value, err := json.Marshal(backpressureEntry{Subject: subject, Delay: delay})
+	if err != nil {
+		return err
+	}
+	if _, err := b.kv.Put(backpressureKey(subject), value); err != nil {
+		return fmt.Errorf("failed to raise backpressure for subject %s: %w", subject, err)
+	}
+	slog.Warn("raised backpressure", "subject", subject, "delay", delay)
+	return nil
+}
+
+// Clear withdraws the backpressure signal for subject.
+func (b *Backpressure) Clear(subject string) error {
+	if err := b.kv.Delete(backpressureKey(subject)); err != nil {
+		return fmt.Errorf("failed to clear backpressure for subject %s: %w", subject, err)
+	}
+	slog.Info("cleared backpressure", "subject", subject)
+	return nil
+}
+
+// Delay returns how long a publisher should wait before publishing to subject.
+func (b *Backpressure) Delay(subject string) time.Duration {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	var delay time.Duration
+	for _, signal := range b.signals {
+		if signal.Delay > delay && subjectMatches(signal.Subject, subject) {
+			delay = signal.Delay
+		}
+	}
+	return delay
+}
+
+// Wait blocks for the delay signaled for subject, or until ctx is done.
+func (b *Backpressure) Wait(ctx context.Context, subject string) error {
+	delay := b.Delay(subject)
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Stop stops watching the bucket.
+func (b *Backpressure) Stop() error {
+	return b.watcher.Stop()
+}
+
+func (b *Backpressure) watch() {
+	for entry := range b.watcher.Updates() {
+		if entry == nil {
+			continue // Marks the end of the initial values
+		}
+		deleted := entry.Operation() != nats.KeyValuePut
+		b.apply(entry.Key(), entry.Value(), deleted)
+	}
+}
+
+// apply updates the local view of the signals with a bucket change.
+func (b *Backpressure) apply(key string, value []byte, deleted bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if deleted {
+		delete(b.signals, key)
+		return
+	}
+	var signal backpressureEntry
+	if err := json.Unmarshal(value, &signal); err != nil {
+		slog.Warn("ignoring malformed backpressure signal", "error", err, "key", key)
+		return
+	}
+	b.signals[key] = signal
+}
+
+// backpressureKey maps a subject pattern to a valid KV key.
+func backpressureKey(subject string) string {
+	return invalidKVKeyChars.ReplaceAllString(wildcardKeyReplacer.Replace(subject), "_")
+}
+
+// updateBackpressure raises or clears the subscriber's backpressure signal based on the consumer's pending count.
+func (ps *PullSubscriber) updateBackpressure(msg *nats.Msg) {
+	if ps.config.Backpressure == nil || ps.config.BackpressureThreshold <= 0 {
+		return
+	}
+	meta, err := msg.Metadata()
+	if err != nil {
+		return
+	}
+
+	ps.mu.Lock()
+	raised := ps.backpressureRaised
+	ps.mu.Unlock()
+
+	threshold := uint64(ps.config.BackpressureThreshold)
+	switch {
+	case !raised && meta.NumPending >= threshold:
+		if err := ps.config.Backpressure.Raise(ps.config.Subject, ps.config.BackpressureDelay); err != nil {
+			slog.Error("failed to raise backpressure", "error", err, "subject", ps.config.Subject)
+			return
+		}
+	case raised && meta.NumPending < threshold/2:
+		// Clear with hysteresis so the signal does not flap around the threshold.
+		if err := ps.config.Backpressure.Clear(ps.config.Subject); err != nil {
+			slog.Error("failed to clear backpressure", "error", err, "subject", ps.config.Subject)
+			return
+		}
+	default:
+		return
+	}
+
+	ps.mu.Lock()
+	ps.backpressureRaised = !raised
+	ps.mu.Unlock()
+}
@@ -0,0 +1,109 @@
+package worker
+
+import (
+	"log/slog"
+	"sync"
+
+	"github.com/nats-io/nats.go"
+)
+
+// ackState tracks a delivered message under the AckAll policy.
+type ackState int
+
+const (
+	ackPending ackState = iota
+	ackSucceeded
+	ackFailed
+)
+
+type ackEntry struct {
+	msg        *nats.Msg
+	streamSeq  uint64
+	state      ackState
+	redeliver  bool // the server will deliver a failed message again
+	superseded bool // a later delivery of the same message has been fetched
+}
+
+// batchAcker acknowledges messages for consumers using the AckAll policy. Because an ACK under AckAll
+// covers every earlier delivery, it only ACKs the newest message below which all deliveries have completed,
+// so a single ACK settles a whole group of messages.
+//
+// A failed message that will be redelivered blocks the ACK floor until its redelivery is fetched; until then
+// its original delivery is still pending on the server and would otherwise be swept up by a later ACK.
+type batchAcker struct {
+	mu       sync.Mutex
+	inflight []*ackEntry
+	ack      func(msg *nats.Msg) error
+	// acked, if set, is called with the successful messages a batch ACK settled, once it has been sent.
+	acked func(msgs []*nats.Msg)
+}
+
+func newBatchAcker(ack func(msg *nats.Msg) error, acked func(msgs []*nats.Msg)) *batchAcker {
+	return &batchAcker{ack: ack, acked: acked}
+}
+
+// track registers a fetched message. Messages must be tracked in the order they were delivered.
+func (b *batchAcker) track(msg *nats.Msg) {
+	entry := &ackEntry{msg: msg}
+	if meta, err := msg.Metadata(); err == nil {
+		entry.streamSeq = meta.Sequence.Stream
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, earlier := range b.inflight {
+		if earlier.streamSeq == entry.streamSeq {
+			earlier.superseded = true
+		}
+	}
+	b.inflight = append(b.inflight, entry)
+}
+
+// succeeded records that msg was processed successfully and ACKs the group it completes, if any.
+func (b *batchAcker) succeeded(msg *nats.Msg) error {
+	return b.complete(msg, ackSucceeded, false)
+}
+
+// failed records that msg was NAKed or terminated. redeliver reports whether the server will deliver it again.
+func (b *batchAcker) failed(msg *nats.Msg, redeliver bool) {
+	_ = b.complete(msg, ackFailed, redeliver)
+}
+
+func (b *batchAcker) complete(msg *nats.Msg, state ackState, redeliver bool) error {
+	b.mu.Lock()
+	var floor *nats.Msg
+	var settled []*nats.Msg
+	for _, entry := range b.inflight {
+		if entry.msg == msg {
+			entry.state = state
+			entry.redeliver = redeliver
+			break
+		}
+	}
+
+	// Advance past every completed message at the head of the queue.
+	for len(b.inflight) > 0 {
+		head := b.inflight[0]
+		if head.state == ackPending || (head.state == ackFailed && head.redeliver && !head.superseded) {
+			break
+		}
+		if head.state == ackSucceeded {
+			floor = head.msg
+			settled = append(settled, head.msg)
+		}
+		b.inflight = b.inflight[1:]
+	}
+	b.mu.Unlock()
+
+	if floor == nil {
+		return nil
+	}
+	if err := b.ack(floor); err != nil {
+		return err
+	}
+	slog.Debug("batch ACKed messages", "subject", floor.Subject, "count", len(settled))
+	if b.acked != nil {
+		b.acked(settled)
+	}
+	return nil
+}
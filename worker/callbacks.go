@@ -0,0 +1,56 @@
+package worker
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// DropReason describes why a message left the worker without being processed successfully.
+type DropReason string
+
+const (
+	// DropQuarantined means the message was routed to the quarantine subject.
+	DropQuarantined DropReason = "quarantined"
+	// DropTerminated means the message was terminated and will not be redelivered.
+	DropTerminated DropReason = "terminated"
+	// DropExhausted means the message failed on its final delivery attempt and was not quarantined.
+	DropExhausted DropReason = "exhausted"
+)
+
+// fail handles a message that could not be processed: it notifies OnError, then quarantines the message
+// if it was on its final delivery attempt or err is terminal (see OutcomeOf), or NAKs it for redelivery after
+// delay, unless err requests another with RetryAfter.
+func (ps *PullSubscriber) fail(msg *nats.Msg, err error, delay time.Duration) {
+	if ps.config.OnError != nil {
+		ps.config.OnError(msg, err)
+	}
+
+	final := ps.isFinalDelivery(msg)
+	terminal := OutcomeOf(err) == OutcomeTerminate
+	if (final || terminal) && ps.config.QuarantineSubject != "" {
+		ps.quarantine(msg, err)
+		return
+	}
+	if terminal {
+		if termErr := ps.term(msg); termErr != nil {
+			slog.Error("failed to TERM message", "error", termErr, "subject", msg.Subject)
+			return
+		}
+		ps.drop(msg, DropTerminated, err)
+		return
+	}
+
+	_ = ps.nak(msg, retryDelay(err, delay))
+	if final {
+		ps.drop(msg, DropExhausted, err)
+	}
+}
+
+// drop notifies OnDrop that a message will not be processed again by this consumer.
+func (ps *PullSubscriber) drop(msg *nats.Msg, reason DropReason, err error) {
+	if ps.config.OnDrop != nil {
+		ps.config.OnDrop(msg, reason, err)
+	}
+}
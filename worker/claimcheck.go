@@ -0,0 +1,125 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nats-io/nats.go"
+)
+
+// ClaimCheckHeader carries the name of the stored payload when a message was published as a claim check.
+const ClaimCheckHeader = "Dev-Kitchen-Claim-Check"
+
+// DefaultClaimCheckThreshold is the payload size above which PublishWithClaimCheck offloads the payload.
+// It leaves headroom below the 1MB NATS default max payload for headers and protocol overhead.
+const DefaultClaimCheckThreshold = 900 * 1024
+
+// PayloadStore is the storage backend used for claim-check payloads.
+// The JetStream Object Store implementation is provided by NewObjectStorePayloadStore;
+// services can plug in other backends (e.g., S3) by implementing this interface.
+type PayloadStore interface {
+	Put(ctx context.Context, name string, data []byte) error
+	Get(ctx context.Context, name string) ([]byte, error)
+	Delete(ctx context.Context, name string) error
+}
+
+// objectStorePayloadStore adapts a JetStream Object Store bucket to the PayloadStore interface.
+type objectStorePayloadStore struct {
+	store nats.ObjectStore
+}
+
+// NewObjectStorePayloadStore creates a PayloadStore backed by a JetStream Object Store bucket.
+func NewObjectStorePayloadStore(store nats.ObjectStore) PayloadStore {
+	return &objectStorePayloadStore{store: store}
+}
+
+func (s *objectStorePayloadStore) Put(ctx context.Context, name string, data []byte) error {
+	_, err := s.store.PutBytes(name, data, nats.Context(ctx))
+	return err
+}
+
+func (s *objectStorePayloadStore) Get(ctx context.Context, name string) ([]byte, error) {
+	return s.store.GetBytes(name, nats.Context(ctx))
+}
+
+func (s *objectStorePayloadStore) Delete(_ context.Context, name string) error {
+	return s.store.Delete(name)
+}
+
+// PublishWithClaimCheck publishes data to subject, offloading it to the payload store when it exceeds threshold.
+// Offloaded messages carry an empty body and the object name in the ClaimCheckHeader; the worker
+// transparently fetches the payload before invoking the handler and deletes it after a successful ACK.
+// A threshold of zero or less uses DefaultClaimCheckThreshold.
+func PublishWithClaimCheck(ctx context.Context, js nats.JetStreamContext, store PayloadStore, subject string, data []byte, threshold int) (*nats.PubAck, error) {
+	if threshold <= 0 {
+		threshold = DefaultClaimCheckThreshold
+	}
+
+	msg := NewMsg(ctx, subject, nil)
+	if len(data) <= threshold {
+		msg.Data = data
+		return js.PublishMsg(msg, nats.Context(ctx))
+	}
+
+	name := fmt.Sprintf("%s/%s", subject, uuid.NewString())
+	if err := store.Put(ctx, name, data); err != nil {
+		return nil, fmt.Errorf("failed to store claim-check payload for subject %s: %w", subject, err)
+	}
+	msg.Header.Set(ClaimCheckHeader, name)
+
+	ack, err := js.PublishMsg(msg, nats.Context(ctx))
+	if err != nil {
+		// The reference was never published, so nobody will ever clean the payload up.
+		if delErr := store.Delete(ctx, name); delErr != nil {
+			slog.Warn("failed to delete orphaned claim-check payload", "error", delErr, "object", name)
+		}
+		return nil, fmt.Errorf("failed to publish claim-check reference for subject %s: %w", subject, err)
+	}
+	return ack, nil
+}
+
+// resolveClaimCheck replaces the message body with the stored payload if the message is a claim check.
+// It returns the object name so the payload can be released once the message is ACKed.
+func (ps *PullSubscriber) resolveClaimCheck(ctx context.Context, msg *nats.Msg) (string, error) {
+	return resolveClaimCheck(ctx, ps.config.PayloadStore, msg)
+}
+
+// resolveClaimCheck replaces the message body with its payload from store if the message is a claim check.
+func resolveClaimCheck(ctx context.Context, store PayloadStore, msg *nats.Msg) (string, error) {
+	name := msg.Header.Get(ClaimCheckHeader)
+	if name == "" {
+		return "", nil
+	}
+	if store == nil {
+		return "", fmt.Errorf("received claim-check message %q but no payload store is configured", name)
+	}
+
+	data, err := store.Get(ctx, name)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch claim-check payload %q: %w", name, err)
+	}
+	msg.Data = data
+	return name, nil
+}
+
+// releaseClaimChecks deletes the claim-check payloads of messages settled by a batch ACK.
+func (ps *PullSubscriber) releaseClaimChecks(msgs []*nats.Msg) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	for _, msg := range msgs {
+		ps.releaseClaimCheck(ctx, msg.Header.Get(ClaimCheckHeader))
+	}
+}
+
+// releaseClaimCheck deletes a claim-check payload after its message has been ACKed.
+func (ps *PullSubscriber) releaseClaimCheck(ctx context.Context, name string) {
+	if name == "" {
+		return
+	}
+	if err := ps.config.PayloadStore.Delete(ctx, name); err != nil {
+		slog.Warn("failed to delete claim-check payload", "error", err, "object", name, "subject", ps.config.Subject)
+	}
+}
@@ -0,0 +1,82 @@
+package worker
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/nats-io/nats.go"
+)
+
+// ensureConsumer creates the durable consumer, or reconciles an existing one with the desired configuration.
+// Drifted settings are updated in place; settings the server refuses to update (e.g., AckPolicy) produce
+// an error that lists the differences.
+func ensureConsumer(js nats.JetStreamContext, stream string, desired *nats.ConsumerConfig) error {
+	info, err := js.ConsumerInfo(stream, desired.Durable)
+	if errors.Is(err, nats.ErrConsumerNotFound) {
+		if _, err := js.AddConsumer(stream, desired); err != nil {
+			return fmt.Errorf("failed to create consumer for subject %s: %w", desired.FilterSubject, err)
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to look up consumer %s on stream %s: %w", desired.Durable, stream, err)
+	}
+
+	diff := diffConsumerConfig(info.Config, *desired)
+	if len(diff) == 0 {
+		return nil
+	}
+
+	slog.Warn("consumer config drift detected, updating", "stream", stream, "durable_name", desired.Durable, "diff", diff)
+	if _, err := js.UpdateConsumer(stream, desired); err != nil {
+		return fmt.Errorf("consumer %s on stream %s differs from the desired config (%s) and could not be updated: %w",
+			desired.Durable, stream, strings.Join(diff, "; "), err)
+	}
+	return nil
+}
+
+// diffConsumerConfig lists the settings in which an existing consumer differs from the desired one.
+// Zero-valued optional settings in desired are left to the server defaults and are not compared.
+func diffConsumerConfig(existing, desired nats.ConsumerConfig) []string {
+	var diff []string
+	add := func(field string, have, want any) {
+		diff = append(diff, fmt.Sprintf("%s: %v -> %v", field, have, want))
+	}
+
+	if existing.AckPolicy != desired.AckPolicy {
+		add("ack_policy", existing.AckPolicy, desired.AckPolicy)
+	}
+	if existing.FilterSubject != desired.FilterSubject {
+		add("filter_subject", existing.FilterSubject, desired.FilterSubject)
+	}
+	if existing.MaxDeliver != desired.MaxDeliver {
+		add("max_deliver", existing.MaxDeliver, desired.MaxDeliver)
+	}
+	if desired.AckWait != 0 && existing.AckWait != desired.AckWait {
+		add("ack_wait", existing.AckWait, desired.AckWait)
+	}
+	if desired.MaxAckPending != 0 && existing.MaxAckPending != desired.MaxAckPending {
+		add("max_ack_pending", existing.MaxAckPending, desired.MaxAckPending)
+	}
+	if desired.SampleFrequency != "" && existing.SampleFrequency != desired.SampleFrequency {
+		add("sample_freq", existing.SampleFrequency, desired.SampleFrequency)
+	}
+	if desired.InactiveThreshold != 0 && existing.InactiveThreshold != desired.InactiveThreshold {
+		add("inactive_threshold", existing.InactiveThreshold, desired.InactiveThreshold)
+	}
+	if desired.Replicas != 0 && existing.Replicas != desired.Replicas {
+		add("num_replicas", existing.Replicas, desired.Replicas)
+	}
+	if desired.MaxRequestBatch != 0 && existing.MaxRequestBatch != desired.MaxRequestBatch {
+		add("max_batch", existing.MaxRequestBatch, desired.MaxRequestBatch)
+	}
+	if desired.Description != "" && existing.Description != desired.Description {
+		add("description", existing.Description, desired.Description)
+	}
+	if len(desired.BackOff) != 0 && fmt.Sprint(existing.BackOff) != fmt.Sprint(desired.BackOff) {
+		add("backoff", existing.BackOff, desired.BackOff)
+	}
+	return diff
+}
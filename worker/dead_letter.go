@@ -0,0 +1,81 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// DeadLetter is a JSON-friendly view of a dead-lettered worker message, suitable for admin endpoints.
+type DeadLetter struct {
+	Sequence         uint64              `json:"sequence"`
+	Subject          string              `json:"subject"`
+	Reason           string              `json:"reason"`
+	OriginalStream   string              `json:"original_stream,omitempty"`
+	OriginalSequence uint64              `json:"original_sequence,omitempty"`
+	Consumer         string              `json:"consumer,omitempty"`
+	DeadLetteredAt   time.Time           `json:"dead_lettered_at"`
+	Headers          map[string][]string `json:"headers,omitempty"`
+	Data             []byte              `json:"data,omitempty"`
+}
+
+// DeadLetterClient lists, inspects and requeues messages the worker dead-lettered to a quarantine subject.
+// It is intended to be mounted behind an admin HTTP endpoint by each service.
+type DeadLetterClient struct {
+	quarantine *Quarantine
+}
+
+// NewDeadLetterClient creates a DeadLetterClient for the stream and subject configured as the
+// worker's QuarantineSubject.
+func NewDeadLetterClient(js nats.JetStreamContext, stream, subject string) *DeadLetterClient {
+	return &DeadLetterClient{quarantine: NewQuarantine(js, stream, subject)}
+}
+
+// List returns up to limit dead-lettered messages, oldest first.
+func (c *DeadLetterClient) List(ctx context.Context, limit int) ([]DeadLetter, error) {
+	msgs, err := c.quarantine.List(ctx, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	letters := make([]DeadLetter, 0, len(msgs))
+	for _, msg := range msgs {
+		letters = append(letters, newDeadLetter(msg))
+	}
+	return letters, nil
+}
+
+// Inspect returns a single dead-lettered message by its sequence.
+func (c *DeadLetterClient) Inspect(ctx context.Context, seq uint64) (*DeadLetter, error) {
+	msg, err := c.quarantine.Get(ctx, seq)
+	if err != nil {
+		return nil, err
+	}
+	letter := newDeadLetter(*msg)
+	return &letter, nil
+}
+
+// Requeue republishes the selected dead-lettered messages to their original subjects.
+func (c *DeadLetterClient) Requeue(ctx context.Context, seqs ...uint64) error {
+	return c.quarantine.Requeue(ctx, seqs...)
+}
+
+// Purge removes all dead-lettered messages.
+func (c *DeadLetterClient) Purge(ctx context.Context) error {
+	return c.quarantine.Purge(ctx)
+}
+
+func newDeadLetter(msg QuarantinedMessage) DeadLetter {
+	return DeadLetter{
+		Sequence:         msg.Sequence,
+		Subject:          msg.OriginalSubject,
+		Reason:           msg.Reason,
+		OriginalStream:   msg.OriginalStream,
+		OriginalSequence: msg.OriginalSequence,
+		Consumer:         msg.Consumer,
+		DeadLetteredAt:   msg.QuarantinedAt,
+		Headers:          msg.Header,
+		Data:             msg.Data,
+	}
+}
@@ -0,0 +1,56 @@
+package worker
+
+import (
+	"sort"
+	"time"
+)
+
+// KeyLockStats describes how much time messages spent waiting for a locking key.
+type KeyLockStats struct {
+	Key          string
+	Acquisitions uint64
+	TotalWait    time.Duration
+	MaxWait      time.Duration
+}
+
+// HottestKeys returns the n locking keys with the highest total wait time, most contended first.
+// A single key dominating this list means that resource is serializing the worker pool.
+func (ps *PullSubscriber) HottestKeys(n int) []KeyLockStats {
+	ps.lockStatsMu.Lock()
+	stats := make([]KeyLockStats, 0, len(ps.lockStats))
+	for _, s := range ps.lockStats {
+		stats = append(stats, *s)
+	}
+	ps.lockStatsMu.Unlock()
+
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].TotalWait > stats[j].TotalWait
+	})
+	if n > 0 && len(stats) > n {
+		stats = stats[:n]
+	}
+	return stats
+}
+
+// recordLockWait accounts the time a message waited for its key lock and reports it to the OnLockWait hook.
+func (ps *PullSubscriber) recordLockWait(key string, wait time.Duration) {
+	ps.lockStatsMu.Lock()
+	if ps.lockStats == nil {
+		ps.lockStats = make(map[string]*KeyLockStats)
+	}
+	s, ok := ps.lockStats[key]
+	if !ok {
+		s = &KeyLockStats{Key: key}
+		ps.lockStats[key] = s
+	}
+	s.Acquisitions++
+	s.TotalWait += wait
+	if wait > s.MaxWait {
+		s.MaxWait = wait
+	}
+	ps.lockStatsMu.Unlock()
+
+	if ps.config.OnLockWait != nil {
+		ps.config.OnLockWait(key, wait)
+	}
+}
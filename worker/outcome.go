@@ -0,0 +1,82 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	common_errors "github.com/hkinc45/dev-kitchen-go-common/errors"
+)
+
+// Outcome is how the worker settles a message its handler failed to process.
+type Outcome int
+
+const (
+	// OutcomeRetry NAKs the message for redelivery, until it exhausts its delivery attempts.
+	OutcomeRetry Outcome = iota
+	// OutcomeTerminate terminates the message at once, routing it to the quarantine subject if one is
+	// configured. Redelivering it would fail the same way.
+	OutcomeTerminate
+)
+
+// terminalError marks an error that must not be retried.
+type terminalError struct{ err error }
+
+func (e terminalError) Error() string { return e.err.Error() }
+func (e terminalError) Unwrap() error { return e.err }
+
+// retryError marks an error that is retried after a specific delay.
+type retryError struct {
+	err   error
+	delay time.Duration
+}
+
+func (e retryError) Error() string { return e.err.Error() }
+func (e retryError) Unwrap() error { return e.err }
+
+// Terminal marks err as terminal: a handler returning it has the message terminated instead of redelivered.
+func Terminal(err error) error {
+	if err == nil {
+		return nil
+	}
+	return terminalError{err}
+}
+
+// RetryAfter marks err as retryable after delay, overriding the worker's default NAK delay.
+func RetryAfter(err error, delay time.Duration) error {
+	if err == nil {
+		return nil
+	}
+	return retryError{err: err, delay: delay}
+}
+
+// OutcomeOf maps a handler error to an outcome, so message handlers share the error model of HTTP handlers:
+//   - errors marked with Terminal are terminated and those marked with RetryAfter retried;
+//   - errors implementing errors.RetryableError, such as those of the errors package, are retried if they
+//     are retryable (see errors.IsRetryable) and terminated otherwise: validation, not found or conflict
+//     errors, but also 500 Internal Server Errors and errors marked WithRetryable(false);
+//   - anything else, such as plain errors and timeouts, is retried.
+func OutcomeOf(err error) Outcome {
+	var terminal terminalError
+	if errors.As(err, &terminal) {
+		return OutcomeTerminate
+	}
+	var retry retryError
+	if errors.As(err, &retry) || errors.Is(err, context.DeadlineExceeded) {
+		return OutcomeRetry
+	}
+	var retryable common_errors.RetryableError
+	if !errors.As(err, &retryable) || retryable.Retryable() {
+		return OutcomeRetry
+	}
+	return OutcomeTerminate
+}
+
+// retryDelay returns the delay requested for err with RetryAfter, or fallback.
+func retryDelay(err error, fallback time.Duration) time.Duration {
+	var retry retryError
+	if errors.As(err, &retry) && retry.delay > 0 {
+		return retry.delay
+	}
+	return fallback
+}
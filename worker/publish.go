@@ -0,0 +1,163 @@
+package worker
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hkinc45/dev-kitchen-go-common/correlation"
+	"github.com/hkinc45/dev-kitchen-go-common/logging"
+	"github.com/nats-io/nats.go"
+)
+
+// NewMsg creates a message for subject that carries the correlation IDs (request ID, trace parent) from ctx,
+// so the handler consuming it logs under the same identifiers as the originating request.
+func NewMsg(ctx context.Context, subject string, data []byte) *nats.Msg {
+	msg := nats.NewMsg(subject)
+	msg.Data = data
+	correlation.Inject(ctx, msg.Header.Set)
+	return msg
+}
+
+// PublisherConfig holds the configuration for a Publisher.
+type PublisherConfig struct {
+	JetStream nats.JetStreamContext
+	// Inline makes Publish invoke the handlers registered with Register directly, in-process, instead of
+	// publishing to NATS. It is intended for running a single service locally without a JetStream container.
+	Inline bool
+	// MsgID, if set, derives the Nats-Msg-Id header of every published message that does not already
+	// carry one, so retried publishes are deduplicated by the stream (see EnsureStream). It must be
+	// deterministic: the same logical event must always produce the same ID. ContentMsgID is a ready-made choice.
+	MsgID func(subject string, data []byte) string
+	// Backpressure, if set, makes publishes wait for the delay signaled by overloaded consumers.
+	Backpressure *Backpressure
+}
+
+// ContentMsgID derives a message ID from a hash of the subject and payload.
+// Identical events published within the stream's duplicate window are therefore stored once.
+func ContentMsgID(subject string, data []byte) string {
+	h := sha256.New()
+	h.Write([]byte(subject))
+	h.Write([]byte{0})
+	h.Write(data)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Publisher publishes events to JetStream, or dispatches them in-process when running in inline mode.
+type Publisher struct {
+	config PublisherConfig
+	mu     sync.RWMutex
+	routes []inlineRoute
+}
+
+// inlineRoute binds a subject pattern to a handler in inline mode.
+type inlineRoute struct {
+	subject string
+	handler Handler
+}
+
+// NewPublisher creates a new Publisher.
+func NewPublisher(cfg PublisherConfig) (*Publisher, error) {
+	if !cfg.Inline && cfg.JetStream == nil {
+		return nil, errors.New("publisher requires a JetStream context unless inline mode is enabled")
+	}
+	if cfg.Inline {
+		slog.Warn("publisher running in inline mode, events will be handled in-process and not published to NATS")
+	}
+	return &Publisher{config: cfg}, nil
+}
+
+// Register binds handler to subject (wildcards allowed) for inline mode. It is a no-op otherwise,
+// so services can register their handlers unconditionally.
+func (p *Publisher) Register(subject string, handler Handler) {
+	if !p.config.Inline {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.routes = append(p.routes, inlineRoute{subject: subject, handler: handler})
+}
+
+// Publish publishes data to subject, carrying the correlation IDs from ctx.
+func (p *Publisher) Publish(ctx context.Context, subject string, data []byte) error {
+	return p.PublishMsg(ctx, NewMsg(ctx, subject, data))
+}
+
+// PublishMsg publishes a prepared message.
+func (p *Publisher) PublishMsg(ctx context.Context, msg *nats.Msg) error {
+	if p.config.MsgID != nil && msg.Header.Get(nats.MsgIdHdr) == "" {
+		if msg.Header == nil {
+			msg.Header = nats.Header{}
+		}
+		msg.Header.Set(nats.MsgIdHdr, p.config.MsgID(msg.Subject, msg.Data))
+	}
+
+	if p.config.Inline {
+		p.dispatchInline(msg)
+		return nil
+	}
+
+	if p.config.Backpressure != nil {
+		if err := p.config.Backpressure.Wait(ctx, msg.Subject); err != nil {
+			return fmt.Errorf("gave up publishing to subject %s under backpressure: %w", msg.Subject, err)
+		}
+	}
+
+	if _, err := p.config.JetStream.PublishMsg(msg, nats.Context(ctx)); err != nil {
+		return fmt.Errorf("failed to publish message to subject %s: %w", msg.Subject, err)
+	}
+	return nil
+}
+
+// dispatchInline runs every handler registered for the message's subject, sequentially and synchronously.
+// As with a real subscriber, handler failures are logged rather than returned to the publisher.
+func (p *Publisher) dispatchInline(msg *nats.Msg) {
+	p.mu.RLock()
+	routes := append([]inlineRoute(nil), p.routes...)
+	p.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+	ctx = correlation.Extract(ctx, msg.Header.Get)
+	logger := logging.FromContext(ctx)
+
+	handled := false
+	for _, route := range routes {
+		if !subjectMatches(route.subject, msg.Subject) {
+			continue
+		}
+		handled = true
+		if err := route.handler.Process(ctx, msg); err != nil {
+			logger.Error("inline handler failed to process message", "error", err, "subject", msg.Subject)
+		}
+	}
+	if !handled {
+		logger.Debug("no inline handler registered for subject", "subject", msg.Subject)
+	}
+}
+
+// subjectMatches reports whether subject matches the NATS subject pattern, which may contain
+// `*` (one token) and `>` (one or more trailing tokens) wildcards.
+func subjectMatches(pattern, subject string) bool {
+	patternTokens := strings.Split(pattern, ".")
+	subjectTokens := strings.Split(subject, ".")
+
+	for i, token := range patternTokens {
+		if token == ">" {
+			return len(subjectTokens) > i
+		}
+		if i >= len(subjectTokens) {
+			return false
+		}
+		if token != "*" && token != subjectTokens[i] {
+			return false
+		}
+	}
+	return len(patternTokens) == len(subjectTokens)
+}
@@ -0,0 +1,230 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// Headers attached to quarantined messages so they can be inspected and requeued later.
+const (
+	QuarantineSubjectHeader  = "Dev-Kitchen-Quarantine-Subject"
+	QuarantineReasonHeader   = "Dev-Kitchen-Quarantine-Reason"
+	QuarantineStreamHeader   = "Dev-Kitchen-Quarantine-Stream"
+	QuarantineSequenceHeader = "Dev-Kitchen-Quarantine-Sequence"
+	QuarantineConsumerHeader = "Dev-Kitchen-Quarantine-Consumer"
+	QuarantineTimeHeader     = "Dev-Kitchen-Quarantined-At"
+)
+
+// QuarantinedMessage is a poison message captured after it exhausted its delivery attempts.
+type QuarantinedMessage struct {
+	// Sequence is the message's sequence in the quarantine stream, used to requeue or inspect it.
+	Sequence         uint64
+	OriginalSubject  string
+	OriginalStream   string
+	OriginalSequence uint64
+	Consumer         string
+	Reason           string
+	QuarantinedAt    time.Time
+	Header           nats.Header
+	Data             []byte
+}
+
+// Quarantine manages poison messages published to a quarantine subject by the worker.
+// The quarantine subject must be captured by a JetStream stream.
+type Quarantine struct {
+	js      nats.JetStreamContext
+	stream  string
+	subject string
+	// browse opens the ephemeral consumer List reads from.
+	browse func(opts ...nats.SubOpt) (Source, error)
+}
+
+// NewQuarantine creates a Quarantine for the given stream and quarantine subject.
+func NewQuarantine(js nats.JetStreamContext, stream, subject string) *Quarantine {
+	q := &Quarantine{js: js, stream: stream, subject: subject}
+	q.browse = func(opts ...nats.SubOpt) (Source, error) {
+		sub, err := js.PullSubscribe(subject, "", opts...)
+		if err != nil {
+			return nil, err
+		}
+		return sub, nil
+	}
+	return q
+}
+
+// List returns up to limit quarantined messages, oldest first.
+func (q *Quarantine) List(ctx context.Context, limit int) ([]QuarantinedMessage, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+
+	sub, err := q.browse(nats.BindStream(q.stream), nats.DeliverAll(), nats.AckNone())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create quarantine browser for subject %s: %w", q.subject, err)
+	}
+	defer func() {
+		if err := sub.Unsubscribe(); err != nil {
+			slog.Warn("error during quarantine browser unsubscribe", "error", err, "subject", q.subject)
+		}
+	}()
+
+	var result []QuarantinedMessage
+	for len(result) < limit {
+		fetchCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+		msgs, err := sub.Fetch(limit-len(result), nats.Context(fetchCtx))
+		cancel()
+		if err != nil {
+			if errors.Is(err, nats.ErrTimeout) || errors.Is(err, context.DeadlineExceeded) {
+				break
+			}
+			return nil, fmt.Errorf("failed to fetch quarantined messages: %w", err)
+		}
+
+		pending := uint64(0)
+		for _, msg := range msgs {
+			meta, err := msg.Metadata()
+			if err != nil {
+				return nil, fmt.Errorf("failed to read quarantined message metadata: %w", err)
+			}
+			result = append(result, newQuarantinedMessage(meta.Sequence.Stream, msg.Header, msg.Data))
+			pending = meta.NumPending
+		}
+		if pending == 0 {
+			break
+		}
+	}
+	return result, nil
+}
+
+// Get returns a single quarantined message by its quarantine stream sequence.
+func (q *Quarantine) Get(ctx context.Context, seq uint64) (*QuarantinedMessage, error) {
+	raw, err := q.js.GetMsg(q.stream, seq, nats.Context(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get quarantined message %d: %w", seq, err)
+	}
+	qm := newQuarantinedMessage(raw.Sequence, raw.Header, raw.Data)
+	return &qm, nil
+}
+
+// Requeue republishes the selected quarantined messages to their original subjects and removes them from quarantine.
+func (q *Quarantine) Requeue(ctx context.Context, seqs ...uint64) error {
+	for _, seq := range seqs {
+		qm, err := q.Get(ctx, seq)
+		if err != nil {
+			return err
+		}
+		if qm.OriginalSubject == "" {
+			return fmt.Errorf("quarantined message %d has no original subject", seq)
+		}
+
+		// Without its Nats-Msg-Id, a requeue within the duplicate window is not dropped as a duplicate of the
+		// original message.
+		msg := nats.NewMsg(qm.OriginalSubject)
+		for key, values := range qm.Header {
+			if strings.HasPrefix(key, "Dev-Kitchen-Quarantine") || key == nats.MsgIdHdr {
+				continue
+			}
+			msg.Header[key] = values
+		}
+		msg.Data = qm.Data
+
+		ack, err := q.js.PublishMsg(msg, nats.Context(ctx))
+		if err != nil {
+			return fmt.Errorf("failed to requeue quarantined message %d to %s: %w", seq, qm.OriginalSubject, err)
+		}
+		if ack.Duplicate {
+			return fmt.Errorf("failed to requeue quarantined message %d to %s: dropped as a duplicate", seq, qm.OriginalSubject)
+		}
+		if err := q.js.DeleteMsg(q.stream, seq, nats.Context(ctx)); err != nil {
+			return fmt.Errorf("requeued quarantined message %d but failed to remove it: %w", seq, err)
+		}
+		slog.Info("requeued quarantined message", "sequence", seq, "subject", qm.OriginalSubject)
+	}
+	return nil
+}
+
+// Purge removes all messages from quarantine.
+func (q *Quarantine) Purge(ctx context.Context) error {
+	if err := q.js.PurgeStream(q.stream, &nats.StreamPurgeRequest{Subject: q.subject}, nats.Context(ctx)); err != nil {
+		return fmt.Errorf("failed to purge quarantine subject %s: %w", q.subject, err)
+	}
+	slog.Info("purged quarantine", "stream", q.stream, "subject", q.subject)
+	return nil
+}
+
+// newQuarantinedMessage decodes the quarantine headers of a stored message.
+func newQuarantinedMessage(seq uint64, header nats.Header, data []byte) QuarantinedMessage {
+	qm := QuarantinedMessage{
+		Sequence:        seq,
+		OriginalSubject: header.Get(QuarantineSubjectHeader),
+		OriginalStream:  header.Get(QuarantineStreamHeader),
+		Consumer:        header.Get(QuarantineConsumerHeader),
+		Reason:          header.Get(QuarantineReasonHeader),
+		Header:          header,
+		Data:            data,
+	}
+	qm.OriginalSequence, _ = strconv.ParseUint(header.Get(QuarantineSequenceHeader), 10, 64)
+	qm.QuarantinedAt, _ = time.Parse(time.RFC3339Nano, header.Get(QuarantineTimeHeader))
+	return qm
+}
+
+// newQuarantineMsg builds the message published to the quarantine subject for a poison message. The
+// Nats-Msg-Id is not copied: it would deduplicate the quarantined copies of messages published with the
+// same ID, and requeues of the message.
+func newQuarantineMsg(subject string, msg *nats.Msg, reason error) *nats.Msg {
+	qmsg := nats.NewMsg(subject)
+	for key, values := range msg.Header {
+		if key == nats.MsgIdHdr {
+			continue
+		}
+		qmsg.Header[key] = values
+	}
+	qmsg.Data = msg.Data
+
+	qmsg.Header.Set(QuarantineSubjectHeader, msg.Subject)
+	qmsg.Header.Set(QuarantineReasonHeader, reason.Error())
+	qmsg.Header.Set(QuarantineTimeHeader, time.Now().UTC().Format(time.RFC3339Nano))
+	if meta, err := msg.Metadata(); err == nil {
+		qmsg.Header.Set(QuarantineStreamHeader, meta.Stream)
+		qmsg.Header.Set(QuarantineConsumerHeader, meta.Consumer)
+		qmsg.Header.Set(QuarantineSequenceHeader, strconv.FormatUint(meta.Sequence.Stream, 10))
+	}
+	return qmsg
+}
+
+// isFinalDelivery reports whether the message is on its last allowed delivery attempt.
+func (ps *PullSubscriber) isFinalDelivery(msg *nats.Msg) bool {
+	meta, err := msg.Metadata()
+	if err != nil {
+		return false
+	}
+	return meta.NumDelivered >= uint64(ps.config.MaxDeliver)
+}
+
+// quarantine publishes a poison message to the quarantine subject and terminates it. If the publish
+// fails the message is NAKed so it can be quarantined on redelivery; on the final delivery there is
+// none, so it is reported to OnDrop as exhausted.
+func (ps *PullSubscriber) quarantine(msg *nats.Msg, reason error) {
+	qmsg := newQuarantineMsg(ps.config.QuarantineSubject, msg, reason)
+	if _, err := ps.config.JetStream.PublishMsg(qmsg); err != nil {
+		slog.Error("failed to quarantine message", "error", err, "subject", msg.Subject)
+		_ = ps.nak(msg, 15*time.Second)
+		if ps.isFinalDelivery(msg) {
+			ps.drop(msg, DropExhausted, errors.Join(reason, fmt.Errorf("failed to quarantine message: %w", err)))
+		}
+		return
+	}
+	if err := ps.term(msg); err != nil {
+		slog.Error("failed to TERM quarantined message", "error", err, "subject", msg.Subject)
+		return
+	}
+	ps.drop(msg, DropQuarantined, reason)
+	slog.Warn("quarantined poison message", "subject", msg.Subject, "quarantine_subject", ps.config.QuarantineSubject, "reason", reason)
+}
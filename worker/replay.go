@@ -0,0 +1,154 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// ReplayFrom selects where a replay starts in the stream.
+// Set either Sequence or Time; the zero value replays the stream from the beginning.
+type ReplayFrom struct {
+	Sequence uint64
+	Time     time.Time
+}
+
+// FromSequence starts a replay at the given stream sequence.
+func FromSequence(seq uint64) ReplayFrom {
+	return ReplayFrom{Sequence: seq}
+}
+
+// FromTime starts a replay at the first message stored at or after t.
+func FromTime(t time.Time) ReplayFrom {
+	return ReplayFrom{Time: t}
+}
+
+// ReplayOptions tunes a replay run.
+type ReplayOptions struct {
+	// DryRun marks the handler context so handlers can skip side effects (see IsDryRun).
+	DryRun bool
+	// BatchSize is the number of messages fetched per request. Defaults to 100.
+	BatchSize int
+	// MaxWait bounds each fetch. Defaults to 5 seconds.
+	MaxWait time.Duration
+	// PayloadStore resolves claim-check messages, as Config.PayloadStore does for subscribers. Payloads are
+	// left in place; those already released after the original delivery count as failures.
+	PayloadStore PayloadStore
+}
+
+// ReplayResult summarizes a replay run.
+type ReplayResult struct {
+	Processed    int
+	Failed       int
+	LastSequence uint64
+}
+
+type dryRunKey struct{}
+
+// IsDryRun reports whether the handler is being invoked by a dry-run replay.
+func IsDryRun(ctx context.Context) bool {
+	dryRun, _ := ctx.Value(dryRunKey{}).(bool)
+	return dryRun
+}
+
+// Replay re-runs historical events on subject through handler using an ephemeral consumer.
+// It stops once it reaches the last message that was in the stream when the replay started, so events
+// published during the replay are left to the regular subscribers. Handler failures are logged and counted
+// but do not stop the replay.
+func Replay(ctx context.Context, js nats.JetStreamContext, stream, subject string, from ReplayFrom, handler Handler, opts ReplayOptions) (*ReplayResult, error) {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 100
+	}
+	if opts.MaxWait == 0 {
+		opts.MaxWait = 5 * time.Second
+	}
+
+	info, err := js.StreamInfo(stream, nats.Context(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stream info for %s: %w", stream, err)
+	}
+	lastSeq := info.State.LastSeq
+
+	subOpts := []nats.SubOpt{nats.BindStream(stream), nats.AckExplicit()}
+	switch {
+	case from.Sequence > 0:
+		subOpts = append(subOpts, nats.StartSequence(from.Sequence))
+	case !from.Time.IsZero():
+		subOpts = append(subOpts, nats.StartTime(from.Time))
+	default:
+		subOpts = append(subOpts, nats.DeliverAll())
+	}
+
+	sub, err := js.PullSubscribe(subject, "", subOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create replay consumer for subject %s: %w", subject, err)
+	}
+	defer func() {
+		if err := sub.Unsubscribe(); err != nil {
+			slog.Warn("error during replay unsubscribe", "error", err, "subject", subject)
+		}
+	}()
+
+	slog.Info("starting replay", "stream", stream, "subject", subject, "last_sequence", lastSeq, "dry_run", opts.DryRun)
+	return replay(ctx, sub, subject, lastSeq, handler, opts)
+}
+
+// replay runs handler over the messages fetched from src until it reaches lastSeq or src runs dry.
+func replay(ctx context.Context, src Source, subject string, lastSeq uint64, handler Handler, opts ReplayOptions) (*ReplayResult, error) {
+	if opts.DryRun {
+		ctx = context.WithValue(ctx, dryRunKey{}, true)
+	}
+
+	result := &ReplayResult{}
+	for {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		msgs, err := src.Fetch(opts.BatchSize, nats.MaxWait(opts.MaxWait))
+		if err != nil {
+			if err == nats.ErrTimeout {
+				// Nothing left that matches the subject.
+				break
+			}
+			return result, fmt.Errorf("failed to fetch replay messages for subject %s: %w", subject, err)
+		}
+
+		done := false
+		for _, msg := range msgs {
+			meta, err := msg.Metadata()
+			if err != nil {
+				return result, fmt.Errorf("failed to read replay message metadata: %w", err)
+			}
+			if meta.Sequence.Stream > lastSeq {
+				done = true
+				break
+			}
+
+			if _, err := resolveClaimCheck(ctx, opts.PayloadStore, msg); err != nil {
+				slog.Error("failed to resolve claim check of replayed message", "error", err, "subject", msg.Subject, "sequence", meta.Sequence.Stream)
+				result.Failed++
+			} else if err := handler.Process(ctx, msg); err != nil {
+				slog.Error("handler failed to replay message", "error", err, "subject", msg.Subject, "sequence", meta.Sequence.Stream)
+				result.Failed++
+			} else {
+				result.Processed++
+			}
+			result.LastSequence = meta.Sequence.Stream
+			_ = msg.Ack()
+
+			if meta.NumPending == 0 || meta.Sequence.Stream == lastSeq {
+				done = true
+				break
+			}
+		}
+		if done {
+			break
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,72 @@
+package worker
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// DefaultDuplicateWindow is the deduplication window EnsureStream applies when the config does not set one.
+const DefaultDuplicateWindow = 2 * time.Minute
+
+// EnsureStream creates the stream described by cfg, or reconciles an existing stream with it. An existing
+// stream keeps its configuration, including settings tuned by operators such as MaxAge, Replicas and limits;
+// only its subjects and, if cfg sets one, its duplicate window are updated when they differ. cfg is not
+// modified.
+//
+// cfg.Duplicates sets the window in which JetStream drops messages whose Nats-Msg-Id it has already stored.
+// Combined with a deterministic PublisherConfig.MsgID, a publisher can safely retry a publish whose
+// acknowledgement was lost: the retry is dropped by the server instead of producing a second event.
+// Together with idempotent handlers this gives exactly-once-ish processing end to end; retries that
+// happen after the window has passed are not deduplicated.
+func EnsureStream(js nats.JetStreamContext, cfg *nats.StreamConfig) (*nats.StreamInfo, error) {
+	info, err := js.StreamInfo(cfg.Name)
+	if errors.Is(err, nats.ErrStreamNotFound) {
+		create := *cfg
+		if create.Duplicates == 0 {
+			create.Duplicates = DefaultDuplicateWindow
+		}
+		info, err = js.AddStream(&create)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create stream %s: %w", cfg.Name, err)
+		}
+		slog.Info("created stream", "stream", cfg.Name, "subjects", create.Subjects, "duplicate_window", create.Duplicates)
+		return info, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up stream %s: %w", cfg.Name, err)
+	}
+
+	update := info.Config
+	changed := false
+	if len(cfg.Subjects) > 0 && !sameSubjects(update.Subjects, cfg.Subjects) {
+		update.Subjects = slices.Clone(cfg.Subjects)
+		changed = true
+	}
+	if cfg.Duplicates != 0 && update.Duplicates != cfg.Duplicates {
+		update.Duplicates = cfg.Duplicates
+		changed = true
+	}
+	if !changed {
+		return info, nil
+	}
+
+	info, err = js.UpdateStream(&update)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update stream %s: %w", cfg.Name, err)
+	}
+	slog.Info("updated stream", "stream", cfg.Name, "subjects", update.Subjects, "duplicate_window", update.Duplicates)
+	return info, nil
+}
+
+// sameSubjects reports whether a and b hold the same subjects, in any order.
+func sameSubjects(a, b []string) bool {
+	a, b = slices.Clone(a), slices.Clone(b)
+	slices.Sort(a)
+	slices.Sort(b)
+	return slices.Equal(a, b)
+}
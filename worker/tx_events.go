@@ -0,0 +1,164 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/nats-io/nats.go"
+)
+
+// Outbox durably stores events as part of a database transaction, so events of a committed transaction
+// survive a crash between the commit and the publish. A separate relay is expected to publish events that
+// were never marked published.
+type Outbox interface {
+	// Save stores the events inside the surrounding transaction.
+	Save(ctx context.Context, events []*nats.Msg) error
+	// MarkPublished flags or removes stored events once they have been published. It is called after the
+	// transaction has committed, so it must not write through that transaction.
+	MarkPublished(ctx context.Context, events []*nats.Msg) error
+}
+
+// SQLExecutor is the part of *sql.DB and *sql.Tx that an Outbox writes through.
+type SQLExecutor interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// TxEvents buffers events raised during a database transaction and publishes them only after the
+// transaction commits, so handlers never observe events for rolled-back writes.
+//
+// Every buffered event carries a Nats-Msg-Id, so a relay re-publishing an event from the outbox after
+// a partial failure is deduplicated by the stream.
+type TxEvents struct {
+	publisher *Publisher
+	outbox    Outbox
+	// marker marks events published after the commit; it is outbox unless RunInTx binds one to the database.
+	marker Outbox
+	mu     sync.Mutex
+	events []*nats.Msg
+}
+
+// NewTxEvents creates an event buffer for a single transaction. outbox may be nil, in which case events
+// of a committed transaction are lost if publishing fails.
+func NewTxEvents(publisher *Publisher, outbox Outbox) *TxEvents {
+	return &TxEvents{publisher: publisher, outbox: outbox, marker: outbox}
+}
+
+// Publish buffers an event until the transaction commits.
+func (e *TxEvents) Publish(ctx context.Context, subject string, data []byte) {
+	e.PublishMsg(NewMsg(ctx, subject, data))
+}
+
+// PublishMsg buffers a prepared message until the transaction commits.
+func (e *TxEvents) PublishMsg(msg *nats.Msg) {
+	if msg.Header == nil {
+		msg.Header = nats.Header{}
+	}
+	if msg.Header.Get(nats.MsgIdHdr) == "" {
+		id := uuid.NewString()
+		if e.publisher.config.MsgID != nil {
+			id = e.publisher.config.MsgID(msg.Subject, msg.Data)
+		}
+		msg.Header.Set(nats.MsgIdHdr, id)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.events = append(e.events, msg)
+}
+
+// BeforeCommit saves the buffered events to the outbox. It must run inside the transaction.
+func (e *TxEvents) BeforeCommit(ctx context.Context) error {
+	e.mu.Lock()
+	events := append([]*nats.Msg(nil), e.events...)
+	e.mu.Unlock()
+
+	if e.outbox == nil || len(events) == 0 {
+		return nil
+	}
+	if err := e.outbox.Save(ctx, events); err != nil {
+		return fmt.Errorf("failed to save %d event(s) to the outbox: %w", len(events), err)
+	}
+	return nil
+}
+
+// AfterCommit publishes the buffered events and marks them published in the outbox.
+// Events that fail to publish are left in the outbox for the relay, and the errors are returned joined.
+func (e *TxEvents) AfterCommit(ctx context.Context) error {
+	e.mu.Lock()
+	events := e.events
+	e.events = nil
+	e.mu.Unlock()
+
+	var published []*nats.Msg
+	var errs []error
+	for _, msg := range events {
+		if err := e.publisher.PublishMsg(ctx, msg); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		published = append(published, msg)
+	}
+
+	if e.marker != nil && len(published) > 0 {
+		if err := e.marker.MarkPublished(ctx, published); err != nil {
+			// The relay will publish them again; the Nats-Msg-Id makes that harmless within the duplicate window.
+			slog.Warn("failed to mark events as published in the outbox", "error", err, "count", len(published))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Rollback discards the buffered events.
+func (e *TxEvents) Rollback() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.events = nil
+}
+
+// RunInTx runs fn in a database/sql transaction and publishes the events it buffers once the transaction
+// has committed. newOutbox, which may be nil, binds an Outbox to an executor: events are saved through one
+// bound to the transaction, and marked published through one bound to db, since the transaction is done by
+// then. Publish failures after a successful commit are logged rather than returned, since the write itself
+// succeeded.
+func RunInTx(ctx context.Context, db *sql.DB, publisher *Publisher, newOutbox func(q SQLExecutor) Outbox,
+	fn func(ctx context.Context, tx *sql.Tx, events *TxEvents) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	events := NewTxEvents(publisher, nil)
+	if newOutbox != nil {
+		events.outbox, events.marker = newOutbox(tx), newOutbox(db)
+	}
+
+	if err := fn(ctx, tx, events); err != nil {
+		events.Rollback()
+		if rbErr := tx.Rollback(); rbErr != nil {
+			slog.Error("failed to roll back transaction", "error", rbErr)
+		}
+		return err
+	}
+
+	if err := events.BeforeCommit(ctx); err != nil {
+		events.Rollback()
+		_ = tx.Rollback()
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		events.Rollback()
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	if err := events.AfterCommit(ctx); err != nil {
+		slog.Error("failed to publish events after commit", "error", err)
+	}
+	return nil
+}
@@ -2,11 +2,15 @@ package worker
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"sync"
 	"time"
 
+	"github.com/hkinc45/dev-kitchen-go-common/correlation"
+	"github.com/hkinc45/dev-kitchen-go-common/internal/ratelimit"
+	"github.com/hkinc45/dev-kitchen-go-common/logging"
 	"github.com/nats-io/nats.go"
 )
 
@@ -18,8 +22,49 @@ type Config struct {
 	BatchSize     int
 	MaxConcurrent int
 	MaxWait       time.Duration
+	MaxDeliver    int
 	Handler       Handler
 	JetStream     nats.JetStreamContext
+	// PayloadStore resolves claim-check messages published with PublishWithClaimCheck.
+	// It is only required when the subject carries claim-check references.
+	PayloadStore PayloadStore
+	// Filter is an optional hook evaluated before a worker slot is acquired.
+	// Messages for which it returns false are ACKed and skipped without invoking the handler.
+	Filter func(msg *nats.Msg) bool
+	// QuarantineSubject, when set, receives messages that fail on their final delivery attempt
+	// together with the failure reason. See Quarantine for listing and requeueing them.
+	QuarantineSubject string
+	// RateLimit caps processing at this many messages per second for the subscriber. Zero disables limiting.
+	RateLimit float64
+	// RateBurst is the number of messages that may start back to back before RateLimit applies. Defaults to 1.
+	RateBurst int
+	// AckAll switches the consumer to the AckAll policy and acknowledges messages in groups: a single ACK is
+	// sent once every earlier delivery has completed. This trades per-message granularity for throughput on
+	// very high-volume streams; successful messages behind a failed one may be redelivered, so handlers must
+	// be idempotent. Changing this on an existing durable requires recreating the consumer.
+	AckAll bool
+	// FetchMode selects how messages are pulled; see FetchMode. Defaults to FetchBlocking.
+	FetchMode FetchMode
+	// Heartbeat, if set, asks the server to send idle heartbeats during long fetches so a dead connection is
+	// detected before MaxWait elapses. It must be less than half of MaxWait; NewPullSubscriber rejects larger
+	// values.
+	Heartbeat time.Duration
+	// ConsumerOverrides, if set, is applied to the consumer configuration before it is created or reconciled,
+	// so advanced settings (sample frequency, inactive threshold, replicas, ...) can be tuned directly.
+	ConsumerOverrides func(cc *nats.ConsumerConfig)
+	// Backpressure, if set together with BackpressureThreshold, is raised while the consumer has more than
+	// BackpressureThreshold messages pending, asking publishers to wait BackpressureDelay before publishing.
+	Backpressure          *Backpressure
+	BackpressureThreshold int
+	BackpressureDelay     time.Duration
+	// OnError, if set, is called whenever a message fails to be processed, before it is NAKed or quarantined.
+	OnError func(msg *nats.Msg, err error)
+	// OnDrop, if set, is called when a message leaves the worker without being processed successfully
+	// (quarantined, terminated, or exhausted its delivery attempts), so services can page or emit metrics.
+	OnDrop func(msg *nats.Msg, reason DropReason, err error)
+	// OnLockWait, if set, is called with the time each message waited for its locking key, so services can
+	// export lock contention as a metric. See also PullSubscriber.HottestKeys.
+	OnLockWait func(key string, wait time.Duration)
 }
 
 // Handler is an interface that processing logic must implement.
@@ -31,62 +76,156 @@ type Handler interface {
 	GetLockingKey(msg *nats.Msg) (string, error)
 }
 
+// FetchMode selects how the dispatcher pulls messages from the consumer.
+type FetchMode int
+
+const (
+	// FetchBlocking requests a batch and dispatches it once the batch is full or MaxWait elapses.
+	FetchBlocking FetchMode = iota
+	// FetchStreaming dispatches each message as soon as it arrives while the pull request is open,
+	// which keeps latency low on low-traffic subjects even with a long MaxWait.
+	FetchStreaming
+)
+
+// batchFetcher is implemented by sources that can stream a pull request's messages, like *nats.Subscription.
+type batchFetcher interface {
+	FetchBatch(batch int, opts ...nats.PullOpt) (nats.MessageBatch, error)
+}
+
+// DefaultMaxDeliver is the number of delivery attempts a message gets when Config.MaxDeliver is unset.
+const DefaultMaxDeliver = 5
+
+// Source delivers fetched messages to the dispatcher. *nats.Subscription satisfies it.
+type Source interface {
+	Fetch(batch int, opts ...nats.PullOpt) ([]*nats.Msg, error)
+	Unsubscribe() error
+}
+
+// Acknowledger settles messages with the server. By default the worker calls the corresponding
+// *nats.Msg methods; test harnesses can substitute their own implementation.
+type Acknowledger interface {
+	Ack(msg *nats.Msg) error
+	Nak(msg *nats.Msg, delay time.Duration) error
+	Term(msg *nats.Msg) error
+}
+
 // PullSubscriber manages a pool of workers to process messages from a NATS JetStream pull subscription.
 type PullSubscriber struct {
 	config     Config
-	sub        *nats.Subscription
+	sub        Source
+	acker      Acknowledger
 	mu         sync.Mutex
 	active     bool
 	keyLocks   map[string]*sync.Mutex
 	keyLocksMu sync.RWMutex
 	semaphore  chan struct{}
+	limiter    *ratelimit.Limiter
+	batch      *batchAcker
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	backpressureRaised bool
+
+	lockStats   map[string]*KeyLockStats
+	lockStatsMu sync.Mutex
 }
 
 // NewPullSubscriber creates and starts a new concurrent pull subscriber.
 func NewPullSubscriber(cfg Config) (*PullSubscriber, error) {
-	// Set sane defaults
-	if cfg.BatchSize <= 0 {
-		cfg.BatchSize = 10
-	}
-	if cfg.MaxConcurrent <= 0 {
-		cfg.MaxConcurrent = 25
-	}
-	if cfg.MaxWait == 0 {
-		cfg.MaxWait = 30 * time.Second
+	cfg, consumerConfig := newConsumerConfig(withDefaults(cfg))
+	if cfg.Heartbeat > 0 && 2*cfg.Heartbeat >= cfg.MaxWait {
+		return nil, fmt.Errorf("heartbeat %s must be less than half of max wait %s", cfg.Heartbeat, cfg.MaxWait)
 	}
 
-	// Create the JetStream consumer
-	_, err := cfg.JetStream.AddConsumer(cfg.StreamName, &nats.ConsumerConfig{
-		Durable:       cfg.DurableName,
-		AckPolicy:     nats.AckExplicitPolicy,
-		FilterSubject: cfg.Subject,
-		MaxDeliver:    5, // This is a reasonable default
-	})
+	// Create the JetStream consumer, or reconcile an existing one that has drifted
+	err := ensureConsumer(cfg.JetStream, cfg.StreamName, consumerConfig)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create consumer for subject %s: %w", cfg.Subject, err)
+		return nil, err
 	}
 
 	// Create the pull subscription
-	sub, err := cfg.JetStream.PullSubscribe(cfg.Subject, cfg.DurableName, nats.BindStream(cfg.StreamName))
+	sub, err := cfg.JetStream.PullSubscribe(cfg.Subject, consumerConfig.Durable, nats.BindStream(cfg.StreamName))
 	if err != nil {
 		return nil, fmt.Errorf("failed to pull subscribe to subject %s: %w", cfg.Subject, err)
 	}
 
+	return NewPullSubscriberWithSource(cfg, sub, nil), nil
+}
+
+// newConsumerConfig builds the consumer configuration for cfg and applies its ConsumerOverrides. The
+// overridden MaxDeliver and ack policy are copied back into cfg, which the subscriber settles messages by.
+func newConsumerConfig(cfg Config) (Config, *nats.ConsumerConfig) {
+	ackPolicy := nats.AckExplicitPolicy
+	if cfg.AckAll {
+		ackPolicy = nats.AckAllPolicy
+	}
+
+	consumerConfig := &nats.ConsumerConfig{
+		Durable:       cfg.DurableName,
+		AckPolicy:     ackPolicy,
+		FilterSubject: cfg.Subject,
+		MaxDeliver:    cfg.MaxDeliver,
+	}
+	if cfg.ConsumerOverrides != nil {
+		cfg.ConsumerOverrides(consumerConfig)
+		cfg.MaxDeliver = consumerConfig.MaxDeliver
+		cfg.AckAll = consumerConfig.AckPolicy == nats.AckAllPolicy
+	}
+	return cfg, consumerConfig
+}
+
+// NewPullSubscriberWithSource starts the worker pool on an existing message source, skipping consumer
+// creation. A nil acker settles messages through the *nats.Msg methods. It is primarily intended for
+// test harnesses such as the workertest package.
+func NewPullSubscriberWithSource(cfg Config, src Source, acker Acknowledger) *PullSubscriber {
+	cfg = withDefaults(cfg)
+
 	ps := &PullSubscriber{
 		config:    cfg,
-		sub:       sub,
+		sub:       src,
+		acker:     acker,
 		active:    true,
 		keyLocks:  make(map[string]*sync.Mutex),
 		semaphore: make(chan struct{}, cfg.MaxConcurrent),
 	}
+	ps.ctx, ps.cancel = context.WithCancel(context.Background())
+	if cfg.RateLimit > 0 {
+		ps.limiter = ratelimit.New(cfg.RateLimit, cfg.RateBurst)
+	}
+	if cfg.AckAll {
+		ps.batch = newBatchAcker(ps.sendAck, ps.releaseClaimChecks)
+	}
 
 	go ps.startDispatcher()
 
 	slog.Info("successfully started concurrent subscriber", "subject", cfg.Subject, "durable_name", cfg.DurableName)
-	return ps, nil
+	return ps
+}
+
+// withDefaults fills in sane defaults for unset configuration values.
+func withDefaults(cfg Config) Config {
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 10
+	}
+	if cfg.MaxConcurrent <= 0 {
+		cfg.MaxConcurrent = 25
+	}
+	if cfg.MaxWait == 0 {
+		cfg.MaxWait = 30 * time.Second
+	}
+	if cfg.MaxDeliver <= 0 {
+		cfg.MaxDeliver = DefaultMaxDeliver
+	}
+	if cfg.BackpressureDelay == 0 {
+		cfg.BackpressureDelay = time.Second
+	}
+	return cfg
 }
 
 // startDispatcher is the main loop that fetches messages and dispatches them to workers.
+// It only requests as many messages as there are free worker slots, so every fetched message starts
+// processing immediately instead of waiting on the semaphore while its AckWait runs down.
 func (ps *PullSubscriber) startDispatcher() {
 	for {
 		ps.mu.Lock()
@@ -96,21 +235,131 @@ func (ps *PullSubscriber) startDispatcher() {
 		}
 		ps.mu.Unlock()
 
-		msgs, err := ps.sub.Fetch(ps.config.BatchSize, nats.MaxWait(ps.config.MaxWait))
+		slots := ps.acquireSlots()
+
+		var fetched int
+		var err error
+		if bf, ok := ps.sub.(batchFetcher); ok && ps.config.FetchMode == FetchStreaming {
+			fetched, err = ps.fetchStreaming(bf, slots)
+		} else {
+			fetched, err = ps.fetchBlocking(slots)
+		}
+		ps.releaseSlots(slots - fetched)
+
 		if err != nil {
-			if err == nats.ErrTimeout {
+			if ps.ctx.Err() != nil {
+				return // Stopped while fetching
+			}
+			if errors.Is(err, nats.ErrTimeout) || errors.Is(err, context.DeadlineExceeded) {
 				continue
 			}
 			slog.Error("failed to fetch messages", "error", err, "subject", ps.config.Subject)
-			time.Sleep(5 * time.Second)
-			continue
+			select {
+			case <-ps.ctx.Done():
+			case <-time.After(5 * time.Second):
+			}
 		}
+	}
+}
 
-		for _, msg := range msgs {
-			ps.semaphore <- struct{}{} // Acquire semaphore slot
-			go ps.processMessage(msg)
+// fetchBlocking fetches up to batch messages in one request and dispatches them, returning how many were fetched.
+func (ps *PullSubscriber) fetchBlocking(batch int) (int, error) {
+	ctx, cancel := context.WithTimeout(ps.ctx, ps.config.MaxWait)
+	defer cancel()
+
+	msgs, err := ps.sub.Fetch(batch, ps.pullOpts(ctx)...)
+	for _, msg := range msgs {
+		ps.handOff(msg)
+	}
+	return len(msgs), err
+}
+
+// fetchStreaming opens a pull request for up to batch messages and dispatches each one as it arrives.
+func (ps *PullSubscriber) fetchStreaming(bf batchFetcher, batch int) (int, error) {
+	ctx, cancel := context.WithTimeout(ps.ctx, ps.config.MaxWait)
+	defer cancel()
+
+	msgBatch, err := bf.FetchBatch(batch, ps.pullOpts(ctx)...)
+	if err != nil {
+		return 0, err
+	}
+
+	fetched := 0
+	for msg := range msgBatch.Messages() {
+		ps.handOff(msg)
+		fetched++
+	}
+	err = msgBatch.Error()
+	if errors.Is(err, nats.ErrTimeout) || errors.Is(err, context.DeadlineExceeded) {
+		// Running out of time is the normal end of a streaming pull; any other error is reported even after
+		// a partial batch.
+		return fetched, nil
+	}
+	return fetched, err
+}
+
+// pullOpts builds the options for a single pull request bounded by ctx.
+func (ps *PullSubscriber) pullOpts(ctx context.Context) []nats.PullOpt {
+	opts := []nats.PullOpt{nats.Context(ctx)}
+	if ps.config.Heartbeat > 0 {
+		opts = append(opts, nats.PullHeartbeat(ps.config.Heartbeat))
+	}
+	return opts
+}
+
+// handOff registers a fetched message with the batch acker, if any, and dispatches it.
+func (ps *PullSubscriber) handOff(msg *nats.Msg) {
+	ps.updateBackpressure(msg)
+	if ps.batch != nil {
+		ps.batch.track(msg)
+	}
+	ps.dispatch(msg)
+}
+
+// acquireSlots blocks until at least one worker slot is free, then claims as many additional free slots
+// as the batch size and rate limit allow. It returns the number of slots claimed.
+func (ps *PullSubscriber) acquireSlots() int {
+	ps.semaphore <- struct{}{}
+	if ps.limiter != nil {
+		_ = ps.limiter.Wait(ps.ctx)
+	}
+
+	n := 1
+	for n < ps.config.BatchSize {
+		if ps.limiter != nil && !ps.limiter.Allow() {
+			break
+		}
+		select {
+		case ps.semaphore <- struct{}{}:
+			n++
+		default:
+			return n
+		}
+	}
+	return n
+}
+
+// releaseSlots frees n claimed worker slots.
+func (ps *PullSubscriber) releaseSlots(n int) {
+	for i := 0; i < n; i++ {
+		<-ps.semaphore
+	}
+}
+
+// dispatch hands a fetched message to a worker using a slot claimed by acquireSlots,
+// skipping messages rejected by the configured filter.
+func (ps *PullSubscriber) dispatch(msg *nats.Msg) {
+	if ps.config.Filter != nil && !ps.config.Filter(msg) {
+		ps.releaseSlots(1)
+		if err := ps.ack(msg); err != nil {
+			slog.Error("failed to ACK filtered message", "error", err, "subject", msg.Subject)
+		} else {
+			slog.Debug("skipped filtered message", "subject", msg.Subject)
 		}
+		return
 	}
+
+	go ps.processMessage(msg)
 }
 
 // processMessage handles the full lifecycle of a single message, including locking and acknowledgement.
@@ -122,35 +371,93 @@ func (ps *PullSubscriber) processMessage(msg *nats.Msg) {
 	lockingKey, err := ps.config.Handler.GetLockingKey(msg)
 	if err != nil {
 		slog.Error("failed to get locking key", "error", err, "subject", msg.Subject)
-		_ = msg.NakWithDelay(5 * time.Second)
+		ps.fail(msg, err, 5*time.Second)
 		return
 	}
 
 	// If a locking key is provided, acquire the specific lock for that key.
 	if lockingKey != "" {
 		keyMutex := ps.getKeyMutex(lockingKey)
+		waitStart := time.Now()
 		keyMutex.Lock()
 		defer keyMutex.Unlock()
+		ps.recordLockWait(lockingKey, time.Since(waitStart))
 	}
 
-	slog.Info("processing message", "subject", msg.Subject, "key", lockingKey)
-
-	// Create a context for the handler
+	// Create a context for the handler, carrying the correlation IDs of the publisher
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute) // 5-minute timeout per message
 	defer cancel()
+	ctx = correlation.Extract(ctx, msg.Header.Get)
+	logger := logging.FromContext(ctx)
+
+	logger.Info("processing message", "subject", msg.Subject, "key", lockingKey)
+
+	claimCheck, err := ps.resolveClaimCheck(ctx, msg)
+	if err != nil {
+		logger.Error("failed to resolve claim-check payload", "error", err, "subject", msg.Subject, "key", lockingKey)
+		ps.fail(msg, err, 15*time.Second)
+		return
+	}
 
 	if err := ps.config.Handler.Process(ctx, msg); err != nil {
-		slog.Error("handler failed to process message", "error", err, "subject", msg.Subject, "key", lockingKey)
-		_ = msg.NakWithDelay(15 * time.Second) // Nak with a longer delay on processing failure
+		logger.Error("handler failed to process message", "error", err, "subject", msg.Subject, "key", lockingKey)
+		ps.fail(msg, err, 15*time.Second) // Nak with a longer delay on processing failure
 	} else {
-		if err := msg.Ack(); err != nil {
-			slog.Error("failed to ACK message", "error", err, "subject", msg.Subject)
+		if err := ps.ack(msg); err != nil {
+			logger.Error("failed to ACK message", "error", err, "subject", msg.Subject)
 		} else {
-			slog.Info("successfully processed and ACKed message", "subject", msg.Subject, "key", lockingKey)
+			logger.Info("successfully processed and ACKed message", "subject", msg.Subject, "key", lockingKey)
+			if ps.batch == nil {
+				// Under AckAll the payload is released once the batch ACK covering the message is sent.
+				ps.releaseClaimCheck(ctx, claimCheck)
+			}
 		}
 	}
 }
 
+// ack, nak and term settle a message through the configured Acknowledger, or the message itself by default.
+// Under the AckAll policy, ACKs are deferred to the batch acker.
+func (ps *PullSubscriber) ack(msg *nats.Msg) error {
+	if ps.batch != nil {
+		return ps.batch.succeeded(msg)
+	}
+	return ps.sendAck(msg)
+}
+
+func (ps *PullSubscriber) nak(msg *nats.Msg, delay time.Duration) error {
+	var err error
+	if ps.acker != nil {
+		err = ps.acker.Nak(msg, delay)
+	} else {
+		err = msg.NakWithDelay(delay)
+	}
+	if ps.batch != nil {
+		ps.batch.failed(msg, err == nil && !ps.isFinalDelivery(msg))
+	}
+	return err
+}
+
+func (ps *PullSubscriber) term(msg *nats.Msg) error {
+	var err error
+	if ps.acker != nil {
+		err = ps.acker.Term(msg)
+	} else {
+		err = msg.Term()
+	}
+	if ps.batch != nil {
+		ps.batch.failed(msg, false)
+	}
+	return err
+}
+
+// sendAck ACKs a message with the server.
+func (ps *PullSubscriber) sendAck(msg *nats.Msg) error {
+	if ps.acker != nil {
+		return ps.acker.Ack(msg)
+	}
+	return msg.Ack()
+}
+
 // getKeyMutex retrieves or creates a mutex for a specific key.
 func (ps *PullSubscriber) getKeyMutex(key string) *sync.Mutex {
 	ps.keyLocksMu.RLock()
@@ -181,6 +488,8 @@ func (ps *PullSubscriber) Stop() {
 		return
 	}
 	ps.active = false
+	// Cancel any in-flight fetch so shutdown does not wait for MaxWait
+	ps.cancel()
 	// Unsubscribe to stop receiving new messages
 	if err := ps.sub.Unsubscribe(); err != nil {
 		slog.Warn("error during unsubscribe", "error", err, "subject", ps.config.Subject)
@@ -2,13 +2,22 @@ package worker
 
 import (
 	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
 	"io"
 	"log/slog"
+	"net/http"
 	"os"
+	"slices"
 	"sync"
 	"testing"
+	"time"
 
+	common_errors "github.com/hkinc45/dev-kitchen-go-common/errors"
 	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
 
@@ -77,3 +86,1243 @@ func BenchmarkWorkerPool(b *testing.B) {
 		ps.processMessage(msg)
 	}
 }
+
+type memoryPayloadStore struct {
+	objects map[string][]byte
+}
+
+func (s *memoryPayloadStore) Put(_ context.Context, name string, data []byte) error {
+	s.objects[name] = data
+	return nil
+}
+
+func (s *memoryPayloadStore) Get(_ context.Context, name string) ([]byte, error) {
+	data, ok := s.objects[name]
+	if !ok {
+		return nil, nats.ErrObjectNotFound
+	}
+	return data, nil
+}
+
+func (s *memoryPayloadStore) Delete(_ context.Context, name string) error {
+	delete(s.objects, name)
+	return nil
+}
+
+func TestProcessMessageClaimCheck(t *testing.T) {
+	store := &memoryPayloadStore{objects: map[string][]byte{"test/obj-1": []byte("large payload")}}
+	handler := &MockHandler{}
+	ps := &PullSubscriber{
+		config: Config{
+			Handler:      handler,
+			PayloadStore: store,
+		},
+		semaphore: make(chan struct{}, 1),
+		keyLocks:  make(map[string]*sync.Mutex),
+	}
+
+	msg := nats.NewMsg("test")
+	msg.Header.Set(ClaimCheckHeader, "test/obj-1")
+
+	handler.On("GetLockingKey", msg).Return("", nil).Once()
+	handler.On("Process", mock.Anything, mock.MatchedBy(func(m *nats.Msg) bool {
+		return string(m.Data) == "large payload"
+	})).Return(nil).Once()
+
+	ps.semaphore <- struct{}{}
+	ps.processMessage(msg)
+
+	handler.AssertExpectations(t)
+}
+
+func TestClaimCheckReleasedAfterBatchAck(t *testing.T) {
+	store := &memoryPayloadStore{objects: map[string][]byte{"test/obj-1": []byte("large payload")}}
+	handler := &MockHandler{}
+	handler.On("GetLockingKey", mock.Anything).Return("", nil)
+	handler.On("Process", mock.Anything, mock.Anything).Return(nil)
+	var batchAcks int
+	ps := &PullSubscriber{
+		config:    Config{Handler: handler, PayloadStore: store, AckAll: true},
+		semaphore: make(chan struct{}, 1),
+		keyLocks:  make(map[string]*sync.Mutex),
+	}
+	ps.batch = newBatchAcker(func(*nats.Msg) error {
+		batchAcks++
+		return nil
+	}, ps.releaseClaimChecks)
+
+	earlier, claimCheck := newJetStreamMsg(1, 1), newJetStreamMsg(2, 1)
+	claimCheck.Header = nats.Header{ClaimCheckHeader: []string{"test/obj-1"}}
+	ps.batch.track(earlier)
+	ps.batch.track(claimCheck)
+
+	// The message is processed, but its ACK waits for the earlier delivery, so the payload must stay.
+	ps.semaphore <- struct{}{}
+	ps.processMessage(claimCheck)
+	assert.Equal(t, 0, batchAcks)
+	assert.Contains(t, store.objects, "test/obj-1")
+
+	ps.semaphore <- struct{}{}
+	ps.processMessage(earlier)
+	assert.Equal(t, 1, batchAcks)
+	assert.NotContains(t, store.objects, "test/obj-1")
+}
+
+func TestDispatchFilter(t *testing.T) {
+	handler := &MockHandler{}
+	ps := &PullSubscriber{
+		config: Config{
+			Handler: handler,
+			Filter: func(msg *nats.Msg) bool {
+				return msg.Subject != "tenant.other"
+			},
+		},
+		semaphore: make(chan struct{}, 1),
+		keyLocks:  make(map[string]*sync.Mutex),
+	}
+
+	ps.semaphore <- struct{}{}
+	ps.dispatch(&nats.Msg{Subject: "tenant.other"})
+
+	assert.Len(t, ps.semaphore, 0)
+	handler.AssertNotCalled(t, "GetLockingKey", mock.Anything)
+	handler.AssertNotCalled(t, "Process", mock.Anything, mock.Anything)
+}
+
+func TestQuarantineMessageRoundTrip(t *testing.T) {
+	msg := nats.NewMsg("recipe.created")
+	msg.Header.Set("Nats-Msg-Id", "abc")
+	msg.Data = []byte(`{"id":"r-1"}`)
+
+	qmsg := newQuarantineMsg("quarantine.recipes", msg, errors.New("boom"))
+	assert.Equal(t, "quarantine.recipes", qmsg.Subject)
+
+	qm := newQuarantinedMessage(42, qmsg.Header, qmsg.Data)
+	assert.Equal(t, uint64(42), qm.Sequence)
+	assert.Equal(t, "recipe.created", qm.OriginalSubject)
+	assert.Equal(t, "boom", qm.Reason)
+	assert.Empty(t, qm.Header.Get(nats.MsgIdHdr), "the quarantined copy must not be deduplicated against the original")
+	assert.Equal(t, msg.Data, qm.Data)
+	assert.False(t, qm.QuarantinedAt.IsZero())
+}
+
+// quarantineJetStream serves stored quarantine messages; with duplicate set, publishes are acknowledged as
+// duplicates, as JetStream does for message IDs seen within the duplicate window.
+type quarantineJetStream struct {
+	fakeJetStream
+	stored    map[uint64]*nats.RawStreamMsg
+	duplicate bool
+}
+
+func (js *quarantineJetStream) GetMsg(_ string, seq uint64, _ ...nats.JSOpt) (*nats.RawStreamMsg, error) {
+	return js.stored[seq], nil
+}
+
+func (js *quarantineJetStream) DeleteMsg(_ string, seq uint64, _ ...nats.JSOpt) error {
+	delete(js.stored, seq)
+	return nil
+}
+
+func (js *quarantineJetStream) PublishMsg(msg *nats.Msg, opts ...nats.PubOpt) (*nats.PubAck, error) {
+	ack, _ := js.fakeJetStream.PublishMsg(msg, opts...)
+	ack.Duplicate = js.duplicate
+	return ack, nil
+}
+
+func TestQuarantineRequeue(t *testing.T) {
+	msg := nats.NewMsg("recipe.created")
+	msg.Header.Set(nats.MsgIdHdr, "abc")
+	msg.Header.Set("X-Request-ID", "req-1")
+	header := newQuarantineMsg("quarantine.recipes", msg, errors.New("boom")).Header
+	newJS := func(duplicate bool) *quarantineJetStream {
+		return &quarantineJetStream{
+			stored:    map[uint64]*nats.RawStreamMsg{7: {Subject: "quarantine.recipes", Sequence: 7, Header: header, Data: []byte(`{}`)}},
+			duplicate: duplicate,
+		}
+	}
+
+	t.Run("republishes without quarantine headers and message ID", func(t *testing.T) {
+		js := newJS(false)
+		assert.NoError(t, NewQuarantine(js, "QUARANTINE", "quarantine.recipes").Requeue(context.Background(), 7))
+		if !assert.Len(t, js.published, 1) {
+			return
+		}
+		requeued := js.published[0]
+		assert.Equal(t, "recipe.created", requeued.Subject)
+		assert.Equal(t, "req-1", requeued.Header.Get("X-Request-ID"))
+		assert.Empty(t, requeued.Header.Get(nats.MsgIdHdr))
+		assert.Empty(t, requeued.Header.Get(QuarantineReasonHeader))
+		assert.Empty(t, js.stored)
+	})
+
+	t.Run("keeps messages dropped as duplicates", func(t *testing.T) {
+		js := newJS(true)
+		assert.Error(t, NewQuarantine(js, "QUARANTINE", "quarantine.recipes").Requeue(context.Background(), 7))
+		assert.Contains(t, js.stored, uint64(7))
+	})
+}
+
+func TestDeadLetterClient(t *testing.T) {
+	original := newJetStreamMsg(42, 5)
+	original.Subject = "recipe.created"
+	original.Header = nats.Header{"X-Request-ID": []string{"req-1"}}
+	original.Data = []byte(`{"id":"r-1"}`)
+	header := newQuarantineMsg("quarantine.recipes", original, errors.New("boom")).Header
+
+	js := &quarantineJetStream{stored: map[uint64]*nats.RawStreamMsg{
+		7: {Subject: "quarantine.recipes", Sequence: 7, Header: header, Data: original.Data},
+		8: {Subject: "quarantine.recipes", Sequence: 8, Header: header, Data: original.Data},
+	}}
+	client := NewDeadLetterClient(js, "QUARANTINE", "quarantine.recipes")
+	client.quarantine.browse = func(...nats.SubOpt) (Source, error) {
+		var queue []*nats.Msg
+		for seq, pending := uint64(7), uint64(1); seq <= 8; seq, pending = seq+1, pending-1 {
+			msg := newReplayMsg(seq, pending)
+			msg.Header, msg.Data = js.stored[seq].Header, js.stored[seq].Data
+			queue = append(queue, msg)
+		}
+		return &fakeSource{queue: queue}, nil
+	}
+	ctx := context.Background()
+
+	letters, err := client.List(ctx, 10)
+	if !assert.NoError(t, err) || !assert.Len(t, letters, 2) {
+		return
+	}
+	assert.Equal(t, uint64(7), letters[0].Sequence)
+	assert.Equal(t, uint64(8), letters[1].Sequence)
+	assert.Equal(t, "recipe.created", letters[0].Subject)
+	assert.Equal(t, "boom", letters[0].Reason)
+	assert.Equal(t, "STREAM", letters[0].OriginalStream)
+	assert.Equal(t, uint64(42), letters[0].OriginalSequence)
+
+	letter, err := client.Inspect(ctx, 8)
+	if assert.NoError(t, err) {
+		assert.Equal(t, uint64(8), letter.Sequence)
+		assert.Equal(t, []byte(`{"id":"r-1"}`), letter.Data)
+		assert.Equal(t, "req-1", nats.Header(letter.Headers).Get("X-Request-ID"))
+	}
+
+	assert.NoError(t, client.Requeue(ctx, 7))
+	if assert.Len(t, js.published, 1) {
+		assert.Equal(t, "recipe.created", js.published[0].Subject)
+		assert.Equal(t, []byte(`{"id":"r-1"}`), js.published[0].Data)
+	}
+	assert.NotContains(t, js.stored, uint64(7))
+	assert.Contains(t, js.stored, uint64(8))
+}
+
+func TestNewConsumerConfig(t *testing.T) {
+	cfg, cc := newConsumerConfig(withDefaults(Config{DurableName: "recipes", Subject: "recipe.>", MaxDeliver: 5}))
+	assert.Equal(t, 5, cc.MaxDeliver)
+	assert.Equal(t, nats.AckExplicitPolicy, cc.AckPolicy)
+	assert.False(t, cfg.AckAll)
+
+	cfg, cc = newConsumerConfig(withDefaults(Config{
+		DurableName: "recipes",
+		Subject:     "recipe.>",
+		MaxDeliver:  5,
+		ConsumerOverrides: func(cc *nats.ConsumerConfig) {
+			cc.MaxDeliver = 3
+			cc.AckPolicy = nats.AckAllPolicy
+		},
+	}))
+	assert.Equal(t, 3, cc.MaxDeliver)
+	assert.Equal(t, 3, cfg.MaxDeliver, "quarantine must fire on the overridden final delivery")
+	assert.True(t, cfg.AckAll)
+}
+
+func TestDiffConsumerConfig(t *testing.T) {
+	desired := nats.ConsumerConfig{
+		Durable:       "recipes",
+		AckPolicy:     nats.AckExplicitPolicy,
+		FilterSubject: "recipe.>",
+		MaxDeliver:    5,
+	}
+
+	t.Run("No Drift", func(t *testing.T) {
+		existing := desired
+		existing.AckWait = 30 * time.Second // server-populated default
+		assert.Empty(t, diffConsumerConfig(existing, desired))
+	})
+
+	t.Run("Drift", func(t *testing.T) {
+		existing := desired
+		existing.MaxDeliver = 3
+		existing.FilterSubject = "recipe.created"
+		diff := diffConsumerConfig(existing, desired)
+		assert.Equal(t, []string{"filter_subject: recipe.created -> recipe.>", "max_deliver: 3 -> 5"}, diff)
+	})
+
+	t.Run("Overridden Settings", func(t *testing.T) {
+		overridden := desired
+		overridden.Replicas = 3
+		overridden.SampleFrequency = "100%"
+		existing := desired
+		existing.Replicas = 1
+		diff := diffConsumerConfig(existing, overridden)
+		assert.Equal(t, []string{"sample_freq:  -> 100%", "num_replicas: 1 -> 3"}, diff)
+	})
+}
+
+func TestPublisherInline(t *testing.T) {
+	p, err := NewPublisher(PublisherConfig{Inline: true})
+	assert.NoError(t, err)
+
+	handler := &MockHandler{}
+	p.Register("recipe.*", handler)
+	handler.On("Process", mock.Anything, mock.MatchedBy(func(m *nats.Msg) bool {
+		return m.Subject == "recipe.created"
+	})).Return(nil).Once()
+
+	assert.NoError(t, p.Publish(context.Background(), "recipe.created", []byte("{}")))
+	assert.NoError(t, p.Publish(context.Background(), "project.created", []byte("{}")))
+
+	handler.AssertExpectations(t)
+}
+
+func TestSubjectMatches(t *testing.T) {
+	assert.True(t, subjectMatches("recipe.created", "recipe.created"))
+	assert.True(t, subjectMatches("recipe.*", "recipe.created"))
+	assert.True(t, subjectMatches("recipe.>", "recipe.version.created"))
+	assert.False(t, subjectMatches("recipe.>", "recipe"))
+	assert.False(t, subjectMatches("recipe.*", "recipe.version.created"))
+	assert.False(t, subjectMatches("recipe.created", "project.created"))
+}
+
+// fakeSource serves queued messages and records the batch size of every fetch.
+type fakeSource struct {
+	mu      sync.Mutex
+	queue   []*nats.Msg
+	batches []int
+}
+
+func (s *fakeSource) Fetch(batch int, _ ...nats.PullOpt) ([]*nats.Msg, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.batches = append(s.batches, batch)
+	if len(s.queue) == 0 {
+		time.Sleep(time.Millisecond)
+		return nil, nats.ErrTimeout
+	}
+	n := min(batch, len(s.queue))
+	msgs := s.queue[:n]
+	s.queue = s.queue[n:]
+	return msgs, nil
+}
+
+func (s *fakeSource) Unsubscribe() error { return nil }
+
+type noopAcker struct{}
+
+func (noopAcker) Ack(*nats.Msg) error                { return nil }
+func (noopAcker) Nak(*nats.Msg, time.Duration) error { return nil }
+func (noopAcker) Term(*nats.Msg) error               { return nil }
+
+func TestDispatcherFetchesOnlyFreeSlots(t *testing.T) {
+	const maxConcurrent = 2
+
+	src := &fakeSource{}
+	for i := 0; i < 6; i++ {
+		src.queue = append(src.queue, &nats.Msg{Subject: "test"})
+	}
+
+	started := make(chan time.Time, 6)
+	release := make(chan struct{})
+	handler := &MockHandler{}
+	handler.On("GetLockingKey", mock.Anything).Return("", nil)
+	handler.On("Process", mock.Anything, mock.Anything).Run(func(mock.Arguments) {
+		started <- time.Now()
+		<-release
+	}).Return(nil)
+
+	ps := NewPullSubscriberWithSource(Config{
+		Handler:       handler,
+		BatchSize:     10,
+		MaxConcurrent: maxConcurrent,
+	}, src, noopAcker{})
+	defer ps.Stop()
+
+	// With both slots busy, no further messages may be fetched.
+	for i := 0; i < maxConcurrent; i++ {
+		select {
+		case <-started:
+		case <-time.After(time.Second):
+			t.Fatal("fetched message did not start processing promptly")
+		}
+	}
+	time.Sleep(20 * time.Millisecond)
+	src.mu.Lock()
+	assert.Len(t, src.queue, 4)
+	for _, batch := range src.batches {
+		assert.LessOrEqual(t, batch, maxConcurrent)
+	}
+	src.mu.Unlock()
+
+	// Every message fetched later must also start as soon as it is fetched.
+	close(release)
+	for i := maxConcurrent; i < 6; i++ {
+		select {
+		case <-started:
+		case <-time.After(time.Second):
+			t.Fatal("fetched message did not start processing promptly")
+		}
+	}
+}
+
+// blockingSource blocks every fetch until its pull context is done and records the options it was given.
+type blockingSource struct {
+	mu           sync.Mutex
+	fetches      int
+	heartbeats   []time.Duration
+	unsubscribed bool
+}
+
+func (s *blockingSource) Fetch(_ int, opts ...nats.PullOpt) ([]*nats.Msg, error) {
+	ctx := context.Background()
+	s.mu.Lock()
+	s.fetches++
+	for _, opt := range opts {
+		switch opt := opt.(type) {
+		case nats.ContextOpt:
+			ctx = opt.Context
+		case nats.PullHeartbeat:
+			s.heartbeats = append(s.heartbeats, time.Duration(opt))
+		}
+	}
+	s.mu.Unlock()
+
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (s *blockingSource) Unsubscribe() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.unsubscribed = true
+	return nil
+}
+
+func TestFetchBlockingTimesOutAndRetries(t *testing.T) {
+	src := &blockingSource{}
+	ps := NewPullSubscriberWithSource(Config{
+		Handler:   &MockHandler{},
+		MaxWait:   20 * time.Millisecond,
+		Heartbeat: 5 * time.Millisecond,
+	}, src, noopAcker{})
+	defer ps.Stop()
+
+	// Each fetch is bounded by MaxWait and followed by the next one.
+	assert.Eventually(t, func() bool {
+		src.mu.Lock()
+		defer src.mu.Unlock()
+		return src.fetches >= 3
+	}, time.Second, 5*time.Millisecond)
+
+	src.mu.Lock()
+	defer src.mu.Unlock()
+	for _, heartbeat := range src.heartbeats {
+		assert.Equal(t, 5*time.Millisecond, heartbeat)
+	}
+	assert.NotEmpty(t, src.heartbeats)
+}
+
+// fakeBatchSource serves its queue through FetchBatch, as a streaming pull would.
+type fakeBatchSource struct {
+	fakeSource
+}
+
+type fakeMessageBatch struct {
+	msgs chan *nats.Msg
+	err  error
+	done chan struct{}
+}
+
+func (b *fakeMessageBatch) Messages() <-chan *nats.Msg { return b.msgs }
+func (b *fakeMessageBatch) Error() error               { return b.err }
+func (b *fakeMessageBatch) Done() <-chan struct{}      { return b.done }
+
+func (s *fakeBatchSource) FetchBatch(batch int, opts ...nats.PullOpt) (nats.MessageBatch, error) {
+	msgs, err := s.Fetch(batch, opts...)
+	b := &fakeMessageBatch{msgs: make(chan *nats.Msg, len(msgs)), err: err, done: make(chan struct{})}
+	for _, msg := range msgs {
+		b.msgs <- msg
+	}
+	close(b.msgs)
+	close(b.done)
+	return b, nil
+}
+
+func TestFetchStreaming(t *testing.T) {
+	src := &fakeBatchSource{}
+	for i := 0; i < 3; i++ {
+		src.queue = append(src.queue, &nats.Msg{Subject: "test"})
+	}
+
+	processed := make(chan struct{}, 3)
+	handler := &MockHandler{}
+	handler.On("GetLockingKey", mock.Anything).Return("", nil)
+	handler.On("Process", mock.Anything, mock.Anything).Run(func(mock.Arguments) {
+		processed <- struct{}{}
+	}).Return(nil)
+
+	ps := NewPullSubscriberWithSource(Config{
+		Handler:   handler,
+		BatchSize: 2,
+		FetchMode: FetchStreaming,
+	}, src, noopAcker{})
+	defer ps.Stop()
+
+	for i := 0; i < 3; i++ {
+		select {
+		case <-processed:
+		case <-time.After(time.Second):
+			t.Fatal("streamed message was not processed")
+		}
+	}
+}
+
+// partialBatchFetcher delivers msgs through a streaming pull that then ends with err.
+type partialBatchFetcher struct {
+	msgs []*nats.Msg
+	err  error
+}
+
+func (f partialBatchFetcher) FetchBatch(int, ...nats.PullOpt) (nats.MessageBatch, error) {
+	b := &fakeMessageBatch{msgs: make(chan *nats.Msg, len(f.msgs)), err: f.err, done: make(chan struct{})}
+	for _, msg := range f.msgs {
+		b.msgs <- msg
+	}
+	close(b.msgs)
+	close(b.done)
+	return b, nil
+}
+
+func TestFetchStreamingPartialBatchError(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		wantErr error
+	}{
+		{name: "timeout", err: nats.ErrTimeout},
+		{name: "deadline", err: context.DeadlineExceeded},
+		{name: "connection closed", err: nats.ErrConnectionClosed, wantErr: nats.ErrConnectionClosed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := &MockHandler{}
+			handler.On("GetLockingKey", mock.Anything).Return("", nil)
+			handler.On("Process", mock.Anything, mock.Anything).Return(nil)
+			ps := &PullSubscriber{
+				config:    Config{Handler: handler, MaxWait: time.Second},
+				acker:     noopAcker{},
+				semaphore: make(chan struct{}, 1),
+				keyLocks:  make(map[string]*sync.Mutex),
+				ctx:       context.Background(),
+			}
+			ps.semaphore <- struct{}{}
+
+			fetched, err := ps.fetchStreaming(partialBatchFetcher{msgs: []*nats.Msg{{Subject: "test"}}, err: tt.err}, 2)
+			assert.Equal(t, 1, fetched)
+			if tt.wantErr == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestStopCancelsInFlightFetch(t *testing.T) {
+	src := &blockingSource{}
+	ps := NewPullSubscriberWithSource(Config{
+		Handler: &MockHandler{},
+		MaxWait: time.Hour,
+	}, src, noopAcker{})
+
+	assert.Eventually(t, func() bool {
+		src.mu.Lock()
+		defer src.mu.Unlock()
+		return src.fetches == 1
+	}, time.Second, time.Millisecond)
+
+	stopped := make(chan struct{})
+	go func() {
+		ps.Stop()
+		ps.Stop() // Stopping twice is a no-op.
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("Stop did not return promptly")
+	}
+
+	// The dispatcher must not fetch again once the in-flight fetch is cancelled.
+	time.Sleep(20 * time.Millisecond)
+	src.mu.Lock()
+	defer src.mu.Unlock()
+	assert.True(t, src.unsubscribed)
+	assert.Equal(t, 1, src.fetches)
+}
+
+func TestNewPullSubscriberRejectsLongHeartbeat(t *testing.T) {
+	for _, heartbeat := range []time.Duration{15 * time.Second, 30 * time.Second, time.Minute} {
+		_, err := NewPullSubscriber(Config{Handler: &MockHandler{}, MaxWait: 30 * time.Second, Heartbeat: heartbeat})
+		assert.ErrorContains(t, err, "heartbeat", heartbeat.String())
+	}
+}
+
+// newJetStreamMsg builds a message whose metadata reports the given stream sequence and delivery count.
+func newJetStreamMsg(streamSeq, delivered uint64) *nats.Msg {
+	return &nats.Msg{
+		Subject: "test",
+		Reply:   fmt.Sprintf("$JS.ACK.STREAM.consumer.%d.%d.%d.0.0", delivered, streamSeq, streamSeq),
+		Sub:     &nats.Subscription{},
+	}
+}
+
+// newReplayMsg builds a message whose metadata reports the given stream sequence and pending count.
+func newReplayMsg(streamSeq, pending uint64) *nats.Msg {
+	return &nats.Msg{
+		Subject: "test",
+		Reply:   fmt.Sprintf("$JS.ACK.STREAM.consumer.1.%d.%d.0.%d", streamSeq, streamSeq, pending),
+		Sub:     &nats.Subscription{},
+	}
+}
+
+// replayHandler fails the sequences in fail and records what it processed.
+type replayHandler struct {
+	fail     map[uint64]bool
+	dryRuns  []bool
+	sequence []uint64
+	bodies   []string
+}
+
+func (h *replayHandler) Process(ctx context.Context, msg *nats.Msg) error {
+	meta, err := msg.Metadata()
+	if err != nil {
+		return err
+	}
+	h.dryRuns = append(h.dryRuns, IsDryRun(ctx))
+	h.sequence = append(h.sequence, meta.Sequence.Stream)
+	h.bodies = append(h.bodies, string(msg.Data))
+	if h.fail[meta.Sequence.Stream] {
+		return errors.New("boom")
+	}
+	return nil
+}
+
+func (h *replayHandler) GetLockingKey(*nats.Msg) (string, error) { return "", nil }
+
+func TestReplay(t *testing.T) {
+	tests := []struct {
+		name          string
+		queue         []*nats.Msg
+		lastSeq       uint64
+		fail          map[uint64]bool
+		dryRun        bool
+		wantSequences []uint64
+		wantResult    ReplayResult
+	}{
+		{
+			name:          "stops at the last sequence",
+			queue:         []*nats.Msg{newReplayMsg(1, 3), newReplayMsg(2, 2), newReplayMsg(3, 1), newReplayMsg(4, 0)},
+			lastSeq:       3,
+			wantSequences: []uint64{1, 2, 3},
+			wantResult:    ReplayResult{Processed: 3, LastSequence: 3},
+		},
+		{
+			name:          "skips messages published during the replay",
+			queue:         []*nats.Msg{newReplayMsg(2, 1), newReplayMsg(5, 0)},
+			lastSeq:       4,
+			wantSequences: []uint64{2},
+			wantResult:    ReplayResult{Processed: 1, LastSequence: 2},
+		},
+		{
+			name:          "stops when nothing is pending",
+			queue:         []*nats.Msg{newReplayMsg(1, 1), newReplayMsg(3, 0)},
+			lastSeq:       10,
+			wantSequences: []uint64{1, 3},
+			wantResult:    ReplayResult{Processed: 2, LastSequence: 3},
+		},
+		{
+			name:          "counts handler failures and continues",
+			queue:         []*nats.Msg{newReplayMsg(1, 2), newReplayMsg(2, 1), newReplayMsg(3, 0)},
+			lastSeq:       3,
+			fail:          map[uint64]bool{2: true},
+			wantSequences: []uint64{1, 2, 3},
+			wantResult:    ReplayResult{Processed: 2, Failed: 1, LastSequence: 3},
+		},
+		{
+			name:          "marks the context in dry runs",
+			queue:         []*nats.Msg{newReplayMsg(1, 0)},
+			lastSeq:       1,
+			dryRun:        true,
+			wantSequences: []uint64{1},
+			wantResult:    ReplayResult{Processed: 1, LastSequence: 1},
+		},
+		{
+			name:       "ends when the source runs dry",
+			lastSeq:    5,
+			wantResult: ReplayResult{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := &replayHandler{fail: tt.fail}
+			src := &fakeSource{queue: tt.queue}
+
+			result, err := replay(context.Background(), src, "test", tt.lastSeq, handler, ReplayOptions{BatchSize: 2, DryRun: tt.dryRun})
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, tt.wantResult, *result)
+			assert.Equal(t, tt.wantSequences, handler.sequence)
+			for _, dryRun := range handler.dryRuns {
+				assert.Equal(t, tt.dryRun, dryRun)
+			}
+		})
+	}
+}
+
+func TestReplayClaimCheck(t *testing.T) {
+	store := &memoryPayloadStore{objects: map[string][]byte{"test/obj-1": []byte("large payload")}}
+	resolved, released := newReplayMsg(1, 1), newReplayMsg(2, 0)
+	resolved.Header = nats.Header{ClaimCheckHeader: []string{"test/obj-1"}}
+	released.Header = nats.Header{ClaimCheckHeader: []string{"test/obj-0"}}
+
+	handler := &replayHandler{}
+	result, err := replay(context.Background(), &fakeSource{queue: []*nats.Msg{resolved, released}}, "test", 2, handler, ReplayOptions{BatchSize: 10, PayloadStore: store})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, ReplayResult{Processed: 1, Failed: 1, LastSequence: 2}, *result)
+	assert.Equal(t, []string{"large payload"}, handler.bodies)
+	assert.Contains(t, store.objects, "test/obj-1", "replay must not release payloads")
+}
+
+func TestReplayStopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	handler := &replayHandler{}
+	result, err := replay(ctx, &fakeSource{queue: []*nats.Msg{newReplayMsg(1, 0)}}, "test", 1, handler, ReplayOptions{})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, ReplayResult{}, *result)
+	assert.Empty(t, handler.sequence)
+}
+
+func TestBatchAcker(t *testing.T) {
+	var acked []*nats.Msg
+	b := newBatchAcker(func(msg *nats.Msg) error {
+		acked = append(acked, msg)
+		return nil
+	}, nil)
+
+	m1, m2, m3 := newJetStreamMsg(1, 1), newJetStreamMsg(2, 1), newJetStreamMsg(3, 1)
+	b.track(m1)
+	b.track(m2)
+	b.track(m3)
+
+	// Out-of-order completion must not ACK past an in-flight message.
+	assert.NoError(t, b.succeeded(m2))
+	assert.Empty(t, acked)
+
+	// A failed message awaiting redelivery blocks the floor.
+	b.failed(m1, true)
+	assert.NoError(t, b.succeeded(m3))
+	assert.Empty(t, acked)
+
+	// Once the redelivery is fetched, the original delivery no longer blocks.
+	m1Redelivered := newJetStreamMsg(1, 2)
+	b.track(m1Redelivered)
+	assert.NoError(t, b.succeeded(m1Redelivered))
+	assert.Equal(t, []*nats.Msg{m1Redelivered}, acked)
+}
+
+func TestHottestKeys(t *testing.T) {
+	var hooked []string
+	ps := &PullSubscriber{config: Config{
+		OnLockWait: func(key string, wait time.Duration) {
+			hooked = append(hooked, key)
+		},
+	}}
+
+	ps.recordLockWait("project-1", 10*time.Millisecond)
+	ps.recordLockWait("project-2", 50*time.Millisecond)
+	ps.recordLockWait("project-1", 5*time.Millisecond)
+
+	hottest := ps.HottestKeys(1)
+	assert.Len(t, hottest, 1)
+	assert.Equal(t, KeyLockStats{Key: "project-2", Acquisitions: 1, TotalWait: 50 * time.Millisecond, MaxWait: 50 * time.Millisecond}, hottest[0])
+	assert.Equal(t, uint64(2), ps.HottestKeys(0)[1].Acquisitions)
+	assert.Equal(t, []string{"project-1", "project-2", "project-1"}, hooked)
+}
+
+func TestFailureCallbacks(t *testing.T) {
+	var errs []error
+	var drops []DropReason
+	handler := &MockHandler{}
+	ps := &PullSubscriber{
+		config: Config{
+			Handler:    handler,
+			MaxDeliver: 2,
+			OnError: func(msg *nats.Msg, err error) {
+				errs = append(errs, err)
+			},
+			OnDrop: func(msg *nats.Msg, reason DropReason, err error) {
+				drops = append(drops, reason)
+			},
+		},
+		acker:     noopAcker{},
+		semaphore: make(chan struct{}, 1),
+		keyLocks:  make(map[string]*sync.Mutex),
+	}
+
+	handler.On("GetLockingKey", mock.Anything).Return("", nil)
+	handler.On("Process", mock.Anything, mock.Anything).Return(errors.New("boom"))
+
+	ps.semaphore <- struct{}{}
+	ps.processMessage(newJetStreamMsg(1, 1))
+	assert.Len(t, errs, 1)
+	assert.Empty(t, drops)
+
+	ps.semaphore <- struct{}{}
+	ps.processMessage(newJetStreamMsg(1, 2))
+	assert.Len(t, errs, 2)
+	assert.Equal(t, []DropReason{DropExhausted}, drops)
+}
+
+// fakeJetStream records published messages; other JetStreamContext methods are not implemented.
+type fakeJetStream struct {
+	nats.JetStreamContext
+	published  []*nats.Msg
+	publishErr error
+}
+
+func (js *fakeJetStream) PublishMsg(msg *nats.Msg, _ ...nats.PubOpt) (*nats.PubAck, error) {
+	if js.publishErr != nil {
+		return nil, js.publishErr
+	}
+	js.published = append(js.published, msg)
+	return &nats.PubAck{Stream: "TEST", Sequence: uint64(len(js.published))}, nil
+}
+
+func TestPublisherMsgID(t *testing.T) {
+	js := &fakeJetStream{}
+	p, err := NewPublisher(PublisherConfig{JetStream: js, MsgID: ContentMsgID})
+	assert.NoError(t, err)
+
+	assert.NoError(t, p.Publish(context.Background(), "recipe.created", []byte(`{"id":"r-1"}`)))
+	assert.NoError(t, p.Publish(context.Background(), "recipe.created", []byte(`{"id":"r-1"}`)))
+	assert.NoError(t, p.Publish(context.Background(), "recipe.created", []byte(`{"id":"r-2"}`)))
+
+	explicit := nats.NewMsg("recipe.created")
+	explicit.Header.Set(nats.MsgIdHdr, "caller-id")
+	assert.NoError(t, p.PublishMsg(context.Background(), explicit))
+
+	ids := make([]string, 0, len(js.published))
+	for _, msg := range js.published {
+		ids = append(ids, msg.Header.Get(nats.MsgIdHdr))
+	}
+	assert.Equal(t, ids[0], ids[1], "identical events must share an ID so the stream drops the retry")
+	assert.NotEqual(t, ids[0], ids[2])
+	assert.Equal(t, "caller-id", ids[3])
+	assert.NotEqual(t, ContentMsgID("recipe.created", nil), ContentMsgID("recipe.deleted", nil))
+}
+
+type recordingOutbox struct {
+	saved     []*nats.Msg
+	published []*nats.Msg
+}
+
+func (o *recordingOutbox) Save(_ context.Context, events []*nats.Msg) error {
+	o.saved = append(o.saved, events...)
+	return nil
+}
+
+func (o *recordingOutbox) MarkPublished(_ context.Context, events []*nats.Msg) error {
+	o.published = append(o.published, events...)
+	return nil
+}
+
+func TestTxEvents(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Commit", func(t *testing.T) {
+		js := &fakeJetStream{}
+		p, _ := NewPublisher(PublisherConfig{JetStream: js})
+		outbox := &recordingOutbox{}
+		events := NewTxEvents(p, outbox)
+
+		events.Publish(ctx, "recipe.created", []byte("{}"))
+		assert.Empty(t, js.published, "events must not be published before commit")
+
+		assert.NoError(t, events.BeforeCommit(ctx))
+		assert.Len(t, outbox.saved, 1)
+		assert.NotEmpty(t, outbox.saved[0].Header.Get(nats.MsgIdHdr))
+
+		assert.NoError(t, events.AfterCommit(ctx))
+		assert.Len(t, js.published, 1)
+		assert.Equal(t, outbox.saved, outbox.published)
+	})
+
+	t.Run("Rollback", func(t *testing.T) {
+		js := &fakeJetStream{}
+		p, _ := NewPublisher(PublisherConfig{JetStream: js})
+		events := NewTxEvents(p, nil)
+
+		events.Publish(ctx, "recipe.created", []byte("{}"))
+		events.Rollback()
+		assert.NoError(t, events.AfterCommit(ctx))
+		assert.Empty(t, js.published)
+	})
+}
+
+// outboxDriver is a database/sql driver keeping an outbox table in memory, so that RunInTx runs against real
+// *sql.DB and *sql.Tx values. It understands two statements, each taking a message ID: "INSERT" stores an
+// event within a transaction, and "UPDATE" marks a committed event published.
+type outboxDriver struct {
+	mu        sync.Mutex
+	saved     []string
+	published []string
+}
+
+func (d *outboxDriver) Open(string) (driver.Conn, error) { return &outboxConn{d: d}, nil }
+
+func (d *outboxDriver) Connect(context.Context) (driver.Conn, error) { return d.Open("") }
+
+func (d *outboxDriver) Driver() driver.Driver { return d }
+
+type outboxConn struct {
+	d       *outboxDriver
+	inTx    bool
+	pending []string
+}
+
+func (c *outboxConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (c *outboxConn) Close() error                        { return nil }
+func (c *outboxConn) Begin() (driver.Tx, error) {
+	c.inTx = true
+	return c, nil
+}
+
+func (c *outboxConn) Commit() error {
+	c.d.mu.Lock()
+	defer c.d.mu.Unlock()
+	c.d.saved = append(c.d.saved, c.pending...)
+	c.inTx, c.pending = false, nil
+	return nil
+}
+
+func (c *outboxConn) Rollback() error {
+	c.inTx, c.pending = false, nil
+	return nil
+}
+
+func (c *outboxConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	id, _ := args[0].Value.(string)
+	c.d.mu.Lock()
+	defer c.d.mu.Unlock()
+	switch {
+	case query == "INSERT" && c.inTx:
+		c.pending = append(c.pending, id)
+	case query == "UPDATE" && slices.Contains(c.d.saved, id):
+		c.d.published = append(c.d.published, id)
+	default:
+		return nil, fmt.Errorf("unexpected %s of %s", query, id)
+	}
+	return driver.RowsAffected(1), nil
+}
+
+// sqlOutbox is an Outbox writing through q, as services implement it.
+type sqlOutbox struct {
+	q SQLExecutor
+}
+
+func (o sqlOutbox) Save(ctx context.Context, events []*nats.Msg) error {
+	for _, msg := range events {
+		if _, err := o.q.ExecContext(ctx, "INSERT", msg.Header.Get(nats.MsgIdHdr)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (o sqlOutbox) MarkPublished(ctx context.Context, events []*nats.Msg) error {
+	for _, msg := range events {
+		if _, err := o.q.ExecContext(ctx, "UPDATE", msg.Header.Get(nats.MsgIdHdr)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestRunInTx(t *testing.T) {
+	ctx := context.Background()
+	newOutbox := func(q SQLExecutor) Outbox { return sqlOutbox{q: q} }
+
+	t.Run("Marks Published Events After Commit", func(t *testing.T) {
+		d := &outboxDriver{}
+		db := sql.OpenDB(d)
+		defer db.Close()
+		js := &fakeJetStream{}
+		p, _ := NewPublisher(PublisherConfig{JetStream: js})
+
+		err := RunInTx(ctx, db, p, newOutbox, func(ctx context.Context, tx *sql.Tx, events *TxEvents) error {
+			events.Publish(ctx, "recipe.created", []byte(`{"id":"r-1"}`))
+			events.Publish(ctx, "recipe.updated", []byte(`{"id":"r-1"}`))
+			return nil
+		})
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Len(t, js.published, 2)
+		assert.Len(t, d.saved, 2)
+		assert.Equal(t, d.saved, d.published, "published events must not be left for the relay")
+	})
+
+	t.Run("Rollback", func(t *testing.T) {
+		d := &outboxDriver{}
+		db := sql.OpenDB(d)
+		defer db.Close()
+		js := &fakeJetStream{}
+		p, _ := NewPublisher(PublisherConfig{JetStream: js})
+
+		err := RunInTx(ctx, db, p, newOutbox, func(ctx context.Context, tx *sql.Tx, events *TxEvents) error {
+			events.Publish(ctx, "recipe.created", []byte(`{"id":"r-1"}`))
+			return errors.New("constraint violated")
+		})
+		assert.EqualError(t, err, "constraint violated")
+		assert.Empty(t, js.published)
+		assert.Empty(t, d.saved)
+	})
+}
+
+func TestBackpressureDelay(t *testing.T) {
+	b := &Backpressure{signals: make(map[string]backpressureEntry)}
+
+	b.apply(backpressureKey("recipe.>"), []byte(`{"subject":"recipe.>","delay":2000000000}`), false)
+	assert.Equal(t, "recipe._all_", backpressureKey("recipe.>"))
+	assert.Equal(t, 2*time.Second, b.Delay("recipe.created"))
+	assert.Zero(t, b.Delay("project.created"))
+
+	b.apply(backpressureKey("recipe.>"), nil, true)
+	assert.Zero(t, b.Delay("recipe.created"))
+}
+
+// recordingAcker records how messages were settled.
+type recordingAcker struct {
+	naks  []time.Duration
+	terms int
+}
+
+func (a *recordingAcker) Ack(*nats.Msg) error { return nil }
+func (a *recordingAcker) Nak(_ *nats.Msg, delay time.Duration) error {
+	a.naks = append(a.naks, delay)
+	return nil
+}
+func (a *recordingAcker) Term(*nats.Msg) error {
+	a.terms++
+	return nil
+}
+
+func TestHandlerErrorOutcomes(t *testing.T) {
+	assert.Equal(t, OutcomeTerminate, OutcomeOf(fmt.Errorf("bad payload: %w", common_errors.NewValidationError())))
+	assert.Equal(t, OutcomeTerminate, OutcomeOf(common_errors.NewNotFoundError("gone")))
+	assert.Equal(t, OutcomeTerminate, OutcomeOf(Terminal(errors.New("unsupported version"))))
+	assert.Equal(t, OutcomeRetry, OutcomeOf(common_errors.NewAPIError(http.StatusTooManyRequests, "slow down")))
+	assert.Equal(t, OutcomeRetry, OutcomeOf(common_errors.NewServiceUnavailableError("down")))
+	assert.Equal(t, OutcomeRetry, OutcomeOf(errors.New("boom")))
+	assert.Equal(t, OutcomeTerminate, OutcomeOf(common_errors.NewInternalServerError("")))
+	assert.Equal(t, OutcomeRetry, OutcomeOf(common_errors.NewInternalServerError("").WithRetryable(true)))
+	assert.Equal(t, OutcomeTerminate, OutcomeOf(common_errors.NewServiceUnavailableError("down for good").WithRetryable(false)))
+	assert.Equal(t, OutcomeRetry, OutcomeOf(common_errors.NewConflictError("locked").WithRetryable(true)))
+
+	process := func(err error) (*recordingAcker, []DropReason) {
+		var drops []DropReason
+		acker := &recordingAcker{}
+		handler := &MockHandler{}
+		handler.On("GetLockingKey", mock.Anything).Return("", nil)
+		handler.On("Process", mock.Anything, mock.Anything).Return(err)
+		ps := &PullSubscriber{
+			config: Config{
+				Handler:    handler,
+				MaxDeliver: 5,
+				OnDrop: func(msg *nats.Msg, reason DropReason, err error) {
+					drops = append(drops, reason)
+				},
+			},
+			acker:     acker,
+			semaphore: make(chan struct{}, 1),
+			keyLocks:  make(map[string]*sync.Mutex),
+		}
+		ps.semaphore <- struct{}{}
+		ps.processMessage(newJetStreamMsg(1, 1))
+		return acker, drops
+	}
+
+	t.Run("Terminal", func(t *testing.T) {
+		acker, drops := process(common_errors.NewBadRequestError("malformed"))
+		assert.Equal(t, 1, acker.terms)
+		assert.Empty(t, acker.naks)
+		assert.Equal(t, []DropReason{DropTerminated}, drops)
+	})
+
+	t.Run("Retry After", func(t *testing.T) {
+		acker, drops := process(RetryAfter(errors.New("locked"), time.Second))
+		assert.Equal(t, []time.Duration{time.Second}, acker.naks)
+		assert.Empty(t, drops)
+	})
+
+	t.Run("Default Delay", func(t *testing.T) {
+		acker, _ := process(errors.New("boom"))
+		assert.Equal(t, []time.Duration{15 * time.Second}, acker.naks)
+	})
+}
+
+func TestDeadLetterAfterMaxDeliver(t *testing.T) {
+	js := &fakeJetStream{}
+	acker := &recordingAcker{}
+	var drops []DropReason
+	handler := &MockHandler{}
+	handler.On("GetLockingKey", mock.Anything).Return("", nil)
+	handler.On("Process", mock.Anything, mock.Anything).Return(errors.New("boom"))
+	ps := &PullSubscriber{
+		config: Config{
+			Handler:           handler,
+			JetStream:         js,
+			MaxDeliver:        3,
+			QuarantineSubject: "quarantine.test",
+			OnDrop: func(msg *nats.Msg, reason DropReason, err error) {
+				drops = append(drops, reason)
+			},
+		},
+		acker:     acker,
+		semaphore: make(chan struct{}, 1),
+		keyLocks:  make(map[string]*sync.Mutex),
+	}
+
+	for delivered := uint64(1); delivered <= 3; delivered++ {
+		msg := newJetStreamMsg(7, delivered)
+		msg.Header = nats.Header{"Tenant": []string{"t-1"}, nats.MsgIdHdr: []string{"abc"}}
+		msg.Data = []byte(`{"id":"r-1"}`)
+		ps.semaphore <- struct{}{}
+		ps.processMessage(msg)
+	}
+
+	// Earlier deliveries are retried; only the final one is dead-lettered.
+	assert.Len(t, acker.naks, 2)
+	assert.Equal(t, 1, acker.terms)
+	assert.Equal(t, []DropReason{DropQuarantined}, drops)
+	if !assert.Len(t, js.published, 1) {
+		return
+	}
+
+	dead := js.published[0]
+	assert.Equal(t, "quarantine.test", dead.Subject)
+	assert.Equal(t, []byte(`{"id":"r-1"}`), dead.Data)
+	assert.Equal(t, "t-1", dead.Header.Get("Tenant"))
+	assert.Empty(t, dead.Header.Get(nats.MsgIdHdr))
+	assert.Equal(t, "test", dead.Header.Get(QuarantineSubjectHeader))
+	assert.Equal(t, "boom", dead.Header.Get(QuarantineReasonHeader))
+	assert.Equal(t, "STREAM", dead.Header.Get(QuarantineStreamHeader))
+	assert.Equal(t, "consumer", dead.Header.Get(QuarantineConsumerHeader))
+	assert.Equal(t, "7", dead.Header.Get(QuarantineSequenceHeader))
+	assert.NotEmpty(t, dead.Header.Get(QuarantineTimeHeader))
+}
+
+func TestQuarantinePublishFailureOnFinalDelivery(t *testing.T) {
+	js := &fakeJetStream{publishErr: errors.New("stream unavailable")}
+	acker := &recordingAcker{}
+	var drops []DropReason
+	var dropErr error
+	handler := &MockHandler{}
+	handler.On("GetLockingKey", mock.Anything).Return("", nil)
+	handler.On("Process", mock.Anything, mock.Anything).Return(errors.New("boom"))
+	ps := &PullSubscriber{
+		config: Config{
+			Handler:           handler,
+			JetStream:         js,
+			MaxDeliver:        2,
+			QuarantineSubject: "quarantine.test",
+			OnDrop: func(msg *nats.Msg, reason DropReason, err error) {
+				drops = append(drops, reason)
+				dropErr = err
+			},
+		},
+		acker:     acker,
+		semaphore: make(chan struct{}, 1),
+		keyLocks:  make(map[string]*sync.Mutex),
+	}
+
+	for delivered := uint64(1); delivered <= 2; delivered++ {
+		ps.semaphore <- struct{}{}
+		ps.processMessage(newJetStreamMsg(7, delivered))
+	}
+
+	// JetStream will not redeliver after the final attempt, so the failed quarantine is reported as a drop.
+	assert.Len(t, acker.naks, 2)
+	assert.Equal(t, 0, acker.terms)
+	assert.Equal(t, []DropReason{DropExhausted}, drops)
+	assert.ErrorContains(t, dropErr, "boom")
+	assert.ErrorContains(t, dropErr, "stream unavailable")
+}
+
+// streamJetStream stores stream configurations; other JetStreamContext methods are not implemented.
+type streamJetStream struct {
+	nats.JetStreamContext
+	streams map[string]nats.StreamConfig
+	updates int
+}
+
+func (js *streamJetStream) StreamInfo(name string, _ ...nats.JSOpt) (*nats.StreamInfo, error) {
+	cfg, ok := js.streams[name]
+	if !ok {
+		return nil, nats.ErrStreamNotFound
+	}
+	return &nats.StreamInfo{Config: cfg}, nil
+}
+
+func (js *streamJetStream) AddStream(cfg *nats.StreamConfig, _ ...nats.JSOpt) (*nats.StreamInfo, error) {
+	js.streams[cfg.Name] = *cfg
+	return &nats.StreamInfo{Config: *cfg}, nil
+}
+
+func (js *streamJetStream) UpdateStream(cfg *nats.StreamConfig, _ ...nats.JSOpt) (*nats.StreamInfo, error) {
+	js.updates++
+	js.streams[cfg.Name] = *cfg
+	return &nats.StreamInfo{Config: *cfg}, nil
+}
+
+func TestEnsureStream(t *testing.T) {
+	js := &streamJetStream{streams: map[string]nats.StreamConfig{}}
+
+	cfg := &nats.StreamConfig{Name: "RECIPES", Subjects: []string{"recipe.>"}}
+	_, err := EnsureStream(js, cfg)
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultDuplicateWindow, js.streams["RECIPES"].Duplicates)
+	assert.Zero(t, cfg.Duplicates, "the caller's config must not be modified")
+
+	// Operators tune the stream; restarts must not reset their settings.
+	tuned := js.streams["RECIPES"]
+	tuned.MaxAge = 24 * time.Hour
+	tuned.Replicas = 3
+	js.streams["RECIPES"] = tuned
+
+	_, err = EnsureStream(js, &nats.StreamConfig{Name: "RECIPES", Subjects: []string{"recipe.>"}})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, js.updates, "an unchanged stream must not be updated")
+
+	_, err = EnsureStream(js, &nats.StreamConfig{Name: "RECIPES", Subjects: []string{"recipe.>", "draft.>"}, Duplicates: time.Minute})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, js.updates)
+	got := js.streams["RECIPES"]
+	assert.Equal(t, []string{"recipe.>", "draft.>"}, got.Subjects)
+	assert.Equal(t, time.Minute, got.Duplicates)
+	assert.Equal(t, 24*time.Hour, got.MaxAge)
+	assert.Equal(t, 3, got.Replicas)
+}
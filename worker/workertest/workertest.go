@@ -0,0 +1,271 @@
+// Package workertest provides an in-memory harness for running worker handlers through the real
+// dispatch, locking and acknowledgement pipeline without a live JetStream cluster.
+package workertest
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hkinc45/dev-kitchen-go-common/worker"
+	"github.com/nats-io/nats.go"
+)
+
+// Outcome is how a delivery was settled by the worker.
+type Outcome string
+
+const (
+	// Pending means the delivery has not been settled yet.
+	Pending Outcome = ""
+	// Acked means the handler succeeded and the message was ACKed.
+	Acked Outcome = "ack"
+	// Naked means the message was NAKed on every allowed delivery attempt.
+	Naked Outcome = "nak"
+	// Termed means the message was terminated and will not be redelivered.
+	Termed Outcome = "term"
+	// Quarantined means the message was published to Config.QuarantineSubject and terminated.
+	Quarantined Outcome = "quarantine"
+)
+
+const (
+	harnessStream   = "WORKERTEST"
+	harnessConsumer = "workertest"
+	fetchPollPeriod = 20 * time.Millisecond
+)
+
+// Harness runs a worker.PullSubscriber against an in-memory message source.
+// NAKed messages are redelivered immediately, ignoring the requested delay, until Config.MaxDeliver is reached.
+type Harness struct {
+	t          testing.TB
+	maxDeliver int
+	subscriber *worker.PullSubscriber
+
+	mu          sync.Mutex
+	queue       []*nats.Msg
+	deliveries  map[*nats.Msg]*Delivery
+	quarantined []*nats.Msg
+	seq         uint64
+	closed      bool
+}
+
+// Delivery tracks a single published message through the pipeline.
+type Delivery struct {
+	h        *Harness
+	subject  string
+	header   nats.Header
+	data     []byte
+	sequence uint64
+
+	attempts    int
+	outcomes    []Outcome
+	quarantined bool
+	done        chan struct{}
+}
+
+// New starts a harness for cfg. Only the handler-related Config fields are used; stream and consumer
+// settings are ignored. Unless cfg.JetStream is set, messages quarantined through cfg.QuarantineSubject are
+// captured by the harness (see Quarantined). The subscriber is stopped when the test finishes.
+func New(t testing.TB, cfg worker.Config) *Harness {
+	t.Helper()
+
+	if cfg.MaxDeliver <= 0 {
+		cfg.MaxDeliver = worker.DefaultMaxDeliver
+	}
+	if cfg.Subject == "" {
+		cfg.Subject = ">"
+	}
+
+	h := &Harness{
+		t:          t,
+		maxDeliver: cfg.MaxDeliver,
+		deliveries: make(map[*nats.Msg]*Delivery),
+	}
+	if cfg.QuarantineSubject != "" && cfg.JetStream == nil {
+		cfg.JetStream = &quarantineStream{h: h}
+	}
+	h.subscriber = worker.NewPullSubscriberWithSource(cfg, h, h)
+	t.Cleanup(h.subscriber.Stop)
+	return h
+}
+
+// Publish enqueues a message for the worker.
+func (h *Harness) Publish(subject string, data []byte) *Delivery {
+	msg := nats.NewMsg(subject)
+	msg.Data = data
+	return h.PublishMsg(msg)
+}
+
+// PublishMsg enqueues a message with headers for the worker.
+func (h *Harness) PublishMsg(msg *nats.Msg) *Delivery {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.seq++
+	d := &Delivery{
+		h:        h,
+		subject:  msg.Subject,
+		header:   msg.Header,
+		data:     msg.Data,
+		sequence: h.seq,
+		done:     make(chan struct{}),
+	}
+	h.enqueueLocked(d)
+	return d
+}
+
+// enqueueLocked builds a fresh delivery attempt for d and queues it. The caller must hold h.mu.
+func (h *Harness) enqueueLocked(d *Delivery) {
+	d.attempts++
+
+	msg := nats.NewMsg(d.subject)
+	for key, values := range d.header {
+		msg.Header[key] = append([]string(nil), values...)
+	}
+	msg.Data = append([]byte(nil), d.data...)
+	// A reply subject in the JetStream ACK format makes msg.Metadata() work as it would against a server.
+	msg.Reply = fmt.Sprintf("$JS.ACK.%s.%s.%d.%d.%d.%d.%d",
+		harnessStream, harnessConsumer, d.attempts, d.sequence, d.sequence, time.Now().UnixNano(), len(h.queue))
+	msg.Sub = &nats.Subscription{}
+
+	h.deliveries[msg] = d
+	h.queue = append(h.queue, msg)
+}
+
+// Fetch implements worker.Source.
+func (h *Harness) Fetch(batch int, _ ...nats.PullOpt) ([]*nats.Msg, error) {
+	deadline := time.Now().Add(10 * fetchPollPeriod)
+	for time.Now().Before(deadline) {
+		h.mu.Lock()
+		if h.closed {
+			h.mu.Unlock()
+			return nil, nats.ErrTimeout
+		}
+		if len(h.queue) > 0 {
+			n := min(batch, len(h.queue))
+			msgs := h.queue[:n:n]
+			h.queue = h.queue[n:]
+			h.mu.Unlock()
+			return msgs, nil
+		}
+		h.mu.Unlock()
+		time.Sleep(fetchPollPeriod)
+	}
+	return nil, nats.ErrTimeout
+}
+
+// Unsubscribe implements worker.Source.
+func (h *Harness) Unsubscribe() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	return nil
+}
+
+// Ack implements worker.Acknowledger.
+func (h *Harness) Ack(msg *nats.Msg) error {
+	return h.settle(msg, Acked)
+}
+
+// Nak implements worker.Acknowledger.
+func (h *Harness) Nak(msg *nats.Msg, _ time.Duration) error {
+	return h.settle(msg, Naked)
+}
+
+// Term implements worker.Acknowledger.
+func (h *Harness) Term(msg *nats.Msg) error {
+	return h.settle(msg, Termed)
+}
+
+// settle records the outcome of a delivery attempt, redelivering NAKed messages while attempts remain.
+func (h *Harness) settle(msg *nats.Msg, outcome Outcome) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	d, ok := h.deliveries[msg]
+	if !ok {
+		return nats.ErrMsgNotFound
+	}
+	delete(h.deliveries, msg)
+
+	if outcome == Termed && d.quarantined {
+		outcome = Quarantined
+	}
+	d.outcomes = append(d.outcomes, outcome)
+	if outcome == Naked && d.attempts < h.maxDeliver && !h.closed {
+		h.enqueueLocked(d)
+		return nil
+	}
+	close(d.done)
+	return nil
+}
+
+// Quarantined returns the messages published to Config.QuarantineSubject so far.
+func (h *Harness) Quarantined() []*nats.Msg {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]*nats.Msg(nil), h.quarantined...)
+}
+
+// quarantineStream captures quarantine publishes; other JetStreamContext methods are not implemented.
+type quarantineStream struct {
+	nats.JetStreamContext
+	h *Harness
+}
+
+// PublishMsg records msg and marks the delivery it quarantines, so its TERM settles as Quarantined.
+func (s *quarantineStream) PublishMsg(msg *nats.Msg, _ ...nats.PubOpt) (*nats.PubAck, error) {
+	h := s.h
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.quarantined = append(h.quarantined, msg)
+	seq, _ := strconv.ParseUint(msg.Header.Get(worker.QuarantineSequenceHeader), 10, 64)
+	for _, d := range h.deliveries {
+		if d.sequence == seq {
+			d.quarantined = true
+		}
+	}
+	return &nats.PubAck{Stream: harnessStream, Sequence: uint64(len(h.quarantined))}, nil
+}
+
+// Wait blocks until the delivery reaches its final outcome or timeout elapses, returning Pending on timeout.
+func (d *Delivery) Wait(timeout time.Duration) Outcome {
+	select {
+	case <-d.done:
+		return d.Outcome()
+	case <-time.After(timeout):
+		return Pending
+	}
+}
+
+// Outcome returns the final outcome of the delivery, or Pending if it has not been settled yet.
+func (d *Delivery) Outcome() Outcome {
+	select {
+	case <-d.done:
+	default:
+		return Pending
+	}
+	d.h.mu.Lock()
+	defer d.h.mu.Unlock()
+	return d.outcomes[len(d.outcomes)-1]
+}
+
+// Attempts returns how many times the message has been delivered.
+func (d *Delivery) Attempts() int {
+	d.h.mu.Lock()
+	defer d.h.mu.Unlock()
+	return d.attempts
+}
+
+// AssertOutcome waits for the delivery to settle and fails the test if it did not end with want.
+func (d *Delivery) AssertOutcome(want Outcome, timeout time.Duration) bool {
+	d.h.t.Helper()
+	got := d.Wait(timeout)
+	if got != want {
+		d.h.t.Errorf("message %d on %s: expected outcome %q, got %q after %d attempt(s)", d.sequence, d.subject, want, got, d.Attempts())
+		return false
+	}
+	return true
+}
@@ -0,0 +1,93 @@
+package workertest
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/hkinc45/dev-kitchen-go-common/worker"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMain(m *testing.M) {
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	os.Exit(m.Run())
+}
+
+type testHandler struct {
+	process func(ctx context.Context, msg *nats.Msg) error
+}
+
+func (h *testHandler) Process(ctx context.Context, msg *nats.Msg) error {
+	return h.process(ctx, msg)
+}
+
+func (h *testHandler) GetLockingKey(msg *nats.Msg) (string, error) {
+	return msg.Header.Get("Key"), nil
+}
+
+func TestHarness(t *testing.T) {
+	t.Run("Ack", func(t *testing.T) {
+		h := New(t, worker.Config{Handler: &testHandler{process: func(ctx context.Context, msg *nats.Msg) error {
+			return nil
+		}}})
+
+		d := h.Publish("recipe.created", []byte(`{"id":"r-1"}`))
+		d.AssertOutcome(Acked, time.Second)
+		assert.Equal(t, 1, d.Attempts())
+	})
+
+	t.Run("Redelivers Until MaxDeliver", func(t *testing.T) {
+		h := New(t, worker.Config{
+			MaxDeliver: 3,
+			Handler: &testHandler{process: func(ctx context.Context, msg *nats.Msg) error {
+				return errors.New("boom")
+			}},
+		})
+
+		d := h.Publish("recipe.created", nil)
+		d.AssertOutcome(Naked, 2*time.Second)
+		assert.Equal(t, 3, d.Attempts())
+	})
+
+	t.Run("Succeeds On Retry", func(t *testing.T) {
+		h := New(t, worker.Config{Handler: &testHandler{process: func(ctx context.Context, msg *nats.Msg) error {
+			meta, err := msg.Metadata()
+			if err != nil {
+				return err
+			}
+			if meta.NumDelivered < 2 {
+				return errors.New("transient")
+			}
+			return nil
+		}}})
+
+		d := h.Publish("recipe.created", nil)
+		d.AssertOutcome(Acked, 2*time.Second)
+		assert.Equal(t, 2, d.Attempts())
+	})
+
+	t.Run("Quarantines After MaxDeliver", func(t *testing.T) {
+		h := New(t, worker.Config{
+			MaxDeliver:        2,
+			QuarantineSubject: "quarantine.recipes",
+			Handler: &testHandler{process: func(ctx context.Context, msg *nats.Msg) error {
+				return errors.New("boom")
+			}},
+		})
+
+		d := h.Publish("recipe.created", []byte(`{"id":"r-1"}`))
+		d.AssertOutcome(Quarantined, 2*time.Second)
+		assert.Equal(t, 2, d.Attempts())
+		if quarantined := h.Quarantined(); assert.Len(t, quarantined, 1) {
+			assert.Equal(t, "quarantine.recipes", quarantined[0].Subject)
+			assert.Equal(t, []byte(`{"id":"r-1"}`), quarantined[0].Data)
+			assert.Equal(t, "boom", quarantined[0].Header.Get(worker.QuarantineReasonHeader))
+		}
+	})
+}